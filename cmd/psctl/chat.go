@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sargassum-world/pslive/internal/clients/apiclient"
+)
+
+var chatTailCommand = command{
+	description: "Print the chat messages sent on an instrument's page as they're sent",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		instrumentID := instrumentFlag(fs)
+		return func(ctx context.Context, c *apiclient.Client) error {
+			iid, err := checkInstrument(*instrumentID)
+			if err != nil {
+				return err
+			}
+
+			lastEventID := ""
+			handler := func(e apiclient.Event) error {
+				lastEventID = e.ID
+				switch e.Type {
+				case apiclient.ResetEventType:
+					fmt.Fprintln(os.Stderr, "(some messages may have been missed while reconnecting)")
+				case apiclient.ChatEventType:
+					var m apiclient.ChatMessageEvent
+					if err := json.Unmarshal(e.Data, &m); err != nil {
+						return errors.Wrapf(err, "couldn't parse chat message event %s", e.ID)
+					}
+					fmt.Printf("%s %s: %s\n", m.SendTime.Local().Format("15:04:05"), m.Sender, m.Body)
+				}
+				return nil
+			}
+			// The server ends the stream if we fall behind, so we resume from the last event we got
+			const reconnectDelay = time.Second
+			for {
+				if err = c.StreamInstrumentEvents(ctx, iid, lastEventID, handler); err != nil {
+					if err == context.Canceled {
+						return nil
+					}
+					return err
+				}
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(reconnectDelay):
+				}
+			}
+		}
+	},
+}
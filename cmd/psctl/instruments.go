@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+
+	"github.com/sargassum-world/pslive/internal/clients/apiclient"
+)
+
+// Output
+
+func newTable() *tabwriter.Writer {
+	const padding = 2
+	return tabwriter.NewWriter(os.Stdout, 0, 0, padding, ' ', 0)
+}
+
+func printJSON(value interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return errors.Wrap(encoder.Encode(value), "couldn't print result")
+}
+
+func formatEnabled(enabled bool) string {
+	if enabled {
+		return "enabled"
+	}
+	return "disabled"
+}
+
+// Params
+
+func instrumentFlag(fs *flag.FlagSet) *int64 {
+	return fs.Int64("instrument", 0, "ID of the instrument (required)")
+}
+
+func checkInstrument(instrumentID int64) (apiclient.InstrumentID, error) {
+	if instrumentID <= 0 {
+		return 0, errors.New("an instrument must be specified with -instrument")
+	}
+	return apiclient.InstrumentID(instrumentID), nil
+}
+
+// listAll gets every page of a collection.
+func listAll[Item any](
+	ctx context.Context,
+	list func(ctx context.Context, p apiclient.PageParams) (apiclient.Page[Item], error),
+) (items []Item, err error) {
+	p := apiclient.PageParams{}
+	for {
+		page, err := list(ctx, p)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, page.Items...)
+		p.Offset = page.Offset + len(page.Items)
+		if len(page.Items) == 0 || p.Offset >= page.Total {
+			return items, nil
+		}
+	}
+}
+
+// Instruments
+
+var instrumentsListCommand = command{
+	description: "List the instruments visible to you",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		return func(ctx context.Context, c *apiclient.Client) error {
+			instruments, err := listAll(ctx, c.ListInstruments)
+			if err != nil {
+				return err
+			}
+			w := newTable()
+			fmt.Fprintln(w, "ID\tNAME\tVISIBILITY\tDESCRIPTION")
+			for _, i := range instruments {
+				fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i.ID, i.Name, i.Visibility, i.Description)
+			}
+			return errors.Wrap(w.Flush(), "couldn't print instruments")
+		}
+	},
+}
+
+// Controllers
+
+func controllerFlag(fs *flag.FlagSet) *int64 {
+	return fs.Int64("controller", 0, "ID of the controller (required)")
+}
+
+func checkController(
+	instrumentID, controllerID int64,
+) (apiclient.InstrumentID, apiclient.ControllerID, error) {
+	iid, err := checkInstrument(instrumentID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if controllerID <= 0 {
+		return 0, 0, errors.New("a controller must be specified with -controller")
+	}
+	return iid, apiclient.ControllerID(controllerID), nil
+}
+
+var controllersListCommand = command{
+	description: "List the controllers of an instrument",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		instrumentID := instrumentFlag(fs)
+		return func(ctx context.Context, c *apiclient.Client) error {
+			iid, err := checkInstrument(*instrumentID)
+			if err != nil {
+				return err
+			}
+			controllers, err := listAll(
+				ctx, func(ctx context.Context, p apiclient.PageParams) (
+					apiclient.Page[apiclient.Controller], error,
+				) {
+					return c.ListControllers(ctx, iid, p)
+				},
+			)
+			if err != nil {
+				return err
+			}
+			w := newTable()
+			fmt.Fprintln(w, "ID\tNAME\tPROTOCOL\tSTATUS\tURL")
+			for _, controller := range controllers {
+				fmt.Fprintf(
+					w, "%d\t%s\t%s\t%s\t%s\n", controller.ID, controller.Name, controller.Protocol,
+					formatEnabled(controller.Enabled), controller.URL,
+				)
+			}
+			return errors.Wrap(w.Flush(), "couldn't print controllers")
+		}
+	},
+}
+
+var controllersStateCommand = command{
+	description: "Show the state of a planktoscope controller as JSON",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		instrumentID := instrumentFlag(fs)
+		controllerID := controllerFlag(fs)
+		return func(ctx context.Context, c *apiclient.Client) error {
+			iid, cid, err := checkController(*instrumentID, *controllerID)
+			if err != nil {
+				return err
+			}
+			state, err := c.GetPlanktoscopeState(ctx, iid, cid)
+			if err != nil {
+				return err
+			}
+			return printJSON(state)
+		}
+	},
+}
+
+// Cameras
+
+var camerasListCommand = command{
+	description: "List the cameras of an instrument",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		instrumentID := instrumentFlag(fs)
+		return func(ctx context.Context, c *apiclient.Client) error {
+			iid, err := checkInstrument(*instrumentID)
+			if err != nil {
+				return err
+			}
+			cameras, err := listAll(
+				ctx, func(ctx context.Context, p apiclient.PageParams) (
+					apiclient.Page[apiclient.Camera], error,
+				) {
+					return c.ListCameras(ctx, iid, p)
+				},
+			)
+			if err != nil {
+				return err
+			}
+			w := newTable()
+			fmt.Fprintln(w, "ID\tNAME\tPROTOCOL\tSTATUS\tURL")
+			for _, camera := range cameras {
+				fmt.Fprintf(
+					w, "%d\t%s\t%s\t%s\t%s\n", camera.ID, camera.Name, camera.Protocol,
+					formatEnabled(camera.Enabled), camera.URL,
+				)
+			}
+			return errors.Wrap(w.Flush(), "couldn't print cameras")
+		}
+	},
+}
+
+var camerasFrameCommand = command{
+	description: "Download the current frame from a camera as a JPEG image",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		instrumentID := instrumentFlag(fs)
+		cameraID := fs.Int64("camera", 0, "ID of the camera (required)")
+		output := fs.String("o", "frame.jpeg", "path of the file to save the frame to")
+		height := fs.Int("height", 0, "height to resize the frame to, in pixels")
+		quality := fs.Int("quality", 0, "JPEG encoding quality, from 1 to 100")
+		return func(ctx context.Context, c *apiclient.Client) error {
+			iid, err := checkInstrument(*instrumentID)
+			if err != nil {
+				return err
+			}
+			if *cameraID <= 0 {
+				return errors.New("a camera must be specified with -camera")
+			}
+			frame, err := c.GetCameraFrame(
+				ctx, iid, apiclient.CameraID(*cameraID),
+				apiclient.FrameParams{Height: *height, Quality: *quality},
+			)
+			if err != nil {
+				return err
+			}
+			const perm = 0o644
+			if err = os.WriteFile(*output, frame, perm); err != nil {
+				return errors.Wrapf(err, "couldn't save frame to %s", *output)
+			}
+			fmt.Fprintf(os.Stderr, "saved frame (%d bytes) to %s\n", len(frame), *output)
+			return nil
+		}
+	},
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/sargassum-world/pslive/internal/clients/apiclient"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+)
+
+const automationJobType = "hcl-v0.1.0"
+
+// Params
+
+func jobFlag(fs *flag.FlagSet) *int64 {
+	return fs.Int64("job", 0, "ID of the automation job (required)")
+}
+
+func checkJob(
+	instrumentID, jobID int64,
+) (apiclient.InstrumentID, apiclient.AutomationJobID, error) {
+	iid, err := checkInstrument(instrumentID)
+	if err != nil {
+		return 0, 0, err
+	}
+	if jobID <= 0 {
+		return 0, 0, errors.New("an automation job must be specified with -job")
+	}
+	return iid, apiclient.AutomationJobID(jobID), nil
+}
+
+// readSpecification reads a job specification from a local file and checks that the server would
+// be able to run it. The job is named after the file, unless a name is specified.
+func readSpecification(path, name string) (jobName, specification string, err error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", errors.Wrapf(err, "couldn't read job specification %s", path)
+	}
+	if jobName = name; jobName == "" {
+		jobName = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if _, err = instruments.NewOrchestratedJob(
+		0, 0, jobName, automationJobType, string(raw),
+	); err != nil {
+		return "", "", errors.Wrapf(err, "invalid job specification %s", path)
+	}
+	return jobName, string(raw), nil
+}
+
+func listJobs(
+	ctx context.Context, c *apiclient.Client, iid apiclient.InstrumentID,
+) ([]apiclient.AutomationJob, error) {
+	return listAll(
+		ctx, func(ctx context.Context, p apiclient.PageParams) (
+			apiclient.Page[apiclient.AutomationJob], error,
+		) {
+			return c.ListAutomationJobs(ctx, iid, p)
+		},
+	)
+}
+
+// Commands
+
+var jobsListCommand = command{
+	description: "List the automation jobs of an instrument",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		instrumentID := instrumentFlag(fs)
+		return func(ctx context.Context, c *apiclient.Client) error {
+			iid, err := checkInstrument(*instrumentID)
+			if err != nil {
+				return err
+			}
+			jobs, err := listJobs(ctx, c, iid)
+			if err != nil {
+				return err
+			}
+			w := newTable()
+			fmt.Fprintln(w, "ID\tNAME\tSTATUS\tDESCRIPTION")
+			for _, job := range jobs {
+				fmt.Fprintf(
+					w, "%d\t%s\t%s\t%s\n", job.ID, job.Name, formatEnabled(job.Enabled), job.Description,
+				)
+			}
+			return errors.Wrap(w.Flush(), "couldn't print automation jobs")
+		}
+	},
+}
+
+var jobsValidateCommand = command{
+	usage:       "file.hcl...",
+	description: "Check local automation job specifications without uploading them",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		return func(ctx context.Context, c *apiclient.Client) error {
+			if fs.NArg() == 0 {
+				return errors.New("no job specification files were specified")
+			}
+			invalid := 0
+			for _, path := range fs.Args() {
+				if _, _, err := readSpecification(path, ""); err != nil {
+					fmt.Fprintln(os.Stderr, err)
+					invalid++
+					continue
+				}
+				fmt.Fprintf(os.Stderr, "%s: ok\n", path)
+			}
+			if invalid > 0 {
+				return errors.Errorf("%d of %d job specifications are invalid", invalid, fs.NArg())
+			}
+			return nil
+		}
+	},
+}
+
+var jobsUploadCommand = command{
+	usage:       "file.hcl",
+	description: "Upload an automation job specification, replacing any job with the same name",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		instrumentID := instrumentFlag(fs)
+		name := fs.String("name", "", "name of the job (default: the file's name)")
+		description := fs.String("description", "", "description of the job")
+		enable := fs.Bool("enable", false, "enable the job after uploading it")
+		return func(ctx context.Context, c *apiclient.Client) error {
+			iid, err := checkInstrument(*instrumentID)
+			if err != nil {
+				return err
+			}
+			if fs.NArg() != 1 {
+				return errors.New("exactly one job specification file must be specified")
+			}
+			jobName, specification, err := readSpecification(fs.Arg(0), *name)
+			if err != nil {
+				return err
+			}
+
+			req := apiclient.AutomationJobRequest{
+				Name:          apiclient.String(jobName),
+				Type:          apiclient.String(automationJobType),
+				Specification: apiclient.String(specification),
+			}
+			if *description != "" {
+				req.Description = description
+			}
+			if *enable {
+				req.Enabled = apiclient.Bool(true)
+			}
+			jobs, err := listJobs(ctx, c, iid)
+			if err != nil {
+				return err
+			}
+			for _, job := range jobs {
+				if job.Name != jobName {
+					continue
+				}
+				if job, err = c.UpdateAutomationJob(ctx, iid, job.ID, req); err != nil {
+					return err
+				}
+				fmt.Fprintf(
+					os.Stderr, "updated %s job %d (%s)\n", formatEnabled(job.Enabled), job.ID, job.Name,
+				)
+				return nil
+			}
+			if req.Enabled == nil {
+				req.Enabled = apiclient.Bool(false)
+			}
+			job, err := c.CreateAutomationJob(ctx, iid, req)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(
+				os.Stderr, "created %s job %d (%s)\n", formatEnabled(job.Enabled), job.ID, job.Name,
+			)
+			return nil
+		}
+	},
+}
+
+func setJobEnabled(
+	ctx context.Context, c *apiclient.Client, instrumentID, jobID int64, enabled bool,
+) error {
+	iid, id, err := checkJob(instrumentID, jobID)
+	if err != nil {
+		return err
+	}
+	job, err := c.UpdateAutomationJob(ctx, iid, id, apiclient.AutomationJobRequest{
+		Enabled: apiclient.Bool(enabled),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "%s job %d (%s)\n", formatEnabled(job.Enabled), job.ID, job.Name)
+	return nil
+}
+
+var jobsEnableCommand = command{
+	description: "Enable an automation job, so that it runs on its schedule",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		instrumentID := instrumentFlag(fs)
+		jobID := jobFlag(fs)
+		return func(ctx context.Context, c *apiclient.Client) error {
+			return setJobEnabled(ctx, c, *instrumentID, *jobID, true)
+		}
+	},
+}
+
+var jobsDisableCommand = command{
+	description: "Disable an automation job, so that it no longer runs",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		instrumentID := instrumentFlag(fs)
+		jobID := jobFlag(fs)
+		return func(ctx context.Context, c *apiclient.Client) error {
+			return setJobEnabled(ctx, c, *instrumentID, *jobID, false)
+		}
+	},
+}
@@ -0,0 +1,116 @@
+// Command psctl is a command-line client for the HTTP API of a Planktoscope Live server.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/env"
+
+	"github.com/sargassum-world/pslive/internal/clients/apiclient"
+)
+
+const envPrefix = "PSCTL_"
+
+// Commands
+
+type command struct {
+	// usage describes the command's arguments, which follow its flags.
+	usage       string
+	description string
+	flags       func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error
+}
+
+var commands = map[string]command{
+	"instruments list":  instrumentsListCommand,
+	"controllers list":  controllersListCommand,
+	"controllers state": controllersStateCommand,
+	"pump start":        pumpStartCommand,
+	"pump stop":         pumpStopCommand,
+	"imager start":      imagerStartCommand,
+	"imager stop":       imagerStopCommand,
+	"jobs list":         jobsListCommand,
+	"jobs validate":     jobsValidateCommand,
+	"jobs upload":       jobsUploadCommand,
+	"jobs enable":       jobsEnableCommand,
+	"jobs disable":      jobsDisableCommand,
+	"chat tail":         chatTailCommand,
+	"cameras list":      camerasListCommand,
+	"cameras frame":     camerasFrameCommand,
+}
+
+func printUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: psctl [-server url] [-token token] <group> <command> [flags]\n\n")
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-20s %s\n", name, commands[name].description)
+	}
+	fmt.Fprintf(os.Stderr, "\nRun psctl <group> <command> -h for the flags of a command.\n")
+	fmt.Fprintf(
+		os.Stderr,
+		"The server and token default to the %sSERVER and %sTOKEN environment variables.\n",
+		envPrefix, envPrefix,
+	)
+}
+
+func run(ctx context.Context, args []string) error {
+	global := flag.NewFlagSet("psctl", flag.ContinueOnError)
+	global.Usage = printUsage
+	server := global.String(
+		"server", env.GetString(envPrefix+"SERVER", "http://localhost:3000"),
+		"URL of the Planktoscope Live server",
+	)
+	token := global.String("token", env.GetString(envPrefix+"TOKEN", ""), "personal API token")
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+	const nameLength = 2
+	args = global.Args()
+	if len(args) < nameLength {
+		printUsage()
+		return flag.ErrHelp
+	}
+	name := strings.Join(args[:nameLength], " ")
+	cmd, ok := commands[name]
+	if !ok {
+		printUsage()
+		return errors.Errorf("unknown command %s", name)
+	}
+
+	fs := flag.NewFlagSet("psctl "+name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: psctl %s [flags] %s\n\n%s\n\n", name, cmd.usage, cmd.description)
+		fs.PrintDefaults()
+	}
+	runCommand := cmd.flags(fs)
+	if err := fs.Parse(args[nameLength:]); err != nil {
+		return err
+	}
+	return runCommand(ctx, apiclient.New(*server, *token))
+}
+
+func main() {
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if err := run(ctx, os.Args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			os.Exit(2) //nolint:gomnd // 2 is the conventional exit code for usage errors
+		}
+		fmt.Fprintf(os.Stderr, "psctl: %s\n", err)
+		cancel()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sargassum-world/pslive/internal/clients/apiclient"
+)
+
+// Params
+
+// commandFlags are the flags common to every controller command.
+type commandFlags struct {
+	instrumentID *int64
+	controllerID *int64
+	wait         *time.Duration
+}
+
+func newCommandFlags(fs *flag.FlagSet) commandFlags {
+	const defaultWait = 10 * time.Second
+	return commandFlags{
+		instrumentID: instrumentFlag(fs),
+		controllerID: controllerFlag(fs),
+		wait: fs.Duration(
+			"wait", defaultWait,
+			"how long to wait for the controller to confirm the command (up to 5m; 0 to not wait)",
+		),
+	}
+}
+
+// parseDirection parses a direction flag, which is left unset if it's empty.
+func parseDirection(raw string) (forward *bool, err error) {
+	switch raw {
+	default:
+		return nil, errors.Errorf("unknown direction %s (must be forward or backward)", raw)
+	case "":
+		return nil, nil
+	case "forward":
+		return apiclient.Bool(true), nil
+	case "backward":
+		return apiclient.Bool(false), nil
+	}
+}
+
+// optionalFloat64 returns nil for a flag left at zero, so that the setting keeps its current value.
+func optionalFloat64(value float64) *float64 {
+	if value == 0 {
+		return nil
+	}
+	return &value
+}
+
+func optionalUint64(value uint64) *uint64 {
+	if value == 0 {
+		return nil
+	}
+	return &value
+}
+
+func reportCommand(action string, result apiclient.CommandResult) {
+	if !result.Confirmed {
+		fmt.Fprintf(os.Stderr, "%s, but the controller hasn't confirmed it yet\n", action)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "%s\n", action)
+}
+
+// Pump
+
+var pumpStartCommand = command{
+	description: "Start the pump of a planktoscope controller",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		cf := newCommandFlags(fs)
+		direction := fs.String("direction", "", "forward or backward (default: current setting)")
+		volume := fs.Float64("volume", 0, "volume to pump, in mL (default: current setting)")
+		flowrate := fs.Float64("flowrate", 0, "flowrate, in mL/min (default: current setting)")
+		return func(ctx context.Context, c *apiclient.Client) error {
+			iid, cid, err := checkController(*cf.instrumentID, *cf.controllerID)
+			if err != nil {
+				return err
+			}
+			forward, err := parseDirection(*direction)
+			if err != nil {
+				return err
+			}
+			result, err := c.SetPump(ctx, iid, cid, *cf.wait, apiclient.PumpRequest{
+				Pumping:  true,
+				Forward:  forward,
+				Volume:   optionalFloat64(*volume),
+				Flowrate: optionalFloat64(*flowrate),
+			})
+			if err != nil {
+				return err
+			}
+			settings := result.State.PumpSettings
+			reportCommand(fmt.Sprintf(
+				"started pumping %g mL at %g mL/min", settings.Volume, settings.Flowrate,
+			), result)
+			return nil
+		}
+	},
+}
+
+var pumpStopCommand = command{
+	description: "Stop the pump of a planktoscope controller",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		cf := newCommandFlags(fs)
+		return func(ctx context.Context, c *apiclient.Client) error {
+			iid, cid, err := checkController(*cf.instrumentID, *cf.controllerID)
+			if err != nil {
+				return err
+			}
+			result, err := c.SetPump(ctx, iid, cid, *cf.wait, apiclient.PumpRequest{Pumping: false})
+			if err != nil {
+				return err
+			}
+			reportCommand("stopped pumping", result)
+			return nil
+		}
+	},
+}
+
+// Imager
+
+var imagerStartCommand = command{
+	description: "Start an imaging run on a planktoscope controller",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		cf := newCommandFlags(fs)
+		direction := fs.String(
+			"direction", "", "pump direction, forward or backward (default: current setting)",
+		)
+		stepVolume := fs.Float64(
+			"step-volume", 0, "volume to pump between frames, in mL (default: current setting)",
+		)
+		stepDelay := fs.Float64(
+			"step-delay", 0, "delay before each frame, in sec (default: current setting)",
+		)
+		steps := fs.Uint64("steps", 0, "number of frames (default: current setting)")
+		project := fs.String("project", "", "sample project ID (default: the instrument's sample)")
+		sample := fs.String("sample", "", "sample ID (default: the instrument's sample)")
+		return func(ctx context.Context, c *apiclient.Client) error {
+			iid, cid, err := checkController(*cf.instrumentID, *cf.controllerID)
+			if err != nil {
+				return err
+			}
+			forward, err := parseDirection(*direction)
+			if err != nil {
+				return err
+			}
+			result, err := c.SetImager(ctx, iid, cid, *cf.wait, apiclient.ImagerRequest{
+				Imaging:         true,
+				SampleProjectID: *project,
+				SampleID:        *sample,
+				Forward:         forward,
+				StepVolume:      optionalFloat64(*stepVolume),
+				StepDelay:       optionalFloat64(*stepDelay),
+				Steps:           optionalUint64(*steps),
+			})
+			if err != nil {
+				return err
+			}
+			reportCommand(fmt.Sprintf(
+				"started imaging %d frames", result.State.ImagerSettings.Steps,
+			), result)
+			return nil
+		}
+	},
+}
+
+var imagerStopCommand = command{
+	description: "Stop the imaging run of a planktoscope controller",
+	flags: func(fs *flag.FlagSet) func(ctx context.Context, c *apiclient.Client) error {
+		cf := newCommandFlags(fs)
+		return func(ctx context.Context, c *apiclient.Client) error {
+			iid, cid, err := checkController(*cf.instrumentID, *cf.controllerID)
+			if err != nil {
+				return err
+			}
+			result, err := c.SetImager(
+				ctx, iid, cid, *cf.wait, apiclient.ImagerRequest{Imaging: false},
+			)
+			if err != nil {
+				return err
+			}
+			reportCommand("stopped imaging", result)
+			return nil
+		}
+	},
+}
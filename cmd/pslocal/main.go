@@ -46,6 +46,13 @@ func overrideConfigDefaults() error {
 			return err
 		}
 	}
+	if os.Getenv("GENERICMQTT_MQTT_CLIENT") == "" {
+		if err := os.Setenv(
+			"GENERICMQTT_MQTT_CLIENT", fmt.Sprintf("pslocal-%d", os.Getpid()),
+		); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -91,7 +98,8 @@ func initializeFromEmpty(ctx context.Context, server *pslive.Server) error {
 		return errors.Wrap(err, "couldn't add default controller for local planktoscope")
 	}
 	if err := server.Globals.Planktoscopes.Add(
-		planktoscope.ClientID(controllerID), controller.URL,
+		ctx, planktoscope.ClientID(controllerID), controller.URL,
+		planktoscope.MQTTAuth{}, planktoscope.Limits{},
 	); err != nil {
 		return errors.Wrap(err, "couldn't start mqtt client for local planktoscope")
 	}
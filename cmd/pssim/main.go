@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/labstack/gommon/log"
+
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope/simulator"
+)
+
+const shutdownTimeout = 5 // sec
+
+func main() {
+	e := echo.New()
+	e.HideBanner = true
+	e.Logger.SetLevel(log.INFO)
+	e.Use(middleware.Recover())
+
+	// Get config
+	config, err := simulator.GetConfig()
+	if err != nil {
+		e.Logger.Fatal(err, "couldn't set up simulator config")
+	}
+
+	// Prepare simulator
+	s := simulator.New(config, e.Logger)
+	e.GET("/", func(c echo.Context) error {
+		return s.HandlePreview(c.Response().Writer, c.Request())
+	})
+
+	// Run simulator
+	ctxRun, cancelRun := signal.NotifyContext(
+		context.Background(), os.Interrupt, syscall.SIGTERM, syscall.SIGQUIT,
+	)
+	go func() {
+		// The connection is retried until it succeeds, so this won't return until connected
+		e.Logger.Infof("connecting to MQTT broker %s", config.URL)
+		if err := s.Connect(); err != nil {
+			e.Logger.Error(err)
+		}
+	}()
+	go func() {
+		address := fmt.Sprintf(":%d", config.Preview.Port)
+		e.Logger.Infof("starting preview stream server on %s", address)
+		if err := e.Start(address); err != nil && err != http.ErrServerClosed {
+			e.Logger.Error(err)
+		}
+		cancelRun()
+	}()
+	<-ctxRun.Done()
+	cancelRun()
+
+	// Shut down simulator
+	ctxShutdown, cancelShutdown := context.WithTimeout(
+		context.Background(), shutdownTimeout*time.Second,
+	)
+	defer cancelShutdown()
+	e.Logger.Infof("attempting to shut down gracefully within %d sec", shutdownTimeout)
+	if err := s.Shutdown(ctxShutdown); err != nil {
+		e.Logger.Warn("forcibly disconnecting from MQTT broker due to failure of graceful shutdown")
+	}
+	if err := e.Shutdown(ctxShutdown); err != nil {
+		e.Logger.Warn("forcibly closing http server due to failure of graceful shutdown")
+		if closeErr := e.Close(); closeErr != nil {
+			e.Logger.Error(closeErr)
+		}
+	}
+	e.Logger.Info("finished shutdown")
+}
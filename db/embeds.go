@@ -8,6 +8,7 @@ import (
 	"github.com/sargassum-world/godest/database"
 	sessions "github.com/sargassum-world/godest/session/sqlitestore"
 
+	"github.com/sargassum-world/pslive/internal/clients/apitokens"
 	"github.com/sargassum-world/pslive/internal/clients/chat"
 	"github.com/sargassum-world/pslive/internal/clients/instruments"
 )
@@ -19,6 +20,7 @@ const appID = 370761302
 // Migrations
 
 var DomainEmbeds map[string]database.DomainEmbeds = map[string]database.DomainEmbeds{
+	"apitokens":   apitokens.NewDomainEmbeds(),
 	"chat":        chat.NewDomainEmbeds(),
 	"instruments": instruments.NewDomainEmbeds(),
 	"sessions":    sessions.NewDomainEmbeds(),
@@ -35,6 +37,19 @@ var MigrationFiles []database.MigrationFile = []database.MigrationFile{
 	{Domain: "instruments", File: instruments.MigrationFiles[4]},
 	{Domain: "instruments", File: instruments.MigrationFiles[5]},
 	{Domain: "instruments", File: instruments.MigrationFiles[6]},
+	{Domain: "instruments", File: instruments.MigrationFiles[7]},
+	{Domain: "instruments", File: instruments.MigrationFiles[8]},
+	{Domain: "instruments", File: instruments.MigrationFiles[9]},
+	{Domain: "instruments", File: instruments.MigrationFiles[10]},
+	{Domain: "instruments", File: instruments.MigrationFiles[11]},
+	{Domain: "instruments", File: instruments.MigrationFiles[12]},
+	{Domain: "instruments", File: instruments.MigrationFiles[13]},
+	{Domain: "instruments", File: instruments.MigrationFiles[14]},
+	{Domain: "instruments", File: instruments.MigrationFiles[15]},
+	{Domain: "apitokens", File: apitokens.MigrationFiles[0]},
+	{Domain: "instruments", File: instruments.MigrationFiles[16]},
+	{Domain: "instruments", File: instruments.MigrationFiles[17]},
+	{Domain: "instruments", File: instruments.MigrationFiles[18]},
 }
 
 // Queries
@@ -2,10 +2,43 @@
 # description: Internal auth-related utilities for the pslive app
 package sargassum.pslive.internal.app.pslive.auth
 
+import future.keywords.in
+
 # METADATA
 # description: |
-#   Check the subject to determine whether it is authenticated
-is_authenticated(subject) {
+#   Check the subject to determine whether it is authenticated, either with a browser session or
+#   with an API token
+is_identified(subject) {
 	subject.authenticated
 	subject.identity != ""
 } else = false
+
+# METADATA
+# description: |
+#   Check the subject to determine whether it is authenticated with a browser session
+is_authenticated(subject) {
+	is_identified(subject)
+	not is_token_subject(subject)
+} else = false
+
+# METADATA
+# description: |
+#   Check the subject to determine whether it is authenticated with an API token
+is_token_subject(subject) {
+	subject.metadata.token
+}
+
+# METADATA
+# description: |
+#   Check whether the subject may act within the scope. Subjects authenticated with a browser
+#   session may act within every scope, while subjects authenticated with an API token may only act
+#   within the scopes granted to the token.
+has_scope(subject, _) {
+	is_authenticated(subject)
+}
+
+has_scope(subject, scope) {
+	is_identified(subject)
+	is_token_subject(subject)
+	scope in subject.metadata.scopes
+}
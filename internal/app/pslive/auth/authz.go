@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/open-policy-agent/opa/ast"
@@ -111,6 +112,9 @@ func (azc *AuthzChecker) requireAuthzWithContextualData(
 func (azc *AuthzChecker) RequireAuthz(
 	ctx context.Context, input map[string]interface{},
 ) (authzErr error, evalErr error) {
+	// The current time can't be in input.context, since everything in input.context is treated as
+	// unknown for transpiling the policy into a database query
+	input["now"] = time.Now().UnixMilli()
 	authzErr, evalErr = azc.requireAuthzWithoutContextualData(ctx, input)
 	if evalErr != nil {
 		return nil, evalErr
@@ -176,6 +180,19 @@ func (azc *AuthzChecker) RequireTSAuthz(
 	)
 }
 
+func (azc *AuthzChecker) RequireVSAuthz(
+	ctx context.Context, streamName string, a Auth,
+) (authzErr error, evalErr error) {
+	return azc.RequireAuthz(
+		ctx,
+		opa.Input{
+			Resource:  opa.NewResource(streamName),
+			Operation: opa.NewOperation("SUB", url.Values{}),
+			Subject:   a.Identity.NewSubject(),
+		}.Map(),
+	)
+}
+
 func (azc *AuthzChecker) NewHTTPMiddleware(ss *session.Store) echo.MiddlewareFunc {
 	return func(next echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -31,10 +31,22 @@ func RegisterGobTypes() {
 type Identity struct {
 	Authenticated bool
 	User          ory.IdentityID
+	// TokenScopes is non-nil if the identity was authenticated with an API token rather than with a
+	// browser session, in which case it lists the scopes granted to the token.
+	TokenScopes []string
+	// TokenID identifies the API token used to authenticate the identity, if any.
+	TokenID int64
 }
 
 func (i Identity) NewSubject() opa.Subject {
-	return opa.NewSubject(string(i.User), i.Authenticated)
+	subject := opa.NewSubject(string(i.User), i.Authenticated)
+	if i.TokenScopes != nil {
+		subject.Metadata = map[string]interface{}{
+			"token":  true,
+			"scopes": i.TokenScopes,
+		}
+	}
+	return subject
 }
 
 func SetIdentity(s *sessions.Session, id ory.IdentityID) {
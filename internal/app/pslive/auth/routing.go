@@ -19,7 +19,7 @@ type (
 func HandleHTTP(h HTTPHandlerFunc, ss *session.Store) echo.HandlerFunc {
 	return func(c echo.Context) error {
 		a, sess, err := GetFromRequest(c.Request(), ss, c.Logger())
-		if sess.IsNew {
+		if sess.IsNew && a.Identity.TokenScopes == nil {
 			// We don't expect the handler to write to the session, so we save it now
 			if serr := sess.Save(c.Request(), c.Response()); serr != nil {
 				return errors.Wrap(err, "couldn't save new session")
@@ -39,6 +39,17 @@ func LookupStored(id string, ss *session.Store) (a Auth, s *sessions.Session, er
 func GetFromRequest(
 	r *http.Request, ss *session.Store, l godest.Logger,
 ) (a Auth, s *sessions.Session, err error) {
+	if identity, ok := getTokenIdentity(r.Context()); ok {
+		// Requests authenticated with API tokens don't belong to browser sessions, so we give them a
+		// new session which shouldn't be saved
+		if s, err = ss.New(r); err != nil {
+			return Auth{}, s, err
+		}
+		a.Identity = identity
+		a.CSRF.Config = ss.CSRFOptions()
+		return a, s, nil
+	}
+
 	s, err = ss.Get(r)
 	if err != nil {
 		// If the user doesn't have a valid session, create one
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/csrf"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/sargassum-world/pslive/internal/clients/apitokens"
+	"github.com/sargassum-world/pslive/internal/clients/ory"
+)
+
+type tokenIdentityKey struct{}
+
+func getTokenIdentity(ctx context.Context) (identity Identity, ok bool) {
+	identity, ok = ctx.Value(tokenIdentityKey{}).(Identity)
+	return identity, ok
+}
+
+func parseBearerToken(header string) (token string, ok bool) {
+	const scheme = "Bearer "
+	if len(header) < len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) {
+		return "", false
+	}
+	return strings.TrimSpace(header[len(scheme):]), true
+}
+
+// NewTokenMiddleware authenticates requests which provide an API token as a bearer token in their
+// Authorization header, so that the token's identity and scopes are used for authorization. Such
+// requests are exempted from CSRF checks, because browsers never attach bearer tokens to requests
+// on their own. This middleware must be used before the CSRF middleware.
+func NewTokenMiddleware(ts *apitokens.Store) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			secret, ok := parseBearerToken(c.Request().Header.Get(echo.HeaderAuthorization))
+			if !ok {
+				return next(c)
+			}
+
+			token, ok, err := ts.Authenticate(c.Request().Context(), secret)
+			if err != nil {
+				return errors.Wrap(err, "couldn't authenticate api token")
+			}
+			if !ok {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+				return echo.NewHTTPError(http.StatusUnauthorized, "invalid or expired api token")
+			}
+			identity := Identity{
+				Authenticated: true,
+				User:          ory.IdentityID(token.IdentityID),
+				TokenScopes:   make([]string, len(token.Scopes)),
+				TokenID:       int64(token.ID),
+			}
+			for i, scope := range token.Scopes {
+				identity.TokenScopes[i] = string(scope)
+			}
+			r := csrf.UnsafeSkipCheck(c.Request())
+			c.SetRequest(r.WithContext(context.WithValue(r.Context(), tokenIdentityKey{}, identity)))
+			return next(c)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package client
 
 import (
+	"github.com/sargassum-world/pslive/internal/clients/genericmqtt"
+	"github.com/sargassum-world/pslive/internal/clients/httpjson"
 	"github.com/sargassum-world/pslive/internal/clients/instruments"
 	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
 )
@@ -12,3 +14,19 @@ func NewPlanktoScopeControllerActionRunnerGetter(
 		return o.Get(planktoscope.ClientID(id))
 	}
 }
+
+func NewGenericMQTTControllerActionRunnerGetter(
+	o *genericmqtt.Orchestrator,
+) instruments.ControllerActionRunnerGetter {
+	return func(id instruments.ControllerID) (a instruments.ControllerActionRunner, ok bool) {
+		return o.Get(genericmqtt.ClientID(id))
+	}
+}
+
+func NewHTTPJSONControllerActionRunnerGetter(
+	o *httpjson.Orchestrator,
+) instruments.ControllerActionRunnerGetter {
+	return func(id instruments.ControllerID) (a instruments.ControllerActionRunner, ok bool) {
+		return o.Get(httpjson.ClientID(id))
+	}
+}
@@ -3,6 +3,7 @@ package client
 
 import (
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sargassum-world/godest"
 	"github.com/sargassum-world/godest/actioncable"
 	"github.com/sargassum-world/godest/authn"
@@ -15,7 +16,11 @@ import (
 
 	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
 	"github.com/sargassum-world/pslive/internal/app/pslive/conf"
+	"github.com/sargassum-world/pslive/internal/clients/apitokens"
 	"github.com/sargassum-world/pslive/internal/clients/chat"
+	"github.com/sargassum-world/pslive/internal/clients/events"
+	"github.com/sargassum-world/pslive/internal/clients/genericmqtt"
+	"github.com/sargassum-world/pslive/internal/clients/httpjson"
 	"github.com/sargassum-world/pslive/internal/clients/instruments"
 	"github.com/sargassum-world/pslive/internal/clients/ory"
 	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
@@ -47,11 +52,20 @@ type Globals struct {
 
 	Instruments    *instruments.Store
 	Planktoscopes  *planktoscope.Orchestrator
+	GenericMQTT    *genericmqtt.Orchestrator
+	HTTPJSON       *httpjson.Orchestrator
 	InstrumentJobs *instruments.JobOrchestrator
+	Control        *instruments.ControlArbiter
+	ControlLeases  *instruments.ControlLeaser
+	Events         *events.Broker
+	Webhooks       *instruments.WebhookDispatcher
 
-	Presence *presence.Store
-	Chat     *chat.Store
-	VSBroker *videostreams.Broker
+	Presence  *presence.Store
+	Chat      *chat.Store
+	APITokens *apitokens.Store
+	VSBroker  *videostreams.Broker
+
+	Metrics *prometheus.Registry
 }
 
 func NewBaseGlobals(
@@ -120,22 +134,38 @@ func NewGlobals(
 		return nil, errors.Wrap(err, "couldn't set up base globals")
 	}
 
-	g.Instruments = instruments.NewStore(g.Base.DB)
-	g.Planktoscopes = planktoscope.NewOrchestrator(l)
+	instrumentsConfig, err := instruments.GetConfig()
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't set up instruments config")
+	}
+	g.Instruments = instruments.NewStore(g.Base.DB, instrumentsConfig)
+	g.Planktoscopes = planktoscope.NewOrchestrator(
+		NewPlanktoscopeSampleStore(g.Instruments), NewPlanktoscopeSettingsStore(g.Instruments), l,
+	)
+	g.GenericMQTT = genericmqtt.NewOrchestrator(l)
+	g.HTTPJSON = httpjson.NewOrchestrator(l)
+	g.Control = instruments.NewControlArbiter()
+	g.Events = events.NewBroker(l)
+	g.Webhooks = instruments.NewWebhookDispatcher(g.Instruments, instrumentsConfig, l)
+	g.ControlLeases = instruments.NewControlLeaser(g.Instruments, instrumentsConfig, l)
 	instrumentControllerActionRunners := instruments.NewControllerActionRunnerStore(
-		g.Instruments,
+		g.Instruments, g.Control,
 		map[string]instruments.ControllerActionRunnerGetter{
-			"planktoscope-v2.3": NewPlanktoScopeControllerActionRunnerGetter(g.Planktoscopes),
+			planktoscope.Protocol: NewPlanktoScopeControllerActionRunnerGetter(g.Planktoscopes),
+			genericmqtt.Protocol:  NewGenericMQTTControllerActionRunnerGetter(g.GenericMQTT),
+			httpjson.Protocol:     NewHTTPJSONControllerActionRunnerGetter(g.HTTPJSON),
 		},
 	)
 	g.InstrumentJobs = instruments.NewJobOrchestrator(map[string]instruments.ActionHandler{
 		"sleep":      instruments.HandleSleepAction,
 		"controller": instrumentControllerActionRunners.HandleControllerAction,
-	}, l)
+	}, g.Control, g.Instruments, g.Events, g.Webhooks, l)
 
 	g.Presence = presence.NewStore()
 	g.Chat = chat.NewStore(g.Base.DB)
+	g.APITokens = apitokens.NewStore(g.Base.DB)
 	g.VSBroker = videostreams.NewBroker(l)
 
+	g.Metrics = NewMetricsRegistry(g)
 	return g, nil
 }
@@ -0,0 +1,159 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/sargassum-world/godest"
+	"github.com/sargassum-world/godest/database"
+	"zombiezen.com/go/sqlite"
+
+	"github.com/sargassum-world/pslive/internal/clients/presence"
+)
+
+const metricsNamespace = "pslive"
+
+// NewMetricsRegistry makes a Prometheus registry of the metrics of the runtime and of the clients
+// in the globals.
+func NewMetricsRegistry(g *Globals) *prometheus.Registry {
+	r := prometheus.NewRegistry()
+	r.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		g.VSBroker,
+		g.Planktoscopes,
+		g.GenericMQTT,
+		g.InstrumentJobs,
+		newPresenceCollector(g.Presence),
+		newDatabaseCollector(g.Base.DB, g.Base.Logger),
+	)
+	return r
+}
+
+// Presence
+
+type presenceCollector struct {
+	ps       *presence.Store
+	sessions *prometheus.Desc
+}
+
+func newPresenceCollector(ps *presence.Store) *presenceCollector {
+	return &presenceCollector{
+		ps: ps,
+		sessions: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "presence", "sessions"),
+			"Number of sessions present on each presence topic, such as an instrument's viewers.",
+			[]string{"topic"}, nil,
+		),
+	}
+}
+
+func (c *presenceCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.sessions
+}
+
+func (c *presenceCollector) Collect(ch chan<- prometheus.Metric) {
+	for topic, count := range c.ps.Counts() {
+		ch <- prometheus.MustNewConstMetric(
+			c.sessions, prometheus.GaugeValue, float64(count), string(topic),
+		)
+	}
+}
+
+// Database
+
+type databaseCollector struct {
+	db     *database.DB
+	logger godest.Logger
+
+	poolSize    *prometheus.Desc
+	acquireTime *prometheus.Desc
+	size        *prometheus.Desc
+	freePages   *prometheus.Desc
+}
+
+func newDatabaseCollector(db *database.DB, l godest.Logger) *databaseCollector {
+	const subsystem = "sqlite"
+	return &databaseCollector{
+		db:     db,
+		logger: l,
+		poolSize: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, subsystem, "pool_connections"),
+			"Maximum number of connections in each SQLite connection pool.",
+			[]string{"pool"}, nil,
+		),
+		acquireTime: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, subsystem, "pool_acquire_seconds"),
+			"Time taken to acquire a connection from the SQLite reader pool during the scrape, which "+
+				"grows when all of the pool's connections are busy.",
+			[]string{"pool"}, nil,
+		),
+		size: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, subsystem, "database_size_bytes"),
+			"Size of the SQLite database, excluding its write-ahead log.",
+			nil, nil,
+		),
+		freePages: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, subsystem, "freelist_pages"),
+			"Number of unused pages in the SQLite database.",
+			nil, nil,
+		),
+	}
+}
+
+func (c *databaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.poolSize
+	ch <- c.acquireTime
+	ch <- c.size
+	ch <- c.freePages
+}
+
+func (c *databaseCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(
+		c.poolSize, prometheus.GaugeValue, float64(c.db.Config.ReadPoolSize), "read",
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.poolSize, prometheus.GaugeValue, float64(c.db.Config.WritePoolSize), "write",
+	)
+
+	// We only probe the reader pool, since waiting on the writer pool would block writes
+	const acquireTimeout = time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), acquireTimeout)
+	defer cancel()
+	start := time.Now()
+	conn, err := c.db.AcquireReader(ctx)
+	if err != nil {
+		c.logger.Error(errors.Wrap(err, "couldn't acquire reader to collect database metrics"))
+		return
+	}
+	defer c.db.ReleaseReader(conn)
+	ch <- prometheus.MustNewConstMetric(
+		c.acquireTime, prometheus.GaugeValue, time.Since(start).Seconds(), "read",
+	)
+
+	var pageCount, pageSize, freelistCount int64
+	if err = database.ExecuteSelection(
+		conn,
+		"SELECT page_count, page_size, freelist_count "+
+			"FROM pragma_page_count(), pragma_page_size(), pragma_freelist_count()",
+		nil,
+		func(s *sqlite.Stmt) error {
+			pageCount = s.GetInt64("page_count")
+			pageSize = s.GetInt64("page_size")
+			freelistCount = s.GetInt64("freelist_count")
+			return nil
+		},
+	); err != nil {
+		c.logger.Error(errors.Wrap(err, "couldn't query database metrics"))
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(
+		c.size, prometheus.GaugeValue, float64(pageCount*pageSize),
+	)
+	ch <- prometheus.MustNewConstMetric(
+		c.freePages, prometheus.GaugeValue, float64(freelistCount),
+	)
+}
@@ -0,0 +1,71 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
+)
+
+type PlanktoscopeSampleStore struct {
+	is *instruments.Store
+}
+
+func NewPlanktoscopeSampleStore(is *instruments.Store) *PlanktoscopeSampleStore {
+	return &PlanktoscopeSampleStore{
+		is: is,
+	}
+}
+
+func (s *PlanktoscopeSampleStore) GetSample(
+	ctx context.Context, id planktoscope.ClientID,
+) (planktoscope.SampleMetadata, error) {
+	controller, err := s.is.GetController(ctx, instruments.ControllerID(id))
+	if err != nil {
+		return planktoscope.SampleMetadata{}, errors.Wrapf(err, "couldn't look up controller %d", id)
+	}
+	sample, err := s.is.GetSample(ctx, controller.InstrumentID)
+	if err != nil {
+		return planktoscope.SampleMetadata{}, errors.Wrapf(
+			err, "couldn't look up sample of instrument %d", controller.InstrumentID,
+		)
+	}
+	return planktoscope.SampleMetadata{
+		ProjectID:    sample.ProjectID,
+		SampleID:     sample.SampleID,
+		Operator:     sample.Operator,
+		SamplingGear: sample.SamplingGear,
+		Latitude:     sample.Latitude,
+		Longitude:    sample.Longitude,
+		DepthMin:     sample.DepthMin,
+		DepthMax:     sample.DepthMax,
+		Volume:       sample.Volume,
+		NetMesh:      sample.NetMesh,
+	}, nil
+}
+
+func (s *PlanktoscopeSampleStore) AddAcquisition(
+	ctx context.Context, id planktoscope.ClientID, start time.Time, source string,
+	metadata planktoscope.Metadata,
+) error {
+	controller, err := s.is.GetController(ctx, instruments.ControllerID(id))
+	if err != nil {
+		return errors.Wrapf(err, "couldn't look up controller %d", id)
+	}
+	marshaled, err := json.Marshal(metadata)
+	if err != nil {
+		return errors.Wrap(err, "couldn't serialize acquisition metadata")
+	}
+	_, err = s.is.AddAcquisition(ctx, instruments.Acquisition{
+		InstrumentID: controller.InstrumentID,
+		ControllerID: controller.ID,
+		StartTime:    start,
+		Source:       source,
+		Metadata:     string(marshaled),
+	})
+	return err
+}
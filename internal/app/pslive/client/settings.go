@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
+)
+
+type PlanktoscopeSettingsStore struct {
+	is *instruments.Store
+}
+
+func NewPlanktoscopeSettingsStore(is *instruments.Store) *PlanktoscopeSettingsStore {
+	return &PlanktoscopeSettingsStore{
+		is: is,
+	}
+}
+
+func (s *PlanktoscopeSettingsStore) GetSettings(
+	ctx context.Context, id planktoscope.ClientID,
+) (settings planktoscope.Settings, saved bool, err error) {
+	recorded, err := s.is.GetControllerSettings(ctx, instruments.ControllerID(id))
+	if err != nil {
+		return planktoscope.Settings{}, false, err
+	}
+	if !recorded.Saved {
+		return planktoscope.Settings{}, false, nil
+	}
+	return planktoscope.Settings{
+		Pump: planktoscope.PumpSettings{
+			Forward:  recorded.PumpForward,
+			Volume:   recorded.PumpVolume,
+			Flowrate: recorded.PumpFlowrate,
+		},
+		Camera: planktoscope.CameraSettings{
+			ISO:                  recorded.CameraISO,
+			ShutterSpeed:         recorded.CameraShutterSpeed,
+			AutoWhiteBalance:     recorded.CameraAutoWhiteBalance,
+			WhiteBalanceRedGain:  recorded.CameraWhiteBalanceRedGain,
+			WhiteBalanceBlueGain: recorded.CameraWhiteBalanceBlueGain,
+		},
+		Imager: planktoscope.ImagerSettings{
+			Forward:    recorded.ImagerForward,
+			StepVolume: recorded.ImagerStepVolume,
+			StepDelay:  recorded.ImagerStepDelay,
+			Steps:      recorded.ImagerSteps,
+		},
+	}, true, nil
+}
+
+func (s *PlanktoscopeSettingsStore) SaveSettings(
+	ctx context.Context, id planktoscope.ClientID, settings planktoscope.Settings,
+) error {
+	cid := instruments.ControllerID(id)
+	return s.is.SetControllerSettings(ctx, cid, instruments.ControllerSettings{
+		PumpForward:                settings.Pump.Forward,
+		PumpVolume:                 settings.Pump.Volume,
+		PumpFlowrate:               settings.Pump.Flowrate,
+		CameraISO:                  settings.Camera.ISO,
+		CameraShutterSpeed:         settings.Camera.ShutterSpeed,
+		CameraAutoWhiteBalance:     settings.Camera.AutoWhiteBalance,
+		CameraWhiteBalanceRedGain:  settings.Camera.WhiteBalanceRedGain,
+		CameraWhiteBalanceBlueGain: settings.Camera.WhiteBalanceBlueGain,
+		ImagerForward:              settings.Imager.Forward,
+		ImagerStepVolume:           settings.Imager.StepVolume,
+		ImagerStepDelay:            settings.Imager.StepDelay,
+		ImagerSteps:                settings.Imager.Steps,
+	})
+}
+
+func (s *PlanktoscopeSettingsStore) RestoresCamera(
+	ctx context.Context, id planktoscope.ClientID,
+) (bool, error) {
+	recorded, err := s.is.GetControllerSettings(ctx, instruments.ControllerID(id))
+	if err != nil {
+		return false, err
+	}
+	return recorded.RestoreCamera, nil
+}
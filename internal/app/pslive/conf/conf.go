@@ -7,8 +7,10 @@ import (
 )
 
 type Config struct {
-	Cache ristretto.Config
-	HTTP  HTTPConfig
+	Cache   ristretto.Config
+	HTTP    HTTPConfig
+	Metrics MetricsConfig
+	Health  HealthConfig
 }
 
 func GetConfig() (c Config, err error) {
@@ -21,5 +23,15 @@ func GetConfig() (c Config, err error) {
 	if err != nil {
 		return Config{}, errors.Wrap(err, "couldn't make http config")
 	}
+
+	c.Metrics, err = getMetricsConfig()
+	if err != nil {
+		return Config{}, errors.Wrap(err, "couldn't make metrics config")
+	}
+
+	c.Health, err = getHealthConfig()
+	if err != nil {
+		return Config{}, errors.Wrap(err, "couldn't make health config")
+	}
 	return c, nil
 }
@@ -0,0 +1,23 @@
+package conf
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/env"
+)
+
+const healthEnvPrefix = "PSLIVE_HEALTH_"
+
+type HealthConfig struct {
+	// ShowDetails is whether readiness checks break down the status of the database, authorization
+	// policies, Ory, controllers, and cameras, rather than only reporting the server's overall
+	// status. The breakdown is shown to anyone who can reach the server, so it should only be enabled
+	// if the readiness route isn't publicly reachable.
+	ShowDetails bool
+}
+
+func getHealthConfig() (c HealthConfig, err error) {
+	if c.ShowDetails, err = env.GetBool(healthEnvPrefix + "SHOWDETAILS"); err != nil {
+		return HealthConfig{}, errors.Wrap(err, "couldn't make show details config")
+	}
+	return c, nil
+}
@@ -5,16 +5,51 @@ import (
 	"github.com/sargassum-world/godest/env"
 )
 
+const httpEnvPrefix = "PSLIVE_HTTP_"
+
 type HTTPConfig struct {
 	GzipLevel int
+	// Address is the TCP address which the server listens on.
+	Address string
+	// TLSCertFile and TLSKeyFile are the paths of the PEM-encoded certificate and private key for
+	// serving HTTPS. If either is empty, the server only serves HTTP.
+	TLSCertFile string
+	TLSKeyFile  string
+	// RedirectAddress is the TCP address of an additional listener which redirects HTTP requests to
+	// HTTPS. If it's empty, or if the server isn't serving HTTPS, no such listener is started.
+	RedirectAddress string
+	// HSTSMaxAge is the max-age, in seconds, of the Strict-Transport-Security header sent in HTTPS
+	// responses. If it's zero, the header isn't sent.
+	HSTSMaxAge int64
+}
+
+func (c HTTPConfig) TLSEnabled() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
 }
 
 func getHTTPConfig() (c HTTPConfig, err error) {
 	const defaultGzipLevel = 1
-	rawGzipLevel, err := env.GetInt64("PSLIVE_HTTP_GZIPLEVEL", defaultGzipLevel)
+	rawGzipLevel, err := env.GetInt64(httpEnvPrefix+"GZIPLEVEL", defaultGzipLevel)
 	if err != nil {
 		return HTTPConfig{}, errors.Wrap(err, "couldn't make gzip level config")
 	}
 	c.GzipLevel = int(rawGzipLevel)
+
+	c.Address = env.GetString(httpEnvPrefix+"ADDRESS", ":3000")
+	c.TLSCertFile = env.GetString(httpEnvPrefix+"TLSCERT", "")
+	c.TLSKeyFile = env.GetString(httpEnvPrefix+"TLSKEY", "")
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return HTTPConfig{}, errors.Errorf(
+			"%sTLSCERT and %sTLSKEY must be set together", httpEnvPrefix, httpEnvPrefix,
+		)
+	}
+	c.RedirectAddress = env.GetString(httpEnvPrefix+"REDIRECTADDRESS", "")
+
+	const defaultHSTSMaxAge = 365 * 24 * 60 * 60 // 1 year
+	if c.HSTSMaxAge, err = env.GetInt64(
+		httpEnvPrefix+"HSTSMAXAGE", defaultHSTSMaxAge,
+	); err != nil {
+		return HTTPConfig{}, errors.Wrap(err, "couldn't make hsts max-age config")
+	}
 	return c, nil
 }
@@ -0,0 +1,32 @@
+package conf
+
+import (
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/env"
+)
+
+const metricsEnvPrefix = "PSLIVE_METRICS_"
+
+type MetricsConfig struct {
+	// Enabled is whether Prometheus metrics are served at /metrics.
+	Enabled bool
+	// Username and Password are the HTTP basic authentication credentials required to get the
+	// metrics. Password must be set if metrics are enabled, since the metrics reveal details about
+	// the server's instruments and users' activity.
+	Username string
+	Password string
+}
+
+func getMetricsConfig() (c MetricsConfig, err error) {
+	if c.Enabled, err = env.GetBool(metricsEnvPrefix + "ENABLED"); err != nil {
+		return MetricsConfig{}, errors.Wrap(err, "couldn't make enabled config")
+	}
+	c.Username = env.GetString(metricsEnvPrefix+"USERNAME", "prometheus")
+	c.Password = env.GetString(metricsEnvPrefix+"PASSWORD", "")
+	if c.Enabled && c.Password == "" {
+		return MetricsConfig{}, errors.Errorf(
+			"%sPASSWORD must be set when metrics are enabled", metricsEnvPrefix,
+		)
+	}
+	return c, nil
+}
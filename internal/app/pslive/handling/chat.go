@@ -83,9 +83,12 @@ func replaceChatSendStream(topic string, authorizeSend bool, a auth.Auth) turbos
 	}
 }
 
+// ChatMessageHandler is called after a chat message is sent, to notify other parts of the server.
+type ChatMessageHandler func(c echo.Context, m ChatMessageViewData) error
+
 func HandleChatMessagesPost(
 	r godest.TemplateRenderer, oc *ory.Client, azc *auth.AuthzChecker,
-	tsh *turbostreams.Hub, cs *chat.Store,
+	tsh *turbostreams.Hub, cs *chat.Store, onMessage ChatMessageHandler,
 ) auth.HTTPHandlerFunc {
 	sendT := sendPartial
 	r.MustHave(sendT)
@@ -113,6 +116,11 @@ func HandleChatMessagesPost(
 		mvd := NewChatMessageViewData(m)
 		mvd.SenderIdentifier = user
 		tsh.Broadcast(string(m.Topic), []turbostreams.Message{appendChatMessageStream(mvd)})
+		if onMessage != nil {
+			if err = onMessage(c, mvd); err != nil {
+				return err
+			}
+		}
 
 		// Render Turbo Stream if accepted
 		if turbostreams.Accepted(c.Request().Header) {
@@ -0,0 +1,30 @@
+package handling
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+)
+
+// FilterListedInstruments returns only the instruments which the user is allowed to see in listings
+// of instruments, according to the visibility of each instrument.
+func FilterListedInstruments(
+	ctx context.Context, unfiltered []instruments.Instrument, a auth.Auth, azc *auth.AuthzChecker,
+) (filtered []instruments.Instrument, err error) {
+	filtered = make([]instruments.Instrument, 0, len(unfiltered))
+	for _, instrument := range unfiltered {
+		path := fmt.Sprintf("/instruments/%d", instrument.ID)
+		listed, err := azc.Allow(ctx, a, path, "LIST", nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't check authz for listing instrument %d", instrument.ID)
+		}
+		if listed {
+			filtered = append(filtered, instrument)
+		}
+	}
+	return filtered, nil
+}
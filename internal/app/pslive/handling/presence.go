@@ -50,8 +50,13 @@ const (
 	subPubDelay       = 100 // ms; delay the pub so that we can update the page whose GET caused the sub
 )
 
+// PresenceChangeHandler is called after the set of sessions present on a topic changes, to notify
+// other parts of the server.
+type PresenceChangeHandler func(c *turbostreams.Context, topic presence.Topic) error
+
 func HandlePresenceSub(
 	r godest.TemplateRenderer, ss *session.Store, oc *ory.Client, ps *presence.Store,
+	onChange PresenceChangeHandler,
 ) turbostreams.HandlerFunc {
 	tList := usersListPartial
 	r.MustHave(tList)
@@ -76,6 +81,9 @@ func HandlePresenceSub(
 					c.Broadcast(string(topic)+"/list", replacePresenceListStream(topic, tList, ps))
 					c.Broadcast(string(topic)+"/count", replacePresenceCountStream(topic, tCount, ps))
 				}()
+				if onChange != nil {
+					return onChange(c, topic)
+				}
 			}
 			return nil
 		},
@@ -85,6 +93,7 @@ func HandlePresenceSub(
 
 func HandlePresenceUnsub(
 	r godest.TemplateRenderer, ss *session.Store, ps *presence.Store,
+	onChange PresenceChangeHandler,
 ) turbostreams.HandlerFunc {
 	tList := usersListPartial
 	r.MustHave(tList)
@@ -96,6 +105,9 @@ func HandlePresenceUnsub(
 			if ps.Remove(topic, presence.SessionID(sess.ID)) {
 				c.Broadcast(string(topic)+"/list", replacePresenceListStream(topic, tList, ps))
 				c.Broadcast(string(topic)+"/count", replacePresenceCountStream(topic, tCount, ps))
+				if onChange != nil {
+					return onChange(c, topic)
+				}
 			}
 			return nil
 		},
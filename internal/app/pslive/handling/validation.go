@@ -0,0 +1,40 @@
+package handling
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// FieldErrors describes the problems with the invalid fields of a request, keyed by the names of
+// the fields.
+type FieldErrors map[string]string
+
+func (e FieldErrors) Add(field, format string, a ...interface{}) {
+	e[field] = fmt.Sprintf(format, a...)
+}
+
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	problems := make([]string, len(fields))
+	for i, field := range fields {
+		problems[i] = fmt.Sprintf("%s %s", field, e[field])
+	}
+	return strings.Join(problems, "; ")
+}
+
+// HTTPError returns a Bad Request error with the field errors as its message, or nil if there are
+// no field errors.
+func (e FieldErrors) HTTPError() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return echo.NewHTTPError(http.StatusBadRequest, e)
+}
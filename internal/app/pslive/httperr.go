@@ -1,8 +1,10 @@
 package pslive
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/csrf"
 	"github.com/labstack/echo/v4"
@@ -12,14 +14,50 @@ import (
 	"github.com/sargassum-world/godest/session"
 
 	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/app/pslive/handling"
 )
 
+// apiPathPrefix is the path prefix of routes which produce JSON instead of HTML pages.
+const apiPathPrefix = "/api/"
+
 type ErrorData struct {
 	Code     int
 	Error    httperr.DescriptiveError
 	Messages []string
 }
 
+// APIError is the body of JSON API error responses.
+type APIError struct {
+	Error APIErrorDetails `json:"error"`
+}
+
+type APIErrorDetails struct {
+	Status  int                  `json:"status"`
+	Message string               `json:"message"`
+	Fields  handling.FieldErrors `json:"fields,omitempty"`
+}
+
+// newAPIError describes the error without leaking any internal details of server errors.
+func newAPIError(code int, err error) APIError {
+	details := APIErrorDetails{Status: code, Message: http.StatusText(code)}
+	herr, ok := err.(*echo.HTTPError)
+	if !ok || code == http.StatusInternalServerError {
+		return APIError{Error: details}
+	}
+	switch message := herr.Message.(type) {
+	case string:
+		details.Message = message
+	case handling.FieldErrors:
+		details.Message = "invalid request fields"
+		details.Fields = message
+	}
+	return APIError{Error: details}
+}
+
+func isAPIRequest(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, apiPathPrefix)
+}
+
 func NewHTTPErrorHandler(tr godest.TemplateRenderer, ss *session.Store) echo.HTTPErrorHandler {
 	tr.MustHave("app/httperr.page.tmpl")
 	return func(err error, c echo.Context) {
@@ -36,6 +74,12 @@ func NewHTTPErrorHandler(tr godest.TemplateRenderer, ss *session.Store) echo.HTT
 		if herr, ok := err.(*echo.HTTPError); ok {
 			code = herr.Code
 		}
+		if isAPIRequest(c.Request()) {
+			if jerr := c.JSON(code, newAPIError(code, err)); jerr != nil {
+				c.Logger().Error(errors.Wrap(jerr, "couldn't render json error in error handler"))
+			}
+			return
+		}
 		errorData := ErrorData{
 			Code:  code,
 			Error: httperr.Describe(code),
@@ -69,6 +113,18 @@ func NewCSRFErrorHandler(
 	tr.MustHave("app/httperr.page.tmpl")
 	return func(w http.ResponseWriter, r *http.Request) {
 		l.Error(csrf.FailureReason(r))
+		code := http.StatusForbidden
+		if isAPIRequest(r) {
+			w.Header().Set(echo.HeaderContentType, echo.MIMEApplicationJSONCharsetUTF8)
+			w.WriteHeader(code)
+			if err := json.NewEncoder(w).Encode(
+				newAPIError(code, echo.NewHTTPError(code, csrf.FailureReason(r).Error())),
+			); err != nil {
+				l.Error(errors.Wrap(err, "couldn't render json error in error handler"))
+			}
+			return
+		}
+
 		// Check authentication & authorization
 		a, sess, serr := auth.GetFromRequest(r, ss, l)
 		if serr != nil {
@@ -80,7 +136,6 @@ func NewCSRFErrorHandler(
 		}
 
 		// Generate error code
-		code := http.StatusForbidden
 		errorData := ErrorData{
 			Code:  code,
 			Error: httperr.Describe(code),
@@ -0,0 +1,50 @@
+package pslive
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NewHTTPMetricsMiddleware makes middleware which records the latencies of HTTP requests by route
+// in the Prometheus registry. Streaming routes (such as video streams and Action Cables) are
+// recorded when their responses end.
+func NewHTTPMetricsMiddleware(r prometheus.Registerer) echo.MiddlewareFunc {
+	requests := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "pslive",
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Time taken to handle HTTP requests, by route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	r.MustRegister(requests)
+
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+			err := next(c)
+
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+			status := c.Response().Status
+			if err != nil {
+				// The error hasn't been rendered into a response yet
+				status = http.StatusInternalServerError
+				var herr *echo.HTTPError
+				if errors.As(err, &herr) {
+					status = herr.Code
+				}
+			}
+			requests.WithLabelValues(
+				c.Request().Method, route, strconv.Itoa(status),
+			).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
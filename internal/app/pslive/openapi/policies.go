@@ -0,0 +1,237 @@
+package openapi
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/open-policy-agent/opa/ast"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/opa"
+)
+
+// Names of the Rego functions which the checks of route descriptions recognize.
+const (
+	hasScopeFunction = "auth.has_scope"
+	routeRule        = "allow"
+)
+
+// Route Policies
+
+type policyRoute struct {
+	method string
+	path   string
+	// rule names the function which the route's policy calls to authorize requests, qualified by the
+	// name of the function's package. It's empty if the route's policy allows all requests.
+	rule string
+}
+
+// normalizePath replaces the names of path parameters, which differ between echo routes and Rego
+// route policies, with a placeholder.
+func normalizePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") {
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// policyIndex lists the route policies and functions defined in a set of Rego modules.
+type policyIndex struct {
+	routes map[routeKey][]policyRoute
+	// functions maps names qualified by package names to the rules defining them
+	functions map[string][]*ast.Rule
+}
+
+func qualify(pkg string, ref ast.Ref) string {
+	name := ref.String()
+	if strings.Contains(name, ".") {
+		return name
+	}
+	return pkg + "." + name
+}
+
+// visitCalls calls f for each function call in the rule, both in expressions and in terms.
+func visitCalls(rule *ast.Rule, f func(operator ast.Ref, operands []*ast.Term)) {
+	ast.WalkExprs(rule, func(e *ast.Expr) bool {
+		if e.IsCall() {
+			f(e.Operator(), e.Operands())
+		}
+		return false
+	})
+	ast.WalkTerms(rule, func(t *ast.Term) bool {
+		if call, ok := t.Value.(ast.Call); ok {
+			if operator, ok := call[0].Value.(ast.Ref); ok {
+				f(operator, call[1:])
+			}
+		}
+		return false
+	})
+}
+
+// parseRoute parses a rule generated from the route table of a Rego route policy, which checks the
+// method and path of the request and then optionally calls a function to authorize the request.
+func parseRoute(pkg string, rule *ast.Rule) (route policyRoute, ok bool) {
+	for _, e := range rule.Body {
+		if !e.IsCall() {
+			continue
+		}
+		operator := e.Operator().String()
+		operands := e.Operands()
+		switch {
+		case operator == ast.Equal.Name && len(operands) == 2:
+			if method, ok := operands[0].Value.(ast.String); ok {
+				route.method = string(method)
+			}
+		case operator == ast.Equality.Name && len(operands) == 2:
+			segments, ok := operands[0].Value.(*ast.Array)
+			if !ok {
+				continue
+			}
+			segments.Foreach(func(t *ast.Term) {
+				if segment, ok := t.Value.(ast.String); ok {
+					route.path += "/" + string(segment)
+					return
+				}
+				route.path += "/*"
+			})
+		default:
+			route.rule = qualify(pkg, e.Operator())
+		}
+	}
+	return route, route.method != "" && route.path != ""
+}
+
+func newPolicyIndex(modules []opa.Module) (index policyIndex, err error) {
+	index = policyIndex{
+		routes:    make(map[routeKey][]policyRoute),
+		functions: make(map[string][]*ast.Rule),
+	}
+	for _, module := range modules {
+		if !strings.HasSuffix(module.Filename, ".rego") {
+			continue
+		}
+		parsed, err := ast.ParseModule(module.Filename, module.Contents)
+		if err != nil {
+			return policyIndex{}, errors.Wrapf(err, "couldn't parse rego module %s", module.Filename)
+		}
+		pkg := parsed.Package.Path[len(parsed.Package.Path)-1].Value.(ast.String)
+		for _, rule := range parsed.Rules {
+			name := rule.Head.Name.String()
+			if name != routeRule {
+				qualified := string(pkg) + "." + name
+				index.functions[qualified] = append(index.functions[qualified], rule)
+				continue
+			}
+			if route, ok := parseRoute(string(pkg), rule); ok {
+				key := routeKey{method: route.method, path: route.path}
+				index.routes[key] = append(index.routes[key], route)
+			}
+		}
+	}
+	return index, nil
+}
+
+// scopes lists the API token scopes which the function checks with auth.has_scope, either directly
+// or through the other functions it calls.
+func (i policyIndex) scopes(function string, visited map[string]bool, scopes map[string]bool) {
+	if visited[function] {
+		return
+	}
+	visited[function] = true
+	pkg := function[:strings.LastIndex(function, ".")]
+	for _, rule := range i.functions[function] {
+		visitCalls(rule, func(operator ast.Ref, operands []*ast.Term) {
+			if operator.String() != hasScopeFunction {
+				i.scopes(qualify(pkg, operator), visited, scopes)
+				return
+			}
+			const scopeOperand = 1
+			if len(operands) <= scopeOperand {
+				return
+			}
+			if scope, ok := operands[scopeOperand].Value.(ast.String); ok {
+				scopes[string(scope)] = true
+			}
+		})
+	}
+}
+
+func formatScopes(scopes map[string]bool) string {
+	formatted := make([]string, 0, len(scopes))
+	for scope := range scopes {
+		formatted = append(formatted, scope)
+	}
+	sort.Strings(formatted)
+	return "[" + strings.Join(formatted, " ") + "]"
+}
+
+// checkAuth checks the authorization requirements described for the route against the route's
+// policy.
+func (i policyIndex) checkAuth(key routeKey, a Auth) error {
+	routes := i.routes[routeKey{method: key.method, path: normalizePath(key.path)}]
+	if len(routes) == 0 {
+		if a.Policy == "" {
+			return nil
+		}
+		return errors.Errorf("no route policy matches %s %s", key.method, key.path)
+	}
+	for _, route := range routes {
+		if route.rule != a.Policy {
+			return errors.Errorf(
+				"route %s %s is described with policy %q, but its route policy calls %q",
+				key.method, key.path, a.Policy, route.rule,
+			)
+		}
+	}
+	if a.Policy == "" {
+		return nil
+	}
+
+	described := make(map[string]bool)
+	for _, scope := range a.Scopes {
+		described[scope] = true
+	}
+	checked := make(map[string]bool)
+	i.scopes(a.Policy, make(map[string]bool), checked)
+	if formatScopes(described) != formatScopes(checked) {
+		return errors.Errorf(
+			"route %s %s is described with scopes %s, but policy %s checks scopes %s",
+			key.method, key.path, formatScopes(described), a.Policy, formatScopes(checked),
+		)
+	}
+	return nil
+}
+
+// CheckPolicies checks that the authorization requirements described for each route match the
+// Rego route policies in the modules: the described policy must be the function which the route's
+// policy calls, and the described API token scopes must be the scopes which that function checks.
+func (r *Registry) CheckPolicies(modules []opa.Module) error {
+	index, err := newPolicyIndex(modules)
+	if err != nil {
+		return err
+	}
+
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	for _, key := range r.routes {
+		op, ok := r.operations[key]
+		if !ok {
+			continue
+		}
+		if err := index.checkAuth(key, op.Auth); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MustCheckPolicies is like CheckPolicies, but it panics if a route description doesn't match the
+// route policies.
+func (r *Registry) MustCheckPolicies(modules []opa.Module) {
+	if err := r.CheckPolicies(modules); err != nil {
+		panic(errors.Wrap(err, "route descriptions don't match route policies"))
+	}
+}
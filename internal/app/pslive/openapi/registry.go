@@ -0,0 +1,470 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+)
+
+// Names of the security schemes in generated documents.
+const (
+	SessionScheme  = "session"
+	APITokenScheme = "apiToken"
+)
+
+// Operation Metadata
+
+// Operation describes what a route does, for the OpenAPI document.
+type Operation struct {
+	Summary     string
+	Description string
+	Tags        []string
+	// Params describes the query parameters of the route. It can also describe path parameters, which
+	// are otherwise documented as plain strings.
+	Params []Param
+	// Body is a value of the type of the JSON request body, if the route accepts one.
+	Body interface{}
+	// Form describes the fields of the URL-encoded form request body, if the route accepts one.
+	Form []Param
+	// Responses describes the successful responses of the route.
+	Responses []Response
+	Auth      Auth
+}
+
+// Param describes a path parameter, query parameter, header, or form field.
+type Param struct {
+	Name        string
+	In          string
+	Description string
+	Required    bool
+	Schema      *Schema
+}
+
+func PathParam(name, description string, schema *Schema) Param {
+	return Param{Name: name, In: "path", Description: description, Required: true, Schema: schema}
+}
+
+func QueryParam(name, description string, schema *Schema) Param {
+	return Param{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func HeaderParam(name, description string, schema *Schema) Param {
+	return Param{Name: name, In: "header", Description: description, Schema: schema}
+}
+
+func FormField(name, description string, schema *Schema) Param {
+	return Param{Name: name, Description: description, Schema: schema}
+}
+
+// Response describes a response of a route.
+type Response struct {
+	Status      int
+	Description string
+	// ContentType is the media type of the response body; it defaults to JSON if Body is set.
+	ContentType string
+	// Body is a value of the type of the JSON response body, if the response has one.
+	Body interface{}
+}
+
+// Auth describes how requests to a route are authorized.
+type Auth struct {
+	// Policy names the Rego rule which authorizes requests to the route.
+	Policy string
+	// Anonymous is set if the policy can allow requests from unauthenticated users, e.g. for public
+	// instruments.
+	Anonymous bool
+	// Scopes lists the API token scopes which the policy requires of requests authenticated with an
+	// API token. If it's nil, the policy doesn't accept API tokens.
+	Scopes []string
+}
+
+// Schemas
+
+func Integer(minimum, maximum float64) *Schema {
+	return &Schema{Type: "integer", Minimum: &minimum, Maximum: &maximum}
+}
+
+func Int64() *Schema {
+	return &Schema{Type: "integer", Format: "int64"}
+}
+
+func Number() *Schema {
+	return &Schema{Type: "number"}
+}
+
+func Boolean() *Schema {
+	return &Schema{Type: "boolean"}
+}
+
+func String() *Schema {
+	return &Schema{Type: "string"}
+}
+
+func Enum(values ...string) *Schema {
+	return &Schema{Type: "string", Enum: values}
+}
+
+// WithDefault sets the default value of the schema.
+func (s *Schema) WithDefault(value interface{}) *Schema {
+	s.Default = value
+	return s
+}
+
+// Registry
+
+type routeKey struct {
+	method string
+	path   string
+}
+
+// Registry records the routes registered on the server and the metadata describing them, to
+// generate an OpenAPI document.
+type Registry struct {
+	info          Info
+	sessionCookie string
+	apiPrefix     string
+	errorBody     interface{}
+	tags          []Tag
+	m             *sync.Mutex
+	routes        []routeKey
+	registered    map[routeKey]bool
+	operations    map[routeKey]Operation
+}
+
+// NewRegistry creates a registry for a document with the provided info. Browser sessions are
+// documented as being held in the cookie named sessionCookie, and routes under apiPrefix are
+// documented as responding to errors with a JSON body of the same type as errorBody.
+func NewRegistry(
+	info Info, sessionCookie string, apiPrefix string, errorBody interface{},
+) *Registry {
+	return &Registry{
+		info:          info,
+		sessionCookie: sessionCookie,
+		apiPrefix:     apiPrefix,
+		errorBody:     errorBody,
+		m:             &sync.Mutex{},
+		registered:    make(map[routeKey]bool),
+		operations:    make(map[routeKey]Operation),
+	}
+}
+
+// Router returns a routing adapter which records every route registered through it.
+func (r *Registry) Router(er godest.EchoRouter) godest.EchoRouter {
+	return recordingRouter{EchoRouter: er, r: r}
+}
+
+func (r *Registry) record(route *echo.Route) *echo.Route {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	key := routeKey{method: route.Method, path: route.Path}
+	if !r.registered[key] {
+		r.registered[key] = true
+		r.routes = append(r.routes, key)
+	}
+	return route
+}
+
+// Tag adds a tag, which can be used to group the operations in the document.
+func (r *Registry) Tag(name, description string) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.tags = append(r.tags, Tag{Name: name, Description: description})
+}
+
+// Describe attaches metadata to the route with the specified method and path. The route must also
+// be registered through the registry's router, or else generation of the document will fail.
+func (r *Registry) Describe(method, path string, op Operation) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	r.operations[routeKey{method: method, path: path}] = op
+}
+
+// Document generates the OpenAPI document for all registered routes.
+func (r *Registry) Document() (doc Document, err error) {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	for key := range r.operations {
+		if !r.registered[key] {
+			return Document{}, errors.Errorf(
+				"described route %s %s was never registered", key.method, key.path,
+			)
+		}
+	}
+
+	doc = Document{
+		OpenAPI: Version,
+		Info:    r.info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas: make(map[string]*Schema),
+			SecuritySchemes: map[string]SecurityScheme{
+				SessionScheme: {
+					Type:        "apiKey",
+					Description: "Browser session established by signing in",
+					In:          "cookie",
+					Name:        r.sessionCookie,
+				},
+				APITokenScheme: {
+					Type:        "http",
+					Description: "Personal API token, limited to the scopes granted to it",
+					Scheme:      "bearer",
+				},
+			},
+		},
+		Tags: r.tags,
+	}
+	for _, key := range r.routes {
+		path, pathParams := openAPIPath(key.path)
+		item, ok := doc.Paths[path]
+		if !ok {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		item[strings.ToLower(key.method)] = r.newOperationObject(
+			key, pathParams, r.operations[key], doc.Components.Schemas,
+		)
+	}
+	return doc, nil
+}
+
+// MustDocument is like Document, but it panics if the document can't be generated. It can be used
+// after all routes are registered, to check that all route descriptions match registered routes.
+func (r *Registry) MustDocument() Document {
+	doc, err := r.Document()
+	if err != nil {
+		panic(errors.Wrap(err, "couldn't generate openapi document"))
+	}
+	return doc
+}
+
+// openAPIPath converts an echo route path into an OpenAPI path template, and lists its parameters.
+func openAPIPath(echoPath string) (path string, params []string) {
+	segments := strings.Split(echoPath, "/")
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		case segment == "*":
+			params = append(params, "path")
+			segments[i] = "{path}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID derives a unique operation ID from the method and path of a route, such as
+// get-instruments-id-cameras for GET /instruments/:id/cameras.
+func operationID(key routeKey) string {
+	words := []string{strings.ToLower(key.method)}
+	for _, segment := range strings.Split(key.path, "/") {
+		segment = strings.TrimPrefix(segment, ":")
+		switch segment {
+		case "":
+			continue
+		case "*":
+			segment = "path"
+		}
+		words = append(words, strings.ReplaceAll(segment, ".", "-"))
+	}
+	return strings.Join(words, "-")
+}
+
+func (r *Registry) newOperationObject(
+	key routeKey, pathParams []string, op Operation, schemas map[string]*Schema,
+) *OperationObject {
+	o := &OperationObject{
+		OperationID: operationID(key),
+		Summary:     op.Summary,
+		Description: op.Description,
+		Tags:        op.Tags,
+		Responses:   make(map[string]ResponseObject),
+		Policy:      op.Auth.Policy,
+	}
+
+	// Parameters
+	described := make(map[string]Param, len(op.Params))
+	for _, param := range op.Params {
+		described[param.In+" "+param.Name] = param
+	}
+	for _, name := range pathParams {
+		param, ok := described["path "+name]
+		if !ok {
+			param = PathParam(name, "", String())
+		}
+		o.Parameters = append(o.Parameters, newParameterObject(param))
+	}
+	for _, param := range op.Params {
+		if param.In != "path" {
+			o.Parameters = append(o.Parameters, newParameterObject(param))
+		}
+	}
+
+	// Request body
+	switch {
+	case op.Body != nil:
+		o.RequestBody = &RequestBodyObject{
+			Required: true,
+			Content: map[string]MediaType{
+				echo.MIMEApplicationJSON: {
+					Schema: schemaGenerator{schemas: schemas, input: true}.schemaFor(reflect.TypeOf(op.Body)),
+				},
+			},
+		}
+	case op.Form != nil:
+		form := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for _, field := range op.Form {
+			schema := *field.Schema
+			schema.Description = field.Description
+			form.Properties[field.Name] = &schema
+			if field.Required {
+				form.Required = append(form.Required, field.Name)
+			}
+		}
+		o.RequestBody = &RequestBodyObject{
+			Required: true,
+			Content:  map[string]MediaType{echo.MIMEApplicationForm: {Schema: form}},
+		}
+	}
+
+	// Responses
+	if len(op.Responses) == 0 {
+		o.Responses["default"] = ResponseObject{Description: "Undocumented response"}
+	}
+	for _, response := range op.Responses {
+		o.Responses[strconv.Itoa(response.Status)] = newResponseObject(response, schemas)
+	}
+	if r.errorBody != nil && strings.HasPrefix(key.path, r.apiPrefix) {
+		o.Responses["default"] = newResponseObject(Response{
+			Description: "Error",
+			Body:        r.errorBody,
+		}, schemas)
+	}
+
+	// Security
+	if op.Auth.Policy != "" || op.Auth.Anonymous || op.Auth.Scopes != nil {
+		o.Security = newSecurityRequirements(op.Auth)
+	}
+	return o
+}
+
+func newParameterObject(param Param) ParameterObject {
+	schema := param.Schema
+	if schema == nil {
+		schema = String()
+	}
+	return ParameterObject{
+		Name:        param.Name,
+		In:          param.In,
+		Description: param.Description,
+		Required:    param.Required,
+		Schema:      schema,
+	}
+}
+
+func newResponseObject(response Response, schemas map[string]*Schema) ResponseObject {
+	description := response.Description
+	if description == "" {
+		description = http.StatusText(response.Status)
+	}
+	o := ResponseObject{Description: description}
+	contentType := response.ContentType
+	if contentType == "" && response.Body != nil {
+		contentType = echo.MIMEApplicationJSON
+	}
+	if contentType != "" {
+		var schema *Schema
+		if response.Body != nil {
+			schema = schemaGenerator{schemas: schemas}.schemaFor(reflect.TypeOf(response.Body))
+		}
+		o.Content = map[string]MediaType{contentType: {Schema: schema}}
+	}
+	return o
+}
+
+func newSecurityRequirements(a Auth) []SecurityRequirement {
+	requirements := []SecurityRequirement{}
+	if a.Anonymous {
+		requirements = append(requirements, SecurityRequirement{})
+	}
+	requirements = append(requirements, SecurityRequirement{SessionScheme: []string{}})
+	if a.Scopes != nil {
+		scopes := append([]string{}, a.Scopes...)
+		sort.Strings(scopes)
+		requirements = append(requirements, SecurityRequirement{APITokenScheme: scopes})
+	}
+	return requirements
+}
+
+// Recording Router
+
+// recordingRouter is a routing adapter which records every route registered through it.
+type recordingRouter struct {
+	godest.EchoRouter
+	r *Registry
+}
+
+func (rr recordingRouter) CONNECT(
+	path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc,
+) *echo.Route {
+	return rr.r.record(rr.EchoRouter.CONNECT(path, h, m...))
+}
+
+func (rr recordingRouter) DELETE(
+	path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc,
+) *echo.Route {
+	return rr.r.record(rr.EchoRouter.DELETE(path, h, m...))
+}
+
+func (rr recordingRouter) GET(
+	path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc,
+) *echo.Route {
+	return rr.r.record(rr.EchoRouter.GET(path, h, m...))
+}
+
+func (rr recordingRouter) HEAD(
+	path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc,
+) *echo.Route {
+	return rr.r.record(rr.EchoRouter.HEAD(path, h, m...))
+}
+
+func (rr recordingRouter) OPTIONS(
+	path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc,
+) *echo.Route {
+	return rr.r.record(rr.EchoRouter.OPTIONS(path, h, m...))
+}
+
+func (rr recordingRouter) PATCH(
+	path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc,
+) *echo.Route {
+	return rr.r.record(rr.EchoRouter.PATCH(path, h, m...))
+}
+
+func (rr recordingRouter) POST(
+	path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc,
+) *echo.Route {
+	return rr.r.record(rr.EchoRouter.POST(path, h, m...))
+}
+
+func (rr recordingRouter) PUT(
+	path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc,
+) *echo.Route {
+	return rr.r.record(rr.EchoRouter.PUT(path, h, m...))
+}
+
+func (rr recordingRouter) TRACE(
+	path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc,
+) *echo.Route {
+	return rr.r.record(rr.EchoRouter.TRACE(path, h, m...))
+}
@@ -0,0 +1,135 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+const componentSchemasRef = "#/components/schemas/"
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaName names the component schema for a named struct type. The "API" prefix used by the
+// types of the JSON API is dropped, and the type arguments of generic types are prepended to the
+// name, so that APIPage[APIInstrument] is named InstrumentPage.
+func schemaName(t reflect.Type) string {
+	name := t.Name()
+	args := ""
+	if start := strings.Index(name, "["); start >= 0 {
+		for _, arg := range strings.Split(strings.TrimSuffix(name[start+1:], "]"), ",") {
+			arg = arg[strings.LastIndex(arg, ".")+1:]
+			args += strings.TrimPrefix(arg, "API")
+		}
+		name = name[:start]
+	}
+	return args + strings.TrimPrefix(name, "API")
+}
+
+// jsonField returns the JSON name of a struct field, and whether the field may be left out.
+func jsonField(f reflect.StructField) (name string, optional bool, ok bool) {
+	if !f.IsExported() {
+		return "", false, false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	optional = strings.Contains(","+opts+",", ",omitempty,") || f.Type.Kind() == reflect.Pointer
+	return name, optional, true
+}
+
+// schemaGenerator generates the schemas of values encoded as JSON. Named struct types are added to
+// schemas and referenced, so that they're only described once in the document.
+type schemaGenerator struct {
+	schemas map[string]*Schema
+	// input is set for request bodies. Since requests may leave out any field to keep its current or
+	// default value, input schemas have no required properties, so they're named separately from the
+	// output schemas of the same types.
+	input bool
+}
+
+func (g schemaGenerator) componentName(t reflect.Type) string {
+	name := schemaName(t)
+	if g.input && !strings.HasSuffix(name, "Request") {
+		name += "Input"
+	}
+	return name
+}
+
+func (g schemaGenerator) schemaFor(t reflect.Type) *Schema {
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+	switch t.Kind() {
+	default:
+		return &Schema{}
+	case reflect.Pointer:
+		return g.schemaFor(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: integerFormat(t)}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: g.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: g.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+		name := g.componentName(t)
+		if _, ok := g.schemas[name]; !ok {
+			g.schemas[name] = nil // prevent infinite recursion on recursive types
+			g.schemas[name] = g.structSchema(t)
+		}
+		return &Schema{Ref: componentSchemasRef + name}
+	}
+}
+
+func integerFormat(t reflect.Type) string {
+	switch t.Kind() {
+	default:
+		return ""
+	case reflect.Int32, reflect.Uint32:
+		return "int32"
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint64:
+		return "int64"
+	}
+}
+
+func (g schemaGenerator) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Tag.Get("json") == "" && f.Type.Kind() == reflect.Struct {
+			embedded := g.structSchema(f.Type)
+			for name, property := range embedded.Properties {
+				s.Properties[name] = property
+			}
+			s.Required = append(s.Required, embedded.Required...)
+			continue
+		}
+		name, optional, ok := jsonField(f)
+		if !ok {
+			continue
+		}
+		s.Properties[name] = g.schemaFor(f.Type)
+		if !optional && !g.input {
+			s.Required = append(s.Required, name)
+		}
+	}
+	return s
+}
@@ -0,0 +1,50 @@
+// Package apidocs contains the route handlers which document the server's HTTP API.
+package apidocs
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/sargassum-world/godest"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/openapi"
+)
+
+// DocumentPath is the stable path of the OpenAPI document for the server's HTTP API.
+const DocumentPath = "/api/v1/openapi.json"
+
+type Handlers struct {
+	docs *openapi.Registry
+}
+
+func New(docs *openapi.Registry) *Handlers {
+	return &Handlers{
+		docs: docs,
+	}
+}
+
+func (h *Handlers) Register(er godest.EchoRouter) {
+	er.GET(DocumentPath, h.HandleDocumentGet())
+	h.docs.Tag("docs", "Documentation of the HTTP API")
+	h.docs.Describe(http.MethodGet, DocumentPath, openapi.Operation{
+		Summary: "Get the OpenAPI document describing every route of the server",
+		Tags:    []string{"docs"},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, ContentType: echo.MIMEApplicationJSON},
+		},
+		Auth: openapi.Auth{Anonymous: true},
+	})
+}
+
+func (h *Handlers) HandleDocumentGet() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Run queries
+		doc, err := h.docs.Document()
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, doc)
+	}
+}
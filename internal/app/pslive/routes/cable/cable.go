@@ -8,6 +8,7 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sargassum-world/godest"
 	"github.com/sargassum-world/godest/actioncable"
 	"github.com/sargassum-world/godest/handling"
@@ -22,7 +23,7 @@ func serveWSConn(
 	r *http.Request, wsc *websocket.Conn, sess *sessions.Session,
 	channelFactories map[string]actioncable.ChannelFactory,
 	cc *session.CSRFTokenChecker, acc *actioncable.Cancellers, wsu websocket.Upgrader,
-	l godest.Logger,
+	connections prometheus.Gauge, l godest.Logger,
 ) {
 	conn, err := actioncable.Upgrade(wsc, actioncable.NewChannelDispatcher(
 		channelFactories, make(map[string]actioncable.Channel),
@@ -45,6 +46,8 @@ func serveWSConn(
 		return
 	}
 
+	connections.Inc()
+	defer connections.Dec()
 	ctx, cancel := context.WithCancel(r.Context())
 	acc.Add(sess.ID, cancel)
 	serr := handling.Except(conn.Serve(ctx), context.Canceled)
@@ -72,14 +75,33 @@ func (h *Handlers) HandleCableGet() auth.HTTPHandlerFuncWithSession {
 			map[string]actioncable.ChannelFactory{
 				turbostreams.ChannelName: turbostreams.NewChannelFactory(h.tsb, sess.ID, h.acs.Check),
 			},
-			h.cc, h.acc, h.wsu, h.l,
+			h.cc, h.acc, h.wsu, h.connections.WithLabelValues("/cable"), h.l,
 		)
 		return nil
 	}
 }
 
+// checkVSAuthz makes an identifier checker which only allows the user to subscribe to video streams
+// which the user is authorized to view.
+func (h *Handlers) checkVSAuthz(ctx context.Context, a auth.Auth) actioncable.IdentifierChecker {
+	return func(identifier string) error {
+		name, err := videostreams.ParseStreamName(identifier)
+		if err != nil {
+			return err
+		}
+		authzErr, evalErr := h.azc.RequireVSAuthz(ctx, name, a)
+		if evalErr != nil {
+			return errors.Wrapf(evalErr, "couldn't check authz on SUB %s", name)
+		}
+		if authzErr != nil {
+			return errors.Wrapf(authzErr, "couldn't authorize SUB %s", name)
+		}
+		return nil
+	}
+}
+
 func (h *Handlers) HandleVideoCableGet() auth.HTTPHandlerFuncWithSession {
-	return func(c echo.Context, _ auth.Auth, sess *sessions.Session) error {
+	return func(c echo.Context, a auth.Auth, sess *sessions.Session) error {
 		wsc, err := h.wsu.Upgrade(c.Response(), c.Request(), nil)
 		if err != nil {
 			return errors.Wrap(err, "couldn't upgrade http request to websocket connection")
@@ -91,9 +113,11 @@ func (h *Handlers) HandleVideoCableGet() auth.HTTPHandlerFuncWithSession {
 		serveWSConn(
 			c.Request(), wsc, sess,
 			map[string]actioncable.ChannelFactory{
-				videostreams.ChannelName: videostreams.NewChannelFactory(h.vsb, sess.ID, h.l, h.acs.Check),
+				videostreams.ChannelName: videostreams.NewChannelFactory(
+					h.vsb, sess.ID, h.l, h.acs.Check, h.checkVSAuthz(c.Request().Context(), a),
+				),
 			},
-			h.cc, h.acc, h.wsu, h.l,
+			h.cc, h.acc, h.wsu, h.connections.WithLabelValues("/video-cable"), h.l,
 		)
 		return nil
 	}
@@ -5,6 +5,7 @@ package cable
 
 import (
 	"github.com/gorilla/websocket"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sargassum-world/godest"
 	"github.com/sargassum-world/godest/actioncable"
 	"github.com/sargassum-world/godest/session"
@@ -17,8 +18,9 @@ import (
 type Handlers struct {
 	r godest.TemplateRenderer
 
-	ss *session.Store
-	cc *session.CSRFTokenChecker
+	ss  *session.Store
+	cc  *session.CSRFTokenChecker
+	azc *auth.AuthzChecker
 
 	acc *actioncable.Cancellers
 	acs actioncable.Signer
@@ -27,18 +29,21 @@ type Handlers struct {
 
 	wsu websocket.Upgrader
 
+	connections *prometheus.GaugeVec
+
 	l godest.Logger
 }
 
 func New(
 	r godest.TemplateRenderer, ss *session.Store, cc *session.CSRFTokenChecker,
-	acc *actioncable.Cancellers, acs actioncable.Signer, tsb *turbostreams.Broker,
-	vsb *videostreams.Broker, l godest.Logger,
+	azc *auth.AuthzChecker, acc *actioncable.Cancellers, acs actioncable.Signer,
+	tsb *turbostreams.Broker, vsb *videostreams.Broker, l godest.Logger,
 ) *Handlers {
 	return &Handlers{
 		r:   r,
 		ss:  ss,
 		cc:  cc,
+		azc: azc,
 		acc: acc,
 		acs: acs,
 		tsb: tsb,
@@ -47,6 +52,12 @@ func New(
 			Subprotocols: actioncable.SupportedSubprotocols(),
 			// TODO: add parameters to the upgrader as needed
 		},
+		connections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "pslive",
+			Subsystem: "actioncable",
+			Name:      "connections",
+			Help:      "Number of open Action Cable connections on each cable.",
+		}, []string{"cable"}),
 		l: l,
 	}
 }
@@ -55,3 +66,13 @@ func (h *Handlers) Register(er godest.EchoRouter) {
 	er.GET("/cable", auth.HandleHTTPWithSession(h.HandleCableGet(), h.ss))
 	er.GET("/video-cable", auth.HandleHTTPWithSession(h.HandleVideoCableGet(), h.ss))
 }
+
+// Describe implements [prometheus.Collector] for the metrics of Action Cable connections.
+func (h *Handlers) Describe(ch chan<- *prometheus.Desc) {
+	h.connections.Describe(ch)
+}
+
+// Collect implements [prometheus.Collector] for the metrics of Action Cable connections.
+func (h *Handlers) Collect(ch chan<- prometheus.Metric) {
+	h.connections.Collect(ch)
+}
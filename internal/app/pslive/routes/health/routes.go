@@ -0,0 +1,400 @@
+// Package health contains the route handlers which report the server's liveness and readiness.
+package health
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+	"github.com/sargassum-world/godest/database"
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/app/pslive/conf"
+	"github.com/sargassum-world/pslive/internal/app/pslive/openapi"
+	vsr "github.com/sargassum-world/pslive/internal/app/pslive/routes/videostreams"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+	"github.com/sargassum-world/pslive/internal/clients/ory"
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
+	"github.com/sargassum-world/pslive/internal/clients/videostreams"
+)
+
+type Handlers struct {
+	config   conf.HealthConfig
+	db       *database.DB
+	dbEmbeds database.Embeds
+	azc      *auth.AuthzChecker
+	oc       *ory.Client
+	is       *instruments.Store
+	pco      *planktoscope.Orchestrator
+	vsb      *videostreams.Broker
+	l        godest.Logger
+
+	writeCheck *writeCheck
+}
+
+func New(
+	config conf.HealthConfig, db *database.DB, dbEmbeds database.Embeds, azc *auth.AuthzChecker,
+	oc *ory.Client, is *instruments.Store, pco *planktoscope.Orchestrator,
+	vsb *videostreams.Broker, l godest.Logger,
+) *Handlers {
+	return &Handlers{
+		config:   config,
+		db:       db,
+		dbEmbeds: dbEmbeds,
+		azc:      azc,
+		oc:       oc,
+		is:       is,
+		pco:      pco,
+		vsb:      vsb,
+		l:        l,
+		writeCheck: &writeCheck{
+			mu: &sync.Mutex{},
+		},
+	}
+}
+
+func (h *Handlers) Register(er godest.EchoRouter, docs *openapi.Registry) {
+	er.GET("/healthz", h.HandleHealthzGet())
+	er.GET("/readyz", h.HandleReadyzGet())
+	docs.Tag("health", "Liveness and readiness of the server")
+	docs.Describe(http.MethodGet, "/healthz", openapi.Operation{
+		Summary:     "Check whether the server process is alive",
+		Description: "The server responds as long as it's able to handle HTTP requests.",
+		Tags:        []string{"health"},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, ContentType: echo.MIMEApplicationJSON},
+		},
+		Auth: openapi.Auth{Anonymous: true},
+	})
+	docs.Describe(http.MethodGet, "/readyz", openapi.Operation{
+		Summary: "Check whether the server is ready to handle requests",
+		Description: "The route responds with a 503 error if the database, the authorization " +
+			"policies, or the Ory identity service are unavailable; problems with planktoscope " +
+			"controllers' MQTT connections or cameras' video sources only mark the server as " +
+			"degraded. If the server's configuration enables it, the response also breaks down the " +
+			"status of each of those components.",
+		Tags: []string{"health"},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, ContentType: echo.MIMEApplicationJSON},
+		},
+		Auth: openapi.Auth{Anonymous: true},
+	})
+}
+
+// Liveness
+
+type Liveness struct {
+	Status string `json:"status"`
+}
+
+func (h *Handlers) HandleHealthzGet() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Produce output
+		return c.JSON(http.StatusOK, Liveness{Status: "alive"})
+	}
+}
+
+// Readiness
+
+const (
+	StatusReady       = "ready"
+	StatusDegraded    = "degraded"
+	StatusUnavailable = "unavailable"
+
+	CheckOK      = "ok"
+	CheckFailed  = "failed"
+	CheckSkipped = "skipped"
+
+	ControllerConnected    = "connected"
+	ControllerDisconnected = "disconnected"
+	ControllerDisabled     = "disabled"
+
+	// CameraIdle indicates that nobody is watching the camera, so its source isn't being read.
+	CameraIdle     = "idle"
+	CameraLive     = "live"
+	CameraStale    = "stale"
+	CameraError    = "error"
+	CameraDisabled = "disabled"
+)
+
+// Check reports the status of a component. Errors aren't reported, since they may reveal details
+// of the server's environment; instead, they're logged.
+type Check struct {
+	Status string `json:"status"`
+}
+
+type ControllerCheck struct {
+	ID           instruments.ControllerID `json:"id"`
+	InstrumentID instruments.InstrumentID `json:"instrumentId"`
+	Status       string                   `json:"status"`
+}
+
+type CameraCheck struct {
+	ID           instruments.CameraID     `json:"id"`
+	InstrumentID instruments.InstrumentID `json:"instrumentId"`
+	Status       string                   `json:"status"`
+	LastFrame    *time.Time               `json:"lastFrame,omitempty"`
+}
+
+type Checks struct {
+	Database    Check             `json:"database"`
+	Authz       Check             `json:"authz"`
+	Ory         Check             `json:"ory"`
+	Controllers []ControllerCheck `json:"controllers"`
+	Cameras     []CameraCheck     `json:"cameras"`
+}
+
+type Readiness struct {
+	Status string `json:"status"`
+	// Checks is only reported if the server's configuration enables it.
+	Checks *Checks `json:"checks,omitempty"`
+}
+
+func (h *Handlers) newCheck(err error) Check {
+	if err != nil {
+		h.l.Error(errors.Wrap(err, "readiness check failed"))
+		return Check{Status: CheckFailed}
+	}
+	return Check{Status: CheckOK}
+}
+
+func (h *Handlers) checkMigrations(conn *sqlite.Conn) error {
+	schema, err := h.dbEmbeds.NewSchema()
+	if err != nil {
+		return errors.Wrap(err, "couldn't load database schema")
+	}
+	var version int
+	if err = database.ExecuteSelection(
+		conn, "PRAGMA user_version", nil,
+		func(s *sqlite.Stmt) error {
+			version = s.ColumnInt(0)
+			return nil
+		},
+	); err != nil {
+		return errors.Wrap(err, "couldn't query database schema version")
+	}
+	if version < len(schema.Migrations) {
+		return errors.Errorf(
+			"database schema version %d is behind the latest version %d",
+			version, len(schema.Migrations),
+		)
+	}
+	return nil
+}
+
+func checkWritable(ctx context.Context, db *database.DB) (err error) {
+	conn, err := db.AcquireWriter(ctx)
+	if err != nil {
+		return errors.Wrap(err, "couldn't acquire database writer")
+	}
+	defer db.ReleaseWriter(conn)
+
+	// We make a change and then roll it back, so that the check doesn't modify the database
+	if err = sqlitex.ExecuteTransient(conn, "SAVEPOINT health_check", nil); err != nil {
+		return errors.Wrap(err, "couldn't start database write check")
+	}
+	defer func() {
+		if rerr := sqlitex.ExecuteTransient(conn, "ROLLBACK TO health_check", nil); rerr != nil {
+			err = errors.Wrap(rerr, "couldn't roll back database write check")
+			// The savepoint started a transaction, which must not be left open on the writer
+			if rerr = sqlitex.ExecuteTransient(conn, "ROLLBACK", nil); rerr != nil {
+				err = errors.Wrapf(err, "couldn't abort database write check either (%s)", rerr)
+			}
+			return
+		}
+		if rerr := sqlitex.ExecuteTransient(conn, "RELEASE health_check", nil); rerr != nil {
+			err = errors.Wrap(rerr, "couldn't finish database write check")
+		}
+	}()
+	return errors.Wrap(
+		sqlitex.ExecuteTransient(conn, "CREATE TABLE main.health_check (id INTEGER)", nil),
+		"couldn't write to database",
+	)
+}
+
+// writeCheck caches the result of the database write check, since the check needs the database's
+// only writer and shouldn't hold it up for every readiness request.
+type writeCheck struct {
+	checked time.Time
+	err     error
+	mu      *sync.Mutex
+}
+
+func (c *writeCheck) run(ctx context.Context, db *database.DB) error {
+	const interval = 30 * time.Second
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.checked.IsZero() && time.Since(c.checked) < interval {
+		return c.err
+	}
+	c.err = checkWritable(ctx, db)
+	c.checked = time.Now()
+	return c.err
+}
+
+func (h *Handlers) checkDatabase(ctx context.Context) error {
+	conn, err := h.db.AcquireReader(ctx)
+	if err != nil {
+		return errors.Wrap(err, "couldn't acquire database reader")
+	}
+	err = h.checkMigrations(conn)
+	h.db.ReleaseReader(conn)
+	if err != nil {
+		return err
+	}
+	return h.writeCheck.run(ctx, h.db)
+}
+
+func (h *Handlers) checkAuthz(ctx context.Context) error {
+	// The policies allow anyone to check the server's health, so evaluating them should succeed
+	allow, err := h.azc.Allow(ctx, auth.Auth{}, "/healthz", http.MethodGet, nil)
+	if err != nil {
+		return errors.Wrap(err, "couldn't evaluate authorization policies")
+	}
+	if !allow {
+		return errors.New("authorization policies weren't loaded correctly")
+	}
+	return nil
+}
+
+func (h *Handlers) checkOry(ctx context.Context) Check {
+	if h.oc.Config.NoAuth {
+		return Check{Status: CheckSkipped}
+	}
+	return h.newCheck(h.oc.CheckAlive(ctx))
+}
+
+func (h *Handlers) checkControllers(insts []instruments.Instrument) []ControllerCheck {
+	connections := h.pco.Connections()
+	checks := make([]ControllerCheck, 0)
+	for _, instrument := range insts {
+		for _, controller := range instrument.Controllers {
+			if controller.Protocol != planktoscope.Protocol {
+				continue
+			}
+			check := ControllerCheck{
+				ID:           controller.ID,
+				InstrumentID: controller.InstrumentID,
+				Status:       ControllerDisabled,
+			}
+			if controller.Enabled {
+				check.Status = ControllerDisconnected
+				if connections[planktoscope.ClientID(controller.ID)] {
+					check.Status = ControllerConnected
+				}
+			}
+			checks = append(checks, check)
+		}
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].ID < checks[j].ID
+	})
+	return checks
+}
+
+func (h *Handlers) checkCameras(insts []instruments.Instrument) []CameraCheck {
+	const staleTimeout = 10 * time.Second
+	checks := make([]CameraCheck, 0)
+	for _, instrument := range insts {
+		for _, camera := range instrument.Cameras {
+			check := CameraCheck{
+				ID:           camera.ID,
+				InstrumentID: camera.InstrumentID,
+				Status:       CameraDisabled,
+			}
+			if camera.Enabled {
+				// The source's errors aren't reported, since they may include the camera's URL
+				check.Status, check.LastFrame = cameraStatus(
+					h.vsb.SourceStatus(vsr.ExternalSourceTopic(camera.URL)), staleTimeout,
+				)
+			}
+			checks = append(checks, check)
+		}
+	}
+	sort.Slice(checks, func(i, j int) bool {
+		return checks[i].ID < checks[j].ID
+	})
+	return checks
+}
+
+func cameraStatus(
+	source videostreams.SourceStatus, staleTimeout time.Duration,
+) (status string, lastFrame *time.Time) {
+	if !source.LastFrame.IsZero() {
+		lastFrame = &source.LastFrame
+	}
+	switch {
+	case source.LastError.After(source.LastFrame) && time.Since(source.LastError) < staleTimeout:
+		return CameraError, lastFrame
+	case source.Subscribers == 0:
+		return CameraIdle, lastFrame
+	case time.Since(source.LastFrame) > staleTimeout:
+		return CameraStale, lastFrame
+	default:
+		return CameraLive, lastFrame
+	}
+}
+
+func (h *Handlers) checkReadiness(ctx context.Context) (readiness Readiness) {
+	checks := Checks{
+		Database: h.newCheck(h.checkDatabase(ctx)),
+		Authz:    h.newCheck(h.checkAuthz(ctx)),
+		Ory:      h.checkOry(ctx),
+	}
+	if h.config.ShowDetails {
+		readiness.Checks = &checks
+	}
+	if checks.Database.Status != CheckOK ||
+		checks.Authz.Status != CheckOK ||
+		checks.Ory.Status == CheckFailed {
+		readiness.Status = StatusUnavailable
+	}
+
+	insts, err := h.is.GetInstruments(ctx)
+	if err != nil {
+		h.l.Error(errors.Wrap(err, "couldn't get instruments to check readiness"))
+		readiness.Status = StatusUnavailable
+	}
+	checks.Controllers = h.checkControllers(insts)
+	checks.Cameras = h.checkCameras(insts)
+	if readiness.Status == StatusUnavailable {
+		return readiness
+	}
+
+	readiness.Status = StatusReady
+	for _, check := range checks.Controllers {
+		if check.Status == ControllerDisconnected {
+			readiness.Status = StatusDegraded
+		}
+	}
+	for _, check := range checks.Cameras {
+		if check.Status == CameraStale || check.Status == CameraError {
+			readiness.Status = StatusDegraded
+		}
+	}
+	return readiness
+}
+
+func (h *Handlers) HandleReadyzGet() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Run queries
+		const checkTimeout = 5 * time.Second
+		ctx, cancel := context.WithTimeout(c.Request().Context(), checkTimeout)
+		defer cancel()
+		readiness := h.checkReadiness(ctx)
+
+		// Produce output
+		if readiness.Status == StatusUnavailable {
+			return c.JSON(http.StatusServiceUnavailable, readiness)
+		}
+		return c.JSON(http.StatusOK, readiness)
+	}
+}
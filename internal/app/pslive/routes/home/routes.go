@@ -6,10 +6,12 @@ import (
 	"fmt"
 
 	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 	"github.com/sargassum-world/godest"
 	"github.com/sargassum-world/godest/session"
 
 	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/app/pslive/handling"
 	"github.com/sargassum-world/pslive/internal/clients/instruments"
 	"github.com/sargassum-world/pslive/internal/clients/ory"
 	"github.com/sargassum-world/pslive/internal/clients/presence"
@@ -18,20 +20,23 @@ import (
 type Handlers struct {
 	r godest.TemplateRenderer
 
-	oc *ory.Client
+	oc  *ory.Client
+	azc *auth.AuthzChecker
 
 	is *instruments.Store
 	ps *presence.Store
 }
 
 func New(
-	r godest.TemplateRenderer, oc *ory.Client, is *instruments.Store, ps *presence.Store,
+	r godest.TemplateRenderer, oc *ory.Client, azc *auth.AuthzChecker,
+	is *instruments.Store, ps *presence.Store,
 ) *Handlers {
 	return &Handlers{
-		r:  r,
-		oc: oc,
-		is: is,
-		ps: ps,
+		r:   r,
+		oc:  oc,
+		azc: azc,
+		is:  is,
+		ps:  ps,
 	}
 }
 
@@ -47,12 +52,16 @@ type HomeViewData struct {
 }
 
 func getHomeViewData(
-	ctx context.Context, oc *ory.Client, is *instruments.Store, ps *presence.Store,
+	ctx context.Context, a auth.Auth, azc *auth.AuthzChecker,
+	oc *ory.Client, is *instruments.Store, ps *presence.Store,
 ) (vd HomeViewData, err error) {
 	in, err := is.GetInstruments(ctx)
 	if err != nil {
 		return HomeViewData{}, err
 	}
+	if in, err = handling.FilterListedInstruments(ctx, in, a, azc); err != nil {
+		return HomeViewData{}, errors.Wrap(err, "couldn't filter instruments")
+	}
 	vd.CameraInstruments = make([]instruments.Instrument, 0, len(in))
 	for _, instrument := range in {
 		if len(instrument.Cameras) > 0 {
@@ -85,7 +94,7 @@ func (h *Handlers) HandleHomeGet() auth.HTTPHandlerFunc {
 	return func(c echo.Context, a auth.Auth) error {
 		// Run queries
 		ctx := c.Request().Context()
-		homeViewData, err := getHomeViewData(ctx, h.oc, h.is, h.ps)
+		homeViewData, err := getHomeViewData(ctx, a, h.azc, h.oc, h.is, h.ps)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,242 @@
+package instruments
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+)
+
+// automationJobType is the default specification type of new automation jobs.
+const automationJobType = "hcl-v0.1.0"
+
+type APIAutomationJob struct {
+	ID            instruments.AutomationJobID `json:"id"`
+	InstrumentID  instruments.InstrumentID    `json:"instrumentId"`
+	Enabled       bool                        `json:"enabled"`
+	Name          string                      `json:"name"`
+	Description   string                      `json:"description"`
+	Type          string                      `json:"type"`
+	Specification string                      `json:"specification"`
+}
+
+func newAPIAutomationJob(j instruments.AutomationJob) APIAutomationJob {
+	return APIAutomationJob{
+		ID:            j.ID,
+		InstrumentID:  j.InstrumentID,
+		Enabled:       j.Enabled,
+		Name:          j.Name,
+		Description:   j.Description,
+		Type:          j.Type,
+		Specification: j.Specification,
+	}
+}
+
+type APIAutomationJobRequest struct {
+	Enabled       bool   `json:"enabled"`
+	Name          string `json:"name"`
+	Description   string `json:"description"`
+	Type          string `json:"type"`
+	Specification string `json:"specification"`
+}
+
+func newAPIAutomationJobRequest(j instruments.AutomationJob) APIAutomationJobRequest {
+	return APIAutomationJobRequest{
+		Enabled:       j.Enabled,
+		Name:          j.Name,
+		Description:   j.Description,
+		Type:          j.Type,
+		Specification: j.Specification,
+	}
+}
+
+// params represents the request in the same way as the automation job settings form.
+func (r APIAutomationJobRequest) params() url.Values {
+	return url.Values{
+		"enabled":       []string{formatFlag(r.Enabled)},
+		"name":          []string{r.Name},
+		"description":   []string{r.Description},
+		"type":          []string{r.Type},
+		"specification": []string{r.Specification},
+	}
+}
+
+// check rejects specifications which couldn't be run, so that they aren't saved only to fail when
+// the job is started.
+func (r APIAutomationJobRequest) check(iid instruments.InstrumentID) error {
+	if err := checkAPIComponentName(r.Name, "automation job"); err != nil {
+		return err
+	}
+	if _, err := instruments.NewOrchestratedJob(
+		0, iid, r.Name, r.Type, r.Specification,
+	); err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return nil
+}
+
+func (h *Handlers) getAPIAutomationJob(
+	c echo.Context, iid instruments.InstrumentID, jobID instruments.AutomationJobID,
+) (instruments.AutomationJob, error) {
+	instrument, err := h.is.GetInstrument(c.Request().Context(), iid)
+	if err != nil {
+		return instruments.AutomationJob{}, err
+	}
+	return getAPIComponent(instrument.AutomationJobs, jobID, "automation job", iid)
+}
+
+func (h *Handlers) HandleAPIAutomationJobsGet() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		instrument, err := h.is.GetInstrument(c.Request().Context(), iid)
+		if err != nil {
+			return err
+		}
+		jobs := sortedComponents(instrument.AutomationJobs)
+		results := make([]APIAutomationJob, len(jobs))
+		for i, job := range jobs {
+			results[i] = newAPIAutomationJob(job)
+		}
+		page, err := newAPIPage(c, results)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, page)
+	}
+}
+
+func (h *Handlers) HandleAPIAutomationJobsPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		req := APIAutomationJobRequest{Type: automationJobType}
+		if err = bindAPIRequest(c, &req); err != nil {
+			return err
+		}
+		params := req.params()
+		setAuditedParams(c, params)
+		if err = req.check(iid); err != nil {
+			return err
+		}
+
+		// Run queries
+		jobID, err := h.addAutomationJob(
+			c.Request().Context(), iid, req.Enabled, req.Name, req.Description, params,
+		)
+		if err != nil {
+			return err
+		}
+		job, err := h.getAPIAutomationJob(c, iid, jobID)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf(
+			"%s/instruments/%d/automation-jobs/%d", apiPrefix, iid, jobID,
+		))
+		return c.JSON(http.StatusCreated, newAPIAutomationJob(job))
+	}
+}
+
+func (h *Handlers) HandleAPIAutomationJobGet() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		jobID, err := parseID[instruments.AutomationJobID](
+			c.Param("automationJobID"), "automation job",
+		)
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		job, err := h.getAPIAutomationJob(c, iid, jobID)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, newAPIAutomationJob(job))
+	}
+}
+
+func (h *Handlers) HandleAPIAutomationJobPatch() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		jobID, err := parseID[instruments.AutomationJobID](
+			c.Param("automationJobID"), "automation job",
+		)
+		if err != nil {
+			return err
+		}
+		job, err := h.getAPIAutomationJob(c, iid, jobID)
+		if err != nil {
+			return err
+		}
+		req := newAPIAutomationJobRequest(job)
+		if err = bindAPIRequest(c, &req); err != nil {
+			return err
+		}
+		params := req.params()
+		setAuditedParams(c, params)
+		if err = req.check(iid); err != nil {
+			return err
+		}
+
+		// Run queries
+		if err = h.updateAutomationJob(
+			c.Request().Context(), jobID, iid, req.Enabled, req.Name, req.Description, params,
+		); err != nil {
+			return err
+		}
+		if job, err = h.getAPIAutomationJob(c, iid, jobID); err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, newAPIAutomationJob(job))
+	}
+}
+
+func (h *Handlers) HandleAPIAutomationJobDelete() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		jobID, err := parseID[instruments.AutomationJobID](
+			c.Param("automationJobID"), "automation job",
+		)
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		if err = h.deleteAutomationJob(c.Request().Context(), jobID); err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.NoContent(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,222 @@
+package instruments
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+)
+
+type APICamera struct {
+	ID           instruments.CameraID     `json:"id"`
+	InstrumentID instruments.InstrumentID `json:"instrumentId"`
+	Enabled      bool                     `json:"enabled"`
+	Name         string                   `json:"name"`
+	Description  string                   `json:"description"`
+	Protocol     string                   `json:"protocol"`
+	URL          string                   `json:"url"`
+}
+
+func newAPICamera(c instruments.Camera) APICamera {
+	return APICamera{
+		ID:           c.ID,
+		InstrumentID: c.InstrumentID,
+		Enabled:      c.Enabled,
+		Name:         c.Name,
+		Description:  c.Description,
+		Protocol:     c.Protocol,
+		URL:          c.URL,
+	}
+}
+
+type APICameraRequest struct {
+	Enabled     bool   `json:"enabled"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Protocol    string `json:"protocol"`
+	URL         string `json:"url"`
+}
+
+func newAPICameraRequest(c instruments.Camera) APICameraRequest {
+	return APICameraRequest{
+		Enabled:     c.Enabled,
+		Name:        c.Name,
+		Description: c.Description,
+		Protocol:    c.Protocol,
+		URL:         c.URL,
+	}
+}
+
+// params represents the request in the same way as the camera settings form.
+func (r APICameraRequest) params() url.Values {
+	return url.Values{
+		"enabled":     []string{formatFlag(r.Enabled)},
+		"name":        []string{r.Name},
+		"description": []string{r.Description},
+		"protocol":    []string{r.Protocol},
+		"url":         []string{r.URL},
+	}
+}
+
+func (h *Handlers) getAPICamera(
+	c echo.Context, iid instruments.InstrumentID, cameraID instruments.CameraID,
+) (instruments.Camera, error) {
+	instrument, err := h.is.GetInstrument(c.Request().Context(), iid)
+	if err != nil {
+		return instruments.Camera{}, err
+	}
+	return getAPIComponent(instrument.Cameras, cameraID, "camera", iid)
+}
+
+func (h *Handlers) HandleAPICamerasGet() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		instrument, err := h.is.GetInstrument(c.Request().Context(), iid)
+		if err != nil {
+			return err
+		}
+		cameras := sortedComponents(instrument.Cameras)
+		results := make([]APICamera, len(cameras))
+		for i, camera := range cameras {
+			results[i] = newAPICamera(camera)
+		}
+		page, err := newAPIPage(c, results)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, page)
+	}
+}
+
+func (h *Handlers) HandleAPICamerasPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		req := APICameraRequest{
+			Enabled:  true,
+			Protocol: cameraProtocol,
+		}
+		if err = bindAPIRequest(c, &req); err != nil {
+			return err
+		}
+		params := req.params()
+		setAuditedParams(c, params)
+		if err = checkAPIComponentName(req.Name, "camera"); err != nil {
+			return err
+		}
+
+		// Run queries
+		cameraID, err := h.addCamera(
+			c.Request().Context(), iid, req.Enabled, req.Name, req.Description, params,
+		)
+		if err != nil {
+			return err
+		}
+		camera, err := h.getAPICamera(c, iid, cameraID)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf(
+			"%s/instruments/%d/cameras/%d", apiPrefix, iid, cameraID,
+		))
+		return c.JSON(http.StatusCreated, newAPICamera(camera))
+	}
+}
+
+func (h *Handlers) HandleAPICameraGet() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		cameraID, err := parseID[instruments.CameraID](c.Param("cameraID"), "camera")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		camera, err := h.getAPICamera(c, iid, cameraID)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, newAPICamera(camera))
+	}
+}
+
+func (h *Handlers) HandleAPICameraPatch() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		cameraID, err := parseID[instruments.CameraID](c.Param("cameraID"), "camera")
+		if err != nil {
+			return err
+		}
+		camera, err := h.getAPICamera(c, iid, cameraID)
+		if err != nil {
+			return err
+		}
+		req := newAPICameraRequest(camera)
+		if err = bindAPIRequest(c, &req); err != nil {
+			return err
+		}
+		params := req.params()
+		setAuditedParams(c, params)
+		if err = checkAPIComponentName(req.Name, "camera"); err != nil {
+			return err
+		}
+
+		// Run queries
+		if err = h.updateCamera(
+			c.Request().Context(), cameraID, iid, req.Enabled, req.Name, req.Description, params,
+		); err != nil {
+			return err
+		}
+		if camera, err = h.getAPICamera(c, iid, cameraID); err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, newAPICamera(camera))
+	}
+}
+
+func (h *Handlers) HandleAPICameraDelete() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		cameraID, err := parseID[instruments.CameraID](c.Param("cameraID"), "camera")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		if err = h.is.DeleteCamera(c.Request().Context(), cameraID); err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.NoContent(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,324 @@
+package instruments
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+)
+
+// APIControllerLimits is the representation of instruments.ControllerLimits in the JSON API. A zero
+// value for any limit means that the setting is not bounded by that limit.
+type APIControllerLimits struct {
+	MaxPumpVolume   float64 `json:"maxPumpVolume"`   // mL
+	MinPumpFlowrate float64 `json:"minPumpFlowrate"` // mL/min
+	MaxPumpFlowrate float64 `json:"maxPumpFlowrate"` // mL/min
+	MaxImagingSteps uint64  `json:"maxImagingSteps"`
+	MinISO          uint64  `json:"minIso"`
+	MaxISO          uint64  `json:"maxIso"`
+	MinShutterSpeed uint64  `json:"minShutterSpeed"` // μs
+	MaxShutterSpeed uint64  `json:"maxShutterSpeed"` // μs
+}
+
+// APIMQTTAuthStatus summarizes the MQTT authentication settings of a controller, since the
+// credentials and certificates themselves are never sent back by the API.
+type APIMQTTAuthStatus struct {
+	HasUsername   bool `json:"hasUsername"`
+	HasPassword   bool `json:"hasPassword"`
+	HasCACert     bool `json:"hasCaCert"`
+	HasClientCert bool `json:"hasClientCert"`
+	HasClientKey  bool `json:"hasClientKey"`
+}
+
+type APIController struct {
+	ID            instruments.ControllerID `json:"id"`
+	InstrumentID  instruments.InstrumentID `json:"instrumentId"`
+	Enabled       bool                     `json:"enabled"`
+	Name          string                   `json:"name"`
+	Description   string                   `json:"description"`
+	Protocol      string                   `json:"protocol"`
+	URL           string                   `json:"url"`
+	Schema        string                   `json:"schema"`
+	Limits        APIControllerLimits      `json:"limits"`
+	RestoreCamera bool                     `json:"restoreCamera"`
+	MQTTAuth      APIMQTTAuthStatus        `json:"mqttAuth"`
+}
+
+func newAPIController(
+	c instruments.Controller, schema string, limits instruments.ControllerLimits,
+	settings instruments.ControllerSettings, mqttAuth instruments.MQTTAuthStatus,
+) APIController {
+	return APIController{
+		ID:            c.ID,
+		InstrumentID:  c.InstrumentID,
+		Enabled:       c.Enabled,
+		Name:          c.Name,
+		Description:   c.Description,
+		Protocol:      c.Protocol,
+		URL:           c.URL,
+		Schema:        schema,
+		Limits:        APIControllerLimits(limits),
+		RestoreCamera: settings.RestoreCamera,
+		MQTTAuth:      APIMQTTAuthStatus(mqttAuth),
+	}
+}
+
+// APIMQTTAuthRequest changes the MQTT authentication settings of a controller. Empty values leave
+// the previous values unchanged; they can only be removed by clearing all credentials.
+type APIMQTTAuthRequest struct {
+	Clear      bool   `json:"clear"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	CACert     string `json:"caCert"`
+	ClientCert string `json:"clientCert"`
+	ClientKey  string `json:"clientKey"`
+}
+
+type APIControllerRequest struct {
+	Enabled       bool                `json:"enabled"`
+	Name          string              `json:"name"`
+	Description   string              `json:"description"`
+	Protocol      string              `json:"protocol"`
+	URL           string              `json:"url"`
+	Schema        string              `json:"schema"`
+	Limits        APIControllerLimits `json:"limits"`
+	RestoreCamera bool                `json:"restoreCamera"`
+	MQTTAuth      APIMQTTAuthRequest  `json:"mqttAuth"`
+}
+
+func newAPIControllerRequest(c APIController) APIControllerRequest {
+	return APIControllerRequest{
+		Enabled:       c.Enabled,
+		Name:          c.Name,
+		Description:   c.Description,
+		Protocol:      c.Protocol,
+		URL:           c.URL,
+		Schema:        c.Schema,
+		Limits:        c.Limits,
+		RestoreCamera: c.RestoreCamera,
+	}
+}
+
+func formatLimit[Limit float64 | uint64](limit Limit) string {
+	if limit == 0 {
+		return ""
+	}
+	return fmt.Sprint(limit)
+}
+
+// params represents the request in the same way as the controller settings form.
+func (r APIControllerRequest) params() url.Values {
+	return url.Values{
+		"enabled":                 []string{formatFlag(r.Enabled)},
+		"name":                    []string{r.Name},
+		"description":             []string{r.Description},
+		"protocol":                []string{r.Protocol},
+		"url":                     []string{r.URL},
+		"schema":                  []string{r.Schema},
+		"limit-max-pump-volume":   []string{formatLimit(r.Limits.MaxPumpVolume)},
+		"limit-min-pump-flowrate": []string{formatLimit(r.Limits.MinPumpFlowrate)},
+		"limit-max-pump-flowrate": []string{formatLimit(r.Limits.MaxPumpFlowrate)},
+		"limit-max-imaging-steps": []string{formatLimit(r.Limits.MaxImagingSteps)},
+		"limit-min-iso":           []string{formatLimit(r.Limits.MinISO)},
+		"limit-max-iso":           []string{formatLimit(r.Limits.MaxISO)},
+		"limit-min-shutter-speed": []string{formatLimit(r.Limits.MinShutterSpeed)},
+		"limit-max-shutter-speed": []string{formatLimit(r.Limits.MaxShutterSpeed)},
+		"restore-camera":          []string{formatFlag(r.RestoreCamera)},
+		"mqtt-clear":              []string{formatFlag(r.MQTTAuth.Clear)},
+		"mqtt-username":           []string{r.MQTTAuth.Username},
+		"mqtt-password":           []string{r.MQTTAuth.Password},
+		"mqtt-ca-cert":            []string{r.MQTTAuth.CACert},
+		"mqtt-client-cert":        []string{r.MQTTAuth.ClientCert},
+		"mqtt-client-key":         []string{r.MQTTAuth.ClientKey},
+	}
+}
+
+func (h *Handlers) getAPIControllers(
+	ctx context.Context, iid instruments.InstrumentID,
+) (controllers map[instruments.ControllerID]APIController, err error) {
+	instrument, err := h.is.GetInstrument(ctx, iid)
+	if err != nil {
+		return nil, err
+	}
+	schemas, err := h.is.GetInstrumentControllerSchemas(ctx, iid)
+	if err != nil {
+		return nil, err
+	}
+	limits, err := h.is.GetInstrumentControllerLimits(ctx, iid)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := h.is.GetInstrumentControllerSettings(ctx, iid)
+	if err != nil {
+		return nil, err
+	}
+	mqttAuths, err := h.is.GetInstrumentControllerMQTTAuthStatuses(ctx, iid)
+	if err != nil {
+		return nil, err
+	}
+	controllers = make(map[instruments.ControllerID]APIController, len(instrument.Controllers))
+	for id, controller := range instrument.Controllers {
+		controllers[id] = newAPIController(
+			controller, schemas[id], limits[id], settings[id], mqttAuths[id],
+		)
+	}
+	return controllers, nil
+}
+
+func (h *Handlers) getAPIController(
+	ctx context.Context, iid instruments.InstrumentID, controllerID instruments.ControllerID,
+) (APIController, error) {
+	controllers, err := h.getAPIControllers(ctx, iid)
+	if err != nil {
+		return APIController{}, err
+	}
+	return getAPIComponent(controllers, controllerID, "controller", iid)
+}
+
+func (h *Handlers) HandleAPIControllersGet() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		controllers, err := h.getAPIControllers(c.Request().Context(), iid)
+		if err != nil {
+			return err
+		}
+		page, err := newAPIPage(c, sortedComponents(controllers))
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, page)
+	}
+}
+
+func (h *Handlers) HandleAPIControllersPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		req := APIControllerRequest{Enabled: true}
+		if err = bindAPIRequest(c, &req); err != nil {
+			return err
+		}
+		params := req.params()
+		setAuditedParams(c, params)
+		if err = checkAPIComponentName(req.Name, "controller"); err != nil {
+			return err
+		}
+
+		// Run queries
+		ctx := c.Request().Context()
+		controllerID, err := h.addController(
+			ctx, iid, req.Enabled, req.Name, req.Description, params,
+		)
+		if err != nil {
+			return err
+		}
+		controller, err := h.getAPIController(ctx, iid, controllerID)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		c.Response().Header().Set(echo.HeaderLocation, fmt.Sprintf(
+			"%s/instruments/%d/controllers/%d", apiPrefix, iid, controllerID,
+		))
+		return c.JSON(http.StatusCreated, controller)
+	}
+}
+
+func (h *Handlers) HandleAPIControllerGet() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		controllerID, err := parseID[instruments.ControllerID](c.Param("controllerID"), "controller")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		controller, err := h.getAPIController(c.Request().Context(), iid, controllerID)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, controller)
+	}
+}
+
+func (h *Handlers) HandleAPIControllerPatch() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		controllerID, err := parseID[instruments.ControllerID](c.Param("controllerID"), "controller")
+		if err != nil {
+			return err
+		}
+		ctx := c.Request().Context()
+		controller, err := h.getAPIController(ctx, iid, controllerID)
+		if err != nil {
+			return err
+		}
+		req := newAPIControllerRequest(controller)
+		if err = bindAPIRequest(c, &req); err != nil {
+			return err
+		}
+		params := req.params()
+		setAuditedParams(c, params)
+		if err = checkAPIComponentName(req.Name, "controller"); err != nil {
+			return err
+		}
+
+		// Run queries
+		if err = h.updateController(
+			ctx, controllerID, iid, req.Enabled, req.Name, req.Description, params,
+		); err != nil {
+			return err
+		}
+		if controller, err = h.getAPIController(ctx, iid, controllerID); err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, controller)
+	}
+}
+
+func (h *Handlers) HandleAPIControllerDelete() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		controllerID, err := parseID[instruments.ControllerID](c.Param("controllerID"), "controller")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		if err = h.deleteController(c.Request().Context(), controllerID); err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.NoContent(http.StatusNoContent)
+	}
+}
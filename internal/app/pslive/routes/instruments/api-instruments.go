@@ -0,0 +1,206 @@
+package instruments
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/atrox/haikunatorgo"
+	"github.com/labstack/echo/v4"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/app/pslive/handling"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+)
+
+type APIInstrument struct {
+	ID          instruments.InstrumentID `json:"id"`
+	Name        string                   `json:"name"`
+	Description string                   `json:"description"`
+	AdminID     instruments.AdminID      `json:"adminId"`
+	Visibility  instruments.Visibility   `json:"visibility"`
+}
+
+func newAPIInstrument(i instruments.Instrument) APIInstrument {
+	return APIInstrument{
+		ID:          i.ID,
+		Name:        i.Name,
+		Description: i.Description,
+		AdminID:     i.AdminID,
+		Visibility:  i.Visibility,
+	}
+}
+
+type APIInstrumentRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility"`
+}
+
+func (r APIInstrumentRequest) params() url.Values {
+	return url.Values{
+		"name":        []string{r.Name},
+		"description": []string{r.Description},
+		"visibility":  []string{r.Visibility},
+	}
+}
+
+func (r APIInstrumentRequest) parse() (visibility instruments.Visibility, err error) {
+	if err = checkAPIComponentName(r.Name, "instrument"); err != nil {
+		return "", err
+	}
+	if visibility, err = instruments.ParseVisibility(r.Visibility); err != nil {
+		return "", echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return visibility, nil
+}
+
+func (h *Handlers) HandleAPIInstrumentsGet() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Run queries
+		ctx := c.Request().Context()
+		unfiltered, err := h.is.GetInstruments(ctx)
+		if err != nil {
+			return err
+		}
+		listed, err := handling.FilterListedInstruments(ctx, unfiltered, a, h.azc)
+		if err != nil {
+			return err
+		}
+		results := make([]APIInstrument, len(listed))
+		for i, instrument := range listed {
+			results[i] = newAPIInstrument(instrument)
+		}
+		page, err := newAPIPage(c, results)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, page)
+	}
+}
+
+func (h *Handlers) HandleAPIInstrumentsPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		req := APIInstrumentRequest{
+			Name:        haikunator.New().Haikunate(),
+			Description: "An unknown instrument!",
+			Visibility:  string(instruments.VisibilityPublic),
+		}
+		if err := bindAPIRequest(c, &req); err != nil {
+			return err
+		}
+		setAuditedParams(c, req.params())
+		visibility, err := req.parse()
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		ctx := c.Request().Context()
+		iid, err := h.is.AddInstrument(ctx, instruments.Instrument{
+			Name:        req.Name,
+			Description: req.Description,
+			AdminID:     instruments.AdminID(a.Identity.User),
+			Visibility:  visibility,
+		})
+		if err != nil {
+			return err
+		}
+		c.Set(auditedInstrumentKey, iid)
+		instrument, err := h.is.GetInstrument(ctx, iid)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		c.Response().Header().Set(
+			echo.HeaderLocation, fmt.Sprintf("%s/instruments/%d", apiPrefix, iid),
+		)
+		return c.JSON(http.StatusCreated, newAPIInstrument(instrument))
+	}
+}
+
+func (h *Handlers) HandleAPIInstrumentGet() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		instrument, err := h.is.GetInstrument(c.Request().Context(), iid)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, newAPIInstrument(instrument))
+	}
+}
+
+func (h *Handlers) HandleAPIInstrumentPatch() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		ctx := c.Request().Context()
+		instrument, err := h.is.GetInstrument(ctx, iid)
+		if err != nil {
+			return err
+		}
+		req := APIInstrumentRequest{
+			Name:        instrument.Name,
+			Description: instrument.Description,
+			Visibility:  string(instrument.Visibility),
+		}
+		if err = bindAPIRequest(c, &req); err != nil {
+			return err
+		}
+		setAuditedParams(c, req.params())
+		visibility, err := req.parse()
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		if err = h.is.UpdateInstrumentName(ctx, iid, req.Name); err != nil {
+			return err
+		}
+		if err = h.is.UpdateInstrumentDescription(ctx, iid, req.Description); err != nil {
+			return err
+		}
+		if err = h.is.UpdateInstrumentVisibility(ctx, iid, visibility); err != nil {
+			return err
+		}
+		if instrument, err = h.is.GetInstrument(ctx, iid); err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, newAPIInstrument(instrument))
+	}
+}
+
+func (h *Handlers) HandleAPIInstrumentDelete() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		if err = h.is.DeleteInstrument(c.Request().Context(), iid); err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.NoContent(http.StatusNoContent)
+	}
+}
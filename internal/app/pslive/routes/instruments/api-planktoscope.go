@@ -0,0 +1,454 @@
+package instruments
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/app/pslive/handling"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
+)
+
+// State
+
+type APIPump struct {
+	StateKnown bool       `json:"stateKnown"`
+	Pumping    bool       `json:"pumping"`
+	Start      *time.Time `json:"start,omitempty"`
+	Duration   float64    `json:"duration"` // sec
+	Deadline   *time.Time `json:"deadline,omitempty"`
+}
+
+type APIPumpSettings struct {
+	Forward  bool    `json:"forward"`
+	Volume   float64 `json:"volume"`   // mL
+	Flowrate float64 `json:"flowrate"` // mL/min
+}
+
+type APICameraSettings struct {
+	StateKnown           bool    `json:"stateKnown"`
+	ISO                  uint64  `json:"iso"`
+	ShutterSpeed         uint64  `json:"shutterSpeed"` // μs
+	AutoWhiteBalance     bool    `json:"autoWhiteBalance"`
+	WhiteBalanceRedGain  float64 `json:"whiteBalanceRedGain"`
+	WhiteBalanceBlueGain float64 `json:"whiteBalanceBlueGain"`
+}
+
+type APIImager struct {
+	StateKnown       bool       `json:"stateKnown"`
+	Imaging          bool       `json:"imaging"`
+	Start            *time.Time `json:"start,omitempty"`
+	End              *time.Time `json:"end,omitempty"`
+	LastStatus       string     `json:"lastStatus"`
+	ImagesCaptured   uint64     `json:"imagesCaptured"`
+	Steps            uint64     `json:"steps"`
+	StepDelay        float64    `json:"stepDelay"` // sec
+	LastImage        *time.Time `json:"lastImage,omitempty"`
+	ElapsedSeconds   float64    `json:"elapsedSeconds"`
+	RemainingSeconds float64    `json:"remainingSeconds"`
+}
+
+type APIImagerSettings struct {
+	Forward    bool    `json:"forward"`
+	StepVolume float64 `json:"stepVolume"` // mL
+	StepDelay  float64 `json:"stepDelay"`  // sec
+	Steps      uint64  `json:"steps"`
+}
+
+type APIPlanktoscopeState struct {
+	ControllerID   instruments.ControllerID `json:"controllerId"`
+	Connected      bool                     `json:"connected"`
+	Pump           APIPump                  `json:"pump"`
+	PumpSettings   APIPumpSettings          `json:"pumpSettings"`
+	CameraSettings APICameraSettings        `json:"cameraSettings"`
+	Imager         APIImager                `json:"imager"`
+	ImagerSettings APIImagerSettings        `json:"imagerSettings"`
+}
+
+// optionalTime represents unknown times as null values.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func newAPIPlanktoscopeState(
+	cid instruments.ControllerID, pc *planktoscope.Client,
+) APIPlanktoscopeState {
+	state := pc.GetState()
+	return APIPlanktoscopeState{
+		ControllerID: cid,
+		Connected:    pc.HasConnection(),
+		Pump: APIPump{
+			StateKnown: state.Pump.StateKnown,
+			Pumping:    state.Pump.Pumping,
+			Start:      optionalTime(state.Pump.Start),
+			Duration:   state.Pump.Duration.Seconds(),
+			Deadline:   optionalTime(state.Pump.Deadline),
+		},
+		PumpSettings:   APIPumpSettings(state.PumpSettings),
+		CameraSettings: APICameraSettings(state.CameraSettings),
+		Imager: APIImager{
+			StateKnown:       state.Imager.StateKnown,
+			Imaging:          state.Imager.Imaging,
+			Start:            optionalTime(state.Imager.Start),
+			End:              optionalTime(state.Imager.End),
+			LastStatus:       state.Imager.LastStatus,
+			ImagesCaptured:   state.Imager.ImagesCaptured,
+			Steps:            state.Imager.Steps,
+			StepDelay:        state.Imager.StepDelay,
+			LastImage:        optionalTime(state.Imager.LastImage),
+			ElapsedSeconds:   state.Imager.ElapsedTime().Seconds(),
+			RemainingSeconds: state.Imager.RemainingTime().Seconds(),
+		},
+		ImagerSettings: APIImagerSettings(state.ImagerSettings),
+	}
+}
+
+func (h *Handlers) getAPIPlanktoscopeClient(c echo.Context) (
+	iid instruments.InstrumentID, cid instruments.ControllerID, pc *planktoscope.Client, err error,
+) {
+	if iid, err = parseID[instruments.InstrumentID](c.Param("id"), "instrument"); err != nil {
+		return 0, 0, nil, err
+	}
+	cid, err = parseID[instruments.ControllerID](c.Param("controllerID"), "controller")
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	pc, ok := h.pco.Get(planktoscope.ClientID(cid))
+	if !ok {
+		return 0, 0, nil, echo.NewHTTPError(http.StatusConflict, fmt.Sprintf(
+			"controller %d on instrument %d isn't an enabled planktoscope controller", cid, iid,
+		))
+	}
+	return iid, cid, pc, nil
+}
+
+func (h *Handlers) HandleAPIPlanktoscopeStateGet() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params & run queries
+		_, cid, pc, err := h.getAPIPlanktoscopeClient(c)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, newAPIPlanktoscopeState(cid, pc))
+	}
+}
+
+// Commands
+
+const maxAPICommandWait = 5 * time.Minute
+
+// parseAPICommandWait parses the wait query param, which is how long the request should wait for
+// the controller to report a state update after it has received the command.
+func parseAPICommandWait(c echo.Context) (wait time.Duration, err error) {
+	raw := c.QueryParam("wait")
+	if raw == "" {
+		return 0, nil
+	}
+	if wait, err = time.ParseDuration(raw); err != nil || wait < 0 || wait > maxAPICommandWait {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"wait must be a duration between 0s and %s", maxAPICommandWait,
+		))
+	}
+	return wait, nil
+}
+
+// awaitAPIStateUpdate waits up to the specified duration for the controller to report a state
+// update. It doesn't wait at all if the duration is zero.
+func awaitAPIStateUpdate(
+	ctx context.Context, wait time.Duration, stateUpdated <-chan struct{},
+) error {
+	if wait == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	if err := awaitStateUpdate(ctx, stateUpdated); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return echo.NewHTTPError(http.StatusGatewayTimeout, fmt.Sprintf(
+				"controller didn't report a state update within %s", wait,
+			))
+		}
+		return err
+	}
+	return nil
+}
+
+// runAPICommand sends an operator's command to the controller and produces the resulting state of
+// the controller. The request waits for a state update only after the command has been sent, so
+// that the wait doesn't hold up later commands to the controller. The response is Accepted if the
+// request didn't wait for a state update.
+func (h *Handlers) runAPICommand(
+	c echo.Context, a auth.Auth, cid instruments.ControllerID, pc *planktoscope.Client,
+	wait time.Duration, stopping bool,
+	send func(ctx context.Context) (stateUpdated <-chan struct{}, err error),
+) error {
+	// Run queries
+	ctx := c.Request().Context()
+	var stateUpdated <-chan struct{}
+	err := h.runOperatorCommand(ctx, cid, a, stopping, func() (err error) {
+		stateUpdated, err = send(ctx)
+		return err
+	})
+	if err == nil {
+		err = awaitAPIStateUpdate(ctx, wait, stateUpdated)
+	}
+	if err != nil {
+		if _, herr := describeCommandError(err); herr != nil {
+			return herr
+		}
+		return err
+	}
+
+	// Produce output
+	code := http.StatusOK
+	if wait == 0 {
+		code = http.StatusAccepted
+	}
+	return c.JSON(code, newAPIPlanktoscopeState(cid, pc))
+}
+
+// Pump
+
+type APIPumpRequest struct {
+	Pumping  *bool   `json:"pumping"`
+	Forward  bool    `json:"forward"`
+	Volume   float64 `json:"volume"`   // mL
+	Flowrate float64 `json:"flowrate"` // mL/min
+}
+
+func (r APIPumpRequest) params() url.Values {
+	return url.Values{
+		"pumping":  []string{formatOptionalFlag(r.Pumping)},
+		"forward":  []string{formatFlag(r.Forward)},
+		"volume":   []string{fmt.Sprint(r.Volume)},
+		"flowrate": []string{fmt.Sprint(r.Flowrate)},
+	}
+}
+
+func (r APIPumpRequest) validate() error {
+	errs := make(handling.FieldErrors)
+	if r.Pumping == nil {
+		errs.Add("pumping", "is required")
+		return errs.HTTPError()
+	}
+	if !*r.Pumping {
+		return nil
+	}
+	if r.Volume <= 0 {
+		errs.Add("volume", "must be positive")
+	}
+	if r.Flowrate <= 0 {
+		errs.Add("flowrate", "must be positive")
+	}
+	return errs.HTTPError()
+}
+
+func (h *Handlers) HandleAPIPumpPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		_, cid, pc, err := h.getAPIPlanktoscopeClient(c)
+		if err != nil {
+			return err
+		}
+		wait, err := parseAPICommandWait(c)
+		if err != nil {
+			return err
+		}
+		settings := pc.GetState().PumpSettings
+		req := APIPumpRequest{
+			Forward:  settings.Forward,
+			Volume:   settings.Volume,
+			Flowrate: settings.Flowrate,
+		}
+		if err = bindAPIRequest(c, &req); err != nil {
+			return err
+		}
+		setAuditedParams(c, req.params())
+		if err = req.validate(); err != nil {
+			return err
+		}
+
+		return h.runAPICommand(c, a, cid, pc, wait, !*req.Pumping, func(
+			ctx context.Context,
+		) (<-chan struct{}, error) {
+			return setPump(ctx, pc, *req.Pumping, req.Forward, req.Volume, req.Flowrate)
+		})
+	}
+}
+
+// Camera
+
+type APICameraSettingsRequest struct {
+	ISO                  uint64  `json:"iso"`
+	ShutterSpeed         uint64  `json:"shutterSpeed"` // μs
+	AutoWhiteBalance     bool    `json:"autoWhiteBalance"`
+	WhiteBalanceRedGain  float64 `json:"whiteBalanceRedGain"`
+	WhiteBalanceBlueGain float64 `json:"whiteBalanceBlueGain"`
+}
+
+func (r APICameraSettingsRequest) params() url.Values {
+	return url.Values{
+		"iso":           []string{strconv.FormatUint(r.ISO, 10)},
+		"shutter-speed": []string{strconv.FormatUint(r.ShutterSpeed, 10)},
+		"awb":           []string{formatFlag(r.AutoWhiteBalance)},
+		"wb-red":        []string{fmt.Sprint(r.WhiteBalanceRedGain)},
+		"wb-blue":       []string{fmt.Sprint(r.WhiteBalanceBlueGain)},
+	}
+}
+
+func (r APICameraSettingsRequest) validate() error {
+	errs := make(handling.FieldErrors)
+	if r.ISO == 0 {
+		errs.Add("iso", "must be positive")
+	}
+	if r.ShutterSpeed == 0 {
+		errs.Add("shutterSpeed", "must be positive")
+	}
+	if r.WhiteBalanceRedGain <= 0 {
+		errs.Add("whiteBalanceRedGain", "must be positive")
+	}
+	if r.WhiteBalanceBlueGain <= 0 {
+		errs.Add("whiteBalanceBlueGain", "must be positive")
+	}
+	return errs.HTTPError()
+}
+
+func (h *Handlers) HandleAPICameraSettingsPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		_, cid, pc, err := h.getAPIPlanktoscopeClient(c)
+		if err != nil {
+			return err
+		}
+		wait, err := parseAPICommandWait(c)
+		if err != nil {
+			return err
+		}
+		settings := pc.GetState().CameraSettings
+		req := APICameraSettingsRequest{
+			ISO:                  settings.ISO,
+			ShutterSpeed:         settings.ShutterSpeed,
+			AutoWhiteBalance:     settings.AutoWhiteBalance,
+			WhiteBalanceRedGain:  settings.WhiteBalanceRedGain,
+			WhiteBalanceBlueGain: settings.WhiteBalanceBlueGain,
+		}
+		if err = bindAPIRequest(c, &req); err != nil {
+			return err
+		}
+		setAuditedParams(c, req.params())
+		if err = req.validate(); err != nil {
+			return err
+		}
+
+		return h.runAPICommand(c, a, cid, pc, wait, false, func(
+			ctx context.Context,
+		) (<-chan struct{}, error) {
+			return setCamera(
+				ctx, pc, req.ISO, req.ShutterSpeed,
+				req.AutoWhiteBalance, req.WhiteBalanceRedGain, req.WhiteBalanceBlueGain,
+			)
+		})
+	}
+}
+
+// Imager
+
+// APIImagerRequest starts or stops imaging. Acquisitions are recorded with the instrument's current
+// sample unless the request provides other sample IDs.
+type APIImagerRequest struct {
+	Imaging         *bool   `json:"imaging"`
+	SampleProjectID string  `json:"sampleProjectId"`
+	SampleID        string  `json:"sampleId"`
+	Forward         bool    `json:"forward"`
+	StepVolume      float64 `json:"stepVolume"` // mL
+	StepDelay       float64 `json:"stepDelay"`  // sec
+	Steps           uint64  `json:"steps"`
+}
+
+func (r APIImagerRequest) params() url.Values {
+	return url.Values{
+		"imaging":           []string{formatOptionalFlag(r.Imaging)},
+		"sample-project-id": []string{r.SampleProjectID},
+		"sample-id":         []string{r.SampleID},
+		"forward":           []string{formatFlag(r.Forward)},
+		"step-volume":       []string{fmt.Sprint(r.StepVolume)},
+		"step-delay":        []string{fmt.Sprint(r.StepDelay)},
+		"steps":             []string{strconv.FormatUint(r.Steps, 10)},
+	}
+}
+
+func (r APIImagerRequest) validate() error {
+	errs := make(handling.FieldErrors)
+	if r.Imaging == nil {
+		errs.Add("imaging", "is required")
+		return errs.HTTPError()
+	}
+	if !*r.Imaging {
+		return nil
+	}
+	if r.StepVolume <= 0 {
+		errs.Add("stepVolume", "must be positive")
+	}
+	if r.StepDelay < 0 {
+		errs.Add("stepDelay", "must not be negative")
+	}
+	if r.Steps == 0 {
+		errs.Add("steps", "must be positive")
+	}
+	return errs.HTTPError()
+}
+
+func (h *Handlers) HandleAPIImagerPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		_, cid, pc, err := h.getAPIPlanktoscopeClient(c)
+		if err != nil {
+			return err
+		}
+		wait, err := parseAPICommandWait(c)
+		if err != nil {
+			return err
+		}
+		settings := pc.GetState().ImagerSettings
+		req := APIImagerRequest{
+			Forward:    settings.Forward,
+			StepVolume: settings.StepVolume,
+			StepDelay:  settings.StepDelay,
+			Steps:      settings.Steps,
+		}
+		if err = bindAPIRequest(c, &req); err != nil {
+			return err
+		}
+		setAuditedParams(c, req.params())
+		if err = req.validate(); err != nil {
+			return err
+		}
+
+		return h.runAPICommand(c, a, cid, pc, wait, !*req.Imaging, func(
+			ctx context.Context,
+		) (<-chan struct{}, error) {
+			return setImager(ctx, pc, *req.Imaging, imagingParams{
+				Source:          planktoscope.AcquisitionSourceAPI,
+				SampleProjectID: req.SampleProjectID,
+				SampleID:        req.SampleID,
+				Forward:         req.Forward,
+				StepVolume:      req.StepVolume,
+				StepDelay:       req.StepDelay,
+				Steps:           req.Steps,
+			})
+		})
+	}
+}
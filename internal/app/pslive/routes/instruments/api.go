@@ -0,0 +1,142 @@
+package instruments
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+)
+
+// apiPrefix is the path prefix of the versioned JSON API.
+const apiPrefix = "/api/v1"
+
+// Requests
+
+// bindAPIRequest decodes the JSON request body into req. Fields which aren't in the request body
+// keep the values they already had, which allows req to be pre-filled with defaults or with the
+// current values of the resource being updated. An empty request body leaves req unchanged.
+func bindAPIRequest(c echo.Context, req interface{}) error {
+	decoder := json.NewDecoder(c.Request().Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(req); err != nil && err != io.EOF {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid request body: %s", err))
+	}
+	return nil
+}
+
+// setAuditedParams records the parameters of a JSON API request for the audit log, which can only
+// read parameters from form values by itself.
+func setAuditedParams(c echo.Context, params url.Values) {
+	c.Set(auditedParamsKey, params)
+}
+
+func formatFlag(flag bool) string {
+	if flag {
+		return flagChecked
+	}
+	return ""
+}
+
+// formatOptionalFlag represents a missing flag as a value which is neither checked nor unchecked.
+func formatOptionalFlag(flag *bool) string {
+	if flag == nil {
+		return "(missing)"
+	}
+	return strconv.FormatBool(*flag)
+}
+
+// Pagination
+
+const (
+	defaultAPIPageLimit = 50
+	maxAPIPageLimit     = 200
+)
+
+type APIPage[Item any] struct {
+	Items  []Item `json:"items"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+	Total  int    `json:"total"`
+}
+
+func parseAPIPageParam(c echo.Context, name string, defaultValue, maxValue int) (int, error) {
+	raw := c.QueryParam(name)
+	if raw == "" {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil || value < 0 || value > maxValue {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"%s must be an integer between 0 and %d", name, maxValue,
+		))
+	}
+	return value, nil
+}
+
+// newAPIPage selects the page of items requested by the offset and limit query params.
+func newAPIPage[Item any](c echo.Context, items []Item) (page APIPage[Item], err error) {
+	if page.Offset, err = parseAPIPageParam(c, "offset", 0, math.MaxInt32); err != nil {
+		return APIPage[Item]{}, err
+	}
+	if page.Limit, err = parseAPIPageParam(
+		c, "limit", defaultAPIPageLimit, maxAPIPageLimit,
+	); err != nil {
+		return APIPage[Item]{}, err
+	}
+	page.Total = len(items)
+	start := page.Offset
+	if start > len(items) {
+		start = len(items)
+	}
+	end := start + page.Limit
+	if end > len(items) {
+		end = len(items)
+	}
+	page.Items = items[start:end]
+	return page, nil
+}
+
+// sortedComponents returns the instrument's components in order of their IDs.
+func sortedComponents[ID ~int64, Component any](components map[ID]Component) []Component {
+	ids := make([]ID, 0, len(components))
+	for id := range components {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	sorted := make([]Component, len(ids))
+	for i, id := range ids {
+		sorted[i] = components[id]
+	}
+	return sorted
+}
+
+// Components
+
+// getAPIComponent looks up a component of the instrument, since the store's components don't all
+// have lookup queries of their own.
+func getAPIComponent[ID ~int64, Component any](
+	components map[ID]Component, id ID, typeName string, iid instruments.InstrumentID,
+) (Component, error) {
+	component, ok := components[id]
+	if !ok {
+		return component, echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf(
+			"%s %d not found on instrument %d", typeName, id, iid,
+		))
+	}
+	return component, nil
+}
+
+func checkAPIComponentName(name, typeName string) error {
+	if name == "" {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("%s needs a name", typeName))
+	}
+	return nil
+}
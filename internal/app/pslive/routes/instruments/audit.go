@@ -0,0 +1,326 @@
+package instruments
+
+import (
+	"context"
+	"fmt"
+	"html/template"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+	"github.com/sargassum-world/godest/session"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+	"github.com/sargassum-world/pslive/internal/clients/ory"
+)
+
+const auditPage = "instruments/audit.page.tmpl"
+
+// Audit Middleware
+
+const (
+	// auditedInstrumentKey is the key of the echo context value which handlers can set to provide
+	// the ID of the instrument they acted on, if the route has no instrument ID parameter.
+	auditedInstrumentKey = "audited-instrument-id"
+	// auditedParamsKey is the key of the echo context value which handlers can set to provide the
+	// parameters of their action, if the parameters weren't provided as form values.
+	auditedParamsKey = "audited-params"
+)
+
+// auditedRouter is a routing adapter which adds an audit middleware to every mutating route.
+type auditedRouter struct {
+	godest.EchoRouter
+	audit echo.MiddlewareFunc
+}
+
+func (r auditedRouter) DELETE(
+	path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc,
+) *echo.Route {
+	return r.EchoRouter.DELETE(path, h, append(m, r.audit)...)
+}
+
+func (r auditedRouter) PATCH(
+	path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc,
+) *echo.Route {
+	return r.EchoRouter.PATCH(path, h, append(m, r.audit)...)
+}
+
+func (r auditedRouter) POST(path string, h echo.HandlerFunc, m ...echo.MiddlewareFunc) *echo.Route {
+	return r.EchoRouter.POST(path, h, append(m, r.audit)...)
+}
+
+// redactedParamFragments are substrings of form parameter names whose values must not be recorded
+// in the audit log.
+var redactedParamFragments = []string{"password", "secret", "token", "key", "cert"}
+
+func newAuditParameters(c echo.Context, csrfFieldName string) map[string]string {
+	formParams, ok := c.Get(auditedParamsKey).(url.Values)
+	if !ok {
+		var err error
+		if formParams, err = c.FormParams(); err != nil {
+			return map[string]string{}
+		}
+	}
+	params := make(map[string]string, len(formParams))
+	for name, values := range formParams {
+		if name == csrfFieldName || name == "omit-csrf-token" {
+			continue
+		}
+		params[name] = strings.Join(values, ", ")
+		lowered := strings.ToLower(name)
+		for _, fragment := range redactedParamFragments {
+			if strings.Contains(lowered, fragment) {
+				params[name] = "(redacted)"
+				break
+			}
+		}
+	}
+	return params
+}
+
+// splitAuditedPath determines the audited component and action from the request's route. If the
+// route ends with a literal segment (e.g. /controllers/:controllerID/pump), that segment is the
+// action on the component identified by the rest of the path; otherwise, the route identifies the
+// component, and the requested state or the request method (if any) determines the action.
+func splitAuditedPath(c echo.Context) (component, action string) {
+	routePath := strings.TrimPrefix(c.Path(), apiPrefix)
+	if routePath == "/instruments" {
+		return "", "created"
+	}
+	route := strings.Trim(strings.TrimPrefix(routePath, "/instruments/:id"), "/")
+	concrete := strings.Trim(strings.TrimPrefix(
+		strings.TrimPrefix(c.Request().URL.Path, apiPrefix), "/instruments/"+c.Param("id"),
+	), "/")
+	if route != "" {
+		if last := route[strings.LastIndex(route, "/")+1:]; !strings.HasPrefix(last, ":") {
+			if i := strings.LastIndex(concrete, "/"); i >= 0 {
+				component = concrete[:i]
+			}
+			return component, last
+		}
+		component = concrete
+	}
+	switch c.Request().Method {
+	case http.MethodPatch:
+		return component, "updated"
+	case http.MethodDelete:
+		return component, "deleted"
+	}
+	if state := c.FormValue("state"); state != "" {
+		return component, state
+	}
+	return component, strings.ToLower(c.Request().Method)
+}
+
+func (h *Handlers) auditMiddleware(ss *session.Store) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			a, _, aerr := auth.GetFromRequest(c.Request(), ss, c.Logger())
+			err := next(c)
+			if aerr != nil {
+				c.Logger().Error(errors.Wrap(aerr, "couldn't determine actor for audit event"))
+				return err
+			}
+
+			e := instruments.AuditEvent{
+				Time:            time.Now(),
+				ActorIdentityID: string(a.Identity.User),
+				APITokenID:      a.Identity.TokenID,
+				Source:          c.RealIP(),
+				Parameters:      newAuditParameters(c, ss.CSRFOptions().FieldName),
+				Outcome:         instruments.AuditOutcomeSucceeded,
+			}
+			if iid, ok := c.Get(auditedInstrumentKey).(instruments.InstrumentID); ok {
+				e.InstrumentID = iid
+			} else if e.InstrumentID, aerr = parseID[instruments.InstrumentID](
+				c.Param("id"), "instrument",
+			); aerr != nil {
+				// The request didn't act on any instrument, so there's nothing to audit
+				return err
+			}
+			e.Component, e.Action = splitAuditedPath(c)
+			if herr, ok := err.(*echo.HTTPError); ok {
+				e.Outcome = instruments.AuditOutcomeFailed
+				e.Error = fmt.Sprint(herr.Message)
+			} else if err != nil {
+				e.Outcome = instruments.AuditOutcomeFailed
+				e.Error = err.Error()
+			} else if c.Response().Status >= http.StatusBadRequest {
+				e.Outcome = instruments.AuditOutcomeFailed
+				e.Error = http.StatusText(c.Response().Status)
+			}
+			// The audit log shouldn't affect the response, since the action was already taken
+			if _, aerr = h.is.AddAuditEvent(context.Background(), e); aerr != nil {
+				c.Logger().Error(errors.Wrapf(
+					aerr, "couldn't audit %s on instrument %d", c.Path(), e.InstrumentID,
+				))
+			}
+			return err
+		}
+	}
+}
+
+// Audit Log
+
+type AuditEventViewData struct {
+	Event           instruments.AuditEvent
+	ActorIdentifier ory.IdentityIdentifier
+	ParameterNames  []string
+}
+
+type AuditViewData struct {
+	Instrument instruments.Instrument
+	Filter     instruments.AuditEventsFilter
+	Events     []AuditEventViewData
+	// ExportQuery is the query string for exporting the filtered events as JSON.
+	ExportQuery template.URL
+}
+
+func parseAuditEventsFilter(c echo.Context) (filter instruments.AuditEventsFilter, err error) {
+	filter.ActorIdentityID = strings.TrimSpace(c.QueryParam("actor"))
+	filter.Component = strings.Trim(strings.TrimSpace(c.QueryParam("component")), "/")
+	filter.Action = strings.TrimSpace(c.QueryParam("action"))
+	switch outcome := instruments.AuditOutcome(c.QueryParam("outcome")); outcome {
+	default:
+		return instruments.AuditEventsFilter{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"invalid audit event outcome %s", outcome,
+		))
+	case "", instruments.AuditOutcomeSucceeded, instruments.AuditOutcomeFailed:
+		filter.Outcome = outcome
+	}
+	if rawLimit := c.QueryParam("limit"); rawLimit != "" {
+		if filter.Limit, err = parseID[int64](rawLimit, "limit"); err != nil {
+			return instruments.AuditEventsFilter{}, err
+		}
+	}
+	return filter, nil
+}
+
+func getAuditViewData(
+	ctx context.Context, iid instruments.InstrumentID, filter instruments.AuditEventsFilter,
+	oc *ory.Client, is *instruments.Store,
+) (vd AuditViewData, err error) {
+	if vd.Instrument, err = is.GetInstrument(ctx, iid); err != nil {
+		return AuditViewData{}, err
+	}
+	vd.Filter = filter
+	//nolint:gosec // The query is encoded from the filter's values, so we know it's well-formed
+	vd.ExportQuery = template.URL(url.Values{
+		"actor":     []string{filter.ActorIdentityID},
+		"component": []string{filter.Component},
+		"action":    []string{filter.Action},
+		"outcome":   []string{string(filter.Outcome)},
+	}.Encode())
+	events, err := is.GetAuditEvents(ctx, iid, filter)
+	if err != nil {
+		return AuditViewData{}, err
+	}
+	identifiers := make(map[string]ory.IdentityIdentifier)
+	vd.Events = make([]AuditEventViewData, len(events))
+	for i, event := range events {
+		vd.Events[i] = AuditEventViewData{Event: event}
+		for name := range event.Parameters {
+			vd.Events[i].ParameterNames = append(vd.Events[i].ParameterNames, name)
+		}
+		sort.Strings(vd.Events[i].ParameterNames)
+		if event.ActorIdentityID == "" {
+			continue
+		}
+		identifier, ok := identifiers[event.ActorIdentityID]
+		if !ok {
+			if identifier, err = oc.GetIdentifier(
+				ctx, ory.IdentityID(event.ActorIdentityID),
+			); err != nil {
+				// The identity may have been deleted, so we fall back to showing its ID
+				identifier = ory.IdentityIdentifier(event.ActorIdentityID)
+			}
+			identifiers[event.ActorIdentityID] = identifier
+		}
+		vd.Events[i].ActorIdentifier = identifier
+	}
+	return vd, nil
+}
+
+func (h *Handlers) HandleInstrumentAuditGet() auth.HTTPHandlerFunc {
+	t := auditPage
+	h.r.MustHave(t)
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		filter, err := parseAuditEventsFilter(c)
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		vd, err := getAuditViewData(c.Request().Context(), iid, filter, h.oc, h.is)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		return h.r.CacheablePage(c.Response(), c.Request(), t, vd, a)
+	}
+}
+
+type AuditEventExport struct {
+	ID              instruments.AuditEventID `json:"id"`
+	InstrumentID    instruments.InstrumentID `json:"instrumentId"`
+	Time            time.Time                `json:"time"`
+	ActorIdentityID string                   `json:"actorIdentityId,omitempty"`
+	APITokenID      int64                    `json:"apiTokenId,omitempty"`
+	Source          string                   `json:"source"`
+	Component       string                   `json:"component"`
+	Action          string                   `json:"action"`
+	Parameters      map[string]string        `json:"parameters"`
+	Outcome         instruments.AuditOutcome `json:"outcome"`
+	Error           string                   `json:"error,omitempty"`
+}
+
+func (h *Handlers) HandleInstrumentAuditJSONGet() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		filter, err := parseAuditEventsFilter(c)
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		events, err := h.is.GetAuditEvents(c.Request().Context(), iid, filter)
+		if err != nil {
+			return err
+		}
+		exported := make([]AuditEventExport, len(events))
+		for i, e := range events {
+			exported[i] = AuditEventExport{
+				ID:              e.ID,
+				InstrumentID:    e.InstrumentID,
+				Time:            e.Time.UTC(),
+				ActorIdentityID: e.ActorIdentityID,
+				APITokenID:      e.APITokenID,
+				Source:          e.Source,
+				Component:       e.Component,
+				Action:          e.Action,
+				Parameters:      e.Parameters,
+				Outcome:         e.Outcome,
+				Error:           e.Error,
+			}
+		}
+
+		// Produce output
+		return c.JSON(http.StatusOK, exported)
+	}
+}
@@ -8,29 +8,29 @@ import (
 	"github.com/sargassum-world/pslive/internal/clients/instruments"
 )
 
+func (h *Handlers) addAutomationJob(
+	ctx context.Context, iid instruments.InstrumentID,
+	enabled bool, name, description string, params url.Values,
+) (instruments.AutomationJobID, error) {
+	specType := params.Get("type")
+	specification := params.Get("specification")
+	id, err := h.is.AddAutomationJob(ctx, instruments.AutomationJob{
+		InstrumentID:  iid,
+		Enabled:       enabled,
+		Name:          name,
+		Description:   description,
+		Type:          specType,
+		Specification: specification,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !enabled {
+		return id, nil
+	}
+	return id, h.ijo.Add(id, iid, name, specType, specification)
+}
+
 func (h *Handlers) HandleInstrumentAutomationJobsPost() auth.HTTPHandlerFunc {
-	return handleInstrumentComponentsPost(
-		func(
-			ctx context.Context, iid instruments.InstrumentID,
-			enabled bool, name, description string, params url.Values,
-		) error {
-			specType := params.Get("type")
-			specification := params.Get("specification")
-			id, err := h.is.AddAutomationJob(ctx, instruments.AutomationJob{
-				InstrumentID:  iid,
-				Enabled:       enabled,
-				Name:          name,
-				Description:   description,
-				Type:          specType,
-				Specification: specification,
-			})
-			if err != nil {
-				return err
-			}
-			if !enabled {
-				return nil
-			}
-			return h.ijo.Add(id, iid, name, specType, specification)
-		},
-	)
+	return handleInstrumentComponentsPost(h.addAutomationJob)
 }
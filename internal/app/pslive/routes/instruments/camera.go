@@ -129,26 +129,37 @@ func externalSourceFrameSender(
 
 // Handlers
 
+// cameraProtocol is the only protocol which cameras can currently be streamed with.
+const cameraProtocol = "mjpeg"
+
+func checkCameraProtocol(protocol string) error {
+	if protocol != cameraProtocol {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"unknown camera protocol %s", protocol,
+		))
+	}
+	return nil
+}
+
+func (h *Handlers) updateCamera(
+	ctx context.Context, id instruments.CameraID, iid instruments.InstrumentID,
+	enabled bool, name, description string, params url.Values,
+) error {
+	if err := checkCameraProtocol(params.Get("protocol")); err != nil {
+		return err
+	}
+	return h.is.UpdateCamera(ctx, instruments.Camera{
+		ID:          id,
+		Enabled:     enabled,
+		Name:        name,
+		Description: description,
+		Protocol:    params.Get("protocol"),
+		URL:         params.Get("url"),
+	})
+}
+
 func (h *Handlers) HandleInstrumentCameraPost() auth.HTTPHandlerFunc {
-	return handleInstrumentComponentPost(
-		"camera",
-		func(
-			ctx context.Context, id instruments.CameraID, iid instruments.InstrumentID,
-			enabled bool, name, description string, params url.Values,
-		) error {
-			protocol := params.Get("protocol")
-			url := params.Get("url")
-			return h.is.UpdateCamera(ctx, instruments.Camera{
-				ID:          id,
-				Enabled:     enabled,
-				Name:        name,
-				Description: description,
-				Protocol:    protocol,
-				URL:         url,
-			})
-		},
-		h.is.DeleteCamera,
-	)
+	return handleInstrumentComponentPost("camera", h.updateCamera, h.is.DeleteCamera)
 }
 
 func (h *Handlers) HandleInstrumentCameraFrameGet() echo.HandlerFunc {
@@ -8,23 +8,23 @@ import (
 	"github.com/sargassum-world/pslive/internal/clients/instruments"
 )
 
+func (h *Handlers) addCamera(
+	ctx context.Context, iid instruments.InstrumentID,
+	enabled bool, name, description string, params url.Values,
+) (instruments.CameraID, error) {
+	if err := checkCameraProtocol(params.Get("protocol")); err != nil {
+		return 0, err
+	}
+	return h.is.AddCamera(ctx, instruments.Camera{
+		InstrumentID: iid,
+		Enabled:      enabled,
+		Name:         name,
+		Description:  description,
+		Protocol:     params.Get("protocol"),
+		URL:          params.Get("url"),
+	})
+}
+
 func (h *Handlers) HandleInstrumentCamerasPost() auth.HTTPHandlerFunc {
-	return handleInstrumentComponentsPost(
-		func(
-			ctx context.Context, id instruments.InstrumentID,
-			enabled bool, name, description string, params url.Values,
-		) error {
-			protocol := params.Get("protocol")
-			url := params.Get("url")
-			_, err := h.is.AddCamera(ctx, instruments.Camera{
-				InstrumentID: id,
-				Enabled:      enabled,
-				Name:         name,
-				Description:  description,
-				Protocol:     protocol,
-				URL:          url,
-			})
-			return err
-		},
-	)
+	return handleInstrumentComponentsPost(h.addCamera)
 }
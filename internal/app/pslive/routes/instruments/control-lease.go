@@ -0,0 +1,314 @@
+package instruments
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/turbostreams"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/app/pslive/handling"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+	"github.com/sargassum-world/pslive/internal/clients/ory"
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
+	"github.com/sargassum-world/pslive/internal/clients/presence"
+)
+
+const controlLeasePartial = "instruments/control-lease.partial.tmpl"
+
+type ControlLeaseViewData struct {
+	Held                bool
+	Lease               instruments.ControlLease
+	HolderIdentifier    ory.IdentityIdentifier
+	RequesterIdentifier ory.IdentityIdentifier
+	// Candidates are the authenticated users on the instrument's page who the holder can pass the
+	// lease on to.
+	Candidates []presence.User
+}
+
+func usersTopic(iid instruments.InstrumentID) presence.Topic {
+	return presence.Topic(fmt.Sprintf("/instruments/%d/users", iid))
+}
+
+func getControlLeaseViewData(
+	ctx context.Context, iid instruments.InstrumentID,
+	cl *instruments.ControlLeaser, oc *ory.Client, ps *presence.Store,
+) (vd ControlLeaseViewData, err error) {
+	if vd.Lease, vd.Held, err = cl.Get(ctx, iid); err != nil {
+		return ControlLeaseViewData{}, errors.Wrapf(
+			err, "couldn't look up control lease for instrument %d", iid,
+		)
+	}
+	if !vd.Held {
+		return vd, nil
+	}
+	if vd.HolderIdentifier, err = oc.GetIdentifier(
+		ctx, ory.IdentityID(vd.Lease.IdentityID),
+	); err != nil {
+		return ControlLeaseViewData{}, errors.Wrapf(
+			err, "couldn't look up identifier of control lease holder for instrument %d", iid,
+		)
+	}
+	if vd.Lease.RequesterIdentityID != "" {
+		if vd.RequesterIdentifier, err = oc.GetIdentifier(
+			ctx, ory.IdentityID(vd.Lease.RequesterIdentityID),
+		); err != nil {
+			return ControlLeaseViewData{}, errors.Wrapf(
+				err, "couldn't look up identifier of control lease requester for instrument %d", iid,
+			)
+		}
+	}
+	known, _ := ps.List(usersTopic(iid))
+	vd.Candidates = make([]presence.User, 0, len(known))
+	for _, user := range known {
+		if string(user.ID) != vd.Lease.IdentityID {
+			vd.Candidates = append(vd.Candidates, user)
+		}
+	}
+	return vd, nil
+}
+
+type ControlLeaseViewAuthz struct {
+	Request  bool
+	Release  bool
+	Transfer bool
+}
+
+func getControlLeaseViewAuthz(
+	ctx context.Context, iid instruments.InstrumentID, a auth.Auth, azc *auth.AuthzChecker,
+) (authz ControlLeaseViewAuthz, err error) {
+	path := fmt.Sprintf("/instruments/%d/control-lease", iid)
+	if authz.Request, err = azc.Allow(ctx, a, path, http.MethodPost, nil); err != nil {
+		return ControlLeaseViewAuthz{}, errors.Wrap(err, "couldn't check authz for requesting control")
+	}
+	path = fmt.Sprintf("/instruments/%d/control-lease/release", iid)
+	if authz.Release, err = azc.Allow(ctx, a, path, http.MethodPost, nil); err != nil {
+		return ControlLeaseViewAuthz{}, errors.Wrap(err, "couldn't check authz for releasing control")
+	}
+	path = fmt.Sprintf("/instruments/%d/control-lease/transfer", iid)
+	if authz.Transfer, err = azc.Allow(ctx, a, path, http.MethodPost, nil); err != nil {
+		return ControlLeaseViewAuthz{}, errors.Wrap(err, "couldn't check authz for passing control")
+	}
+	return authz, nil
+}
+
+func replaceControlLeaseStream(
+	iid instruments.InstrumentID, vd ControlLeaseViewData, a auth.Auth,
+) turbostreams.Message {
+	return turbostreams.Message{
+		Action:   turbostreams.ActionReplace,
+		Target:   fmt.Sprintf("/instruments/%d/control-lease", iid),
+		Template: controlLeasePartial,
+		Data: map[string]interface{}{
+			"InstrumentID": iid,
+			"ControlLease": vd,
+			"Auth":         a,
+		},
+	}
+}
+
+func (h *Handlers) ModifyControlLeaseMsgData() handling.DataModifier {
+	return func(
+		ctx context.Context, a auth.Auth, data map[string]interface{},
+	) (modifications map[string]interface{}, err error) {
+		rawIID, ok := data["InstrumentID"]
+		if !ok {
+			return nil, errors.New(
+				"couldn't find instrument id from turbostreams message data to check authorizations",
+			)
+		}
+		iid, ok := rawIID.(instruments.InstrumentID)
+		if !ok {
+			return nil, errors.Errorf(
+				"instrument id has unexpected type %T in turbostreams message data for checking authorization",
+				rawIID,
+			)
+		}
+		modifications = make(map[string]interface{})
+		if modifications["Authorizations"], err = getControlLeaseViewAuthz(
+			ctx, iid, a, h.azc,
+		); err != nil {
+			return nil, errors.Wrapf(err, "couldn't check authz for control lease of instrument %d", iid)
+		}
+		return modifications, nil
+	}
+}
+
+// broadcastControllerAuthorizations re-sends the controls of the instrument's planktoscope
+// controllers, so that each viewer's controls are re-rendered with their updated authorizations.
+func (h *Handlers) broadcastControllerAuthorizations(
+	ctx context.Context, c *turbostreams.Context, iid instruments.InstrumentID,
+) error {
+	instrument, err := h.is.GetInstrument(ctx, iid)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't look up controllers of instrument %d", iid)
+	}
+	for _, controller := range instrument.Controllers {
+		if !controller.Enabled || controller.Protocol != planktoscope.Protocol {
+			continue
+		}
+		pc, ok := h.pco.Get(planktoscope.ClientID(controller.ID))
+		if !ok || !pc.HasConnection() {
+			continue
+		}
+		// We insert empty Auth objects because the MSG handlers will add the auth object for each
+		// client
+		topic := fmt.Sprintf("/instruments/%d/controllers/%d", iid, controller.ID)
+		c.Broadcast(topic+"/pump", replacePumpStream(iid, controller.ID, auth.Auth{}, pc))
+		c.Broadcast(topic+"/camera", replaceCameraStream(iid, controller.ID, auth.Auth{}, pc))
+		c.Broadcast(topic+"/imager", replaceImagerStream(iid, controller.ID, auth.Auth{}, pc))
+	}
+	return nil
+}
+
+func (h *Handlers) HandleControlLeasePub() turbostreams.HandlerFunc {
+	t := controlLeasePartial
+	h.r.MustHave(t)
+	return func(c *turbostreams.Context) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+
+		// Publish on lease change
+		changed := h.cl.Changed()
+		previous, _, err := h.cl.Get(c.Context(), iid)
+		if err != nil {
+			return err
+		}
+		for {
+			ctx := c.Context()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-changed:
+				if err := ctx.Err(); err != nil {
+					// Context was also canceled and it should have priority
+					return err
+				}
+				changed = h.cl.Changed()
+				vd, err := getControlLeaseViewData(ctx, iid, h.cl, h.oc, h.ps)
+				if err != nil {
+					return err
+				}
+				// Changes are broadcast for all instruments, so we skip changes to other instruments
+				if vd.Lease == previous {
+					continue
+				}
+				holderChanged := vd.Lease.IdentityID != previous.IdentityID
+				previous = vd.Lease
+				// We insert an empty Auth object because the MSG handler will add the auth object for
+				// each client
+				c.Publish(replaceControlLeaseStream(iid, vd, auth.Auth{}))
+				if !holderChanged {
+					continue
+				}
+				if err := h.broadcastControllerAuthorizations(ctx, c, iid); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func (h *Handlers) HandleControlLeasePost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		ctx := c.Request().Context()
+		lease, granted, err := h.cl.Request(ctx, iid, string(a.Identity.User))
+		if err != nil {
+			return err
+		}
+		if granted {
+			c.Logger().Infof("control of instrument %d was leased to %s", iid, lease.IdentityID)
+		}
+
+		// Redirect user
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/instruments/%d", iid))
+	}
+}
+
+func (h *Handlers) HandleControlLeaseReleasePost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		ctx := c.Request().Context()
+		lease, held, err := h.cl.Get(ctx, iid)
+		if err != nil {
+			return err
+		}
+		if held {
+			if err = h.cl.Release(ctx, lease); err != nil {
+				return err
+			}
+			c.Logger().Infof(
+				"lease of %s on control of instrument %d was released by %s",
+				lease.IdentityID, iid, a.Identity.User,
+			)
+		}
+
+		// Redirect user
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/instruments/%d", iid))
+	}
+}
+
+func (h *Handlers) HandleControlLeaseTransferPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		identityID := c.FormValue("identity-id")
+
+		// Run queries
+		ctx := c.Request().Context()
+		lease, held, err := h.cl.Get(ctx, iid)
+		if err != nil {
+			return err
+		}
+		if !held {
+			return echo.NewHTTPError(http.StatusConflict, fmt.Sprintf(
+				"nobody holds control of instrument %d", iid,
+			))
+		}
+		present := false
+		known, _ := h.ps.List(usersTopic(iid))
+		for _, user := range known {
+			if string(user.ID) == identityID {
+				present = true
+				break
+			}
+		}
+		if !present {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+				"user %s isn't viewing instrument %d", identityID, iid,
+			))
+		}
+		if err = h.cl.Transfer(ctx, lease, identityID); err != nil {
+			return err
+		}
+		c.Logger().Infof(
+			"control of instrument %d was passed from %s to %s by %s",
+			iid, lease.IdentityID, identityID, a.Identity.User,
+		)
+
+		// Redirect user
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/instruments/%d", iid))
+	}
+}
@@ -0,0 +1,123 @@
+package instruments
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/turbostreams"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+	"github.com/sargassum-world/pslive/internal/clients/ory"
+)
+
+const controlPartial = "instruments/control.partial.tmpl"
+
+type ControlViewData struct {
+	Status              instruments.ControlStatus
+	CommanderIdentifier ory.IdentityIdentifier
+}
+
+func getControlViewData(
+	ctx context.Context, cid instruments.ControllerID,
+	ca *instruments.ControlArbiter, oc *ory.Client,
+) (vd ControlViewData, err error) {
+	vd.Status = ca.GetStatus(cid)
+	if !vd.Status.Commanding || vd.Status.Commander.IsAutomation() {
+		return vd, nil
+	}
+	if vd.CommanderIdentifier, err = oc.GetIdentifier(
+		ctx, ory.IdentityID(vd.Status.Commander.OperatorID),
+	); err != nil {
+		return ControlViewData{}, errors.Wrapf(
+			err, "couldn't look up identifier of operator commanding controller %d", cid,
+		)
+	}
+	return vd, nil
+}
+
+func getControlsViewData(
+	ctx context.Context, controllerIDs []instruments.ControllerID,
+	ca *instruments.ControlArbiter, oc *ory.Client,
+) (vd map[instruments.ControllerID]ControlViewData, err error) {
+	vd = make(map[instruments.ControllerID]ControlViewData)
+	for _, cid := range controllerIDs {
+		if vd[cid], err = getControlViewData(ctx, cid, ca, oc); err != nil {
+			return nil, err
+		}
+	}
+	return vd, nil
+}
+
+func replaceControlStream(
+	iid instruments.InstrumentID, cid instruments.ControllerID, vd ControlViewData,
+) turbostreams.Message {
+	return turbostreams.Message{
+		Action:   turbostreams.ActionReplace,
+		Target:   fmt.Sprintf("/instruments/%d/controllers/%d/control", iid, cid),
+		Template: controlPartial,
+		Data: map[string]interface{}{
+			"InstrumentID": iid,
+			"ControllerID": cid,
+			"Control":      vd,
+		},
+	}
+}
+
+func (h *Handlers) HandleControlPub() turbostreams.HandlerFunc {
+	t := controlPartial
+	h.r.MustHave(t)
+	return func(c *turbostreams.Context) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		cid, err := parseID[instruments.ControllerID](c.Param("controllerID"), "controller")
+		if err != nil {
+			return err
+		}
+
+		// Publish on control change
+		changed := h.ca.Changed()
+		previous := h.ca.GetStatus(cid)
+		for {
+			ctx := c.Context()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-changed:
+				if err := ctx.Err(); err != nil {
+					// Context was also canceled and it should have priority
+					return err
+				}
+				changed = h.ca.Changed()
+				vd, err := getControlViewData(ctx, cid, h.ca, h.oc)
+				if err != nil {
+					return err
+				}
+				// Changes are broadcast for all controllers, so we skip changes to other controllers
+				if vd.Status == previous {
+					continue
+				}
+				previous = vd.Status
+				c.Publish(replaceControlStream(iid, cid, vd))
+			}
+		}
+	}
+}
+
+// runOperatorCommand sends the operator's command to the controller through the controller's
+// command queue. Commands which stop the controller's activity skip the queue, so that they can't
+// be held up by an automation job's reservation of the controller or by a slow command.
+func (h *Handlers) runOperatorCommand(
+	ctx context.Context, cid instruments.ControllerID, a auth.Auth, stopping bool,
+	command func() error,
+) error {
+	if stopping {
+		return command()
+	}
+	holder := instruments.ControlHolder{OperatorID: string(a.Identity.User)}
+	return h.ca.Command(ctx, cid, holder, command)
+}
@@ -2,46 +2,235 @@ package instruments
 
 import (
 	"context"
+	"fmt"
+	"math"
+	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
 
 	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/clients/genericmqtt"
+	"github.com/sargassum-world/pslive/internal/clients/httpjson"
 	"github.com/sargassum-world/pslive/internal/clients/instruments"
 	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
 )
 
+// checkControllerProtocol rejects controller settings which no client could be started with.
+func checkControllerProtocol(protocol, schema string) error {
+	switch protocol {
+	default:
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"unknown controller protocol %s", protocol,
+		))
+	case planktoscope.Protocol:
+		return nil
+	case genericmqtt.Protocol:
+		if _, err := genericmqtt.ParseSchema(schema); err != nil {
+			return echo.NewHTTPError(
+				http.StatusBadRequest, errors.Wrap(err, "invalid controller schema").Error(),
+			)
+		}
+		return nil
+	case httpjson.Protocol:
+		if _, err := httpjson.ParseSchema(schema); err != nil {
+			return echo.NewHTTPError(
+				http.StatusBadRequest, errors.Wrap(err, "invalid controller schema").Error(),
+			)
+		}
+		return nil
+	}
+}
+
+// updateControllerClient starts, restarts, or stops the client for the controller to match its
+// settings. Since the controller's protocol may have changed, any client for another protocol is
+// stopped.
+func (h *Handlers) updateControllerClient(
+	ctx context.Context, c instruments.Controller,
+	mqttAuth instruments.MQTTAuth, schema string, limits instruments.ControllerLimits,
+) error {
+	if !c.Enabled || c.Protocol != planktoscope.Protocol {
+		if err := h.pco.Remove(ctx, planktoscope.ClientID(c.ID)); err != nil {
+			return err
+		}
+	}
+	if !c.Enabled || c.Protocol != genericmqtt.Protocol {
+		if err := h.gmo.Remove(ctx, genericmqtt.ClientID(c.ID)); err != nil {
+			return err
+		}
+	}
+	if !c.Enabled || c.Protocol != httpjson.Protocol {
+		if err := h.hjo.Remove(ctx, httpjson.ClientID(c.ID)); err != nil {
+			return err
+		}
+	}
+	if !c.Enabled {
+		return nil
+	}
+
+	switch c.Protocol {
+	default:
+		return errors.Errorf("unknown protocol %s for controller %d", c.Protocol, c.ID)
+	case planktoscope.Protocol:
+		return h.pco.Update(
+			ctx, planktoscope.ClientID(c.ID), c.URL,
+			planktoscope.MQTTAuth(mqttAuth), planktoscope.Limits(limits),
+		)
+	case genericmqtt.Protocol:
+		return h.gmo.Update(
+			ctx, genericmqtt.ClientID(c.ID), c.URL, planktoscope.MQTTAuth(mqttAuth), schema,
+		)
+	case httpjson.Protocol:
+		return h.hjo.Update(ctx, httpjson.ClientID(c.ID), c.URL, schema)
+	}
+}
+
+func (h *Handlers) removeControllerClient(ctx context.Context, id instruments.ControllerID) error {
+	if err := h.pco.Remove(ctx, planktoscope.ClientID(id)); err != nil {
+		return err
+	}
+	if err := h.gmo.Remove(ctx, genericmqtt.ClientID(id)); err != nil {
+		return err
+	}
+	return h.hjo.Remove(ctx, httpjson.ClientID(id))
+}
+
+// parseMQTTAuth updates the previous MQTT authentication settings of a controller with the values
+// provided in the controller settings form. Since the credentials and certificates are never sent
+// back to the browser, empty values in the form leave the previous values unchanged; they can only
+// be removed by clearing all credentials.
+func parseMQTTAuth(params url.Values, previous instruments.MQTTAuth) (a instruments.MQTTAuth) {
+	a = previous
+	if username := strings.TrimSpace(params.Get("mqtt-username")); username != "" {
+		a.Username = username
+	}
+	if password := params.Get("mqtt-password"); password != "" {
+		a.Password = password
+	}
+	if caCert := strings.TrimSpace(params.Get("mqtt-ca-cert")); caCert != "" {
+		a.CACert = caCert
+	}
+	if clientCert := strings.TrimSpace(params.Get("mqtt-client-cert")); clientCert != "" {
+		a.ClientCert = clientCert
+	}
+	if clientKey := strings.TrimSpace(params.Get("mqtt-client-key")); clientKey != "" {
+		a.ClientKey = clientKey
+	}
+	return a
+}
+
+func parseOptionalUint(raw string, fieldName string) (uint64, error) {
+	if raw = strings.TrimSpace(raw); raw == "" {
+		return 0, nil
+	}
+	const uintBase = 10
+	const uintWidth = 64
+	parsed, err := strconv.ParseUint(raw, uintBase, uintWidth)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, errors.Wrapf(
+			err, "couldn't parse %s", fieldName,
+		))
+	}
+	return parsed, nil
+}
+
+// parseControllerLimits parses the safety limits provided in the controller settings form. Blank
+// limits are left as zero, which means that they aren't enforced.
+func parseControllerLimits(params url.Values) (l instruments.ControllerLimits, err error) {
+	floatFields := []struct {
+		name  string
+		value *float64
+	}{
+		{"limit-max-pump-volume", &l.MaxPumpVolume},
+		{"limit-min-pump-flowrate", &l.MinPumpFlowrate},
+		{"limit-max-pump-flowrate", &l.MaxPumpFlowrate},
+	}
+	for _, field := range floatFields {
+		if *field.value, err = parseOptionalFloat(params.Get(field.name), field.name); err != nil {
+			return instruments.ControllerLimits{}, err
+		}
+		if math.IsNaN(*field.value) || math.IsInf(*field.value, 0) {
+			return instruments.ControllerLimits{}, echo.NewHTTPError(
+				http.StatusBadRequest, fmt.Sprintf("%s must be a finite number", field.name),
+			)
+		}
+	}
+	uintFields := []struct {
+		name  string
+		value *uint64
+	}{
+		{"limit-max-imaging-steps", &l.MaxImagingSteps},
+		{"limit-min-iso", &l.MinISO},
+		{"limit-max-iso", &l.MaxISO},
+		{"limit-min-shutter-speed", &l.MinShutterSpeed},
+		{"limit-max-shutter-speed", &l.MaxShutterSpeed},
+	}
+	for _, field := range uintFields {
+		if *field.value, err = parseOptionalUint(params.Get(field.name), field.name); err != nil {
+			return instruments.ControllerLimits{}, err
+		}
+	}
+	if err = planktoscope.Limits(l).Validate(); err != nil {
+		return instruments.ControllerLimits{}, echo.NewHTTPError(
+			http.StatusBadRequest, errors.Wrap(err, "invalid safety limits").Error(),
+		)
+	}
+	return l, nil
+}
+
+func (h *Handlers) updateController(
+	ctx context.Context, id instruments.ControllerID, iid instruments.InstrumentID,
+	enabled bool, name, description string, params url.Values,
+) error {
+	protocol := params.Get("protocol")
+	url := params.Get("url")
+	schema := strings.TrimSpace(params.Get("schema"))
+	if err := checkControllerProtocol(protocol, schema); err != nil {
+		return err
+	}
+	limits, err := parseControllerLimits(params)
+	if err != nil {
+		return err
+	}
+	controller := instruments.Controller{
+		ID:          id,
+		Enabled:     enabled,
+		Name:        name,
+		Description: description,
+		Protocol:    protocol,
+		URL:         url,
+	}
+	var previousAuth instruments.MQTTAuth
+	if strings.ToLower(params.Get("mqtt-clear")) != flagChecked {
+		// Credentials which can't be decrypted anymore are useless, so they're replaced as if they
+		// had never been set
+		if previousAuth, err = h.is.GetControllerMQTTAuth(ctx, id); err != nil &&
+			!errors.Is(err, instruments.ErrSecretUnreadable) {
+			return err
+		}
+	}
+	mqttAuth := parseMQTTAuth(params, previousAuth)
+	if err = h.is.UpdateConfiguredController(ctx, controller, instruments.ControllerConfig{
+		Schema:        schema,
+		Limits:        limits,
+		RestoreCamera: strings.ToLower(params.Get("restore-camera")) == flagChecked,
+		MQTTAuth:      mqttAuth,
+	}); err != nil {
+		return err
+	}
+	return h.updateControllerClient(ctx, controller, mqttAuth, schema, limits)
+}
+
+func (h *Handlers) deleteController(ctx context.Context, id instruments.ControllerID) error {
+	if err := h.is.DeleteController(ctx, id); err != nil {
+		return err
+	}
+	return h.removeControllerClient(ctx, id)
+}
+
 func (h *Handlers) HandleInstrumentControllerPost() auth.HTTPHandlerFunc {
-	return handleInstrumentComponentPost(
-		"controller",
-		func(
-			ctx context.Context, id instruments.ControllerID, iid instruments.InstrumentID,
-			enabled bool, name, description string, params url.Values,
-		) error {
-			protocol := params.Get("protocol")
-			url := params.Get("url")
-			if err := h.is.UpdateController(ctx, instruments.Controller{
-				ID:          id,
-				Enabled:     enabled,
-				Name:        name,
-				Description: description,
-				Protocol:    protocol,
-				URL:         url,
-			}); err != nil {
-				return err
-			}
-			// Note: when we have other controllers, we'll need to generalize this
-			if !enabled {
-				return h.pco.Remove(ctx, planktoscope.ClientID(id))
-			}
-			return h.pco.Update(ctx, planktoscope.ClientID(id), url)
-		},
-		func(ctx context.Context, id instruments.ControllerID) error {
-			if err := h.is.DeleteController(ctx, id); err != nil {
-				return err
-			}
-			if err := h.pco.Remove(ctx, planktoscope.ClientID(id)); err != nil {
-				return err
-			}
-			return nil
-		},
-	)
+	return handleInstrumentComponentPost("controller", h.updateController, h.deleteController)
 }
@@ -3,35 +3,48 @@ package instruments
 import (
 	"context"
 	"net/url"
+	"strings"
 
 	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
 	"github.com/sargassum-world/pslive/internal/clients/instruments"
-	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
 )
 
+func (h *Handlers) addController(
+	ctx context.Context, iid instruments.InstrumentID,
+	enabled bool, name, description string, params url.Values,
+) (instruments.ControllerID, error) {
+	protocol := params.Get("protocol")
+	url := params.Get("url")
+	schema := strings.TrimSpace(params.Get("schema"))
+	if err := checkControllerProtocol(protocol, schema); err != nil {
+		return 0, err
+	}
+	limits, err := parseControllerLimits(params)
+	if err != nil {
+		return 0, err
+	}
+	controller := instruments.Controller{
+		InstrumentID: iid,
+		Enabled:      enabled,
+		Name:         name,
+		Description:  description,
+		Protocol:     protocol,
+		URL:          url,
+	}
+	mqttAuth := parseMQTTAuth(params, instruments.MQTTAuth{})
+	controllerID, err := h.is.AddConfiguredController(ctx, controller, instruments.ControllerConfig{
+		Schema:        schema,
+		Limits:        limits,
+		RestoreCamera: strings.ToLower(params.Get("restore-camera")) == flagChecked,
+		MQTTAuth:      mqttAuth,
+	})
+	if err != nil {
+		return 0, err
+	}
+	controller.ID = controllerID
+	return controllerID, h.updateControllerClient(ctx, controller, mqttAuth, schema, limits)
+}
+
 func (h *Handlers) HandleInstrumentControllersPost() auth.HTTPHandlerFunc {
-	return handleInstrumentComponentsPost(
-		func(
-			ctx context.Context, iid instruments.InstrumentID,
-			enabled bool, name, description string, params url.Values,
-		) error {
-			protocol := params.Get("protocol")
-			url := params.Get("url")
-			controllerID, err := h.is.AddController(ctx, instruments.Controller{
-				InstrumentID: iid,
-				Enabled:      enabled,
-				Name:         name,
-				Description:  description,
-				Protocol:     protocol,
-				URL:          url,
-			})
-			if err != nil {
-				return err
-			}
-			if !enabled {
-				return nil
-			}
-			return h.pco.Add(planktoscope.ClientID(controllerID), url)
-		},
-	)
+	return handleInstrumentComponentsPost(h.addController)
 }
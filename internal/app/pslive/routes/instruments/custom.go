@@ -0,0 +1,192 @@
+package instruments
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/turbostreams"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/clients/customctl"
+	"github.com/sargassum-world/pslive/internal/clients/genericmqtt"
+	"github.com/sargassum-world/pslive/internal/clients/httpjson"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+)
+
+// customControllerClient is a client for a controller whose commands and states are defined by the
+// instrument admin in a schema, rather than by pslive.
+type customControllerClient interface {
+	GetStates() []customctl.StateValue
+	StateBroadcasted() <-chan struct{}
+	GetCommandParams(command string) (params customctl.Params, ok bool)
+	RunCommand(ctx context.Context, command string, rawParams map[string]string) error
+}
+
+func (h *Handlers) getCustomControllerClient(
+	cid instruments.ControllerID,
+) (client customControllerClient, ok bool) {
+	if gc, ok := h.gmo.Get(genericmqtt.ClientID(cid)); ok {
+		return gc, true
+	}
+	if hc, ok := h.hjo.Get(httpjson.ClientID(cid)); ok {
+		return hc, true
+	}
+	return nil, false
+}
+
+// State
+
+const customControllerStatePartial = "instruments/custom/state.partial.tmpl"
+
+func replaceCustomControllerStateStream(
+	iid instruments.InstrumentID, cid instruments.ControllerID, name string, a auth.Auth,
+	client customControllerClient,
+) turbostreams.Message {
+	return turbostreams.Message{
+		Action:   turbostreams.ActionReplace,
+		Target:   fmt.Sprintf("/instruments/%d/controllers/%d/state", iid, cid),
+		Template: customControllerStatePartial,
+		Data: map[string]interface{}{
+			"InstrumentID":   iid,
+			"ControllerID":   cid,
+			"ControllerName": name,
+			"States":         client.GetStates(),
+			"Auth":           a,
+		},
+	}
+}
+
+func (h *Handlers) HandleCustomControllerStatePub() turbostreams.HandlerFunc {
+	t := customControllerStatePartial
+	h.r.MustHave(t)
+	return func(c *turbostreams.Context) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		cid, err := parseID[instruments.ControllerID](c.Param("controllerID"), "controller")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		client, ok := h.getCustomControllerClient(cid)
+		if !ok {
+			return errors.Errorf(
+				"custom controller client for controller %d on instrument %d not found for pub", cid, iid,
+			)
+		}
+		controller, err := h.is.GetController(c.Context(), cid)
+		if err != nil {
+			return err
+		}
+
+		// Publish on state update
+		for {
+			ctx := c.Context()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-client.StateBroadcasted():
+				if err := ctx.Err(); err != nil {
+					// Context was also canceled and it should have priority
+					return err
+				}
+				// We insert an empty Auth object because the MSG handler will add the auth object for each
+				// client
+				message := replaceCustomControllerStateStream(iid, cid, controller.Name, auth.Auth{}, client)
+				c.Publish(message)
+			}
+		}
+	}
+}
+
+// Commands
+
+type CustomControllerViewAuthz struct {
+	Commands map[string]bool
+}
+
+func getCustomControllerViewAuthz(
+	ctx context.Context, iid instruments.InstrumentID, cid instruments.ControllerID,
+	commands []string, a auth.Auth, azc *auth.AuthzChecker,
+) (authz CustomControllerViewAuthz, err error) {
+	authz.Commands = make(map[string]bool)
+	for _, command := range commands {
+		path := fmt.Sprintf("/instruments/%d/controllers/%d/commands/%s", iid, cid, command)
+		if authz.Commands[command], err = azc.Allow(ctx, a, path, http.MethodPost, nil); err != nil {
+			return CustomControllerViewAuthz{}, errors.Wrapf(
+				err, "couldn't check authz for running command %s", command,
+			)
+		}
+	}
+	return authz, nil
+}
+
+func (h *Handlers) HandleCustomControllerCommandPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		cid, err := parseID[instruments.ControllerID](c.Param("controllerID"), "controller")
+		if err != nil {
+			return err
+		}
+		name := c.Param("command")
+		params, err := c.FormParams()
+		if err != nil {
+			return errors.Wrap(err, "couldn't parse form params")
+		}
+
+		// Run queries
+		client, ok := h.getCustomControllerClient(cid)
+		if !ok {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf(
+				"custom controller client for controller %d on instrument %d not found", cid, iid,
+			))
+		}
+		commandParams, ok := client.GetCommandParams(name)
+		if !ok {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf(
+				"command %s not found for controller %d on instrument %d", name, cid, iid,
+			))
+		}
+		rawParams := make(map[string]string)
+		for _, p := range commandParams {
+			// Blank fields fall back to the params' default values, except for strings, which can be
+			// empty
+			if value := params.Get(p.Name); value != "" || p.Type == customctl.ParamString {
+				rawParams[p.Name] = value
+			}
+		}
+		if _, err = commandParams.Parse(rawParams); err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, errors.Wrapf(
+				err, "invalid params for command %s", name,
+			).Error())
+		}
+		ctx := c.Request().Context()
+		if err = h.runOperatorCommand(ctx, cid, a, false, func() error {
+			return client.RunCommand(ctx, name, rawParams)
+		}); err != nil {
+			var reservationErr instruments.ReservationError
+			if errors.As(err, &reservationErr) {
+				return echo.NewHTTPError(http.StatusConflict, reservationErr.Error())
+			}
+			return err
+		}
+
+		// State updates are delivered over Turbo Streams, so we don't need to send anything back
+		if turbostreams.Accepted(c.Request().Header) {
+			return h.r.TurboStream(c.Response())
+		}
+
+		// Redirect user
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/instruments/%d", iid))
+	}
+}
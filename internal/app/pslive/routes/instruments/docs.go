@@ -0,0 +1,497 @@
+package instruments
+
+import (
+	"math"
+	"net/http"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/openapi"
+	"github.com/sargassum-world/pslive/internal/clients/apitokens"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+)
+
+// API Docs
+
+const (
+	jpegType        = "image/jpeg"
+	mjpegType       = "multipart/x-mixed-replace"
+	eventStreamType = "text/event-stream"
+	turboStreamType = "text/vnd.turbo-stream.html"
+)
+
+// Auth requirements
+
+func policyAuth(policy string, anonymous bool, scopes ...apitokens.Scope) openapi.Auth {
+	a := openapi.Auth{Policy: "instruments." + policy, Anonymous: anonymous}
+	if scopes != nil {
+		a.Scopes = make([]string, len(scopes))
+		for i, scope := range scopes {
+			a.Scopes[i] = string(scope)
+		}
+	}
+	return a
+}
+
+// visibleAuth describes policies which allow everyone to view public and unlisted instruments.
+func visibleAuth(policy string) openapi.Auth {
+	return policyAuth(policy, true, apitokens.ScopeInstrumentsRead)
+}
+
+// configAuth describes policies which allow instrument administrators to view configuration.
+func configAuth(policy string) openapi.Auth {
+	return policyAuth(policy, false, apitokens.ScopeInstrumentsRead)
+}
+
+// adminAuth describes policies which allow instrument administrators to change configuration.
+func adminAuth(policy string) openapi.Auth {
+	return policyAuth(policy, false, apitokens.ScopeInstrumentsManage)
+}
+
+// controlAuth describes policies which allow instrument operators to operate controllers.
+func controlAuth(policy string) openapi.Auth {
+	return policyAuth(policy, false, apitokens.ScopeControllersControl)
+}
+
+// jobsAuth describes policies which allow instrument administrators to manage automation jobs.
+func jobsAuth(policy string) openapi.Auth {
+	return policyAuth(policy, false, apitokens.ScopeJobsManage)
+}
+
+// Params
+
+func idParams(params ...openapi.Param) []openapi.Param {
+	return append([]openapi.Param{
+		openapi.PathParam("id", "ID of the instrument", openapi.Int64()),
+		openapi.PathParam("cameraID", "ID of the camera", openapi.Int64()),
+		openapi.PathParam("controllerID", "ID of the controller", openapi.Int64()),
+		openapi.PathParam("automationJobID", "ID of the automation job", openapi.Int64()),
+	}, params...)
+}
+
+func pageParams() []openapi.Param {
+	return idParams(
+		openapi.QueryParam(
+			"offset", "Number of items to skip", openapi.Integer(0, math.MaxInt32).WithDefault(0),
+		),
+		openapi.QueryParam(
+			"limit", "Maximum number of items to return",
+			openapi.Integer(0, maxAPIPageLimit).WithDefault(defaultAPIPageLimit),
+		),
+	)
+}
+
+func commandParams() []openapi.Param {
+	return idParams(openapi.QueryParam(
+		"wait",
+		"How long to wait for the controller to report its new state, as a Go duration such as 10s, "+
+			"up to "+maxAPICommandWait.String()+"; by default, the request doesn't wait",
+		openapi.String(),
+	))
+}
+
+func formField(name, description string, schema *openapi.Schema) openapi.Param {
+	p := openapi.FormField(name, description, schema)
+	p.Required = true
+	return p
+}
+
+// Responses
+
+func jsonResponse(status int, description string, body interface{}) []openapi.Response {
+	return []openapi.Response{{Status: status, Description: description, Body: body}}
+}
+
+func deletedResponse(component string) []openapi.Response {
+	return []openapi.Response{{Status: http.StatusNoContent, Description: component + " deleted"}}
+}
+
+func commandResponses() []openapi.Response {
+	return []openapi.Response{
+		{
+			Status:      http.StatusOK,
+			Description: "The state of the controller after it reported the command's effects",
+			Body:        APIPlanktoscopeState{},
+		},
+		{
+			Status:      http.StatusAccepted,
+			Description: "The state of the controller when the command was sent",
+			Body:        APIPlanktoscopeState{},
+		},
+	}
+}
+
+func formCommandResponses() []openapi.Response {
+	return []openapi.Response{
+		{
+			Status:      http.StatusOK,
+			Description: "Empty Turbo Stream, if Turbo Streams are accepted",
+			ContentType: turboStreamType,
+		},
+		{Status: http.StatusSeeOther, Description: "Redirect to the instrument's page"},
+	}
+}
+
+// Routes
+
+func (h *Handlers) describeRoutes(docs *openapi.Registry) {
+	h.describeMediaRoutes(docs)
+	h.describeEventRoutes(docs)
+	h.describeFormCommandRoutes(docs)
+	h.describeAPIInstrumentRoutes(docs)
+	h.describeAPICameraRoutes(docs)
+	h.describeAPIControllerRoutes(docs)
+	h.describeAPIPlanktoscopeRoutes(docs)
+	h.describeAPIAutomationJobRoutes(docs)
+}
+
+func (h *Handlers) describeMediaRoutes(docs *openapi.Registry) {
+	const tag = "camera-streams"
+	docs.Tag(tag, "Images and video streams from the cameras of instruments")
+	const maxHeight = 4096
+	const defaultHeight = 400
+	const maxQuality = 100
+	const defaultQuality = 80
+	docs.Describe(
+		http.MethodGet, "/instruments/:id/cameras/:cameraID/frame.jpeg", openapi.Operation{
+			Summary: "Get the current frame from a camera",
+			Tags:    []string{tag},
+			Params: idParams(
+				openapi.QueryParam(
+					"height", "Height to resize the frame to, in pixels",
+					openapi.Integer(1, maxHeight).WithDefault(defaultHeight),
+				),
+				openapi.QueryParam(
+					"quality", "JPEG encoding quality",
+					openapi.Integer(1, maxQuality).WithDefault(defaultQuality),
+				),
+			),
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Description: "The current frame", ContentType: jpegType},
+			},
+			Auth: visibleAuth("allow_camera_get"),
+		},
+	)
+	docs.Describe(
+		http.MethodGet, "/instruments/:id/cameras/:cameraID/stream.mjpeg", openapi.Operation{
+			Summary: "Stream the frames from a camera",
+			Tags:    []string{tag},
+			Params: idParams(openapi.QueryParam(
+				"annotated", "Whether to overlay the stream's framerate and latency on each frame",
+				openapi.Boolean().WithDefault(false),
+			)),
+			Responses: []openapi.Response{
+				{Status: http.StatusOK, Description: "An MJPEG stream of frames", ContentType: mjpegType},
+			},
+			Auth: visibleAuth("allow_camera_get"),
+		},
+	)
+}
+
+func (h *Handlers) describeEventRoutes(docs *openapi.Registry) {
+	const tag = "instrument-events"
+	docs.Tag(tag, "Live feeds of changes to the state of instruments")
+	docs.Describe(http.MethodGet, "/instruments/:id/events", openapi.Operation{
+		Summary: "Stream the events of an instrument",
+		Description: "Streams Server-Sent Events with JSON data. Events of type " + pumpEventType +
+			", " + cameraEventType + ", and " + imagerEventType + " carry the new state of a " +
+			"planktoscope controller (as a PlanktoscopeState), " + instruments.JobStatusEventType +
+			" events carry the status of an automation job's run, " + chatEventType + " events " +
+			"carry new chat messages, and " + presenceEventType + " events carry the number of " +
+			"viewers of the instrument. Events which were published recently after the event " +
+			"identified by the Last-Event-ID header are replayed first; if some of those events are " +
+			"no longer available, a " + resetEventType + " event is sent before them.",
+		Tags: []string{tag},
+		Params: idParams(openapi.HeaderParam(
+			"Last-Event-ID", "ID of the last event received before reconnecting", openapi.String(),
+		)),
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, Description: "A stream of events", ContentType: eventStreamType},
+		},
+		Auth: visibleAuth("allow_instrument_get"),
+	})
+}
+
+func (h *Handlers) describeFormCommandRoutes(docs *openapi.Registry) {
+	const tag = "controller-forms"
+	docs.Tag(tag, "Form submissions from the web pages to operate Planktoscope controllers")
+	direction := openapi.Enum("forward", "reverse")
+	docs.Describe(
+		http.MethodPost, "/instruments/:id/controllers/:controllerID/pump", openapi.Operation{
+			Summary: "Start or stop the pump of a Planktoscope",
+			Tags:    []string{tag},
+			Params:  idParams(),
+			Form: []openapi.Param{
+				formField("pumping", "Pump action", openapi.Enum("start", "restart", "stop")),
+				openapi.FormField("direction", "Pumping direction", direction),
+				openapi.FormField("volume", "Volume to pump, in mL", openapi.Number()),
+				openapi.FormField("flowrate", "Pump flowrate, in mL/min", openapi.Number()),
+			},
+			Responses: formCommandResponses(),
+			Auth:      controlAuth("allow_controller_pump_post"),
+		},
+	)
+	docs.Describe(
+		http.MethodPost, "/instruments/:id/controllers/:controllerID/camera", openapi.Operation{
+			Summary: "Change the camera settings of a Planktoscope",
+			Tags:    []string{tag},
+			Params:  idParams(),
+			Form: []openapi.Param{
+				formField("iso", "Camera sensor ISO", openapi.Int64()),
+				formField("shutter-speed", "Camera shutter speed, in μs", openapi.Int64()),
+				openapi.FormField("awb", "Whether to use auto white balance", openapi.Boolean()),
+				formField("wb-red", "White balance red gain", openapi.Number()),
+				formField("wb-blue", "White balance blue gain", openapi.Number()),
+			},
+			Responses: formCommandResponses(),
+			Auth:      controlAuth("allow_controller_camera_post"),
+		},
+	)
+	docs.Describe(
+		http.MethodPost, "/instruments/:id/controllers/:controllerID/imager", openapi.Operation{
+			Summary: "Start or stop image acquisition by a Planktoscope",
+			Tags:    []string{tag},
+			Params:  idParams(),
+			Form: []openapi.Param{
+				formField("imaging", "Imaging action", openapi.Enum("start", "stop")),
+				openapi.FormField("direction", "Pumping direction between images", direction),
+				openapi.FormField(
+					"step-volume", "Volume to pump between images, in mL", openapi.Number(),
+				),
+				openapi.FormField(
+					"step-delay", "Time to wait for the sample to settle before each image, in sec",
+					openapi.Number(),
+				),
+				openapi.FormField("steps", "Number of images to acquire", openapi.Int64()),
+			},
+			Responses: formCommandResponses(),
+			Auth:      controlAuth("allow_controller_imager_post"),
+		},
+	)
+}
+
+func (h *Handlers) describeAPIInstrumentRoutes(docs *openapi.Registry) {
+	const tag = "instruments"
+	docs.Tag(tag, "Imaging instruments")
+	const path = apiPrefix + "/instruments/:id"
+	docs.Describe(http.MethodGet, apiPrefix+"/instruments", openapi.Operation{
+		Summary:     "List instruments",
+		Description: "Lists the instruments which are listed publicly or visible to the requester.",
+		Tags:        []string{tag},
+		Params:      pageParams(),
+		Responses:   jsonResponse(http.StatusOK, "A page of instruments", APIPage[APIInstrument]{}),
+		Auth:        openapi.Auth{Anonymous: true, Scopes: []string{}},
+	})
+	docs.Describe(http.MethodPost, apiPrefix+"/instruments", openapi.Operation{
+		Summary:     "Create an instrument",
+		Description: "Creates an instrument administered by the requester.",
+		Tags:        []string{tag},
+		Body:        APIInstrumentRequest{},
+		Responses:   jsonResponse(http.StatusCreated, "The new instrument", APIInstrument{}),
+		Auth:        adminAuth("allow_instruments_post"),
+	})
+	docs.Describe(http.MethodGet, path, openapi.Operation{
+		Summary:   "Get an instrument",
+		Tags:      []string{tag},
+		Params:    idParams(),
+		Responses: jsonResponse(http.StatusOK, "The instrument", APIInstrument{}),
+		Auth:      visibleAuth("allow_instrument_get"),
+	})
+	docs.Describe(http.MethodPatch, path, openapi.Operation{
+		Summary:     "Update an instrument",
+		Description: "Fields left out of the request keep their current values.",
+		Tags:        []string{tag},
+		Params:      idParams(),
+		Body:        APIInstrumentRequest{},
+		Responses:   jsonResponse(http.StatusOK, "The updated instrument", APIInstrument{}),
+		Auth:        adminAuth("allow_instrument_post"),
+	})
+	docs.Describe(http.MethodDelete, path, openapi.Operation{
+		Summary:   "Delete an instrument",
+		Tags:      []string{tag},
+		Params:    idParams(),
+		Responses: deletedResponse("Instrument"),
+		Auth:      adminAuth("allow_instrument_post"),
+	})
+}
+
+func (h *Handlers) describeAPICameraRoutes(docs *openapi.Registry) {
+	const tag = "cameras"
+	docs.Tag(tag, "Camera configuration of instruments")
+	const collectionPath = apiPrefix + "/instruments/:id/cameras"
+	const path = collectionPath + "/:cameraID"
+	docs.Describe(http.MethodGet, collectionPath, openapi.Operation{
+		Summary:   "List the cameras of an instrument",
+		Tags:      []string{tag},
+		Params:    pageParams(),
+		Responses: jsonResponse(http.StatusOK, "A page of cameras", APIPage[APICamera]{}),
+		Auth:      configAuth("allow_instrument_config_get"),
+	})
+	docs.Describe(http.MethodPost, collectionPath, openapi.Operation{
+		Summary:   "Add a camera to an instrument",
+		Tags:      []string{tag},
+		Params:    idParams(),
+		Body:      APICameraRequest{},
+		Responses: jsonResponse(http.StatusCreated, "The new camera", APICamera{}),
+		Auth:      adminAuth("allow_instrument_post"),
+	})
+	docs.Describe(http.MethodGet, path, openapi.Operation{
+		Summary:   "Get a camera of an instrument",
+		Tags:      []string{tag},
+		Params:    idParams(),
+		Responses: jsonResponse(http.StatusOK, "The camera", APICamera{}),
+		Auth:      configAuth("allow_camera_config_get"),
+	})
+	docs.Describe(http.MethodPatch, path, openapi.Operation{
+		Summary:     "Update a camera of an instrument",
+		Description: "Fields left out of the request keep their current values.",
+		Tags:        []string{tag},
+		Params:      idParams(),
+		Body:        APICameraRequest{},
+		Responses:   jsonResponse(http.StatusOK, "The updated camera", APICamera{}),
+		Auth:        adminAuth("allow_camera_post"),
+	})
+	docs.Describe(http.MethodDelete, path, openapi.Operation{
+		Summary:   "Delete a camera of an instrument",
+		Tags:      []string{tag},
+		Params:    idParams(),
+		Responses: deletedResponse("Camera"),
+		Auth:      adminAuth("allow_camera_post"),
+	})
+}
+
+func (h *Handlers) describeAPIControllerRoutes(docs *openapi.Registry) {
+	const tag = "controllers"
+	docs.Tag(tag, "Controller configuration of instruments")
+	const collectionPath = apiPrefix + "/instruments/:id/controllers"
+	const path = collectionPath + "/:controllerID"
+	docs.Describe(http.MethodGet, collectionPath, openapi.Operation{
+		Summary:   "List the controllers of an instrument",
+		Tags:      []string{tag},
+		Params:    pageParams(),
+		Responses: jsonResponse(http.StatusOK, "A page of controllers", APIPage[APIController]{}),
+		Auth:      configAuth("allow_instrument_config_get"),
+	})
+	docs.Describe(http.MethodPost, collectionPath, openapi.Operation{
+		Summary:   "Add a controller to an instrument",
+		Tags:      []string{tag},
+		Params:    idParams(),
+		Body:      APIControllerRequest{},
+		Responses: jsonResponse(http.StatusCreated, "The new controller", APIController{}),
+		Auth:      adminAuth("allow_instrument_post"),
+	})
+	docs.Describe(http.MethodGet, path, openapi.Operation{
+		Summary:   "Get a controller of an instrument",
+		Tags:      []string{tag},
+		Params:    idParams(),
+		Responses: jsonResponse(http.StatusOK, "The controller", APIController{}),
+		Auth:      configAuth("allow_controller_config_get"),
+	})
+	docs.Describe(http.MethodPatch, path, openapi.Operation{
+		Summary: "Update a controller of an instrument",
+		Description: "Fields left out of the request keep their current values. MQTT credentials " +
+			"are never returned, and they can only be removed by clearing all of them.",
+		Tags:      []string{tag},
+		Params:    idParams(),
+		Body:      APIControllerRequest{},
+		Responses: jsonResponse(http.StatusOK, "The updated controller", APIController{}),
+		Auth:      adminAuth("allow_controller_post"),
+	})
+	docs.Describe(http.MethodDelete, path, openapi.Operation{
+		Summary:   "Delete a controller of an instrument",
+		Tags:      []string{tag},
+		Params:    idParams(),
+		Responses: deletedResponse("Controller"),
+		Auth:      adminAuth("allow_controller_post"),
+	})
+}
+
+func (h *Handlers) describeAPIPlanktoscopeRoutes(docs *openapi.Registry) {
+	const tag = "planktoscope"
+	docs.Tag(tag, "Live state and operation of Planktoscope controllers")
+	const path = apiPrefix + "/instruments/:id/controllers/:controllerID"
+	const commandDescription = "Settings left out of the request keep their current values."
+	docs.Describe(http.MethodGet, path+"/state", openapi.Operation{
+		Summary:   "Get the live state of a Planktoscope controller",
+		Tags:      []string{tag},
+		Params:    idParams(),
+		Responses: jsonResponse(http.StatusOK, "The state of the controller", APIPlanktoscopeState{}),
+		Auth:      visibleAuth("allow_controller_get"),
+	})
+	docs.Describe(http.MethodPost, path+"/pump", openapi.Operation{
+		Summary:     "Start or stop the pump of a Planktoscope",
+		Description: commandDescription,
+		Tags:        []string{tag},
+		Params:      commandParams(),
+		Body:        APIPumpRequest{},
+		Responses:   commandResponses(),
+		Auth:        controlAuth("allow_controller_pump_post"),
+	})
+	docs.Describe(http.MethodPost, path+"/camera", openapi.Operation{
+		Summary:     "Change the camera settings of a Planktoscope",
+		Description: commandDescription,
+		Tags:        []string{tag},
+		Params:      commandParams(),
+		Body:        APICameraSettingsRequest{},
+		Responses:   commandResponses(),
+		Auth:        controlAuth("allow_controller_camera_post"),
+	})
+	docs.Describe(http.MethodPost, path+"/imager", openapi.Operation{
+		Summary:     "Start or stop image acquisition by a Planktoscope",
+		Description: commandDescription,
+		Tags:        []string{tag},
+		Params:      commandParams(),
+		Body:        APIImagerRequest{},
+		Responses:   commandResponses(),
+		Auth:        controlAuth("allow_controller_imager_post"),
+	})
+}
+
+func (h *Handlers) describeAPIAutomationJobRoutes(docs *openapi.Registry) {
+	const tag = "automation-jobs"
+	docs.Tag(tag, "Automation jobs of instruments")
+	const collectionPath = apiPrefix + "/instruments/:id/automation-jobs"
+	const path = collectionPath + "/:automationJobID"
+	docs.Describe(http.MethodGet, collectionPath, openapi.Operation{
+		Summary: "List the automation jobs of an instrument",
+		Tags:    []string{tag},
+		Params:  pageParams(),
+		Responses: jsonResponse(
+			http.StatusOK, "A page of automation jobs", APIPage[APIAutomationJob]{},
+		),
+		Auth: configAuth("allow_instrument_config_get"),
+	})
+	docs.Describe(http.MethodPost, collectionPath, openapi.Operation{
+		Summary: "Add an automation job to an instrument",
+		Description: "The job's specification is checked before it's saved, and its type defaults " +
+			"to " + automationJobType + ".",
+		Tags:      []string{tag},
+		Params:    idParams(),
+		Body:      APIAutomationJobRequest{},
+		Responses: jsonResponse(http.StatusCreated, "The new automation job", APIAutomationJob{}),
+		Auth:      jobsAuth("allow_automation_jobs_post"),
+	})
+	docs.Describe(http.MethodGet, path, openapi.Operation{
+		Summary:   "Get an automation job of an instrument",
+		Tags:      []string{tag},
+		Params:    idParams(),
+		Responses: jsonResponse(http.StatusOK, "The automation job", APIAutomationJob{}),
+		Auth:      configAuth("allow_automation_job_config_get"),
+	})
+	docs.Describe(http.MethodPatch, path, openapi.Operation{
+		Summary:     "Update an automation job of an instrument",
+		Description: "Fields left out of the request keep their current values.",
+		Tags:        []string{tag},
+		Params:      idParams(),
+		Body:        APIAutomationJobRequest{},
+		Responses: jsonResponse(
+			http.StatusOK, "The updated automation job", APIAutomationJob{},
+		),
+		Auth: jobsAuth("allow_automation_job_post"),
+	})
+	docs.Describe(http.MethodDelete, path, openapi.Operation{
+		Summary:   "Delete an automation job of an instrument",
+		Tags:      []string{tag},
+		Params:    idParams(),
+		Responses: deletedResponse("Automation job"),
+		Auth:      jobsAuth("allow_automation_job_post"),
+	})
+}
@@ -0,0 +1,246 @@
+package instruments
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/turbostreams"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/app/pslive/handling"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+	"github.com/sargassum-world/pslive/internal/clients/ory"
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
+)
+
+const emergencyStopPartial = "instruments/emergency-stop.partial.tmpl"
+
+// emergencyStopTimeout is how long to wait for each controller to accept a stop command, so that an
+// unreachable controller can't prevent the emergency stop from reaching the other controllers.
+const emergencyStopTimeout = 5 * time.Second
+
+type EmergencyStopViewData struct {
+	Stopped           bool
+	Stop              instruments.EmergencyStop
+	StopperIdentifier ory.IdentityIdentifier
+}
+
+func getEmergencyStopViewData(
+	ctx context.Context, iid instruments.InstrumentID, is *instruments.Store, oc *ory.Client,
+) (vd EmergencyStopViewData, err error) {
+	if vd.Stop, vd.Stopped, err = is.GetEmergencyStop(ctx, iid); err != nil {
+		return EmergencyStopViewData{}, err
+	}
+	if !vd.Stopped {
+		return vd, nil
+	}
+	if vd.StopperIdentifier, err = oc.GetIdentifier(
+		ctx, ory.IdentityID(vd.Stop.IdentityID),
+	); err != nil {
+		return EmergencyStopViewData{}, errors.Wrapf(
+			err, "couldn't look up identifier of emergency stopper for instrument %d", iid,
+		)
+	}
+	return vd, nil
+}
+
+type EmergencyStopViewAuthz struct {
+	Stop  bool
+	Clear bool
+}
+
+func getEmergencyStopViewAuthz(
+	ctx context.Context, iid instruments.InstrumentID, a auth.Auth, azc *auth.AuthzChecker,
+) (authz EmergencyStopViewAuthz, err error) {
+	path := fmt.Sprintf("/instruments/%d/emergency-stop", iid)
+	if authz.Stop, err = azc.Allow(ctx, a, path, http.MethodPost, nil); err != nil {
+		return EmergencyStopViewAuthz{}, errors.Wrap(err, "couldn't check authz for emergency stop")
+	}
+	path = fmt.Sprintf("/instruments/%d/emergency-stop/clear", iid)
+	if authz.Clear, err = azc.Allow(ctx, a, path, http.MethodPost, nil); err != nil {
+		return EmergencyStopViewAuthz{}, errors.Wrap(
+			err, "couldn't check authz for clearing emergency stop",
+		)
+	}
+	return authz, nil
+}
+
+func replaceEmergencyStopStream(
+	iid instruments.InstrumentID, vd EmergencyStopViewData, a auth.Auth,
+) turbostreams.Message {
+	return turbostreams.Message{
+		Action:   turbostreams.ActionReplace,
+		Target:   fmt.Sprintf("/instruments/%d/emergency-stop", iid),
+		Template: emergencyStopPartial,
+		Data: map[string]interface{}{
+			"InstrumentID":  iid,
+			"EmergencyStop": vd,
+			"Auth":          a,
+		},
+	}
+}
+
+func (h *Handlers) ModifyEmergencyStopMsgData() handling.DataModifier {
+	return func(
+		ctx context.Context, a auth.Auth, data map[string]interface{},
+	) (modifications map[string]interface{}, err error) {
+		rawIID, ok := data["InstrumentID"]
+		if !ok {
+			return nil, errors.New(
+				"couldn't find instrument id from turbostreams message data to check authorizations",
+			)
+		}
+		iid, ok := rawIID.(instruments.InstrumentID)
+		if !ok {
+			return nil, errors.Errorf(
+				"instrument id has unexpected type %T in turbostreams message data for checking authorization",
+				rawIID,
+			)
+		}
+		modifications = make(map[string]interface{})
+		if modifications["Authorizations"], err = getEmergencyStopViewAuthz(
+			ctx, iid, a, h.azc,
+		); err != nil {
+			return nil, errors.Wrapf(err, "couldn't check authz for emergency stop of instrument %d", iid)
+		}
+		return modifications, nil
+	}
+}
+
+func waitForStopCommand(token mqtt.Token, err error) error {
+	if err != nil {
+		return err
+	}
+	if !token.WaitTimeout(emergencyStopTimeout) {
+		return errors.New("timed out waiting for command to be sent")
+	}
+	return token.Error()
+}
+
+// stopControllers sends stop commands for every activity of every enabled controller of the
+// instrument, returning the errors for controllers which couldn't be stopped instead of giving up
+// early. Controllers with custom protocols have no standard stop commands, so they're skipped.
+func stopControllers(
+	ctx context.Context, iid instruments.InstrumentID,
+	is *instruments.Store, pco *planktoscope.Orchestrator,
+) (errs []error) {
+	instrument, err := is.GetInstrument(ctx, iid)
+	if err != nil {
+		return []error{errors.Wrapf(err, "couldn't look up controllers of instrument %d", iid)}
+	}
+	for _, controller := range instrument.Controllers {
+		if !controller.Enabled || controller.Protocol != planktoscope.Protocol {
+			continue
+		}
+		pc, ok := pco.Get(planktoscope.ClientID(controller.ID))
+		if !ok {
+			errs = append(errs, errors.Errorf(
+				"planktoscope client for controller %d on instrument %d not found for emergency stop",
+				controller.ID, iid,
+			))
+			continue
+		}
+		if err := waitForStopCommand(pc.StopPump()); err != nil {
+			errs = append(errs, errors.Wrapf(
+				err, "couldn't stop pump of controller %d on instrument %d", controller.ID, iid,
+			))
+		}
+		if err := waitForStopCommand(pc.StopImaging()); err != nil {
+			errs = append(errs, errors.Wrapf(
+				err, "couldn't stop imaging of controller %d on instrument %d", controller.ID, iid,
+			))
+		}
+	}
+	return errs
+}
+
+func (h *Handlers) broadcastEmergencyStop(ctx context.Context, iid instruments.InstrumentID) error {
+	vd, err := getEmergencyStopViewData(ctx, iid, h.is, h.oc)
+	if err != nil {
+		return err
+	}
+	// We insert an empty Auth object because the MSG handler will add the auth object for each
+	// client
+	h.tsh.Broadcast(
+		fmt.Sprintf("/instruments/%d/emergency-stop", iid),
+		[]turbostreams.Message{replaceEmergencyStopStream(iid, vd, auth.Auth{})},
+	)
+	return nil
+}
+
+func (h *Handlers) HandleEmergencyStopPost() auth.HTTPHandlerFunc {
+	t := emergencyStopPartial
+	h.r.MustHave(t)
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		ctx := c.Request().Context()
+		if _, err = h.is.GetInstrument(ctx, iid); err != nil {
+			return echo.NewHTTPError(
+				http.StatusNotFound, fmt.Sprintf("instrument %d not found", iid),
+			)
+		}
+		// Automation must be halted before the controllers are stopped, so that a running job can't
+		// restart a controller after it was stopped. A run which doesn't return in time shouldn't
+		// prevent the controllers from being stopped.
+		if err = h.ijo.Halt(ctx, iid); err != nil {
+			c.Logger().Error(errors.Wrap(err, "couldn't wait for automation to halt"))
+		}
+		stop := instruments.EmergencyStop{
+			InstrumentID: iid,
+			StopTime:     time.Now(),
+			IdentityID:   string(a.Identity.User),
+		}
+		if err = h.is.SetEmergencyStop(ctx, stop); err != nil {
+			return err
+		}
+		c.Logger().Warnf("instrument %d was emergency-stopped by %s", iid, stop.IdentityID)
+		for _, err := range stopControllers(ctx, iid, h.is, h.pco) {
+			c.Logger().Error(errors.Wrap(err, "emergency stop was incomplete"))
+		}
+		if err = h.broadcastEmergencyStop(ctx, iid); err != nil {
+			return err
+		}
+
+		// Redirect user
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/instruments/%d", iid))
+	}
+}
+
+func (h *Handlers) HandleEmergencyStopClearPost() auth.HTTPHandlerFunc {
+	t := emergencyStopPartial
+	h.r.MustHave(t)
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		ctx := c.Request().Context()
+		if err = h.is.ClearEmergencyStop(ctx, iid); err != nil {
+			return err
+		}
+		h.ijo.Resume(iid)
+		c.Logger().Warnf(
+			"emergency stop of instrument %d was cleared by %s", iid, a.Identity.User,
+		)
+		if err = h.broadcastEmergencyStop(ctx, iid); err != nil {
+			return err
+		}
+
+		// Redirect user
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/instruments/%d", iid))
+	}
+}
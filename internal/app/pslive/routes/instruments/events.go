@@ -0,0 +1,240 @@
+package instruments
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/turbostreams"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/handling"
+	"github.com/sargassum-world/pslive/internal/clients/chat"
+	"github.com/sargassum-world/pslive/internal/clients/events"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
+	"github.com/sargassum-world/pslive/internal/clients/presence"
+)
+
+// Event types
+
+const (
+	pumpEventType     = "controller.pump"
+	cameraEventType   = "controller.camera"
+	imagerEventType   = "controller.imager"
+	chatEventType     = "chat.message"
+	presenceEventType = "presence.count"
+	// resetEventType is sent before the replayed events when some of the events after the
+	// Last-Event-ID sent by the client are no longer available, so that the client knows to reload
+	// any state it has accumulated from events.
+	resetEventType = "reset"
+)
+
+type APIChatMessageEvent struct {
+	ID       chat.MessageID `json:"id"`
+	SendTime time.Time      `json:"sendTime"`
+	Sender   string         `json:"sender"`
+	Body     string         `json:"body"`
+}
+
+type APIPresenceEvent struct {
+	Viewers int `json:"viewers"`
+}
+
+// Producers
+
+func (h *Handlers) publishEvent(
+	iid instruments.InstrumentID, eventType string, data interface{},
+) error {
+	_, err := h.eb.Publish(instruments.EventsTopic(iid), eventType, data)
+	return errors.Wrapf(err, "couldn't publish event for instrument %d", iid)
+}
+
+// publishChatMessageEvent publishes the chat message as an event, and it delivers the message to
+// the instrument's webhooks.
+func (h *Handlers) publishChatMessageEvent(c echo.Context, m handling.ChatMessageViewData) error {
+	iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+	if err != nil {
+		return err
+	}
+	e := APIChatMessageEvent{
+		ID:       m.ID,
+		SendTime: m.SendTime,
+		Sender:   string(m.SenderIdentifier),
+		Body:     m.Body,
+	}
+	if err = h.wd.Enqueue(
+		c.Request().Context(), iid, instruments.WebhookEventChatMessage, e,
+	); err != nil {
+		return err
+	}
+	return h.publishEvent(iid, chatEventType, e)
+}
+
+func (h *Handlers) publishPresenceEvent(c *turbostreams.Context, topic presence.Topic) error {
+	iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+	if err != nil {
+		return err
+	}
+	return h.publishEvent(iid, presenceEventType, APIPresenceEvent{Viewers: h.ps.Count(topic)})
+}
+
+func (h *Handlers) publishPlanktoscopeEvents(
+	ctx context.Context, iid instruments.InstrumentID, cid instruments.ControllerID,
+	pc *planktoscope.Client,
+) error {
+	for {
+		var eventType string
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-pc.PumpStateBroadcasted():
+			eventType = pumpEventType
+		case <-pc.CameraStateBroadcasted():
+			eventType = cameraEventType
+		case <-pc.ImagerStateBroadcasted():
+			eventType = imagerEventType
+		}
+		if err := ctx.Err(); err != nil {
+			// Context was also canceled and it should have priority
+			return err
+		}
+		if err := h.publishEvent(iid, eventType, newAPIPlanktoscopeState(cid, pc)); err != nil {
+			return err
+		}
+	}
+}
+
+// produceInstrumentEvents publishes the state updates of the instrument's planktoscope controllers.
+// Events about chat messages, presence, and automation jobs are published by their own handlers.
+func (h *Handlers) produceInstrumentEvents(iid instruments.InstrumentID) events.Producer {
+	// Controllers may be added, removed, or reconnected while the producer runs, so we periodically
+	// look them up again
+	const refreshInterval = time.Minute
+	return func(ctx context.Context, _ events.Topic) error {
+		for {
+			instrument, err := h.is.GetInstrument(ctx, iid)
+			if err != nil {
+				return errors.Wrapf(err, "couldn't look up controllers of instrument %d", iid)
+			}
+			refreshCtx, cancel := context.WithTimeout(ctx, refreshInterval)
+			eg, egctx := errgroup.WithContext(refreshCtx)
+			for _, controller := range instrument.Controllers {
+				if !controller.Enabled || controller.Protocol != planktoscope.Protocol {
+					continue
+				}
+				pc, ok := h.pco.Get(planktoscope.ClientID(controller.ID))
+				if !ok {
+					continue
+				}
+				cid := controller.ID
+				eg.Go(func() error {
+					return h.publishPlanktoscopeEvents(egctx, iid, cid, pc)
+				})
+			}
+			<-refreshCtx.Done()
+			err = eg.Wait()
+			cancel()
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return ctxErr
+			}
+			if err != nil && err != context.DeadlineExceeded {
+				return err
+			}
+		}
+	}
+}
+
+// Handlers
+
+func parseLastEventID(c echo.Context) (after *events.EventID, err error) {
+	raw := c.Request().Header.Get("Last-Event-ID")
+	if raw == "" {
+		return nil, nil
+	}
+	const intBase = 10
+	const intWidth = 64
+	id, err := strconv.ParseUint(raw, intBase, intWidth)
+	if err != nil {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid event id %s", raw))
+	}
+	parsed := events.EventID(id)
+	return &parsed, nil
+}
+
+func writeEvent(c echo.Context, e events.Event) error {
+	if _, err := fmt.Fprintf(
+		c.Response(), "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, e.Data,
+	); err != nil {
+		return errors.Wrapf(err, "couldn't write event %d", e.ID)
+	}
+	return nil
+}
+
+func (h *Handlers) HandleInstrumentEventsGet() echo.HandlerFunc {
+	const keepaliveInterval = 30 * time.Second
+	return func(c echo.Context) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		after, err := parseLastEventID(c)
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		ctx := c.Request().Context()
+		if _, err = h.is.GetInstrument(ctx, iid); err != nil {
+			return err
+		}
+		replay, complete, received := h.eb.Subscribe(
+			ctx, instruments.EventsTopic(iid), after, h.produceInstrumentEvents(iid),
+		)
+
+		// Produce output
+		c.Response().Header().Set(echo.HeaderContentType, "text/event-stream")
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-cache")
+		// Prevent reverse proxies from buffering the stream
+		c.Response().Header().Set("X-Accel-Buffering", "no")
+		c.Response().WriteHeader(http.StatusOK)
+		if !complete {
+			if _, err := fmt.Fprintf(c.Response(), "event: %s\ndata: {}\n\n", resetEventType); err != nil {
+				return errors.Wrap(err, "couldn't write reset event")
+			}
+		}
+		for _, e := range replay {
+			if err := writeEvent(c, e); err != nil {
+				return err
+			}
+		}
+		c.Response().Flush()
+		keepalive := time.NewTicker(keepaliveInterval)
+		defer keepalive.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return nil
+			case e, ok := <-received:
+				if !ok {
+					// The subscription was dropped because we fell behind or the producer failed; the
+					// client will reconnect with the ID of the last event it received
+					return nil
+				}
+				if err := writeEvent(c, e); err != nil {
+					return err
+				}
+			case <-keepalive.C:
+				if _, err := fmt.Fprint(c.Response(), ": keepalive\n\n"); err != nil {
+					return errors.Wrap(err, "couldn't write keepalive comment")
+				}
+			}
+			c.Response().Flush()
+		}
+	}
+}
@@ -15,6 +15,8 @@ import (
 	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
 	"github.com/sargassum-world/pslive/internal/app/pslive/handling"
 	"github.com/sargassum-world/pslive/internal/clients/chat"
+	"github.com/sargassum-world/pslive/internal/clients/genericmqtt"
+	"github.com/sargassum-world/pslive/internal/clients/httpjson"
 	"github.com/sargassum-world/pslive/internal/clients/instruments"
 	"github.com/sargassum-world/pslive/internal/clients/ory"
 	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
@@ -37,11 +39,11 @@ func parseID[ID ~int64](raw string, typeName string) (ID, error) {
 
 // Components (common across cameras & controllers)
 
-func handleInstrumentComponentsPost(
+func handleInstrumentComponentsPost[ComponentID ~int64](
 	storeAdder func(
 		ctx context.Context, iid instruments.InstrumentID,
 		enabled bool, name, description string, params url.Values,
-	) error,
+	) (ComponentID, error),
 ) auth.HTTPHandlerFunc {
 	return func(c echo.Context, a auth.Auth) error {
 		// Parse params
@@ -58,7 +60,7 @@ func handleInstrumentComponentsPost(
 		}
 
 		// Run queries
-		if err := storeAdder(
+		if _, err := storeAdder(
 			c.Request().Context(), iid, enabled, name, description, params,
 		); err != nil {
 			return err
@@ -127,19 +129,32 @@ func handleInstrumentComponentPost[ComponentID ~int64](
 // Instrument
 
 type InstrumentViewData struct {
-	Instrument       instruments.Instrument
-	ControllerIDs    []instruments.ControllerID
-	Controllers      map[instruments.ControllerID]planktoscope.Planktoscope
-	AdminIdentifier  ory.IdentityIdentifier
-	KnownViewers     []presence.User
-	AnonymousViewers []presence.SessionID
-	ChatMessages     []handling.ChatMessageViewData
+	Instrument         instruments.Instrument
+	ControllerIDs      []instruments.ControllerID
+	Controllers        map[instruments.ControllerID]planktoscope.Planktoscope
+	GenericMQTT        map[instruments.ControllerID]genericmqtt.Controller
+	HTTPJSON           map[instruments.ControllerID]httpjson.Controller
+	ControllerAuths    map[instruments.ControllerID]instruments.MQTTAuthStatus
+	ControllerSchemas  map[instruments.ControllerID]string
+	ControllerLimits   map[instruments.ControllerID]instruments.ControllerLimits
+	ControllerSettings map[instruments.ControllerID]instruments.ControllerSettings
+	Controls           map[instruments.ControllerID]ControlViewData
+	EmergencyStop      EmergencyStopViewData
+	ControlLease       ControlLeaseViewData
+	Sample             instruments.Sample
+	Acquisitions       []instruments.Acquisition
+	AdminIdentifier    ory.IdentityIdentifier
+	Members            []InstrumentMemberViewData
+	KnownViewers       []presence.User
+	AnonymousViewers   []presence.SessionID
+	ChatMessages       []handling.ChatMessageViewData
 }
 
 func getInstrumentViewData(
 	ctx context.Context, iid instruments.InstrumentID,
 	oc *ory.Client, is *instruments.Store, pco *planktoscope.Orchestrator,
-	ps *presence.Store, cs *chat.Store,
+	gmo *genericmqtt.Orchestrator, hjo *httpjson.Orchestrator, ca *instruments.ControlArbiter,
+	cl *instruments.ControlLeaser, ps *presence.Store, cs *chat.Store,
 ) (vd InstrumentViewData, err error) {
 	if vd.Instrument, err = is.GetInstrument(ctx, iid); err != nil {
 		// TODO: is this the best way to handle errors from is.GetInstrumentByID?
@@ -150,22 +165,101 @@ func getInstrumentViewData(
 
 	vd.ControllerIDs = make([]instruments.ControllerID, 0, len(vd.Instrument.Controllers))
 	vd.Controllers = make(map[instruments.ControllerID]planktoscope.Planktoscope)
+	vd.GenericMQTT = make(map[instruments.ControllerID]genericmqtt.Controller)
+	vd.HTTPJSON = make(map[instruments.ControllerID]httpjson.Controller)
 	for _, controller := range vd.Instrument.Controllers {
 		if !controller.Enabled {
 			continue
 		}
-		pc, ok := pco.Get(planktoscope.ClientID(controller.ID))
-		if !ok {
-			return InstrumentViewData{}, errors.Errorf(
-				"planktoscope client for instrument %d not found", iid,
-			)
-		}
-		if pc.HasConnection() {
-			// TODO: display some indication to the user when a controller is unreachable, and push
-			// updates over Turbo Streams when a controller's reachability changes
-			vd.ControllerIDs = append(vd.ControllerIDs, controller.ID)
+		// TODO: display some indication to the user when a controller is unreachable, and push
+		// updates over Turbo Streams when a controller's reachability changes
+		switch controller.Protocol {
+		default:
+			continue
+		case planktoscope.Protocol:
+			pc, ok := pco.Get(planktoscope.ClientID(controller.ID))
+			if !ok {
+				return InstrumentViewData{}, errors.Errorf(
+					"planktoscope client for instrument %d not found", iid,
+				)
+			}
+			if !pc.HasConnection() {
+				continue
+			}
 			vd.Controllers[controller.ID] = pc.GetState()
+		case genericmqtt.Protocol:
+			gc, ok := gmo.Get(genericmqtt.ClientID(controller.ID))
+			if !ok {
+				// The client isn't running if the controller's schema was invalid
+				continue
+			}
+			if !gc.HasConnection() {
+				continue
+			}
+			vd.GenericMQTT[controller.ID] = gc.GetState()
+		case httpjson.Protocol:
+			hc, ok := hjo.Get(httpjson.ClientID(controller.ID))
+			if !ok {
+				// The client isn't running if the controller's schema was invalid
+				continue
+			}
+			if !hc.HasConnection() {
+				continue
+			}
+			vd.HTTPJSON[controller.ID] = hc.GetState()
 		}
+		vd.ControllerIDs = append(vd.ControllerIDs, controller.ID)
+	}
+
+	if vd.ControllerAuths, err = is.GetInstrumentControllerMQTTAuthStatuses(ctx, iid); err != nil {
+		return InstrumentViewData{}, errors.Wrapf(
+			err, "couldn't look up controller mqtt auths for instrument %d", iid,
+		)
+	}
+
+	if vd.ControllerSchemas, err = is.GetInstrumentControllerSchemas(ctx, iid); err != nil {
+		return InstrumentViewData{}, errors.Wrapf(
+			err, "couldn't look up controller schemas for instrument %d", iid,
+		)
+	}
+
+	if vd.ControllerLimits, err = is.GetInstrumentControllerLimits(ctx, iid); err != nil {
+		return InstrumentViewData{}, errors.Wrapf(
+			err, "couldn't look up controller limits for instrument %d", iid,
+		)
+	}
+
+	if vd.ControllerSettings, err = is.GetInstrumentControllerSettings(ctx, iid); err != nil {
+		return InstrumentViewData{}, errors.Wrapf(
+			err, "couldn't look up controller settings for instrument %d", iid,
+		)
+	}
+
+	if vd.Controls, err = getControlsViewData(ctx, vd.ControllerIDs, ca, oc); err != nil {
+		return InstrumentViewData{}, errors.Wrapf(
+			err, "couldn't look up control of controllers for instrument %d", iid,
+		)
+	}
+
+	if vd.EmergencyStop, err = getEmergencyStopViewData(ctx, iid, is, oc); err != nil {
+		return InstrumentViewData{}, errors.Wrapf(
+			err, "couldn't look up emergency stop for instrument %d", iid,
+		)
+	}
+
+	if vd.ControlLease, err = getControlLeaseViewData(ctx, iid, cl, oc, ps); err != nil {
+		return InstrumentViewData{}, err
+	}
+
+	if vd.Sample, err = is.GetSample(ctx, iid); err != nil {
+		return InstrumentViewData{}, errors.Wrapf(err, "couldn't look up sample for instrument %d", iid)
+	}
+	if vd.Acquisitions, err = is.GetAcquisitionsByInstrument(
+		ctx, iid, instruments.DefaultAcquisitionsLimit,
+	); err != nil {
+		return InstrumentViewData{}, errors.Wrapf(
+			err, "couldn't look up acquisitions for instrument %d", iid,
+		)
 	}
 
 	if vd.AdminIdentifier, err = oc.GetIdentifier(
@@ -176,6 +270,10 @@ func getInstrumentViewData(
 		)
 	}
 
+	if vd.Members, err = getInstrumentMembersViewData(ctx, iid, is, oc); err != nil {
+		return InstrumentViewData{}, err
+	}
+
 	// Chat
 	vd.KnownViewers, vd.AnonymousViewers = ps.List(presence.Topic(
 		fmt.Sprintf("/instruments/%d/users", iid)))
@@ -199,21 +297,39 @@ func getInstrumentViewData(
 }
 
 type InstrumentViewAuthz struct {
-	SendChat    bool
-	Controllers map[instruments.ControllerID]interface{}
+	Administer    bool
+	SetSample     bool
+	SendChat      bool
+	EmergencyStop EmergencyStopViewAuthz
+	ControlLease  ControlLeaseViewAuthz
+	Controllers   map[instruments.ControllerID]interface{}
+}
+
+func getControllerViewAuthz(
+	ctx context.Context, iid instruments.InstrumentID, cid instruments.ControllerID,
+	vd InstrumentViewData, a auth.Auth, azc *auth.AuthzChecker,
+) (authz interface{}, err error) {
+	if controller, ok := vd.GenericMQTT[cid]; ok {
+		return getCustomControllerViewAuthz(ctx, iid, cid, controller.Schema.CommandNames(), a, azc)
+	}
+	if controller, ok := vd.HTTPJSON[cid]; ok {
+		return getCustomControllerViewAuthz(ctx, iid, cid, controller.Schema.CommandNames(), a, azc)
+	}
+	return getPlanktoscopeControllerViewAuthz(ctx, iid, cid, a, azc)
 }
 
 func getInstrumentViewAuthz(
-	ctx context.Context, iid instruments.InstrumentID, controllerIDs []instruments.ControllerID,
+	ctx context.Context, iid instruments.InstrumentID, vd InstrumentViewData,
 	a auth.Auth, azc *auth.AuthzChecker,
 ) (authz InstrumentViewAuthz, err error) {
+	controllerIDs := vd.ControllerIDs
 	eg, egctx := errgroup.WithContext(ctx)
 	controllerAuthorizations := make([]interface{}, len(controllerIDs))
 	for i, controllerID := range controllerIDs {
 		eg.Go(func(i int, cid instruments.ControllerID) func() error {
 			return func() (err error) {
-				if controllerAuthorizations[i], err = getPlanktoscopeControllerViewAuthz(
-					egctx, iid, cid, a, azc,
+				if controllerAuthorizations[i], err = getControllerViewAuthz(
+					egctx, iid, cid, vd, a, azc,
 				); err != nil {
 					return errors.Wrapf(
 						err, "couldn't check authz for controller %d for instrument %d", cid, iid,
@@ -223,6 +339,32 @@ func getInstrumentViewAuthz(
 			}
 		}(i, controllerID))
 	}
+	eg.Go(func() (err error) {
+		path := fmt.Sprintf("/instruments/%d", iid)
+		if authz.Administer, err = azc.Allow(egctx, a, path, http.MethodPost, nil); err != nil {
+			return errors.Wrapf(err, "couldn't check authz for administering instrument %d", iid)
+		}
+		return nil
+	})
+	eg.Go(func() (err error) {
+		path := fmt.Sprintf("/instruments/%d/sample", iid)
+		if authz.SetSample, err = azc.Allow(egctx, a, path, http.MethodPost, nil); err != nil {
+			return errors.Wrapf(err, "couldn't check authz for setting sample for instrument %d", iid)
+		}
+		return nil
+	})
+	eg.Go(func() (err error) {
+		if authz.EmergencyStop, err = getEmergencyStopViewAuthz(egctx, iid, a, azc); err != nil {
+			return errors.Wrapf(err, "couldn't check authz for emergency stop for instrument %d", iid)
+		}
+		return nil
+	})
+	eg.Go(func() (err error) {
+		if authz.ControlLease, err = getControlLeaseViewAuthz(egctx, iid, a, azc); err != nil {
+			return errors.Wrapf(err, "couldn't check authz for control lease for instrument %d", iid)
+		}
+		return nil
+	})
 	eg.Go(func() (err error) {
 		path := fmt.Sprintf("/instruments/%d/chat/messages", iid)
 		if authz.SendChat, err = azc.Allow(egctx, a, path, http.MethodPost, nil); err != nil {
@@ -254,12 +396,14 @@ func (h *Handlers) HandleInstrumentGet() auth.HTTPHandlerFunc {
 
 		// Run queries
 		ctx := c.Request().Context()
-		instrumentViewData, err := getInstrumentViewData(ctx, iid, h.oc, h.is, h.pco, h.ps, h.cs)
+		instrumentViewData, err := getInstrumentViewData(
+			ctx, iid, h.oc, h.is, h.pco, h.gmo, h.hjo, h.ca, h.cl, h.ps, h.cs,
+		)
 		if err != nil {
 			return err
 		}
 		if a.Authorizations, err = getInstrumentViewAuthz(
-			ctx, iid, instrumentViewData.ControllerIDs, a, h.azc,
+			ctx, iid, instrumentViewData, a, h.azc,
 		); err != nil {
 			return err
 		}
@@ -340,3 +484,30 @@ func (h *Handlers) HandleInstrumentDescriptionPost() auth.HTTPHandlerFunc {
 		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/instruments/%d", iid))
 	}
 }
+
+func (h *Handlers) HandleInstrumentVisibilityPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		visibility, err := instruments.ParseVisibility(c.FormValue("visibility"))
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+
+		// Run queries
+		if err := h.is.UpdateInstrumentVisibility(
+			c.Request().Context(), iid, visibility,
+		); err != nil {
+			return err
+		}
+		c.Logger().Infof(
+			"instrument %d was made %s by %s", iid, visibility, a.Identity.User,
+		)
+
+		// Redirect user
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/instruments/%d", iid))
+	}
+}
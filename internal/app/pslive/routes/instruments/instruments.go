@@ -10,6 +10,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/app/pslive/handling"
 	"github.com/sargassum-world/pslive/internal/clients/instruments"
 	"github.com/sargassum-world/pslive/internal/clients/ory"
 )
@@ -20,11 +21,15 @@ type InstrumentsViewData struct {
 }
 
 func getInstrumentsViewData(
-	ctx context.Context, oc *ory.Client, is *instruments.Store,
+	ctx context.Context, a auth.Auth, azc *auth.AuthzChecker, oc *ory.Client, is *instruments.Store,
 ) (vd InstrumentsViewData, err error) {
-	if vd.Instruments, err = is.GetInstruments(ctx); err != nil {
+	in, err := is.GetInstruments(ctx)
+	if err != nil {
 		return InstrumentsViewData{}, err
 	}
+	if vd.Instruments, err = handling.FilterListedInstruments(ctx, in, a, azc); err != nil {
+		return InstrumentsViewData{}, errors.Wrap(err, "couldn't filter instruments")
+	}
 
 	vd.AdminIdentifiers = make(map[instruments.AdminID]ory.IdentityIdentifier)
 	for _, instrument := range vd.Instruments {
@@ -59,7 +64,7 @@ func (h *Handlers) HandleInstrumentsGet() auth.HTTPHandlerFunc {
 	return func(c echo.Context, a auth.Auth) error {
 		// Run queries
 		ctx := c.Request().Context()
-		instrumentsViewData, err := getInstrumentsViewData(ctx, h.oc, h.is)
+		instrumentsViewData, err := getInstrumentsViewData(ctx, a, h.azc, h.oc, h.is)
 		if err != nil {
 			return err
 		}
@@ -84,6 +89,7 @@ func (h *Handlers) HandleInstrumentsPost() auth.HTTPHandlerFunc {
 		if err != nil {
 			return err
 		}
+		c.Set(auditedInstrumentKey, id)
 
 		// Redirect user
 		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/instruments/%d", int64(id)))
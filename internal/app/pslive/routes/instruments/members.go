@@ -0,0 +1,169 @@
+package instruments
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+	"github.com/sargassum-world/pslive/internal/clients/ory"
+)
+
+type InstrumentMemberViewData struct {
+	Member     instruments.InstrumentMember
+	Identifier ory.IdentityIdentifier
+}
+
+func getInstrumentMembersViewData(
+	ctx context.Context, iid instruments.InstrumentID, is *instruments.Store, oc *ory.Client,
+) (vd []InstrumentMemberViewData, err error) {
+	members, err := is.GetInstrumentMembers(ctx, iid)
+	if err != nil {
+		return nil, err
+	}
+	vd = make([]InstrumentMemberViewData, len(members))
+	for i, member := range members {
+		vd[i].Member = member
+		if vd[i].Identifier, err = oc.GetIdentifier(
+			ctx, ory.IdentityID(member.IdentityID),
+		); err != nil {
+			return nil, errors.Wrapf(
+				err, "couldn't look up identifier of member %s of instrument %d", member.IdentityID, iid,
+			)
+		}
+	}
+	return vd, nil
+}
+
+func parseMemberRole(raw string) (instruments.MemberRole, error) {
+	role, err := instruments.ParseMemberRole(raw)
+	if err != nil {
+		return "", echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	return role, nil
+}
+
+func (h *Handlers) HandleInstrumentMembersPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		identifier := ory.IdentityIdentifier(c.FormValue("identifier"))
+		role, err := parseMemberRole(c.FormValue("role"))
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		ctx := c.Request().Context()
+		identity, found, err := h.oc.FindIdentity(ctx, identifier)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+				"user %s not found", identifier,
+			))
+		}
+		instrument, err := h.is.GetInstrument(ctx, iid)
+		if err != nil {
+			return err
+		}
+		if string(identity.ID) == string(instrument.AdminID) {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+				"user %s is already the administrator of instrument %d", identifier, iid,
+			))
+		}
+		if err = h.is.SetInstrumentMember(ctx, instruments.InstrumentMember{
+			InstrumentID: iid,
+			IdentityID:   string(identity.ID),
+			Role:         role,
+		}); err != nil {
+			return err
+		}
+		c.Logger().Infof(
+			"%s was made %s of instrument %d by %s", identity.ID, role, iid, a.Identity.User,
+		)
+
+		// Redirect user
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/instruments/%d", iid))
+	}
+}
+
+func (h *Handlers) isInstrumentMember(
+	ctx context.Context, iid instruments.InstrumentID, identityID string,
+) (bool, error) {
+	members, err := h.is.GetInstrumentMembers(ctx, iid)
+	if err != nil {
+		return false, err
+	}
+	for _, member := range members {
+		if member.IdentityID == identityID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (h *Handlers) HandleInstrumentMemberPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		identityID := c.Param("identityID")
+		state := c.FormValue("state")
+
+		// Run queries
+		ctx := c.Request().Context()
+		switch state {
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+				"invalid member state %s", state,
+			))
+		case "updated":
+			role, err := parseMemberRole(c.FormValue("role"))
+			if err != nil {
+				return err
+			}
+			// Only existing members can be updated; new members must be added by their identifier, so
+			// that they're looked up in Ory
+			isMember, err := h.isInstrumentMember(ctx, iid, identityID)
+			if err != nil {
+				return err
+			}
+			if !isMember {
+				return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf(
+					"user %s is not a member of instrument %d", identityID, iid,
+				))
+			}
+			if err = h.is.SetInstrumentMember(ctx, instruments.InstrumentMember{
+				InstrumentID: iid,
+				IdentityID:   identityID,
+				Role:         role,
+			}); err != nil {
+				return err
+			}
+			c.Logger().Infof(
+				"%s was made %s of instrument %d by %s", identityID, role, iid, a.Identity.User,
+			)
+		case "deleted":
+			if err = h.is.DeleteInstrumentMember(ctx, iid, identityID); err != nil {
+				return err
+			}
+			c.Logger().Infof(
+				"%s was removed from instrument %d by %s", identityID, iid, a.Identity.User,
+			)
+		}
+
+		// Redirect user
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/instruments/%d", iid))
+	}
+}
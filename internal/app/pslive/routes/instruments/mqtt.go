@@ -0,0 +1,205 @@
+package instruments
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/turbostreams"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
+)
+
+const (
+	mqttConsolePage     = "instruments/mqtt/console.page.tmpl"
+	mqttMessagesPartial = "instruments/mqtt/messages.partial.tmpl"
+	allTopicsFilter     = "#"
+)
+
+// encodeTopicFilter encodes an MQTT topic filter (which may contain slashes and wildcards) as a
+// single path segment of a Turbo Streams topic.
+func encodeTopicFilter(filter string) string {
+	if filter == "" {
+		filter = allTopicsFilter
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(filter))
+}
+
+func decodeTopicFilter(raw string) (string, error) {
+	filter, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid topic filter %s", raw)
+	}
+	return string(filter), nil
+}
+
+func replaceMQTTMessagesStream(
+	iid instruments.InstrumentID, cid instruments.ControllerID, filter string,
+	pc *planktoscope.Client,
+) turbostreams.Message {
+	return turbostreams.Message{
+		Action:   turbostreams.ActionReplace,
+		Target:   fmt.Sprintf("/instruments/%d/controllers/%d/mqtt/messages", iid, cid),
+		Template: mqttMessagesPartial,
+		Data: map[string]interface{}{
+			"InstrumentID":  iid,
+			"ControllerID":  cid,
+			"Filter":        filter,
+			"EncodedFilter": encodeTopicFilter(filter),
+			"Messages":      pc.GetTraffic(filter),
+		},
+	}
+}
+
+type MQTTConsoleViewData struct {
+	Instrument    instruments.Instrument
+	Controller    instruments.Controller
+	Filter        string
+	EncodedFilter string
+	Messages      []planktoscope.TrafficMessage
+}
+
+func (h *Handlers) HandleControllerMQTTGet() auth.HTTPHandlerFunc {
+	t := mqttConsolePage
+	h.r.MustHave(t)
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		cid, err := parseID[instruments.ControllerID](c.Param("controllerID"), "controller")
+		if err != nil {
+			return err
+		}
+		filter := strings.TrimSpace(c.QueryParam("topic"))
+		if filter == "" {
+			filter = allTopicsFilter
+		}
+
+		// Run queries
+		vd := MQTTConsoleViewData{Filter: filter, EncodedFilter: encodeTopicFilter(filter)}
+		if vd.Instrument, err = h.is.GetInstrument(c.Request().Context(), iid); err != nil {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf("instrument %d not found", iid))
+		}
+		found := false
+		for _, controller := range vd.Instrument.Controllers {
+			if controller.ID == cid {
+				vd.Controller = controller
+				found = true
+				break
+			}
+		}
+		if !found {
+			return echo.NewHTTPError(http.StatusNotFound, fmt.Sprintf(
+				"controller %d not found on instrument %d", cid, iid,
+			))
+		}
+		pc, ok := h.pco.Get(planktoscope.ClientID(cid))
+		if !ok {
+			return errors.Errorf(
+				"planktoscope client for controller %d on instrument %d not found for mqtt console",
+				cid, iid,
+			)
+		}
+		vd.Messages = pc.GetTraffic(filter)
+
+		// Produce output
+		return h.r.CacheablePage(c.Response(), c.Request(), t, vd, a)
+	}
+}
+
+func (h *Handlers) HandleControllerMQTTMessagesPub() turbostreams.HandlerFunc {
+	t := mqttMessagesPartial
+	h.r.MustHave(t)
+	return func(c *turbostreams.Context) error {
+		// Parse params & run queries
+		iid, cid, pc, err := getPlanktoscopeClientForPub(c, h.pco)
+		if err != nil {
+			return err
+		}
+		filter, err := decodeTopicFilter(c.Param("filter"))
+		if err != nil {
+			return err
+		}
+
+		// Publish on MQTT traffic
+		for {
+			ctx := c.Context()
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-pc.TrafficBroadcasted():
+				if err := ctx.Err(); err != nil {
+					// Context was also canceled and it should have priority
+					return err
+				}
+				c.Publish(replaceMQTTMessagesStream(iid, cid, filter, pc))
+			}
+		}
+	}
+}
+
+func parseQoS(raw string) (byte, error) {
+	const (
+		base    = 10
+		bitSize = 8
+		maxQoS  = 2
+	)
+	qos, err := strconv.ParseUint(raw, base, bitSize)
+	if err != nil || qos > maxQoS {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid qos %s", raw))
+	}
+	return byte(qos), nil
+}
+
+func (h *Handlers) HandleControllerMQTTPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		cid, err := parseID[instruments.ControllerID](c.Param("controllerID"), "controller")
+		if err != nil {
+			return err
+		}
+		topic := strings.TrimSpace(c.FormValue("topic"))
+		if topic == "" || strings.ContainsAny(topic, "+#") {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+				"invalid topic %s for publishing", topic,
+			))
+		}
+		qos, err := parseQoS(c.FormValue("qos"))
+		if err != nil {
+			return err
+		}
+		retained := strings.ToLower(c.FormValue("retained")) == flagChecked
+
+		// Run queries
+		pc, ok := h.pco.Get(planktoscope.ClientID(cid))
+		if !ok {
+			return errors.Errorf(
+				"planktoscope client for controller %d on instrument %d not found for mqtt post",
+				cid, iid,
+			)
+		}
+		token := pc.PublishRaw(topic, qos, retained, c.FormValue("payload"))
+		if token.Wait(); token.Error() != nil {
+			return errors.Wrapf(token.Error(), "couldn't publish test message to %s", topic)
+		}
+
+		// Redirect user
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf(
+			"/instruments/%d/controllers/%d/mqtt?topic=%s",
+			iid, cid, url.QueryEscape(c.FormValue("filter")),
+		))
+	}
+}
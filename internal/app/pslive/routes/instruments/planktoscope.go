@@ -6,7 +6,6 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/eclipse/paho.mqtt.golang"
 	"github.com/labstack/echo/v4"
@@ -19,6 +18,72 @@ import (
 	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
 )
 
+// Command Errors
+
+// handleCommandError reports a violation of the controller's safety limits, or a rejection of the
+// command because of an automation job's reservation of the controller, as an error message in the
+// submitted form. It passes through any other error.
+func (h *Handlers) handleCommandError(
+	c echo.Context, err error, form turbostreams.Message, authorizations interface{},
+) error {
+	message, herr := describeCommandError(err)
+	if herr == nil {
+		return err
+	}
+	if !turbostreams.Accepted(c.Request().Header) {
+		return herr
+	}
+	data, ok := form.Data.(map[string]interface{})
+	if !ok {
+		return errors.Errorf("turbo stream message data has unexpected type %T", form.Data)
+	}
+	data["Authorizations"] = authorizations
+	data["ErrorMessage"] = message
+	return h.r.TurboStream(c.Response(), form)
+}
+
+// describeCommandError describes a violation of the controller's safety limits, or a rejection of
+// the command because of an automation job's reservation of the controller, both as a message for
+// the operator and as an HTTP error. It returns a nil HTTP error for any other error.
+func describeCommandError(err error) (message string, herr *echo.HTTPError) {
+	var limitErr planktoscope.LimitError
+	var reservationErr instruments.ReservationError
+	switch {
+	default:
+		return "", nil
+	case errors.As(err, &limitErr):
+		message = limitErr.Error()
+		return message, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"command violates the controller's safety limits: %s", message,
+		))
+	case errors.As(err, &reservationErr):
+		message = reservationErr.Error()
+		return message, echo.NewHTTPError(http.StatusConflict, message)
+	}
+}
+
+// awaitSent waits until the command has been sent to the controller, or until the context is
+// done.
+func awaitSent(ctx context.Context, token mqtt.Token) error {
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "couldn't wait for command to be sent")
+	case <-token.Done():
+		return token.Error()
+	}
+}
+
+// awaitStateUpdate waits until the controller reports an update of its state after a command, or
+// until the context is done.
+func awaitStateUpdate(ctx context.Context, stateUpdated <-chan struct{}) error {
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "controller didn't report a state update")
+	case <-stateUpdated:
+		return nil
+	}
+}
+
 // Pump
 
 const pumpPartial = "instruments/planktoscope/pump.partial.tmpl"
@@ -43,41 +108,50 @@ func replacePumpStream(
 	}
 }
 
-func handlePumpSettings(
-	pumpingRaw, direction, volumeRaw, flowrateRaw string, pc *planktoscope.Client,
-) (err error) {
-	pumping := (strings.ToLower(pumpingRaw) == "start") || (strings.ToLower(pumpingRaw) == "restart")
+// setPump sends a command to start or stop the pump and waits until the controller has received
+// it. The returned channel is closed when the controller next reports its pump state.
+func setPump(
+	ctx context.Context, pc *planktoscope.Client, pumping, forward bool, volume, flowrate float64,
+) (stateUpdated <-chan struct{}, err error) {
 	var token mqtt.Token
 	if !pumping {
 		if token, err = pc.StopPump(); err != nil {
-			return err
+			return nil, err
 		}
-	} else {
+	} else if token, err = pc.StartPump(forward, volume, flowrate); err != nil {
+		return nil, err
+	}
+
+	stateUpdated = pc.PumpStateBroadcasted()
+	if err = awaitSent(ctx, token); err != nil {
+		return nil, err
+	}
+	return stateUpdated, nil
+}
+
+func handlePumpSettings(
+	ctx context.Context, pumpingRaw, direction, volumeRaw, flowrateRaw string,
+	pc *planktoscope.Client,
+) (stateUpdated <-chan struct{}, err error) {
+	pumping := (strings.ToLower(pumpingRaw) == "start") || (strings.ToLower(pumpingRaw) == "restart")
+	forward := strings.ToLower(direction) == "forward"
+	var volume, flowrate float64
+	if pumping {
 		// TODO: use echo's request binding functionality instead of strconv.ParseFloat
-		// TODO: perform input validation and handle invalid inputs
-		forward := strings.ToLower(direction) == "forward"
 		const floatWidth = 64
-		volume, err := strconv.ParseFloat(volumeRaw, floatWidth)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(err, "couldn't parse volume"))
-		}
-		flowrate, err := strconv.ParseFloat(flowrateRaw, floatWidth)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(err, "couldn't parse flowrate"))
+		if volume, err = strconv.ParseFloat(volumeRaw, floatWidth); err != nil {
+			return nil, echo.NewHTTPError(
+				http.StatusBadRequest, errors.Wrap(err, "couldn't parse volume"),
+			)
 		}
-		if token, err = pc.StartPump(forward, volume, flowrate); err != nil {
-			return err
+		if flowrate, err = strconv.ParseFloat(flowrateRaw, floatWidth); err != nil {
+			return nil, echo.NewHTTPError(
+				http.StatusBadRequest, errors.Wrap(err, "couldn't parse flowrate"),
+			)
 		}
 	}
 
-	stateUpdated := pc.PumpStateBroadcasted()
-	// TODO: instead of waiting forever, have a timeout before redirecting and displaying a
-	// warning message that we haven't heard any pump state updates from the planktoscope.
-	if token.Wait(); token.Error() != nil {
-		return token.Error()
-	}
-	<-stateUpdated
-	return nil
+	return setPump(ctx, pc, pumping, forward, volume, flowrate)
 }
 
 func (h *Handlers) HandlePumpPub() turbostreams.HandlerFunc {
@@ -166,11 +240,28 @@ func (h *Handlers) HandlePumpPost() auth.HTTPHandlerFunc {
 				"planktoscope client for controller %d on instrument %d not found for pump post", cid, iid,
 			)
 		}
-		if err = handlePumpSettings(
-			c.FormValue("pumping"), c.FormValue("direction"),
-			c.FormValue("volume"), c.FormValue("flowrate"), pc,
-		); err != nil {
+		pumpingRaw := strings.ToLower(c.FormValue("pumping"))
+		stopping := pumpingRaw != "start" && pumpingRaw != "restart"
+		ctx := c.Request().Context()
+		var stateUpdated <-chan struct{}
+		if err = h.runOperatorCommand(ctx, cid, a, stopping, func() (err error) {
+			stateUpdated, err = handlePumpSettings(
+				ctx, pumpingRaw, c.FormValue("direction"), c.FormValue("volume"), c.FormValue("flowrate"),
+				pc,
+			)
 			return err
+		}); err == nil {
+			// TODO: instead of waiting until the request is canceled, have a timeout before
+			// redirecting and displaying a warning message that we haven't heard any pump updates from
+			// the planktoscope.
+			err = awaitStateUpdate(ctx, stateUpdated)
+		}
+		if err != nil {
+			authz, aerr := getPlanktoscopePumpViewAuthz(ctx, iid, cid, a, h.azc)
+			if aerr != nil {
+				return aerr
+			}
+			return h.handleCommandError(c, err, replacePumpStream(iid, cid, a, pc), authz)
 		}
 
 		// We rely on Turbo Streams over websockets, so we return an empty response here to avoid a race
@@ -209,54 +300,63 @@ func replaceCameraStream(
 	}
 }
 
+// setCamera sends a command to change the camera settings and waits until the controller has
+// received it. The returned channel is closed when the controller next reports its camera settings.
+func setCamera(
+	ctx context.Context, pc *planktoscope.Client, iso, shutterSpeed uint64,
+	autoWhiteBalance bool, whiteBalanceRedGain, whiteBalanceBlueGain float64,
+) (stateUpdated <-chan struct{}, err error) {
+	token, err := pc.SetCamera(
+		iso, shutterSpeed, autoWhiteBalance, whiteBalanceRedGain, whiteBalanceBlueGain,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	stateUpdated = pc.CameraStateBroadcasted()
+	if err = awaitSent(ctx, token); err != nil {
+		return nil, err
+	}
+	return stateUpdated, nil
+}
+
 func handleCameraSettings(
-	isoRaw, shutterSpeedRaw,
+	ctx context.Context, isoRaw, shutterSpeedRaw,
 	autoWhiteBalanceRaw, whiteBalanceRedGainRaw, whiteBalanceBlueGainRaw string,
 	pc *planktoscope.Client,
-) (err error) {
-	var token mqtt.Token
+) (stateUpdated <-chan struct{}, err error) {
 	// TODO: use echo's request binding functionality instead of strconv.ParseFloat
-	// TODO: perform input validation and handle invalid inputs
 	const uintBase = 10
 	const uintWidth = 64
 	iso, err := strconv.ParseUint(isoRaw, uintBase, uintWidth)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(err, "couldn't parse iso"))
+		return nil, echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(err, "couldn't parse iso"))
 	}
 	shutterSpeed, err := strconv.ParseUint(shutterSpeedRaw, uintBase, uintWidth)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(err, "couldn't parse shutter speed"))
+		return nil, echo.NewHTTPError(
+			http.StatusBadRequest, errors.Wrap(err, "couldn't parse shutter speed"),
+		)
 	}
 
 	const floatWidth = 64
 	autoWhiteBalance := strings.ToLower(autoWhiteBalanceRaw) == flagChecked
 	whiteBalanceRedGain, err := strconv.ParseFloat(whiteBalanceRedGainRaw, floatWidth)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(
+		return nil, echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(
 			err, "couldn't parse white balance red gain",
 		))
 	}
 	whiteBalanceBlueGain, err := strconv.ParseFloat(whiteBalanceBlueGainRaw, floatWidth)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(
+		return nil, echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(
 			err, "couldn't parse white balance blue gain",
 		))
 	}
 
-	if token, err = pc.SetCamera(
-		iso, shutterSpeed, autoWhiteBalance, whiteBalanceRedGain, whiteBalanceBlueGain,
-	); err != nil {
-		return err
-	}
-
-	stateUpdated := pc.CameraStateBroadcasted()
-	// TODO: instead of waiting forever, have a timeout before redirecting and displaying a
-	// warning message that we haven't heard any camera settings updates from the planktoscope.
-	if token.Wait(); token.Error() != nil {
-		return token.Error()
-	}
-	<-stateUpdated
-	return nil
+	return setCamera(
+		ctx, pc, iso, shutterSpeed, autoWhiteBalance, whiteBalanceRedGain, whiteBalanceBlueGain,
+	)
 }
 
 func (h *Handlers) HandleCameraPub() turbostreams.HandlerFunc {
@@ -348,11 +448,26 @@ func (h *Handlers) HandleCameraPost() auth.HTTPHandlerFunc {
 				cid, iid,
 			)
 		}
-		if err = handleCameraSettings(
-			c.FormValue("iso"), c.FormValue("shutter-speed"),
-			c.FormValue("awb"), c.FormValue("wb-red"), c.FormValue("wb-blue"), pc,
-		); err != nil {
+		ctx := c.Request().Context()
+		var stateUpdated <-chan struct{}
+		if err = h.runOperatorCommand(ctx, cid, a, false, func() (err error) {
+			stateUpdated, err = handleCameraSettings(
+				ctx, c.FormValue("iso"), c.FormValue("shutter-speed"),
+				c.FormValue("awb"), c.FormValue("wb-red"), c.FormValue("wb-blue"), pc,
+			)
 			return err
+		}); err == nil {
+			// TODO: instead of waiting until the request is canceled, have a timeout before
+			// redirecting and displaying a warning message that we haven't heard any camera settings
+			// updates from the planktoscope.
+			err = awaitStateUpdate(ctx, stateUpdated)
+		}
+		if err != nil {
+			authz, aerr := getPlanktoscopeCameraViewAuthz(ctx, iid, cid, a, h.azc)
+			if aerr != nil {
+				return aerr
+			}
+			return h.handleCommandError(c, err, replaceCameraStream(iid, cid, a, pc), authz)
 		}
 
 		// We rely on Turbo Streams over websockets, so we return an empty response here to avoid a race
@@ -392,60 +507,82 @@ func replaceImagerStream(
 	}
 }
 
-func handleImagerSettings(
-	sampleProjectID, sampleID, imagingRaw, direction, stepVolumeRaw, stepDelayRaw, stepsRaw string,
-	pc *planktoscope.Client,
-) (err error) {
-	imaging := strings.ToLower(imagingRaw) == "start"
+// imagingParams are the parameters of a command to start imaging.
+type imagingParams struct {
+	// Source is the acquisition source recorded with the sample metadata.
+	Source string
+	// If provided, SampleProjectID and SampleID override the IDs of the instrument's current sample
+	SampleProjectID string
+	SampleID        string
+	Forward         bool
+	StepVolume      float64
+	StepDelay       float64
+	Steps           uint64
+}
+
+// setImager sends a command to start or stop imaging and waits until the controller has received
+// it. The returned channel is closed when the controller next reports its imager state.
+func setImager(
+	ctx context.Context, pc *planktoscope.Client, imaging bool, p imagingParams,
+) (stateUpdated <-chan struct{}, err error) {
 	var token mqtt.Token
 	if !imaging {
 		if token, err = pc.StopImaging(); err != nil {
-			return err
+			return nil, err
 		}
 	} else {
+		// Limits must be checked before the acquisition is recorded with the sample metadata
+		if err = pc.GetLimits().CheckImaging(p.StepVolume, p.StepDelay, p.Steps); err != nil {
+			return nil, err
+		}
+		if err = pc.SetSampleMetadata(ctx, p.Source, p.SampleProjectID, p.SampleID); err != nil {
+			return nil, err
+		}
+		if token, err = pc.StartImaging(p.Forward, p.StepVolume, p.StepDelay, p.Steps); err != nil {
+			return nil, err
+		}
+	}
+
+	stateUpdated = pc.ImagerStateBroadcasted()
+	if err = awaitSent(ctx, token); err != nil {
+		return nil, err
+	}
+	return stateUpdated, nil
+}
+
+func handleImagerSettings(
+	ctx context.Context,
+	imagingRaw, direction, stepVolumeRaw, stepDelayRaw, stepsRaw string,
+	pc *planktoscope.Client,
+) (stateUpdated <-chan struct{}, err error) {
+	imaging := strings.ToLower(imagingRaw) == "start"
+	p := imagingParams{
+		Source:  planktoscope.AcquisitionSourceGUI,
+		Forward: strings.ToLower(direction) == "forward",
+	}
+	if imaging {
 		// TODO: use echo's request binding functionality instead of strconv.ParseFloat
-		// TODO: perform input validation and handle invalid inputs
-		forward := strings.ToLower(direction) == "forward"
 		const floatWidth = 64
-		stepVolume, err := strconv.ParseFloat(stepVolumeRaw, floatWidth)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(
+		if p.StepVolume, err = strconv.ParseFloat(stepVolumeRaw, floatWidth); err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(
 				err, "couldn't parse step volume",
 			))
 		}
-		stepDelay, err := strconv.ParseFloat(stepDelayRaw, floatWidth)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(
+		if p.StepDelay, err = strconv.ParseFloat(stepDelayRaw, floatWidth); err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(
 				err, "couldn't parse step delay",
 			))
 		}
 		const base = 10
 		const bitSize = 64
-		steps, err := strconv.ParseUint(stepsRaw, base, bitSize)
-		if err != nil {
-			return echo.NewHTTPError(http.StatusBadRequest, errors.Wrap(err, "couldn't parse steps"))
-		}
-		if token, err = pc.SetMetadata(sampleProjectID, sampleID, time.Now()); err != nil {
-			return err
-		}
-		// TODO: instead of waiting forever, have a timeout before redirecting and displaying a
-		// warning message that we couldn't update the imaging metadata
-		if token.Wait(); token.Error() != nil {
-			return token.Error()
-		}
-		if token, err = pc.StartImaging(forward, stepVolume, stepDelay, steps); err != nil {
-			return err
+		if p.Steps, err = strconv.ParseUint(stepsRaw, base, bitSize); err != nil {
+			return nil, echo.NewHTTPError(
+				http.StatusBadRequest, errors.Wrap(err, "couldn't parse steps"),
+			)
 		}
 	}
 
-	stateUpdated := pc.ImagerStateBroadcasted()
-	// TODO: instead of waiting forever, have a timeout before redirecting and displaying a
-	// warning message that we haven't heard any imager state updates from the planktoscope.
-	if token.Wait(); token.Error() != nil {
-		return token.Error()
-	}
-	<-stateUpdated
-	return nil
+	return setImager(ctx, pc, imaging, p)
 }
 
 func (h *Handlers) HandleImagerPub() turbostreams.HandlerFunc {
@@ -530,10 +667,6 @@ func (h *Handlers) HandleImagerPost() auth.HTTPHandlerFunc {
 		}
 
 		// Run queries
-		instrument, err := h.is.GetInstrument(c.Request().Context(), iid)
-		if err != nil {
-			return err
-		}
 		pc, ok := h.pco.Get(planktoscope.ClientID(cid))
 		if !ok {
 			return errors.Errorf(
@@ -541,11 +674,27 @@ func (h *Handlers) HandleImagerPost() auth.HTTPHandlerFunc {
 				cid, iid,
 			)
 		}
-		if err = handleImagerSettings(
-			instrument.Name, "gui", c.FormValue("imaging"), c.FormValue("direction"),
-			c.FormValue("step-volume"), c.FormValue("step-delay"), c.FormValue("steps"), pc,
-		); err != nil {
+		ctx := c.Request().Context()
+		stopping := strings.ToLower(c.FormValue("imaging")) != "start"
+		var stateUpdated <-chan struct{}
+		if err = h.runOperatorCommand(ctx, cid, a, stopping, func() (err error) {
+			stateUpdated, err = handleImagerSettings(
+				ctx, c.FormValue("imaging"), c.FormValue("direction"),
+				c.FormValue("step-volume"), c.FormValue("step-delay"), c.FormValue("steps"), pc,
+			)
 			return err
+		}); err == nil {
+			// TODO: instead of waiting until the request is canceled, have a timeout before
+			// redirecting and displaying a warning message that we haven't heard any imager state
+			// updates from the planktoscope.
+			err = awaitStateUpdate(ctx, stateUpdated)
+		}
+		if err != nil {
+			authz, aerr := getPlanktoscopeImagerViewAuthz(ctx, iid, cid, a, h.azc)
+			if aerr != nil {
+				return aerr
+			}
+			return h.handleCommandError(c, err, replaceImagerStream(iid, cid, a, pc), authz)
 		}
 
 		// We rely on Turbo Streams over websockets, so we return an empty response here to avoid a race
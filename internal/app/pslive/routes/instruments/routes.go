@@ -8,7 +8,11 @@ import (
 
 	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
 	"github.com/sargassum-world/pslive/internal/app/pslive/handling"
+	"github.com/sargassum-world/pslive/internal/app/pslive/openapi"
 	"github.com/sargassum-world/pslive/internal/clients/chat"
+	"github.com/sargassum-world/pslive/internal/clients/events"
+	"github.com/sargassum-world/pslive/internal/clients/genericmqtt"
+	"github.com/sargassum-world/pslive/internal/clients/httpjson"
 	"github.com/sargassum-world/pslive/internal/clients/instruments"
 	"github.com/sargassum-world/pslive/internal/clients/ory"
 	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
@@ -26,16 +30,24 @@ type Handlers struct {
 
 	is  *instruments.Store
 	pco *planktoscope.Orchestrator
+	gmo *genericmqtt.Orchestrator
+	hjo *httpjson.Orchestrator
 	ijo *instruments.JobOrchestrator
+	ca  *instruments.ControlArbiter
+	cl  *instruments.ControlLeaser
 	ps  *presence.Store
 	cs  *chat.Store
 	vsb *videostreams.Broker
+	eb  *events.Broker
+	wd  *instruments.WebhookDispatcher
 }
 
 func New(
 	r godest.TemplateRenderer, oc *ory.Client, azc *auth.AuthzChecker, tsh *turbostreams.Hub,
-	is *instruments.Store, pco *planktoscope.Orchestrator, ijo *instruments.JobOrchestrator,
-	ps *presence.Store, cs *chat.Store, vsb *videostreams.Broker,
+	is *instruments.Store, pco *planktoscope.Orchestrator, gmo *genericmqtt.Orchestrator,
+	hjo *httpjson.Orchestrator, ijo *instruments.JobOrchestrator, ca *instruments.ControlArbiter,
+	cl *instruments.ControlLeaser, ps *presence.Store, cs *chat.Store, vsb *videostreams.Broker,
+	eb *events.Broker, wd *instruments.WebhookDispatcher,
 ) *Handlers {
 	return &Handlers{
 		r:   r,
@@ -44,25 +56,59 @@ func New(
 		tsh: tsh,
 		is:  is,
 		pco: pco,
+		gmo: gmo,
+		hjo: hjo,
 		ijo: ijo,
+		ca:  ca,
+		cl:  cl,
 		ps:  ps,
 		cs:  cs,
 		vsb: vsb,
+		eb:  eb,
+		wd:  wd,
 	}
 }
 
 func (h *Handlers) Register(
 	er godest.EchoRouter, tsr turbostreams.Router, vsr videostreams.Router, ss *session.Store,
+	docs *openapi.Registry,
 ) {
-	hr := auth.NewHTTPRouter(er, ss)
+	hr := auth.NewHTTPRouter(auditedRouter{er, h.auditMiddleware(ss)}, ss)
 	hr.GET("/instruments", h.HandleInstrumentsGet())
 	hr.POST("/instruments", h.HandleInstrumentsPost())
 	hr.GET("/instruments/:id", h.HandleInstrumentGet())
 	hr.POST("/instruments/:id", h.HandleInstrumentPost())
 	hr.POST("/instruments/:id/name", h.HandleInstrumentNamePost())
 	hr.POST("/instruments/:id/description", h.HandleInstrumentDescriptionPost())
-	tsr.SUB("/instruments/:id/users", handling.HandlePresenceSub(h.r, ss, h.oc, h.ps))
-	tsr.UNSUB("/instruments/:id/users", handling.HandlePresenceUnsub(h.r, ss, h.ps))
+	hr.POST("/instruments/:id/visibility", h.HandleInstrumentVisibilityPost())
+	hr.GET("/instruments/:id/audit", h.HandleInstrumentAuditGet())
+	hr.GET("/instruments/:id/audit.json", h.HandleInstrumentAuditJSONGet())
+	hr.POST("/instruments/:id/members", h.HandleInstrumentMembersPost())
+	hr.POST("/instruments/:id/members/:identityID", h.HandleInstrumentMemberPost())
+	hr.POST("/instruments/:id/sample", h.HandleInstrumentSamplePost())
+	tsr.SUB("/instruments/:id/sample", turbostreams.EmptyHandler)
+	tsr.MSG("/instruments/:id/sample", handling.HandleTSMsg(h.r, ss, h.ModifySampleMsgData()))
+	hr.POST("/instruments/:id/emergency-stop", h.HandleEmergencyStopPost())
+	hr.POST("/instruments/:id/emergency-stop/clear", h.HandleEmergencyStopClearPost())
+	tsr.SUB("/instruments/:id/emergency-stop", turbostreams.EmptyHandler)
+	tsr.MSG("/instruments/:id/emergency-stop", handling.HandleTSMsg(
+		h.r, ss, h.ModifyEmergencyStopMsgData(),
+	))
+	hr.POST("/instruments/:id/control-lease", h.HandleControlLeasePost())
+	hr.POST("/instruments/:id/control-lease/release", h.HandleControlLeaseReleasePost())
+	hr.POST("/instruments/:id/control-lease/transfer", h.HandleControlLeaseTransferPost())
+	tsr.SUB("/instruments/:id/control-lease", turbostreams.EmptyHandler)
+	tsr.PUB("/instruments/:id/control-lease", h.HandleControlLeasePub())
+	tsr.MSG("/instruments/:id/control-lease", handling.HandleTSMsg(
+		h.r, ss, h.ModifyControlLeaseMsgData(),
+	))
+	er.GET("/instruments/:id/events", h.HandleInstrumentEventsGet())
+	tsr.SUB("/instruments/:id/users", handling.HandlePresenceSub(
+		h.r, ss, h.oc, h.ps, h.publishPresenceEvent,
+	))
+	tsr.UNSUB("/instruments/:id/users", handling.HandlePresenceUnsub(
+		h.r, ss, h.ps, h.publishPresenceEvent,
+	))
 	tsr.SUB("/instruments/:id/users/list", turbostreams.EmptyHandler)
 	tsr.MSG("/instruments/:id/users/list", handling.HandleTSMsg(h.r, ss))
 	tsr.SUB("/instruments/:id/users/count", turbostreams.EmptyHandler)
@@ -75,6 +121,9 @@ func (h *Handlers) Register(
 	vsr.PUB("/instruments/:id/cameras/:cameraID/stream.mjpeg", h.HandleInstrumentCameraStreamPub())
 	hr.POST("/instruments/:id/controllers", h.HandleInstrumentControllersPost())
 	hr.POST("/instruments/:id/controllers/:controllerID", h.HandleInstrumentControllerPost())
+	tsr.SUB("/instruments/:id/controllers/:controllerID/control", turbostreams.EmptyHandler)
+	tsr.PUB("/instruments/:id/controllers/:controllerID/control", h.HandleControlPub())
+	tsr.MSG("/instruments/:id/controllers/:controllerID/control", handling.HandleTSMsg(h.r, ss))
 	tsr.SUB("/instruments/:id/controllers/:controllerID/pump", turbostreams.EmptyHandler)
 	tsr.PUB("/instruments/:id/controllers/:controllerID/pump", h.HandlePumpPub())
 	tsr.MSG("/instruments/:id/controllers/:controllerID/pump", handling.HandleTSMsg(
@@ -93,14 +142,84 @@ func (h *Handlers) Register(
 		h.r, ss, h.ModifyImagerMsgData(),
 	))
 	hr.POST("/instruments/:id/controllers/:controllerID/imager", h.HandleImagerPost())
+	hr.GET("/instruments/:id/controllers/:controllerID/mqtt", h.HandleControllerMQTTGet())
+	hr.POST("/instruments/:id/controllers/:controllerID/mqtt", h.HandleControllerMQTTPost())
+	tsr.SUB(
+		"/instruments/:id/controllers/:controllerID/mqtt/messages/:filter", turbostreams.EmptyHandler,
+	)
+	tsr.PUB(
+		"/instruments/:id/controllers/:controllerID/mqtt/messages/:filter",
+		h.HandleControllerMQTTMessagesPub(),
+	)
+	tsr.MSG(
+		"/instruments/:id/controllers/:controllerID/mqtt/messages/:filter",
+		handling.HandleTSMsg(h.r, ss),
+	)
+	tsr.SUB("/instruments/:id/controllers/:controllerID/state", turbostreams.EmptyHandler)
+	tsr.PUB("/instruments/:id/controllers/:controllerID/state", h.HandleCustomControllerStatePub())
+	tsr.MSG("/instruments/:id/controllers/:controllerID/state", handling.HandleTSMsg(h.r, ss))
+	hr.POST(
+		"/instruments/:id/controllers/:controllerID/commands/:command",
+		h.HandleCustomControllerCommandPost(),
+	)
 	hr.POST("/instruments/:id/automation-jobs", h.HandleInstrumentAutomationJobsPost())
 	hr.POST(
 		"/instruments/:id/automation-jobs/:automationJobID", h.HandleInstrumentAutomationJobPost(),
 	)
+	hr.GET("/instruments/:id/webhooks", h.HandleInstrumentWebhooksGet())
+	hr.POST("/instruments/:id/webhooks", h.HandleInstrumentWebhooksPost())
+	hr.GET("/instruments/:id/webhooks/:webhookID", h.HandleInstrumentWebhookGet())
+	hr.POST("/instruments/:id/webhooks/:webhookID", h.HandleInstrumentWebhookPost())
+	hr.POST(
+		"/instruments/:id/webhooks/:webhookID/deliveries/:deliveryID/redeliver",
+		h.HandleInstrumentWebhookRedeliverPost(),
+	)
+	hr.GET(apiPrefix+"/instruments", h.HandleAPIInstrumentsGet())
+	hr.POST(apiPrefix+"/instruments", h.HandleAPIInstrumentsPost())
+	hr.GET(apiPrefix+"/instruments/:id", h.HandleAPIInstrumentGet())
+	hr.PATCH(apiPrefix+"/instruments/:id", h.HandleAPIInstrumentPatch())
+	hr.DELETE(apiPrefix+"/instruments/:id", h.HandleAPIInstrumentDelete())
+	hr.GET(apiPrefix+"/instruments/:id/cameras", h.HandleAPICamerasGet())
+	hr.POST(apiPrefix+"/instruments/:id/cameras", h.HandleAPICamerasPost())
+	hr.GET(apiPrefix+"/instruments/:id/cameras/:cameraID", h.HandleAPICameraGet())
+	hr.PATCH(apiPrefix+"/instruments/:id/cameras/:cameraID", h.HandleAPICameraPatch())
+	hr.DELETE(apiPrefix+"/instruments/:id/cameras/:cameraID", h.HandleAPICameraDelete())
+	hr.GET(apiPrefix+"/instruments/:id/controllers", h.HandleAPIControllersGet())
+	hr.POST(apiPrefix+"/instruments/:id/controllers", h.HandleAPIControllersPost())
+	hr.GET(apiPrefix+"/instruments/:id/controllers/:controllerID", h.HandleAPIControllerGet())
+	hr.PATCH(apiPrefix+"/instruments/:id/controllers/:controllerID", h.HandleAPIControllerPatch())
+	hr.DELETE(
+		apiPrefix+"/instruments/:id/controllers/:controllerID", h.HandleAPIControllerDelete(),
+	)
+	hr.GET(
+		apiPrefix+"/instruments/:id/controllers/:controllerID/state",
+		h.HandleAPIPlanktoscopeStateGet(),
+	)
+	hr.POST(apiPrefix+"/instruments/:id/controllers/:controllerID/pump", h.HandleAPIPumpPost())
+	hr.POST(
+		apiPrefix+"/instruments/:id/controllers/:controllerID/camera", h.HandleAPICameraSettingsPost(),
+	)
+	hr.POST(apiPrefix+"/instruments/:id/controllers/:controllerID/imager", h.HandleAPIImagerPost())
+	hr.GET(apiPrefix+"/instruments/:id/automation-jobs", h.HandleAPIAutomationJobsGet())
+	hr.POST(apiPrefix+"/instruments/:id/automation-jobs", h.HandleAPIAutomationJobsPost())
+	hr.GET(
+		apiPrefix+"/instruments/:id/automation-jobs/:automationJobID", h.HandleAPIAutomationJobGet(),
+	)
+	hr.PATCH(
+		apiPrefix+"/instruments/:id/automation-jobs/:automationJobID",
+		h.HandleAPIAutomationJobPatch(),
+	)
+	hr.DELETE(
+		apiPrefix+"/instruments/:id/automation-jobs/:automationJobID",
+		h.HandleAPIAutomationJobDelete(),
+	)
 	tsr.SUB("/instruments/:id/chat/messages", turbostreams.EmptyHandler)
 	tsr.MSG("/instruments/:id/chat/messages", handling.HandleTSMsg(h.r, ss))
 	// TODO: add a paginated GET handler for chat messages to support chat history infiniscroll
-	hr.POST("/instruments/:id/chat/messages", handling.HandleChatMessagesPost(
-		h.r, h.oc, h.azc, h.tsh, h.cs,
+	// Chat messages aren't control or configuration actions, so they aren't audited
+	chr := auth.NewHTTPRouter(er, ss)
+	chr.POST("/instruments/:id/chat/messages", handling.HandleChatMessagesPost(
+		h.r, h.oc, h.azc, h.tsh, h.cs, h.publishChatMessageEvent,
 	))
+	h.describeRoutes(docs)
 }
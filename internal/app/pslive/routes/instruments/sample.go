@@ -0,0 +1,179 @@
+package instruments
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/turbostreams"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/app/pslive/handling"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+)
+
+const samplePartial = "instruments/sample.partial.tmpl"
+
+func parseOptionalFloat(raw string, fieldName string) (float64, error) {
+	if raw = strings.TrimSpace(raw); raw == "" {
+		return 0, nil
+	}
+	const floatWidth = 64
+	parsed, err := strconv.ParseFloat(raw, floatWidth)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, errors.Wrapf(
+			err, "couldn't parse %s", fieldName,
+		))
+	}
+	return parsed, nil
+}
+
+func parseSample(c echo.Context, iid instruments.InstrumentID) (s instruments.Sample, err error) {
+	s = instruments.Sample{
+		InstrumentID: iid,
+		ProjectID:    strings.TrimSpace(c.FormValue("project-id")),
+		SampleID:     strings.TrimSpace(c.FormValue("sample-id")),
+		Operator:     strings.TrimSpace(c.FormValue("operator")),
+		SamplingGear: strings.TrimSpace(c.FormValue("sampling-gear")),
+	}
+	fields := []struct {
+		name  string
+		value *float64
+	}{
+		{"latitude", &s.Latitude},
+		{"longitude", &s.Longitude},
+		{"depth-min", &s.DepthMin},
+		{"depth-max", &s.DepthMax},
+		{"volume", &s.Volume},
+		{"net-mesh", &s.NetMesh},
+	}
+	for _, field := range fields {
+		if *field.value, err = parseOptionalFloat(c.FormValue(field.name), field.name); err != nil {
+			return instruments.Sample{}, err
+		}
+	}
+	const maxLatitude = 90
+	if math.Abs(s.Latitude) > maxLatitude {
+		return instruments.Sample{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"latitude %g must be between -%d and %d degrees", s.Latitude, maxLatitude, maxLatitude,
+		))
+	}
+	const maxLongitude = 180
+	if math.Abs(s.Longitude) > maxLongitude {
+		return instruments.Sample{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+			"longitude %g must be between -%d and %d degrees", s.Longitude, maxLongitude, maxLongitude,
+		))
+	}
+	return s, nil
+}
+
+type SampleViewData struct {
+	Sample       instruments.Sample
+	Acquisitions []instruments.Acquisition
+}
+
+func getSampleViewData(
+	ctx context.Context, iid instruments.InstrumentID, is *instruments.Store,
+) (vd SampleViewData, err error) {
+	if vd.Sample, err = is.GetSample(ctx, iid); err != nil {
+		return SampleViewData{}, err
+	}
+	if vd.Acquisitions, err = is.GetAcquisitionsByInstrument(
+		ctx, iid, instruments.DefaultAcquisitionsLimit,
+	); err != nil {
+		return SampleViewData{}, err
+	}
+	return vd, nil
+}
+
+func replaceSampleStream(
+	iid instruments.InstrumentID, vd SampleViewData, a auth.Auth,
+) turbostreams.Message {
+	return turbostreams.Message{
+		Action:   turbostreams.ActionReplace,
+		Target:   fmt.Sprintf("/instruments/%d/sample", iid),
+		Template: samplePartial,
+		Data: map[string]interface{}{
+			"InstrumentID": iid,
+			"Sample":       vd.Sample,
+			"Acquisitions": vd.Acquisitions,
+			"Auth":         a,
+		},
+	}
+}
+
+type SampleViewAuthz struct {
+	SetSample bool
+}
+
+func (h *Handlers) ModifySampleMsgData() handling.DataModifier {
+	return func(
+		ctx context.Context, a auth.Auth, data map[string]interface{},
+	) (modifications map[string]interface{}, err error) {
+		iid, ok := data["InstrumentID"].(instruments.InstrumentID)
+		if !ok {
+			return nil, errors.Errorf(
+				"instrument id has unexpected type %T in turbostreams message data for checking authorization",
+				data["InstrumentID"],
+			)
+		}
+		path := fmt.Sprintf("/instruments/%d/sample", iid)
+		setSample, err := h.azc.Allow(ctx, a, path, http.MethodPost, nil)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't check authz for setting sample for instrument %d", iid)
+		}
+		return map[string]interface{}{"Authorizations": SampleViewAuthz{SetSample: setSample}}, nil
+	}
+}
+
+func (h *Handlers) broadcastSample(ctx context.Context, iid instruments.InstrumentID) error {
+	vd, err := getSampleViewData(ctx, iid, h.is)
+	if err != nil {
+		return err
+	}
+	// We insert an empty Auth object because the MSG handler will add the auth object for each
+	// client
+	h.tsh.Broadcast(
+		fmt.Sprintf("/instruments/%d/sample", iid),
+		[]turbostreams.Message{replaceSampleStream(iid, vd, auth.Auth{})},
+	)
+	return nil
+}
+
+func (h *Handlers) HandleInstrumentSamplePost() auth.HTTPHandlerFunc {
+	h.r.MustHave(samplePartial)
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		sample, err := parseSample(c, iid)
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		ctx := c.Request().Context()
+		if err = h.is.SetSample(ctx, sample); err != nil {
+			return err
+		}
+		if err = h.broadcastSample(ctx, iid); err != nil {
+			return err
+		}
+
+		// We rely on Turbo Streams over websockets, so we return an empty response here to avoid a race
+		// condition of two Turbo Stream replace messages
+		if turbostreams.Accepted(c.Request().Header) {
+			return h.r.TurboStream(c.Response())
+		}
+
+		// Redirect user
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/instruments/%d", iid))
+	}
+}
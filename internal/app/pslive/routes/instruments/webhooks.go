@@ -0,0 +1,240 @@
+package instruments
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+)
+
+const (
+	webhooksPage = "instruments/webhooks.page.tmpl"
+	webhookPage  = "instruments/webhook.page.tmpl"
+)
+
+func parseWebhookParams(c echo.Context) (w instruments.Webhook, err error) {
+	w.URL = strings.TrimSpace(c.FormValue("url"))
+	if err = instruments.ValidateWebhookURL(w.URL); err != nil {
+		return instruments.Webhook{}, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+	}
+	w.Description = c.FormValue("description")
+	w.Enabled = strings.ToLower(c.FormValue("enabled")) == flagChecked
+	params, err := c.FormParams()
+	if err != nil {
+		return instruments.Webhook{}, errors.Wrap(err, "couldn't parse form params")
+	}
+	for _, raw := range params["event-types"] {
+		eventType, ok := instruments.ParseWebhookEventType(raw)
+		if !ok {
+			return instruments.Webhook{}, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+				"invalid webhook event type %s", raw,
+			))
+		}
+		w.EventTypes = append(w.EventTypes, eventType)
+	}
+	return w, nil
+}
+
+// Webhooks
+
+type WebhooksViewData struct {
+	Instrument instruments.Instrument
+	Webhooks   []instruments.Webhook
+	EventTypes []instruments.WebhookEventType
+}
+
+func getWebhooksViewData(
+	ctx context.Context, iid instruments.InstrumentID, is *instruments.Store,
+) (vd WebhooksViewData, err error) {
+	if vd.Instrument, err = is.GetInstrument(ctx, iid); err != nil {
+		return WebhooksViewData{}, err
+	}
+	if vd.Webhooks, err = is.GetWebhooksByInstrument(ctx, iid); err != nil {
+		return WebhooksViewData{}, err
+	}
+	vd.EventTypes = instruments.WebhookEventTypes
+	return vd, nil
+}
+
+func (h *Handlers) HandleInstrumentWebhooksGet() auth.HTTPHandlerFunc {
+	t := webhooksPage
+	h.r.MustHave(t)
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		vd, err := getWebhooksViewData(c.Request().Context(), iid, h.is)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		return h.r.CacheablePage(c.Response(), c.Request(), t, vd, a)
+	}
+}
+
+func (h *Handlers) HandleInstrumentWebhooksPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		w, err := parseWebhookParams(c)
+		if err != nil {
+			return err
+		}
+		w.InstrumentID = iid
+
+		// Run queries
+		if w.Secret, err = instruments.NewWebhookSecret(); err != nil {
+			return err
+		}
+		webhookID, err := h.is.AddWebhook(c.Request().Context(), w)
+		if err != nil {
+			return err
+		}
+
+		// Redirect user
+		return c.Redirect(
+			http.StatusSeeOther, fmt.Sprintf("/instruments/%d/webhooks/%d", iid, webhookID),
+		)
+	}
+}
+
+// Webhook
+
+type WebhookViewData struct {
+	Instrument instruments.Instrument
+	Webhook    instruments.Webhook
+	Deliveries []instruments.WebhookDelivery
+	EventTypes []instruments.WebhookEventType
+}
+
+func getWebhookViewData(
+	ctx context.Context, iid instruments.InstrumentID, webhookID instruments.WebhookID,
+	is *instruments.Store,
+) (vd WebhookViewData, err error) {
+	if vd.Instrument, err = is.GetInstrument(ctx, iid); err != nil {
+		return WebhookViewData{}, err
+	}
+	if vd.Webhook, err = is.GetWebhook(ctx, webhookID); err != nil {
+		return WebhookViewData{}, err
+	}
+	if vd.Deliveries, err = is.GetWebhookDeliveries(
+		ctx, webhookID, instruments.DefaultWebhookDeliveriesLimit,
+	); err != nil {
+		return WebhookViewData{}, err
+	}
+	vd.EventTypes = instruments.WebhookEventTypes
+	return vd, nil
+}
+
+func (h *Handlers) HandleInstrumentWebhookGet() auth.HTTPHandlerFunc {
+	t := webhookPage
+	h.r.MustHave(t)
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		webhookID, err := parseID[instruments.WebhookID](c.Param("webhookID"), "webhook")
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		vd, err := getWebhookViewData(c.Request().Context(), iid, webhookID, h.is)
+		if err != nil {
+			return err
+		}
+
+		// Produce output
+		return h.r.CacheablePage(c.Response(), c.Request(), t, vd, a)
+	}
+}
+
+func (h *Handlers) HandleInstrumentWebhookPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		webhookID, err := parseID[instruments.WebhookID](c.Param("webhookID"), "webhook")
+		if err != nil {
+			return err
+		}
+		state := c.FormValue("state")
+
+		// Run queries
+		ctx := c.Request().Context()
+		switch state {
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+				"invalid webhook state %s", state,
+			))
+		case "updated":
+			w, err := parseWebhookParams(c)
+			if err != nil {
+				return err
+			}
+			w.ID = webhookID
+			if err = h.is.UpdateWebhook(ctx, w); err != nil {
+				return err
+			}
+		case "deleted":
+			if err = h.is.DeleteWebhook(ctx, webhookID); err != nil {
+				return err
+			}
+			// Redirect user
+			return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/instruments/%d/webhooks", iid))
+		}
+
+		// Redirect user
+		return c.Redirect(
+			http.StatusSeeOther, fmt.Sprintf("/instruments/%d/webhooks/%d", iid, webhookID),
+		)
+	}
+}
+
+func (h *Handlers) HandleInstrumentWebhookRedeliverPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		iid, err := parseID[instruments.InstrumentID](c.Param("id"), "instrument")
+		if err != nil {
+			return err
+		}
+		webhookID, err := parseID[instruments.WebhookID](c.Param("webhookID"), "webhook")
+		if err != nil {
+			return err
+		}
+		deliveryID, err := parseID[instruments.WebhookDeliveryID](
+			c.Param("deliveryID"), "webhook delivery",
+		)
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		if _, err = h.wd.Redeliver(c.Request().Context(), webhookID, deliveryID); err != nil {
+			return err
+		}
+
+		// Redirect user
+		return c.Redirect(
+			http.StatusSeeOther, fmt.Sprintf("/instruments/%d/webhooks/%d", iid, webhookID),
+		)
+	}
+}
@@ -0,0 +1,73 @@
+// Package metrics contains the route handlers which expose the server's Prometheus metrics.
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sargassum-world/godest"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/conf"
+	"github.com/sargassum-world/pslive/internal/app/pslive/openapi"
+)
+
+type Handlers struct {
+	config  conf.MetricsConfig
+	metrics http.Handler
+}
+
+func New(config conf.MetricsConfig, registry *prometheus.Registry) *Handlers {
+	return &Handlers{
+		config: config,
+		metrics: promhttp.HandlerFor(registry, promhttp.HandlerOpts{
+			// Responses are already compressed by the server's gzip middleware
+			DisableCompression: true,
+		}),
+	}
+}
+
+func (h *Handlers) Register(er godest.EchoRouter, docs *openapi.Registry) {
+	er.GET("/metrics", h.HandleMetricsGet())
+	docs.Tag("metrics", "Monitoring of the server")
+	docs.Describe(http.MethodGet, "/metrics", openapi.Operation{
+		Summary: "Get the server's metrics in the Prometheus text format",
+		Description: "Metrics are only served if they're enabled in the server's configuration, " +
+			"and otherwise the route responds with a 404 error. Requests must provide the username " +
+			"and password set in the configuration with HTTP basic authentication.",
+		Tags: []string{"metrics"},
+		Responses: []openapi.Response{
+			{Status: http.StatusOK, ContentType: "text/plain"},
+		},
+		Auth: openapi.Auth{Anonymous: true},
+	})
+}
+
+func (h *Handlers) checkCredentials(r *http.Request) bool {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	usernameMatches := subtle.ConstantTimeCompare([]byte(username), []byte(h.config.Username))
+	passwordMatches := subtle.ConstantTimeCompare([]byte(password), []byte(h.config.Password))
+	return usernameMatches&passwordMatches == 1
+}
+
+func (h *Handlers) HandleMetricsGet() echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// Check authentication
+		if !h.config.Enabled {
+			return echo.NewHTTPError(http.StatusNotFound, "metrics are disabled")
+		}
+		if !h.checkCredentials(c.Request()) {
+			c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Basic realm="metrics"`)
+			return echo.NewHTTPError(http.StatusUnauthorized, "invalid metrics credentials")
+		}
+
+		// Produce output
+		h.metrics.ServeHTTP(c.Response(), c.Request())
+		return nil
+	}
+}
@@ -45,9 +45,12 @@ func (h *Handlers) Register(
 	hr := auth.NewHTTPRouter(er, ss)
 	// TODO: make and use a middleware which checks to ensure the users exist
 	tsr.SUB(
-		"/private-chats/:first/:second/chat/users", handling.HandlePresenceSub(h.r, ss, h.oc, h.ps),
+		"/private-chats/:first/:second/chat/users",
+		handling.HandlePresenceSub(h.r, ss, h.oc, h.ps, nil),
+	)
+	tsr.UNSUB(
+		"/private-chats/:first/:second/chat/users", handling.HandlePresenceUnsub(h.r, ss, h.ps, nil),
 	)
-	tsr.UNSUB("/private-chats/:first/:second/chat/users", handling.HandlePresenceUnsub(h.r, ss, h.ps))
 	tsr.SUB("/private-chats/:first/:second/chat/users/list", turbostreams.EmptyHandler)
 	tsr.MSG("/private-chats/:first/:second/chat/users/list", handling.HandleTSMsg(h.r, ss))
 	tsr.SUB("/private-chats/:first/:second/chat/messages", turbostreams.EmptyHandler)
@@ -55,6 +58,6 @@ func (h *Handlers) Register(
 	// TODO: add a paginated GET handler for chat messages to support chat history infiniscroll
 	// TODO: make the paginated GET handler check for user authorization to view the chat history
 	hr.POST("/private-chats/:first/:second/chat/messages", handling.HandleChatMessagesPost(
-		h.r, h.oc, h.azc, h.tsh, h.cs,
+		h.r, h.oc, h.azc, h.tsh, h.cs, nil,
 	))
 }
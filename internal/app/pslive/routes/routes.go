@@ -3,14 +3,20 @@ package routes
 
 import (
 	"github.com/sargassum-world/godest"
+	"github.com/sargassum-world/godest/database"
+	"github.com/sargassum-world/godest/opa"
 	"github.com/sargassum-world/godest/turbostreams"
 
 	"github.com/sargassum-world/pslive/internal/app/pslive/client"
+	"github.com/sargassum-world/pslive/internal/app/pslive/openapi"
+	"github.com/sargassum-world/pslive/internal/app/pslive/routes/apidocs"
 	"github.com/sargassum-world/pslive/internal/app/pslive/routes/assets"
 	"github.com/sargassum-world/pslive/internal/app/pslive/routes/auth"
 	"github.com/sargassum-world/pslive/internal/app/pslive/routes/cable"
+	"github.com/sargassum-world/pslive/internal/app/pslive/routes/health"
 	"github.com/sargassum-world/pslive/internal/app/pslive/routes/home"
 	"github.com/sargassum-world/pslive/internal/app/pslive/routes/instruments"
+	"github.com/sargassum-world/pslive/internal/app/pslive/routes/metrics"
 	"github.com/sargassum-world/pslive/internal/app/pslive/routes/privatechat"
 	"github.com/sargassum-world/pslive/internal/app/pslive/routes/users"
 	"github.com/sargassum-world/pslive/internal/app/pslive/routes/videostreams"
@@ -18,14 +24,23 @@ import (
 )
 
 type Handlers struct {
-	r       godest.TemplateRenderer
-	globals *client.Globals
+	r           godest.TemplateRenderer
+	globals     *client.Globals
+	dbEmbeds    database.Embeds
+	docs        *openapi.Registry
+	regoModules []opa.Module
 }
 
-func New(r godest.TemplateRenderer, globals *client.Globals) *Handlers {
+func New(
+	r godest.TemplateRenderer, globals *client.Globals, dbEmbeds database.Embeds,
+	docs *openapi.Registry, regoModules []opa.Module,
+) *Handlers {
 	return &Handlers{
-		r:       r,
-		globals: globals,
+		r:           r,
+		globals:     globals,
+		dbEmbeds:    dbEmbeds,
+		docs:        docs,
+		regoModules: regoModules,
 	}
 }
 
@@ -44,20 +59,35 @@ func (h *Handlers) Register(
 	cs := h.globals.Chat
 	vsb := h.globals.VSBroker
 
+	// Every route is registered through the docs router, so that it's listed in the API docs
+	er = h.docs.Router(er)
 	assets.RegisterStatic(er, em)
 	assets.NewTemplated(h.r).Register(er)
-	cable.New(
-		h.r, ss, h.globals.Base.CSRFChecker, acc, h.globals.Base.ACSigner, h.globals.Base.TSBroker,
+	cableHandlers := cable.New(
+		h.r, ss, h.globals.Base.CSRFChecker, azc, acc, h.globals.Base.ACSigner, h.globals.Base.TSBroker,
 		vsb, l,
-	).Register(er)
-	home.New(h.r, oc, is, ps).Register(er, ss)
+	)
+	cableHandlers.Register(er)
+	h.globals.Metrics.MustRegister(cableHandlers)
+	home.New(h.r, oc, azc, is, ps).Register(er, ss)
 	auth.New(h.r, ss, ac, oc, acc, ps, l).Register(er)
 	instruments.New(
-		h.r, oc, azc, tsh, is, h.globals.Planktoscopes, h.globals.InstrumentJobs, ps, cs, vsb,
-	).Register(er, tsr, vsr, ss)
+		h.r, oc, azc, tsh, is, h.globals.Planktoscopes, h.globals.GenericMQTT, h.globals.HTTPJSON,
+		h.globals.InstrumentJobs, h.globals.Control, h.globals.ControlLeases, ps, cs, vsb,
+		h.globals.Events, h.globals.Webhooks,
+	).Register(er, tsr, vsr, ss, h.docs)
 	privatechat.New(h.r, oc, azc, tsh, ps, cs).Register(er, tsr, ss)
-	users.New(h.r, ac, oc, azc, tsh, is, ps, cs).Register(er, tsr, ss)
-	videostreams.New(vsb).Register(er, vsr)
+	users.New(h.r, ac, oc, azc, tsh, is, ps, cs, h.globals.APITokens).Register(er, tsr, ss)
+	videostreams.New(vsb).Register(er, vsr, h.docs)
+	apidocs.New(h.docs).Register(er)
+	metrics.New(h.globals.Config.Metrics, h.globals.Metrics).Register(er, h.docs)
+	health.New(
+		h.globals.Config.Health, h.globals.Base.DB, h.dbEmbeds, azc, oc, is, h.globals.Planktoscopes,
+		vsb, l,
+	).Register(er, h.docs)
+	// Check that every route description matches a registered route and its route policy
+	h.docs.MustDocument()
+	h.docs.MustCheckPolicies(h.regoModules)
 
 	tsr.PUB("/*", turbostreams.EmptyHandler)
 	tsr.UNSUB("/*", turbostreams.EmptyHandler)
@@ -0,0 +1,185 @@
+package users
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/turbostreams"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
+	"github.com/sargassum-world/pslive/internal/clients/apitokens"
+	"github.com/sargassum-world/pslive/internal/clients/ory"
+)
+
+const apiTokensPartial = "users/api-tokens.partial.tmpl"
+
+// apiTokenLifetimes are the numbers of days for which users can choose to make API tokens valid.
+var apiTokenLifetimes []int = []int{7, 30, 90, 365}
+
+type APITokenViewData struct {
+	Token   apitokens.Token
+	Expired bool
+}
+
+type APITokensViewData struct {
+	IdentityID ory.IdentityID
+	Tokens     []APITokenViewData
+	Scopes     []apitokens.Scope
+	Lifetimes  []int
+	// NewSecret is the secret of a newly-created token, which can only be shown once.
+	NewSecret string
+}
+
+func getAPITokensViewData(
+	ctx context.Context, id ory.IdentityID, ts *apitokens.Store,
+) (vd APITokensViewData, err error) {
+	vd.IdentityID = id
+	vd.Scopes = apitokens.Scopes
+	vd.Lifetimes = apiTokenLifetimes
+	tokens, err := ts.GetTokensByIdentityID(ctx, apitokens.IdentityID(id))
+	if err != nil {
+		return APITokensViewData{}, err
+	}
+	now := time.Now()
+	vd.Tokens = make([]APITokenViewData, len(tokens))
+	for i, token := range tokens {
+		vd.Tokens[i] = APITokenViewData{
+			Token:   token,
+			Expired: token.Expired(now),
+		}
+	}
+	return vd, nil
+}
+
+func parseAPITokenLifetime(raw string) (time.Duration, error) {
+	days, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("invalid lifetime %s", raw))
+	}
+	for _, allowed := range apiTokenLifetimes {
+		if days == allowed {
+			const day = 24 * time.Hour
+			return time.Duration(days) * day, nil
+		}
+	}
+	return 0, echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+		"unsupported lifetime of %d days", days,
+	))
+}
+
+func parseAPITokenScopes(raw []string) ([]apitokens.Scope, error) {
+	scopes := make([]apitokens.Scope, 0, len(raw))
+	for _, rawScope := range raw {
+		scope, err := apitokens.ParseScope(rawScope)
+		if err != nil {
+			return nil, echo.NewHTTPError(http.StatusBadRequest, err.Error())
+		}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 0 {
+		return nil, echo.NewHTTPError(http.StatusBadRequest, "api token needs at least one scope")
+	}
+	return scopes, nil
+}
+
+func (h *Handlers) HandleAPITokensPost() auth.HTTPHandlerFunc {
+	t := apiTokensPartial
+	h.r.MustHave(t)
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		id := ory.IdentityID(c.Param("id"))
+		name := c.FormValue("name")
+		if name == "" {
+			return echo.NewHTTPError(http.StatusBadRequest, "api token needs a name")
+		}
+		lifetime, err := parseAPITokenLifetime(c.FormValue("lifetime"))
+		if err != nil {
+			return err
+		}
+		formParams, err := c.FormParams()
+		if err != nil {
+			return errors.Wrap(err, "couldn't parse form params")
+		}
+		scopes, err := parseAPITokenScopes(formParams["scope"])
+		if err != nil {
+			return err
+		}
+
+		// Run queries
+		ctx := c.Request().Context()
+		now := time.Now()
+		tokenID, secret, err := h.ts.AddToken(ctx, apitokens.Token{
+			IdentityID:     apitokens.IdentityID(id),
+			Name:           name,
+			Scopes:         scopes,
+			CreationTime:   now,
+			ExpirationTime: now.Add(lifetime),
+		})
+		if err != nil {
+			return err
+		}
+		c.Logger().Infof("api token %d was created for %s", tokenID, id)
+
+		// Render Turbo Stream if accepted
+		if turbostreams.Accepted(c.Request().Header) {
+			vd, err := getAPITokensViewData(ctx, id, h.ts)
+			if err != nil {
+				return err
+			}
+			// The secret isn't stored, so this is the only chance to show it to the user
+			vd.NewSecret = secret
+			return h.r.TurboStream(c.Response(), turbostreams.Message{
+				Action:   turbostreams.ActionReplace,
+				Target:   fmt.Sprintf("/users/%s/api-tokens", id),
+				Template: t,
+				Data: map[string]interface{}{
+					"APITokens": vd,
+					"Auth":      a,
+				},
+			})
+		}
+
+		// Redirect user
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/users/%s", id))
+	}
+}
+
+func (h *Handlers) HandleAPITokenPost() auth.HTTPHandlerFunc {
+	return func(c echo.Context, a auth.Auth) error {
+		// Parse params
+		id := ory.IdentityID(c.Param("id"))
+		const intBase = 10
+		const intWidth = 64
+		rawTokenID := c.Param("tokenID")
+		tokenID, err := strconv.ParseInt(rawTokenID, intBase, intWidth)
+		if err != nil {
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+				"invalid api token id %s", rawTokenID,
+			))
+		}
+		state := c.FormValue("state")
+
+		// Run queries
+		switch state {
+		default:
+			return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf(
+				"invalid api token state %s", state,
+			))
+		case "deleted":
+			if err = h.ts.DeleteToken(
+				c.Request().Context(), apitokens.IdentityID(id), apitokens.TokenID(tokenID),
+			); err != nil {
+				return err
+			}
+			c.Logger().Infof("api token %d of %s was revoked", tokenID, id)
+		}
+
+		// Redirect user
+		return c.Redirect(http.StatusSeeOther, fmt.Sprintf("/users/%s", id))
+	}
+}
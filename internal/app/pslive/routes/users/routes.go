@@ -9,6 +9,7 @@ import (
 
 	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
 	"github.com/sargassum-world/pslive/internal/app/pslive/handling"
+	"github.com/sargassum-world/pslive/internal/clients/apitokens"
 	"github.com/sargassum-world/pslive/internal/clients/chat"
 	"github.com/sargassum-world/pslive/internal/clients/instruments"
 	"github.com/sargassum-world/pslive/internal/clients/ory"
@@ -27,11 +28,13 @@ type Handlers struct {
 	is *instruments.Store
 	ps *presence.Store
 	cs *chat.Store
+	ts *apitokens.Store
 }
 
 func New(
 	r godest.TemplateRenderer, ac *authn.Client, oc *ory.Client, azc *auth.AuthzChecker,
 	tsh *turbostreams.Hub, is *instruments.Store, ps *presence.Store, cs *chat.Store,
+	ts *apitokens.Store,
 ) *Handlers {
 	return &Handlers{
 		r:   r,
@@ -42,6 +45,7 @@ func New(
 		is:  is,
 		ps:  ps,
 		cs:  cs,
+		ts:  ts,
 	}
 }
 
@@ -51,14 +55,16 @@ func (h *Handlers) Register(
 	hr := auth.NewHTTPRouter(er, ss)
 	hr.GET("/users", h.HandleUsersGet())
 	hr.GET("/users/:id", h.HandleUserGet())
-	tsr.SUB("/users/:id/chat/users", handling.HandlePresenceSub(h.r, ss, h.oc, h.ps))
-	tsr.UNSUB("/users/:id/chat/users", handling.HandlePresenceUnsub(h.r, ss, h.ps))
+	tsr.SUB("/users/:id/chat/users", handling.HandlePresenceSub(h.r, ss, h.oc, h.ps, nil))
+	tsr.UNSUB("/users/:id/chat/users", handling.HandlePresenceUnsub(h.r, ss, h.ps, nil))
 	tsr.SUB("/users/:id/chat/users/list", turbostreams.EmptyHandler)
 	tsr.MSG("/users/:id/chat/users/list", handling.HandleTSMsg(h.r, ss))
 	tsr.SUB("/users/:id/chat/messages", turbostreams.EmptyHandler)
 	tsr.MSG("/users/:id/chat/messages", handling.HandleTSMsg(h.r, ss))
 	// TODO: add a paginated GET handler for chat messages to support chat history infiniscroll
 	hr.POST("/users/:id/chat/messages", handling.HandleChatMessagesPost(
-		h.r, h.oc, h.azc, h.tsh, h.cs,
+		h.r, h.oc, h.azc, h.tsh, h.cs, nil,
 	))
+	hr.POST("/users/:id/api-tokens", h.HandleAPITokensPost())
+	hr.POST("/users/:id/api-tokens/:tokenID", h.HandleAPITokenPost())
 }
@@ -28,11 +28,13 @@ type UserViewData struct {
 	PrivateChatMessages     []handling.ChatMessageViewData
 
 	Instruments []instruments.Instrument
+
+	APITokens APITokensViewData
 }
 
 func getUserViewData(
 	ctx context.Context, id ory.IdentityID, a auth.Auth, oc *ory.Client,
-	is *instruments.Store, ps *presence.Store, cs *chat.Store,
+	azc *auth.AuthzChecker, is *instruments.Store, ps *presence.Store, cs *chat.Store,
 ) (vd UserViewData, err error) {
 	if vd.Identity, err = oc.GetIdentity(ctx, id); err != nil {
 		return UserViewData{}, err
@@ -84,9 +86,13 @@ func getUserViewData(
 	}
 
 	// Instruments
-	if vd.Instruments, err = is.GetInstrumentsByAdminID(ctx, instruments.AdminID(id)); err != nil {
+	administered, err := is.GetInstrumentsByAdminID(ctx, instruments.AdminID(id))
+	if err != nil {
 		return UserViewData{}, err
 	}
+	if vd.Instruments, err = handling.FilterListedInstruments(ctx, administered, a, azc); err != nil {
+		return UserViewData{}, errors.Wrapf(err, "couldn't filter instruments of user %s", id)
+	}
 	// TODO: we should adapt it into a []InstrumentViewData or something
 
 	return vd, nil
@@ -99,6 +105,7 @@ type UserViewAuthz struct {
 	GetPrivateChat   bool
 	SendPrivateChat  bool
 	CreateInstrument bool
+	ManageAPITokens  bool
 }
 
 func getUserViewAuthz(
@@ -158,6 +165,13 @@ func getUserViewAuthz(
 		}
 		return nil
 	})
+	eg.Go(func() (err error) {
+		path := fmt.Sprintf("/users/%s/api-tokens", id)
+		if authz.ManageAPITokens, err = azc.Allow(egctx, a, path, http.MethodPost, nil); err != nil {
+			return errors.Wrapf(err, "couldn't check authz for managing api tokens of user %s", id)
+		}
+		return nil
+	})
 	if err := eg.Wait(); err != nil {
 		return UserViewAuthz{}, err
 	}
@@ -173,13 +187,20 @@ func (h *Handlers) HandleUserGet() auth.HTTPHandlerFunc {
 
 		// Run queries
 		ctx := c.Request().Context()
-		userViewData, err := getUserViewData(ctx, id, a, h.oc, h.is, h.ps, h.cs)
+		userViewData, err := getUserViewData(ctx, id, a, h.oc, h.azc, h.is, h.ps, h.cs)
 		if err != nil {
 			return err
 		}
-		if a.Authorizations, err = getUserViewAuthz(ctx, id, a, h.azc); err != nil {
+		authz, err := getUserViewAuthz(ctx, id, a, h.azc)
+		if err != nil {
 			return err
 		}
+		a.Authorizations = authz
+		if authz.ManageAPITokens {
+			if userViewData.APITokens, err = getAPITokensViewData(ctx, id, h.ts); err != nil {
+				return err
+			}
+		}
 
 		// Produce output
 		return h.r.CacheablePage(c.Response(), c.Request(), t, userViewData, a)
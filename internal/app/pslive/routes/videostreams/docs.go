@@ -0,0 +1,126 @@
+package videostreams
+
+import (
+	"net/http"
+
+	"github.com/sargassum-world/pslive/internal/app/pslive/openapi"
+)
+
+// API Docs
+
+const (
+	jpegType  = "image/jpeg"
+	mjpegType = "multipart/x-mixed-replace"
+	// maxDimension is the largest image width or height which the API docs suggest.
+	maxDimension = 4096
+)
+
+func dimensionParams() []openapi.Param {
+	const defaultWidth = 800
+	const defaultHeight = 600
+	return []openapi.Param{
+		openapi.QueryParam(
+			"width", "Width of the image, in pixels",
+			openapi.Integer(1, maxDimension).WithDefault(defaultWidth),
+		),
+		openapi.QueryParam(
+			"height", "Height of the image, in pixels",
+			openapi.Integer(1, maxDimension).WithDefault(defaultHeight),
+		),
+	}
+}
+
+func qualityParam(defaultQuality int) openapi.Param {
+	const maxQuality = 100
+	return openapi.QueryParam(
+		"quality", "JPEG encoding quality",
+		openapi.Integer(1, maxQuality).WithDefault(defaultQuality),
+	)
+}
+
+func annotatedParam() openapi.Param {
+	return openapi.QueryParam(
+		"annotated", "Whether to overlay the stream's framerate and latency on each frame",
+		openapi.Boolean().WithDefault(false),
+	)
+}
+
+func urlParam() openapi.Param {
+	p := openapi.QueryParam(
+		"url", "URL of the external MJPEG stream, which must be query-escaped", openapi.String(),
+	)
+	p.Required = true
+	return p
+}
+
+func frameResponses() []openapi.Response {
+	return []openapi.Response{
+		{Status: http.StatusOK, Description: "The current frame", ContentType: jpegType},
+	}
+}
+
+func streamResponses() []openapi.Response {
+	return []openapi.Response{
+		{Status: http.StatusOK, Description: "An MJPEG stream of frames", ContentType: mjpegType},
+	}
+}
+
+func describeRoutes(docs *openapi.Registry) {
+	const tag = "video-streams"
+	const generatedQuality = 20
+	const externalQuality = 80
+	const externalHeight = 360
+	docs.Tag(tag, "Test patterns and proxies of external video streams")
+	public := openapi.Auth{Anonymous: true}
+	docs.Describe(http.MethodGet, "/video-streams/random-color/frame.jpeg", openapi.Operation{
+		Summary:   "Get an image of a random uniform color",
+		Tags:      []string{tag},
+		Params:    append(dimensionParams(), qualityParam(generatedQuality)),
+		Responses: frameResponses(),
+		Auth:      public,
+	})
+	docs.Describe(http.MethodGet, "/video-streams/random-color/stream.mjpeg", openapi.Operation{
+		Summary:   "Stream images of random uniform colors, once per second",
+		Tags:      []string{tag},
+		Params:    append(dimensionParams(), qualityParam(generatedQuality)),
+		Responses: streamResponses(),
+		Auth:      public,
+	})
+	docs.Describe(http.MethodGet, "/video-streams/animated-color/frame.jpeg", openapi.Operation{
+		Summary:   "Get the current image of a smoothly-changing uniform color",
+		Tags:      []string{tag},
+		Params:    append(dimensionParams(), qualityParam(generatedQuality)),
+		Responses: frameResponses(),
+		Auth:      public,
+	})
+	docs.Describe(http.MethodGet, "/video-streams/animated-color/stream.mjpeg", openapi.Operation{
+		Summary: "Stream images of a smoothly-changing uniform color",
+		Tags:    []string{tag},
+		Params: append(
+			dimensionParams(), qualityParam(generatedQuality), annotatedParam(),
+		),
+		Responses: streamResponses(),
+		Auth:      public,
+	})
+	docs.Describe(http.MethodGet, "/video-streams/external-stream/frame.jpeg", openapi.Operation{
+		Summary: "Get the current frame of an external MJPEG stream",
+		Tags:    []string{tag},
+		Params: []openapi.Param{
+			urlParam(),
+			openapi.QueryParam(
+				"height", "Height to resize the frame to, in pixels",
+				openapi.Integer(1, maxDimension).WithDefault(externalHeight),
+			),
+			qualityParam(externalQuality),
+		},
+		Responses: frameResponses(),
+		Auth:      public,
+	})
+	docs.Describe(http.MethodGet, "/video-streams/external-stream/stream.mjpeg", openapi.Operation{
+		Summary:   "Proxy an external MJPEG stream",
+		Tags:      []string{tag},
+		Params:    []openapi.Param{urlParam(), annotatedParam()},
+		Responses: streamResponses(),
+		Auth:      public,
+	})
+}
@@ -20,6 +20,14 @@ import (
 
 // Helpers
 
+// ExternalSourceTopic returns the video stream topic on which frames from the external source at
+// the URL are published.
+func ExternalSourceTopic(sourceURL string) string {
+	return fmt.Sprintf(
+		"/video-streams/external-stream/source.mjpeg?url=%s", url.QueryEscape(sourceURL),
+	)
+}
+
 func parseURLParam(raw string) (string, error) {
 	if raw == "" {
 		return "", errors.New("missing query param 'url' to specify the external source")
@@ -51,9 +59,7 @@ func (h *Handlers) HandleExternalSourceFrameGet() echo.HandlerFunc {
 
 		// Subscribe to source stream
 		ctx, cancel := context.WithCancel(c.Request().Context())
-		source := fmt.Sprintf(
-			"/video-streams/external-stream/source.mjpeg?url=%s", url.QueryEscape(sourceURL),
-		)
+		source := ExternalSourceTopic(sourceURL)
 		frameBuffer := h.vsb.Subscribe(ctx, source)
 
 		// Generate data
@@ -140,9 +146,7 @@ func (h *Handlers) HandleExternalSourceStreamGet() echo.HandlerFunc {
 
 		// Subscribe to source stream
 		ctx := c.Request().Context()
-		source := fmt.Sprintf(
-			"/video-streams/external-stream/source.mjpeg?url=%s", url.QueryEscape(sourceURL),
-		)
+		source := ExternalSourceTopic(sourceURL)
 		c.Logger().Debugf("subscribing to external stream source %s", sourceURL)
 		frameBuffer := h.vsb.Subscribe(ctx, source)
 
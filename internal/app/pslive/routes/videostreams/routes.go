@@ -8,6 +8,7 @@ import (
 
 	"github.com/sargassum-world/godest"
 
+	"github.com/sargassum-world/pslive/internal/app/pslive/openapi"
 	"github.com/sargassum-world/pslive/internal/clients/videostreams"
 )
 
@@ -32,7 +33,9 @@ func New(vsb *videostreams.Broker) *Handlers {
 	}
 }
 
-func (h *Handlers) Register(er godest.EchoRouter, vsr videostreams.Router) {
+func (h *Handlers) Register(
+	er godest.EchoRouter, vsr videostreams.Router, docs *openapi.Registry,
+) {
 	er.GET("/video-streams/random-color/frame.jpeg", h.HandleRandomColorFrameGet())
 	er.GET("/video-streams/random-color/stream.mjpeg", h.HandleRandomColorStreamGet())
 	er.GET("/video-streams/animated-color/frame.jpeg", h.HandleAnimatedColorFrameGet())
@@ -41,4 +44,5 @@ func (h *Handlers) Register(er godest.EchoRouter, vsr videostreams.Router) {
 	er.GET("/video-streams/external-stream/frame.jpeg", h.HandleExternalSourceFrameGet())
 	er.GET("/video-streams/external-stream/stream.mjpeg", h.HandleExternalSourceStreamGet())
 	vsr.PUB("/video-streams/external-stream/source.mjpeg", h.HandleExternalSourcePub())
+	describeRoutes(docs)
 }
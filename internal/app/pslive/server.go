@@ -3,7 +3,8 @@ package pslive
 
 import (
 	"context"
-	"fmt"
+	"crypto/tls"
+	"net"
 	"net/http"
 
 	"github.com/Masterminds/sprig/v3"
@@ -24,9 +25,11 @@ import (
 	"github.com/sargassum-world/pslive/internal/app/pslive/auth"
 	"github.com/sargassum-world/pslive/internal/app/pslive/client"
 	"github.com/sargassum-world/pslive/internal/app/pslive/conf"
+	"github.com/sargassum-world/pslive/internal/app/pslive/openapi"
 	"github.com/sargassum-world/pslive/internal/app/pslive/routes"
 	"github.com/sargassum-world/pslive/internal/app/pslive/routes/assets"
 	"github.com/sargassum-world/pslive/internal/app/pslive/tmplfunc"
+	"github.com/sargassum-world/pslive/internal/clients/tlscerts"
 	"github.com/sargassum-world/pslive/web"
 )
 
@@ -38,6 +41,11 @@ type Server struct {
 	Renderer godest.TemplateRenderer
 	Handlers *routes.Handlers
 	Workers  []Worker
+
+	// TLSCerts is nil unless the server is configured to serve HTTPS.
+	TLSCerts *tlscerts.Reloader
+	// HTTPSRedirect is nil unless the server is configured to redirect HTTP requests to HTTPS.
+	HTTPSRedirect *http.Server
 }
 
 func RegoDeps() []opa.Module {
@@ -76,8 +84,32 @@ func NewServer(config conf.Config, workers []Worker, logger godest.Logger) (s *S
 		return nil, errors.Wrap(err, "couldn't make template renderer")
 	}
 
-	s.Handlers = routes.New(s.Renderer, s.Globals)
-	return s, err
+	s.Handlers = routes.New(s.Renderer, s.Globals, s.DBEmbeds, openapi.NewRegistry(
+		openapi.Info{
+			Title:       "Planktoscope Live",
+			Description: "Remote viewing and control of Planktoscope imaging instruments",
+			Version:     "1",
+		},
+		s.Globals.Base.Sessions.Config.CookieName, apiPathPrefix, APIError{},
+	), NewRegoModules())
+
+	if !config.HTTP.TLSEnabled() {
+		return s, nil
+	}
+	if s.TLSCerts, err = tlscerts.NewReloader(
+		config.HTTP.TLSCertFile, config.HTTP.TLSKeyFile, logger,
+	); err != nil {
+		return nil, errors.Wrap(err, "couldn't load tls certificate")
+	}
+	if config.HTTP.RedirectAddress == "" {
+		return s, nil
+	}
+	if s.HTTPSRedirect, err = newHTTPSRedirectServer(
+		config.HTTP.RedirectAddress, config.HTTP.Address,
+	); err != nil {
+		return nil, errors.Wrap(err, "couldn't make https redirect server")
+	}
+	return s, nil
 }
 
 // Echo
@@ -138,16 +170,21 @@ func (s *Server) configureHeaders(e *echo.Echo) error {
 			cspbuilder.BaseURI:        {"'none'"},
 			cspbuilder.FormAction:     {"'self'"},
 			cspbuilder.FrameAncestors: {"'none'"},
-			// TODO: add HTTPS-related settings for CSP, including upgrade-insecure-requests
 		},
 	}
+	var hstsMaxAge int64
+	if s.TLSCerts != nil {
+		cspBuilder.Directives[cspbuilder.UpgradeInsecureRequests] = []string{}
+		hstsMaxAge = s.Globals.Config.HTTP.HSTSMaxAge
+	}
 	csp, err := cspBuilder.Build()
 	if err != nil {
 		return errors.Wrap(err, "couldn't build content security policy")
 	}
 
 	e.Use(echo.WrapMiddleware(secure.New(secure.Options{
-		// TODO: add HTTPS options
+		// The Strict-Transport-Security header is only sent in responses to HTTPS requests
+		STSSeconds:              hstsMaxAge,
 		FrameDeny:               true,
 		ContentTypeNosniff:      true,
 		ContentSecurityPolicy:   csp,
@@ -161,6 +198,7 @@ func (s *Server) configureHeaders(e *echo.Echo) error {
 
 func (s *Server) Register(e *echo.Echo) error {
 	e.Use(middleware.Recover())
+	e.Use(NewHTTPMetricsMiddleware(s.Globals.Metrics))
 	s.configureLogging(e)
 	if err := s.configureHeaders(e); err != nil {
 		return errors.Wrap(err, "couldn't configure http headers")
@@ -174,11 +212,12 @@ func (s *Server) Register(e *echo.Echo) error {
 
 	// Other Middleware
 	e.Pre(middleware.RemoveTrailingSlash())
+	e.Use(auth.NewTokenMiddleware(s.Globals.APITokens))
 	e.Use(echo.WrapMiddleware(s.Globals.Base.Sessions.NewCSRFMiddleware(
 		csrf.ErrorHandler(NewCSRFErrorHandler(s.Renderer, e.Logger, s.Globals.Base.Sessions)),
 	)))
-	e.Use(gmw.RequireContentTypes(echo.MIMEApplicationForm))
-	// TODO: enable Prometheus and rate-limiting
+	e.Use(gmw.RequireContentTypes(echo.MIMEApplicationForm, echo.MIMEApplicationJSON))
+	// TODO: enable rate-limiting
 
 	// Authorization Middleware
 	e.Use(s.Globals.Base.AuthzChecker.NewHTTPMiddleware(s.Globals.Base.Sessions))
@@ -226,13 +265,36 @@ func (s *Server) runWorkersInContext(ctx context.Context) error {
 	return eg.Wait()
 }
 
-const port = 3000 // TODO: configure this with env var
-
 func (s *Server) Run(e *echo.Echo) error {
 	s.Globals.Base.Logger.Info("starting pslive server")
 	if err := s.openDB(context.Background()); err != nil {
 		return errors.Wrap(err, "couldn't open database")
 	}
+	if err := s.Globals.Instruments.CheckSecretsKey(context.Background()); err != nil {
+		return errors.Wrap(err, "couldn't check instruments secrets key")
+	}
+
+	// We listen on all addresses before serving on any of them, so that the server fails to start if
+	// it can't bind to one of its addresses
+	address := s.Globals.Config.HTTP.Address
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't listen on %s", address)
+	}
+	var redirectListener net.Listener
+	if s.HTTPSRedirect != nil {
+		if redirectListener, err = net.Listen("tcp", s.HTTPSRedirect.Addr); err != nil {
+			_ = listener.Close()
+			return errors.Wrapf(err, "couldn't listen on %s for https redirects", s.HTTPSRedirect.Addr)
+		}
+	}
+	if s.TLSCerts == nil {
+		e.Listener = listener
+	} else {
+		e.TLSServer.Addr = address
+		e.TLSServer.TLSConfig = s.newTLSConfig()
+		e.TLSListener = tls.NewListener(listener, e.TLSServer.TLSConfig)
+	}
 
 	// The echo http server can't be canceled by context cancelation, so the API shouldn't promise to
 	// stop blocking execution on context cancelation - so we use the background context here. The
@@ -249,10 +311,21 @@ func (s *Server) Run(e *echo.Echo) error {
 		return nil
 	})
 	eg.Go(func() error {
-		address := fmt.Sprintf(":%d", port)
-		s.Globals.Base.Logger.Infof("starting http server on %s", address)
-		return e.Start(address)
+		if s.TLSCerts == nil {
+			s.Globals.Base.Logger.Infof("starting http server on %s", address)
+			return e.Start(address)
+		}
+		s.Globals.Base.Logger.Infof("starting https server on %s", address)
+		return e.StartServer(e.TLSServer)
 	})
+	if s.HTTPSRedirect != nil {
+		eg.Go(func() error {
+			s.Globals.Base.Logger.Infof(
+				"starting http server on %s to redirect to https", s.HTTPSRedirect.Addr,
+			)
+			return s.HTTPSRedirect.Serve(redirectListener)
+		})
+	}
 	if err := eg.Wait(); err != http.ErrServerClosed {
 		return errors.Wrap(err, "http server encountered error")
 	}
@@ -267,6 +340,16 @@ func (s *Server) Shutdown(ctx context.Context, e *echo.Echo) (err error) {
 		s.Globals.Base.Logger.Error(errors.Wrap(errEcho, "couldn't shut down http server"))
 		err = errEcho
 	}
+	if s.HTTPSRedirect != nil {
+		if errRedirect := s.HTTPSRedirect.Shutdown(ctx); errRedirect != nil {
+			s.Globals.Base.Logger.Error(errors.Wrap(
+				errRedirect, "couldn't shut down https redirect server",
+			))
+			if err == nil {
+				err = errRedirect
+			}
+		}
+	}
 	if errDB := s.Globals.Base.DB.Close(); errDB != nil {
 		s.Globals.Base.Logger.Error(errors.Wrap(errDB, "couldn't close database"))
 		if err == nil {
@@ -279,10 +362,27 @@ func (s *Server) Shutdown(ctx context.Context, e *echo.Echo) (err error) {
 			err = errPO
 		}
 	}
+	if errGMO := s.Globals.GenericMQTT.Close(ctx); errGMO != nil {
+		s.Globals.Base.Logger.Error(errors.Wrap(errGMO, "couldn't close generic mqtt clients"))
+		if err == nil {
+			err = errGMO
+		}
+	}
+	if errHJO := s.Globals.HTTPJSON.Close(ctx); errHJO != nil {
+		s.Globals.Base.Logger.Error(errors.Wrap(errHJO, "couldn't close http json clients"))
+		if err == nil {
+			err = errHJO
+		}
+	}
 	s.Globals.InstrumentJobs.Close()
 	return err
 }
 
 func (s *Server) Close(e *echo.Echo) error {
+	if s.HTTPSRedirect != nil {
+		if err := s.HTTPSRedirect.Close(); err != nil {
+			return errors.Wrap(err, "https redirect server encountered error when closing its listener")
+		}
+	}
 	return errors.Wrap(e.Close(), "http server encountered error when closing an underlying listener")
 }
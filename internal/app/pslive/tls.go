@@ -0,0 +1,53 @@
+package pslive
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/unrolled/secure"
+)
+
+func (s *Server) newTLSConfig() *tls.Config {
+	return &tls.Config{
+		GetCertificate: s.TLSCerts.GetCertificate,
+		MinVersion:     tls.VersionTLS12,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+}
+
+// newHTTPSRedirectServer makes an HTTP server which listens on the address and permanently
+// redirects every request to the same host and path on the HTTPS address's port.
+func newHTTPSRedirectServer(address, httpsAddress string) (*http.Server, error) {
+	_, httpsPort, err := net.SplitHostPort(httpsAddress)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't parse https address %s", httpsAddress)
+	}
+	hostFunc := secure.SSLHostFunc(func(host string) string {
+		if hostname, _, err := net.SplitHostPort(host); err == nil {
+			host = hostname
+		}
+		host = strings.Trim(host, "[]") // IPv6 hosts without ports are still bracketed
+		if httpsPort == "443" {
+			if strings.Contains(host, ":") {
+				return "[" + host + "]"
+			}
+			return host
+		}
+		return net.JoinHostPort(host, httpsPort)
+	})
+	redirect := secure.New(secure.Options{
+		SSLRedirect: true,
+		SSLHostFunc: &hostFunc,
+	})
+
+	const readHeaderTimeout = 10 * time.Second
+	return &http.Server{
+		Addr:              address,
+		Handler:           redirect.Handler(http.NotFoundHandler()),
+		ReadHeaderTimeout: readHeaderTimeout,
+	}, nil
+}
@@ -42,7 +42,7 @@ func serveVSBroker(ctx context.Context, s *Server) error {
 
 func establishPlanktoscopeConnections(ctx context.Context, s *Server) error {
 	if err := workers.EstablishPlanktoscopeControllerConnections(
-		ctx, s.Globals.Instruments, s.Globals.Planktoscopes,
+		ctx, s.Globals.Instruments, s.Globals.Planktoscopes, s.Globals.Base.Logger,
 	); err != nil && err != context.Canceled {
 		l := s.Globals.Base.Logger
 		l.Error(errors.Wrap(err, "couldn't establish planktoscope controller connections"))
@@ -50,6 +50,26 @@ func establishPlanktoscopeConnections(ctx context.Context, s *Server) error {
 	return nil
 }
 
+func establishGenericMQTTConnections(ctx context.Context, s *Server) error {
+	if err := workers.EstablishGenericMQTTControllerConnections(
+		ctx, s.Globals.Instruments, s.Globals.GenericMQTT, s.Globals.Base.Logger,
+	); err != nil && err != context.Canceled {
+		l := s.Globals.Base.Logger
+		l.Error(errors.Wrap(err, "couldn't establish generic mqtt controller connections"))
+	}
+	return nil
+}
+
+func establishHTTPJSONConnections(ctx context.Context, s *Server) error {
+	if err := workers.EstablishHTTPJSONControllerConnections(
+		ctx, s.Globals.Instruments, s.Globals.HTTPJSON, s.Globals.Base.Logger,
+	); err != nil && err != context.Canceled {
+		l := s.Globals.Base.Logger
+		l.Error(errors.Wrap(err, "couldn't establish http json controller connections"))
+	}
+	return nil
+}
+
 func orchestrateInstrumentJobs(ctx context.Context, s *Server) error {
 	if err := s.Globals.InstrumentJobs.Orchestrate(ctx); err != nil && err != context.Canceled {
 		l := s.Globals.Base.Logger
@@ -60,7 +80,7 @@ func orchestrateInstrumentJobs(ctx context.Context, s *Server) error {
 
 func startInstrumentJobs(ctx context.Context, s *Server) error {
 	if err := workers.StartInstrumentJobs(
-		ctx, s.Globals.Instruments, s.Globals.InstrumentJobs,
+		ctx, s.Globals.Instruments, s.Globals.InstrumentJobs, s.Globals.Base.Logger,
 	); err != nil && err != context.Canceled {
 		l := s.Globals.Base.Logger
 		l.Error(errors.Wrap(err, "couldn't start automation jobs"))
@@ -68,13 +88,59 @@ func startInstrumentJobs(ctx context.Context, s *Server) error {
 	return nil
 }
 
+func expireControlLeases(ctx context.Context, s *Server) error {
+	if err := workers.ExpireControlLeases(
+		ctx, s.Globals.ControlLeases, s.Globals.Presence,
+	); err != nil && err != context.Canceled {
+		l := s.Globals.Base.Logger
+		l.Error(errors.Wrap(err, "couldn't expire control leases"))
+	}
+	return nil
+}
+
+func dispatchWebhooks(ctx context.Context, s *Server) error {
+	if err := s.Globals.Webhooks.Dispatch(ctx); err != nil && err != context.Canceled {
+		l := s.Globals.Base.Logger
+		l.Error(errors.Wrap(err, "webhook dispatcher encountered error while dispatching"))
+	}
+	return nil
+}
+
+func triggerPlanktoscopeWebhooks(ctx context.Context, s *Server) error {
+	if err := workers.TriggerPlanktoscopeWebhooks(
+		ctx, s.Globals.Instruments, s.Globals.Planktoscopes, s.Globals.Webhooks, s.Globals.Base.Logger,
+	); err != nil && err != context.Canceled {
+		l := s.Globals.Base.Logger
+		l.Error(errors.Wrap(err, "couldn't trigger planktoscope webhooks"))
+	}
+	return nil
+}
+
+func reloadTLSCertificate(ctx context.Context, s *Server) error {
+	if s.TLSCerts == nil {
+		return nil
+	}
+	const interval = 10 * time.Second
+	if err := s.TLSCerts.PeriodicallyReload(ctx, interval); err != nil && err != context.Canceled {
+		l := s.Globals.Base.Logger
+		l.Error(errors.Wrap(err, "couldn't periodically reload tls certificate"))
+	}
+	return nil
+}
+
 func DefaultWorkers() []Worker {
 	return []Worker{
 		periodicallyCleanupSessions,
 		serveTSBroker,
 		serveVSBroker,
 		establishPlanktoscopeConnections,
+		establishGenericMQTTConnections,
+		establishHTTPJSONConnections,
 		orchestrateInstrumentJobs,
 		startInstrumentJobs,
+		expireControlLeases,
+		dispatchWebhooks,
+		triggerPlanktoscopeWebhooks,
+		reloadTLSCertificate,
 	}
 }
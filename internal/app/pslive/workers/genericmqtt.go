@@ -0,0 +1,40 @@
+package workers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+
+	"github.com/sargassum-world/pslive/internal/clients/genericmqtt"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
+)
+
+func EstablishGenericMQTTControllerConnections(
+	ctx context.Context, is *instruments.Store, gmo *genericmqtt.Orchestrator, l godest.Logger,
+) error {
+	initialClients, err := is.GetEnabledControllersByProtocol(ctx, genericmqtt.Protocol)
+	if err != nil {
+		return errors.Wrap(err, "couldn't determine which generic mqtt controllers to connect to")
+	}
+	for _, client := range initialClients {
+		auth, err := is.GetControllerMQTTAuth(ctx, client.ID)
+		if err != nil {
+			l.Error(errors.Wrapf(err, "couldn't look up mqtt auth for controller %d", client.ID))
+			continue
+		}
+		schema, err := is.GetControllerSchema(ctx, client.ID)
+		if err != nil {
+			l.Error(errors.Wrapf(err, "couldn't look up schema for controller %d", client.ID))
+			continue
+		}
+		if err := gmo.Add(
+			genericmqtt.ClientID(client.ID), client.URL, planktoscope.MQTTAuth(auth), schema,
+		); err != nil {
+			l.Error(errors.Wrapf(err, "couldn't add client for controller %d", client.ID))
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+package workers
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+
+	"github.com/sargassum-world/pslive/internal/clients/httpjson"
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+)
+
+func EstablishHTTPJSONControllerConnections(
+	ctx context.Context, is *instruments.Store, hjo *httpjson.Orchestrator, l godest.Logger,
+) error {
+	initialClients, err := is.GetEnabledControllersByProtocol(ctx, httpjson.Protocol)
+	if err != nil {
+		return errors.Wrap(err, "couldn't determine which http json controllers to connect to")
+	}
+	for _, client := range initialClients {
+		schema, err := is.GetControllerSchema(ctx, client.ID)
+		if err != nil {
+			l.Error(errors.Wrapf(err, "couldn't look up schema for controller %d", client.ID))
+			continue
+		}
+		if err := hjo.Add(httpjson.ClientID(client.ID), client.URL, schema); err != nil {
+			l.Error(errors.Wrapf(err, "couldn't add client for controller %d", client.ID))
+		}
+	}
+
+	return nil
+}
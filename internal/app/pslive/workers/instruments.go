@@ -2,24 +2,58 @@ package workers
 
 import (
 	"context"
+	"fmt"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
 
 	"github.com/sargassum-world/pslive/internal/clients/instruments"
+	"github.com/sargassum-world/pslive/internal/clients/presence"
 )
 
 func StartInstrumentJobs(
-	ctx context.Context, is *instruments.Store, ajo *instruments.JobOrchestrator,
+	ctx context.Context, is *instruments.Store, ajo *instruments.JobOrchestrator, l godest.Logger,
 ) error {
+	stops, err := is.GetEmergencyStops(ctx)
+	if err != nil {
+		return errors.Wrap(err, "couldn't determine which instruments are emergency-stopped")
+	}
+	for _, stop := range stops {
+		if err := ajo.Halt(ctx, stop.InstrumentID); err != nil {
+			return errors.Wrapf(err, "couldn't halt automation of instrument %d", stop.InstrumentID)
+		}
+	}
+
 	initialJobs, err := is.GetEnabledAutomationJobs(ctx)
 	if err != nil {
 		return errors.Wrap(err, "couldn't determine which automation jobs to start")
 	}
 	for _, job := range initialJobs {
 		if err := ajo.Add(job.ID, job.InstrumentID, job.Name, job.Type, job.Specification); err != nil {
-			return err
+			// A job with an invalid specification shouldn't prevent the other jobs from starting
+			l.Error(errors.Wrapf(err, "couldn't start automation job %d", job.ID))
 		}
 	}
 
 	return nil
 }
+
+// ExpireControlLeases releases control leases on instruments once they expire, or once their
+// holders leave the instruments' pages.
+func ExpireControlLeases(
+	ctx context.Context, cl *instruments.ControlLeaser, ps *presence.Store,
+) error {
+	const interval = 5 * time.Second
+	return cl.PeriodicallyExpire(
+		ctx, interval, func(iid instruments.InstrumentID, identityID string) bool {
+			known, _ := ps.List(presence.Topic(fmt.Sprintf("/instruments/%d/users", iid)))
+			for _, user := range known {
+				if string(user.ID) == identityID {
+					return true
+				}
+			}
+			return false
+		},
+	)
+}
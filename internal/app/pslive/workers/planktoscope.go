@@ -5,21 +5,37 @@ import (
 	"context"
 
 	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
 
 	"github.com/sargassum-world/pslive/internal/clients/instruments"
 	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
 )
 
 func EstablishPlanktoscopeControllerConnections(
-	ctx context.Context, is *instruments.Store, pco *planktoscope.Orchestrator,
+	ctx context.Context, is *instruments.Store, pco *planktoscope.Orchestrator, l godest.Logger,
 ) error {
 	initialClients, err := is.GetEnabledControllersByProtocol(ctx, planktoscope.Protocol)
 	if err != nil {
 		return errors.Wrap(err, "couldn't determine which planktoscope controllers to connect to")
 	}
+	// A failure with one controller (e.g. a secret which can't be decrypted) shouldn't prevent
+	// connections to the other controllers
 	for _, client := range initialClients {
-		if err := pco.Add(planktoscope.ClientID(client.ID), client.URL); err != nil {
-			return err
+		auth, err := is.GetControllerMQTTAuth(ctx, client.ID)
+		if err != nil {
+			l.Error(errors.Wrapf(err, "couldn't look up mqtt auth for controller %d", client.ID))
+			continue
+		}
+		limits, err := is.GetControllerLimits(ctx, client.ID)
+		if err != nil {
+			l.Error(errors.Wrapf(err, "couldn't look up limits for controller %d", client.ID))
+			continue
+		}
+		if err := pco.Add(
+			ctx, planktoscope.ClientID(client.ID), client.URL,
+			planktoscope.MQTTAuth(auth), planktoscope.Limits(limits),
+		); err != nil {
+			l.Error(errors.Wrapf(err, "couldn't add client for controller %d", client.ID))
 		}
 	}
 
@@ -0,0 +1,159 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+
+	"github.com/sargassum-world/pslive/internal/clients/instruments"
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
+)
+
+type pumpDoneWebhookData struct {
+	ControllerID instruments.ControllerID `json:"controllerId"`
+	Start        time.Time                `json:"start"`
+	Duration     float64                  `json:"duration"` // sec
+}
+
+type imagerDoneWebhookData struct {
+	ControllerID   instruments.ControllerID `json:"controllerId"`
+	Start          time.Time                `json:"start"`
+	End            time.Time                `json:"end"`
+	LastStatus     string                   `json:"lastStatus"`
+	ImagesCaptured uint64                   `json:"imagesCaptured"`
+	Steps          uint64                   `json:"steps"`
+}
+
+type connectivityWebhookData struct {
+	ControllerID instruments.ControllerID `json:"controllerId"`
+	Connected    bool                     `json:"connected"`
+}
+
+func enqueuePlanktoscopeWebhooks(
+	ctx context.Context, controller instruments.Controller, pc *planktoscope.Client,
+	wd *instruments.WebhookDispatcher, logger godest.Logger,
+) error {
+	enqueue := func(eventType instruments.WebhookEventType, data interface{}) {
+		// The event should be recorded even if it coincides with the cancellation of the context
+		if err := wd.Enqueue(
+			context.Background(), controller.InstrumentID, eventType, data,
+		); err != nil {
+			logger.Error(errors.Wrapf(
+				err, "couldn't enqueue webhook deliveries for controller %d", controller.ID,
+			))
+		}
+	}
+
+	prevState := pc.GetState()
+	prevConnected := pc.HasConnection()
+	for {
+		// We must get the channels before checking the state, so that no broadcasts are missed
+		pumpB := pc.PumpStateBroadcasted()
+		imagerB := pc.ImagerStateBroadcasted()
+		connectionB := pc.ConnectionBroadcasted()
+		state := pc.GetState()
+		connected := pc.HasConnection()
+
+		if prevState.Pump.Pumping && !state.Pump.Pumping {
+			enqueue(instruments.WebhookEventPumpDone, pumpDoneWebhookData{
+				ControllerID: controller.ID,
+				Start:        prevState.Pump.Start,
+				Duration:     time.Since(prevState.Pump.Start).Seconds(),
+			})
+		}
+		if prevState.Imager.Imaging && !state.Imager.Imaging {
+			enqueue(instruments.WebhookEventImagerDone, imagerDoneWebhookData{
+				ControllerID:   controller.ID,
+				Start:          state.Imager.Start,
+				End:            state.Imager.End,
+				LastStatus:     state.Imager.LastStatus,
+				ImagesCaptured: state.Imager.ImagesCaptured,
+				Steps:          state.Imager.Steps,
+			})
+		}
+		if prevConnected != connected {
+			enqueue(instruments.WebhookEventControllerConnectivity, connectivityWebhookData{
+				ControllerID: controller.ID,
+				Connected:    connected,
+			})
+		}
+		prevState = state
+		prevConnected = connected
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-pumpB:
+		case <-imagerB:
+		case <-connectionB:
+		}
+	}
+}
+
+// TriggerPlanktoscopeWebhooks watches the states of the enabled planktoscope controllers and adds
+// webhook deliveries for the instruments' pump, imager, and connectivity events.
+func TriggerPlanktoscopeWebhooks(
+	ctx context.Context, is *instruments.Store, pco *planktoscope.Orchestrator,
+	wd *instruments.WebhookDispatcher, logger godest.Logger,
+) error {
+	// Controllers may be added, removed, or reconnected while we watch them, so we periodically
+	// look them up again
+	const refreshInterval = 5 * time.Second
+	type watch struct {
+		client *planktoscope.Client
+		cancel func()
+	}
+	watched := make(map[instruments.ControllerID]watch)
+	defer func() {
+		for _, w := range watched {
+			w.cancel()
+		}
+	}()
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
+	for {
+		controllers, err := is.GetEnabledControllersByProtocol(ctx, planktoscope.Protocol)
+		if err != nil {
+			return errors.Wrap(err, "couldn't determine which planktoscope controllers to watch")
+		}
+		current := make(map[instruments.ControllerID]struct{})
+		for _, controller := range controllers {
+			pc, ok := pco.Get(planktoscope.ClientID(controller.ID))
+			if !ok {
+				continue
+			}
+			current[controller.ID] = struct{}{}
+			if w, ok := watched[controller.ID]; ok {
+				if w.client == pc {
+					continue
+				}
+				w.cancel()
+			}
+			wctx, cancel := context.WithCancel(ctx)
+			watched[controller.ID] = watch{client: pc, cancel: cancel}
+			go func(controller instruments.Controller) {
+				if err := enqueuePlanktoscopeWebhooks(
+					wctx, controller, pc, wd, logger,
+				); err != nil && err != context.Canceled {
+					logger.Error(errors.Wrapf(
+						err, "couldn't watch controller %d for webhook events", controller.ID,
+					))
+				}
+			}(controller)
+		}
+		for cid, w := range watched {
+			if _, ok := current[cid]; !ok {
+				w.cancel()
+				delete(watched, cid)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
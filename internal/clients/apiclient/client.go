@@ -0,0 +1,222 @@
+// Package apiclient provides a client for the versioned JSON API of a Planktoscope Live server, as
+// described by the server's OpenAPI document at /api/v1/openapi.json.
+package apiclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// APIPrefix is the path prefix of the versioned JSON API.
+const APIPrefix = "/api/v1"
+
+// Client makes requests to the JSON API of a server.
+type Client struct {
+	// BaseURL is the URL of the server, e.g. https://live.planktoscope.community.
+	BaseURL string
+	// Token is a personal API token sent as a bearer token. Requests are anonymous if it's empty.
+	Token string
+	HTTP  *http.Client
+}
+
+func New(baseURL, token string) *Client {
+	return &Client{
+		BaseURL: strings.TrimSuffix(baseURL, "/"),
+		Token:   token,
+		HTTP:    http.DefaultClient,
+	}
+}
+
+// Errors
+
+// Error is an error response from the API.
+type Error struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+	// Fields describes the problem with each invalid field of the request, if any.
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Fields) == 0 {
+		return fmt.Sprintf("api error %d: %s", e.Status, e.Message)
+	}
+	names := make([]string, 0, len(e.Fields))
+	for name := range e.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	problems := make([]string, len(names))
+	for i, name := range names {
+		problems[i] = name + " " + e.Fields[name]
+	}
+	return fmt.Sprintf("api error %d: %s: %s", e.Status, e.Message, strings.Join(problems, "; "))
+}
+
+// IsStatus checks whether err is an error response from the API with the specified status code.
+func IsStatus(err error, status int) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.Status == status
+}
+
+func newError(res *http.Response, body []byte) error {
+	var errorBody struct {
+		Error *Error `json:"error"`
+	}
+	if err := json.Unmarshal(body, &errorBody); err != nil || errorBody.Error == nil {
+		message := strings.TrimSpace(string(body))
+		if contentType := res.Header.Get("Content-Type"); !strings.HasPrefix(contentType, "text/") &&
+			!strings.HasPrefix(contentType, "application/json") {
+			// Some routes respond to errors with a placeholder image, which isn't a useful message
+			message = http.StatusText(res.StatusCode)
+		}
+		return &Error{Status: res.StatusCode, Message: message}
+	}
+	errorBody.Error.Status = res.StatusCode
+	return errorBody.Error
+}
+
+// Requests
+
+// maxResponseSize is the largest response body which the client will read.
+const maxResponseSize = 16 << 20 // 16 MiB
+
+func (c *Client) newRequest(
+	ctx context.Context, method, path string, query url.Values, body interface{},
+) (*http.Request, error) {
+	u := c.BaseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var bodyReader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, errors.Wrapf(err, "couldn't encode request body for %s %s", method, path)
+		}
+		bodyReader = bytes.NewReader(encoded)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, bodyReader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't make request for %s %s", method, path)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	return req, nil
+}
+
+// send sends the request and returns the response body if the response was successful.
+func (c *Client) send(req *http.Request) (res *http.Response, body []byte, err error) {
+	if res, err = c.HTTP.Do(req); err != nil {
+		return nil, nil, errors.Wrapf(
+			err, "couldn't send request for %s %s", req.Method, req.URL.Path,
+		)
+	}
+	defer res.Body.Close()
+
+	if body, err = io.ReadAll(io.LimitReader(res.Body, maxResponseSize)); err != nil {
+		return nil, nil, errors.Wrapf(
+			err, "couldn't read response for %s %s", req.Method, req.URL.Path,
+		)
+	}
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return res, nil, errors.Wrapf(
+			newError(res, body), "%s %s failed", req.Method, req.URL.Path,
+		)
+	}
+	return res, body, nil
+}
+
+// do sends a JSON API request and decodes the JSON response body into result, unless result is
+// nil. It returns the status code of the response.
+func (c *Client) do(
+	ctx context.Context, method, path string, query url.Values, body, result interface{},
+) (status int, err error) {
+	req, err := c.newRequest(ctx, method, APIPrefix+path, query, body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	res, response, err := c.send(req)
+	if err != nil {
+		return 0, err
+	}
+	if result == nil {
+		return res.StatusCode, nil
+	}
+	if err = json.Unmarshal(response, result); err != nil {
+		return 0, errors.Wrapf(err, "couldn't parse response for %s %s", method, path)
+	}
+	return res.StatusCode, nil
+}
+
+// Documentation
+
+// GetOpenAPIDocument gets the OpenAPI document which describes the routes of the server.
+func (c *Client) GetOpenAPIDocument(ctx context.Context) (json.RawMessage, error) {
+	var doc json.RawMessage
+	_, err := c.do(ctx, http.MethodGet, "/openapi.json", nil, nil, &doc)
+	return doc, err
+}
+
+// Pagination
+
+// Page is a page of the items in a collection.
+type Page[Item any] struct {
+	Items  []Item `json:"items"`
+	Offset int    `json:"offset"`
+	Limit  int    `json:"limit"`
+	Total  int    `json:"total"`
+}
+
+// PageParams selects a page of the items in a collection. A zero Limit selects the server's default
+// page size.
+type PageParams struct {
+	Offset int
+	Limit  int
+}
+
+func (p PageParams) query() url.Values {
+	query := make(url.Values)
+	if p.Offset > 0 {
+		query.Set("offset", strconv.Itoa(p.Offset))
+	}
+	if p.Limit > 0 {
+		query.Set("limit", strconv.Itoa(p.Limit))
+	}
+	return query
+}
+
+// Optional Fields
+
+// Fields of update requests are pointers, so that fields left as nil keep their current values.
+
+func Bool(value bool) *bool {
+	return &value
+}
+
+func String(value string) *string {
+	return &value
+}
+
+func Float64(value float64) *float64 {
+	return &value
+}
+
+func Uint64(value uint64) *uint64 {
+	return &value
+}
@@ -0,0 +1,113 @@
+package apiclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Event types of the instrument events feed
+const (
+	PumpEventType      = "controller.pump"
+	CameraEventType    = "controller.camera"
+	ImagerEventType    = "controller.imager"
+	JobStatusEventType = "job.status"
+	ChatEventType      = "chat.message"
+	PresenceEventType  = "presence.count"
+	// ResetEventType is sent before the replayed events when some of the events after the last
+	// received event are no longer available, so clients should reload any state they have
+	// accumulated from events.
+	ResetEventType = "reset"
+)
+
+// Event is an event from the Server-Sent Events feed of an instrument.
+type Event struct {
+	ID   string
+	Type string
+	Data json.RawMessage
+}
+
+type ChatMessageEvent struct {
+	ID       int64     `json:"id"`
+	SendTime time.Time `json:"sendTime"`
+	Sender   string    `json:"sender"`
+	Body     string    `json:"body"`
+}
+
+// maxEventLineSize is the longest line of the events feed which the client will read.
+const maxEventLineSize = 1 << 20 // 1 MiB
+
+// readEvents parses the stream of Server-Sent Events and passes each event to the handler.
+func readEvents(r io.Reader, handler func(e Event) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxEventLineSize)
+	e := Event{}
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if len(data) > 0 {
+				e.Data = json.RawMessage(strings.Join(data, "\n"))
+				if err := handler(e); err != nil {
+					return err
+				}
+			}
+			e = Event{ID: e.ID}
+			data = nil
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // comments are used as keepalives
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			e.ID = value
+		case "event":
+			e.Type = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	return errors.Wrap(scanner.Err(), "couldn't read events")
+}
+
+// StreamInstrumentEvents receives the events of the instrument and passes each event to the
+// handler, until the context is canceled, the handler returns an error, or the server ends the
+// stream. If lastEventID is not empty, the server first replays the events after that event.
+// The stream can be resumed by calling this function again with the ID of the last event.
+func (c *Client) StreamInstrumentEvents(
+	ctx context.Context, iid InstrumentID, lastEventID string, handler func(e Event) error,
+) error {
+	// The events feed is served outside the versioned JSON API
+	req, err := c.newRequest(ctx, http.MethodGet, instrumentPath(iid)+"/events", nil, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't send request for %s %s", req.Method, req.URL.Path)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+		return errors.Wrapf(newError(res, body), "%s %s failed", req.Method, req.URL.Path)
+	}
+	if err = readEvents(res.Body, handler); err != nil && ctx.Err() != nil {
+		// The stream was closed because the context was canceled
+		return ctx.Err()
+	}
+	return err
+}
@@ -0,0 +1,341 @@
+package apiclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+type (
+	InstrumentID    int64
+	CameraID        int64
+	ControllerID    int64
+	AutomationJobID int64
+)
+
+// Instruments
+
+type Instrument struct {
+	ID          InstrumentID `json:"id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	AdminID     string       `json:"adminId"`
+	// Visibility is one of public, unlisted, or members.
+	Visibility string `json:"visibility"`
+}
+
+type InstrumentRequest struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Visibility  *string `json:"visibility,omitempty"`
+}
+
+func instrumentPath(id InstrumentID) string {
+	return fmt.Sprintf("/instruments/%d", id)
+}
+
+// ListInstruments lists the instruments which are listed publicly or visible to the client.
+func (c *Client) ListInstruments(
+	ctx context.Context, p PageParams,
+) (page Page[Instrument], err error) {
+	_, err = c.do(ctx, http.MethodGet, "/instruments", p.query(), nil, &page)
+	return page, err
+}
+
+func (c *Client) CreateInstrument(
+	ctx context.Context, req InstrumentRequest,
+) (instrument Instrument, err error) {
+	_, err = c.do(ctx, http.MethodPost, "/instruments", nil, req, &instrument)
+	return instrument, err
+}
+
+func (c *Client) GetInstrument(
+	ctx context.Context, id InstrumentID,
+) (instrument Instrument, err error) {
+	_, err = c.do(ctx, http.MethodGet, instrumentPath(id), nil, nil, &instrument)
+	return instrument, err
+}
+
+func (c *Client) UpdateInstrument(
+	ctx context.Context, id InstrumentID, req InstrumentRequest,
+) (instrument Instrument, err error) {
+	_, err = c.do(ctx, http.MethodPatch, instrumentPath(id), nil, req, &instrument)
+	return instrument, err
+}
+
+func (c *Client) DeleteInstrument(ctx context.Context, id InstrumentID) error {
+	_, err := c.do(ctx, http.MethodDelete, instrumentPath(id), nil, nil, nil)
+	return err
+}
+
+// Cameras
+
+type Camera struct {
+	ID           CameraID     `json:"id"`
+	InstrumentID InstrumentID `json:"instrumentId"`
+	Enabled      bool         `json:"enabled"`
+	Name         string       `json:"name"`
+	Description  string       `json:"description"`
+	Protocol     string       `json:"protocol"`
+	URL          string       `json:"url"`
+}
+
+type CameraRequest struct {
+	Enabled     *bool   `json:"enabled,omitempty"`
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Protocol    *string `json:"protocol,omitempty"`
+	URL         *string `json:"url,omitempty"`
+}
+
+func camerasPath(iid InstrumentID) string {
+	return instrumentPath(iid) + "/cameras"
+}
+
+func cameraPath(iid InstrumentID, id CameraID) string {
+	return fmt.Sprintf("%s/%d", camerasPath(iid), id)
+}
+
+func (c *Client) ListCameras(
+	ctx context.Context, iid InstrumentID, p PageParams,
+) (page Page[Camera], err error) {
+	_, err = c.do(ctx, http.MethodGet, camerasPath(iid), p.query(), nil, &page)
+	return page, err
+}
+
+func (c *Client) CreateCamera(
+	ctx context.Context, iid InstrumentID, req CameraRequest,
+) (camera Camera, err error) {
+	_, err = c.do(ctx, http.MethodPost, camerasPath(iid), nil, req, &camera)
+	return camera, err
+}
+
+func (c *Client) GetCamera(
+	ctx context.Context, iid InstrumentID, id CameraID,
+) (camera Camera, err error) {
+	_, err = c.do(ctx, http.MethodGet, cameraPath(iid, id), nil, nil, &camera)
+	return camera, err
+}
+
+func (c *Client) UpdateCamera(
+	ctx context.Context, iid InstrumentID, id CameraID, req CameraRequest,
+) (camera Camera, err error) {
+	_, err = c.do(ctx, http.MethodPatch, cameraPath(iid, id), nil, req, &camera)
+	return camera, err
+}
+
+func (c *Client) DeleteCamera(ctx context.Context, iid InstrumentID, id CameraID) error {
+	_, err := c.do(ctx, http.MethodDelete, cameraPath(iid, id), nil, nil, nil)
+	return err
+}
+
+// FrameParams adjusts the frame returned by GetCameraFrame. Zero values select the server's
+// defaults.
+type FrameParams struct {
+	// Height is the height to resize the frame to, in pixels.
+	Height int
+	// Quality is the JPEG encoding quality, from 1 to 100.
+	Quality int
+}
+
+// GetCameraFrame gets the current frame from the camera, as a JPEG image.
+func (c *Client) GetCameraFrame(
+	ctx context.Context, iid InstrumentID, id CameraID, p FrameParams,
+) (jpeg []byte, err error) {
+	query := make(url.Values)
+	if p.Height > 0 {
+		query.Set("height", strconv.Itoa(p.Height))
+	}
+	if p.Quality > 0 {
+		query.Set("quality", strconv.Itoa(p.Quality))
+	}
+	// Camera frames are served outside the versioned JSON API
+	req, err := c.newRequest(ctx, http.MethodGet, cameraPath(iid, id)+"/frame.jpeg", query, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "image/jpeg")
+	_, jpeg, err = c.send(req)
+	return jpeg, err
+}
+
+// Controllers
+
+// ControllerLimits bounds the settings of a controller's commands. A zero value for any limit
+// means that the setting is not bounded by that limit.
+type ControllerLimits struct {
+	MaxPumpVolume   float64 `json:"maxPumpVolume"`   // mL
+	MinPumpFlowrate float64 `json:"minPumpFlowrate"` // mL/min
+	MaxPumpFlowrate float64 `json:"maxPumpFlowrate"` // mL/min
+	MaxImagingSteps uint64  `json:"maxImagingSteps"`
+	MinISO          uint64  `json:"minIso"`
+	MaxISO          uint64  `json:"maxIso"`
+	MinShutterSpeed uint64  `json:"minShutterSpeed"` // μs
+	MaxShutterSpeed uint64  `json:"maxShutterSpeed"` // μs
+}
+
+// MQTTAuthStatus reports which MQTT credentials are set for a controller, since the credentials
+// themselves are never returned by the API.
+type MQTTAuthStatus struct {
+	HasUsername   bool `json:"hasUsername"`
+	HasPassword   bool `json:"hasPassword"`
+	HasCACert     bool `json:"hasCaCert"`
+	HasClientCert bool `json:"hasClientCert"`
+	HasClientKey  bool `json:"hasClientKey"`
+}
+
+type Controller struct {
+	ID            ControllerID     `json:"id"`
+	InstrumentID  InstrumentID     `json:"instrumentId"`
+	Enabled       bool             `json:"enabled"`
+	Name          string           `json:"name"`
+	Description   string           `json:"description"`
+	Protocol      string           `json:"protocol"`
+	URL           string           `json:"url"`
+	Schema        string           `json:"schema"`
+	Limits        ControllerLimits `json:"limits"`
+	RestoreCamera bool             `json:"restoreCamera"`
+	MQTTAuth      MQTTAuthStatus   `json:"mqttAuth"`
+}
+
+// ControllerLimitsRequest changes the limits of a controller. Limits left as nil keep their current
+// values, and limits set to zero are removed.
+type ControllerLimitsRequest struct {
+	MaxPumpVolume   *float64 `json:"maxPumpVolume,omitempty"`
+	MinPumpFlowrate *float64 `json:"minPumpFlowrate,omitempty"`
+	MaxPumpFlowrate *float64 `json:"maxPumpFlowrate,omitempty"`
+	MaxImagingSteps *uint64  `json:"maxImagingSteps,omitempty"`
+	MinISO          *uint64  `json:"minIso,omitempty"`
+	MaxISO          *uint64  `json:"maxIso,omitempty"`
+	MinShutterSpeed *uint64  `json:"minShutterSpeed,omitempty"`
+	MaxShutterSpeed *uint64  `json:"maxShutterSpeed,omitempty"`
+}
+
+// MQTTAuthRequest changes the MQTT credentials of a controller. Empty values leave the previous
+// values unchanged; they can only be removed by clearing all credentials.
+type MQTTAuthRequest struct {
+	Clear      bool   `json:"clear,omitempty"`
+	Username   string `json:"username,omitempty"`
+	Password   string `json:"password,omitempty"`
+	CACert     string `json:"caCert,omitempty"`
+	ClientCert string `json:"clientCert,omitempty"`
+	ClientKey  string `json:"clientKey,omitempty"`
+}
+
+type ControllerRequest struct {
+	Enabled       *bool                    `json:"enabled,omitempty"`
+	Name          *string                  `json:"name,omitempty"`
+	Description   *string                  `json:"description,omitempty"`
+	Protocol      *string                  `json:"protocol,omitempty"`
+	URL           *string                  `json:"url,omitempty"`
+	Schema        *string                  `json:"schema,omitempty"`
+	Limits        *ControllerLimitsRequest `json:"limits,omitempty"`
+	RestoreCamera *bool                    `json:"restoreCamera,omitempty"`
+	MQTTAuth      *MQTTAuthRequest         `json:"mqttAuth,omitempty"`
+}
+
+func controllersPath(iid InstrumentID) string {
+	return instrumentPath(iid) + "/controllers"
+}
+
+func controllerPath(iid InstrumentID, id ControllerID) string {
+	return fmt.Sprintf("%s/%d", controllersPath(iid), id)
+}
+
+func (c *Client) ListControllers(
+	ctx context.Context, iid InstrumentID, p PageParams,
+) (page Page[Controller], err error) {
+	_, err = c.do(ctx, http.MethodGet, controllersPath(iid), p.query(), nil, &page)
+	return page, err
+}
+
+func (c *Client) CreateController(
+	ctx context.Context, iid InstrumentID, req ControllerRequest,
+) (controller Controller, err error) {
+	_, err = c.do(ctx, http.MethodPost, controllersPath(iid), nil, req, &controller)
+	return controller, err
+}
+
+func (c *Client) GetController(
+	ctx context.Context, iid InstrumentID, id ControllerID,
+) (controller Controller, err error) {
+	_, err = c.do(ctx, http.MethodGet, controllerPath(iid, id), nil, nil, &controller)
+	return controller, err
+}
+
+func (c *Client) UpdateController(
+	ctx context.Context, iid InstrumentID, id ControllerID, req ControllerRequest,
+) (controller Controller, err error) {
+	_, err = c.do(ctx, http.MethodPatch, controllerPath(iid, id), nil, req, &controller)
+	return controller, err
+}
+
+func (c *Client) DeleteController(ctx context.Context, iid InstrumentID, id ControllerID) error {
+	_, err := c.do(ctx, http.MethodDelete, controllerPath(iid, id), nil, nil, nil)
+	return err
+}
+
+// Automation Jobs
+
+type AutomationJob struct {
+	ID            AutomationJobID `json:"id"`
+	InstrumentID  InstrumentID    `json:"instrumentId"`
+	Enabled       bool            `json:"enabled"`
+	Name          string          `json:"name"`
+	Description   string          `json:"description"`
+	Type          string          `json:"type"`
+	Specification string          `json:"specification"`
+}
+
+type AutomationJobRequest struct {
+	Enabled       *bool   `json:"enabled,omitempty"`
+	Name          *string `json:"name,omitempty"`
+	Description   *string `json:"description,omitempty"`
+	Type          *string `json:"type,omitempty"`
+	Specification *string `json:"specification,omitempty"`
+}
+
+func automationJobsPath(iid InstrumentID) string {
+	return instrumentPath(iid) + "/automation-jobs"
+}
+
+func automationJobPath(iid InstrumentID, id AutomationJobID) string {
+	return fmt.Sprintf("%s/%d", automationJobsPath(iid), id)
+}
+
+func (c *Client) ListAutomationJobs(
+	ctx context.Context, iid InstrumentID, p PageParams,
+) (page Page[AutomationJob], err error) {
+	_, err = c.do(ctx, http.MethodGet, automationJobsPath(iid), p.query(), nil, &page)
+	return page, err
+}
+
+func (c *Client) CreateAutomationJob(
+	ctx context.Context, iid InstrumentID, req AutomationJobRequest,
+) (job AutomationJob, err error) {
+	_, err = c.do(ctx, http.MethodPost, automationJobsPath(iid), nil, req, &job)
+	return job, err
+}
+
+func (c *Client) GetAutomationJob(
+	ctx context.Context, iid InstrumentID, id AutomationJobID,
+) (job AutomationJob, err error) {
+	_, err = c.do(ctx, http.MethodGet, automationJobPath(iid, id), nil, nil, &job)
+	return job, err
+}
+
+func (c *Client) UpdateAutomationJob(
+	ctx context.Context, iid InstrumentID, id AutomationJobID, req AutomationJobRequest,
+) (job AutomationJob, err error) {
+	_, err = c.do(ctx, http.MethodPatch, automationJobPath(iid, id), nil, req, &job)
+	return job, err
+}
+
+func (c *Client) DeleteAutomationJob(
+	ctx context.Context, iid InstrumentID, id AutomationJobID,
+) error {
+	_, err := c.do(ctx, http.MethodDelete, automationJobPath(iid, id), nil, nil, nil)
+	return err
+}
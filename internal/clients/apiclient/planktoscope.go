@@ -0,0 +1,146 @@
+package apiclient
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// State
+
+type Pump struct {
+	StateKnown bool       `json:"stateKnown"`
+	Pumping    bool       `json:"pumping"`
+	Start      *time.Time `json:"start,omitempty"`
+	Duration   float64    `json:"duration"` // sec
+	Deadline   *time.Time `json:"deadline,omitempty"`
+}
+
+type PumpSettings struct {
+	Forward  bool    `json:"forward"`
+	Volume   float64 `json:"volume"`   // mL
+	Flowrate float64 `json:"flowrate"` // mL/min
+}
+
+type CameraSettings struct {
+	StateKnown           bool    `json:"stateKnown"`
+	ISO                  uint64  `json:"iso"`
+	ShutterSpeed         uint64  `json:"shutterSpeed"` // μs
+	AutoWhiteBalance     bool    `json:"autoWhiteBalance"`
+	WhiteBalanceRedGain  float64 `json:"whiteBalanceRedGain"`
+	WhiteBalanceBlueGain float64 `json:"whiteBalanceBlueGain"`
+}
+
+type Imager struct {
+	StateKnown       bool       `json:"stateKnown"`
+	Imaging          bool       `json:"imaging"`
+	Start            *time.Time `json:"start,omitempty"`
+	End              *time.Time `json:"end,omitempty"`
+	LastStatus       string     `json:"lastStatus"`
+	ImagesCaptured   uint64     `json:"imagesCaptured"`
+	Steps            uint64     `json:"steps"`
+	StepDelay        float64    `json:"stepDelay"` // sec
+	LastImage        *time.Time `json:"lastImage,omitempty"`
+	ElapsedSeconds   float64    `json:"elapsedSeconds"`
+	RemainingSeconds float64    `json:"remainingSeconds"`
+}
+
+type ImagerSettings struct {
+	Forward    bool    `json:"forward"`
+	StepVolume float64 `json:"stepVolume"` // mL
+	StepDelay  float64 `json:"stepDelay"`  // sec
+	Steps      uint64  `json:"steps"`
+}
+
+// PlanktoscopeState is the live state of a Planktoscope controller.
+type PlanktoscopeState struct {
+	ControllerID   ControllerID   `json:"controllerId"`
+	Connected      bool           `json:"connected"`
+	Pump           Pump           `json:"pump"`
+	PumpSettings   PumpSettings   `json:"pumpSettings"`
+	CameraSettings CameraSettings `json:"cameraSettings"`
+	Imager         Imager         `json:"imager"`
+	ImagerSettings ImagerSettings `json:"imagerSettings"`
+}
+
+func (c *Client) GetPlanktoscopeState(
+	ctx context.Context, iid InstrumentID, cid ControllerID,
+) (state PlanktoscopeState, err error) {
+	_, err = c.do(ctx, http.MethodGet, controllerPath(iid, cid)+"/state", nil, nil, &state)
+	return state, err
+}
+
+// Commands
+
+// Settings left as nil in command requests keep their current values.
+
+type PumpRequest struct {
+	Pumping  bool     `json:"pumping"`
+	Forward  *bool    `json:"forward,omitempty"`
+	Volume   *float64 `json:"volume,omitempty"`   // mL
+	Flowrate *float64 `json:"flowrate,omitempty"` // mL/min
+}
+
+type CameraSettingsRequest struct {
+	ISO                  *uint64  `json:"iso,omitempty"`
+	ShutterSpeed         *uint64  `json:"shutterSpeed,omitempty"` // μs
+	AutoWhiteBalance     *bool    `json:"autoWhiteBalance,omitempty"`
+	WhiteBalanceRedGain  *float64 `json:"whiteBalanceRedGain,omitempty"`
+	WhiteBalanceBlueGain *float64 `json:"whiteBalanceBlueGain,omitempty"`
+}
+
+type ImagerRequest struct {
+	Imaging bool `json:"imaging"`
+	// SampleProjectID and SampleID override the IDs of the instrument's current sample, if set.
+	SampleProjectID string   `json:"sampleProjectId,omitempty"`
+	SampleID        string   `json:"sampleId,omitempty"`
+	Forward         *bool    `json:"forward,omitempty"`
+	StepVolume      *float64 `json:"stepVolume,omitempty"` // mL
+	StepDelay       *float64 `json:"stepDelay,omitempty"`  // sec
+	Steps           *uint64  `json:"steps,omitempty"`
+}
+
+// CommandResult is the state of a controller after a command was sent to it.
+type CommandResult struct {
+	State PlanktoscopeState
+	// Confirmed is set if the controller reported a state update after receiving the command.
+	// Otherwise, State is the state of the controller when the command was sent.
+	Confirmed bool
+}
+
+// runCommand sends a command to the controller. If wait is positive, the server waits up to that
+// long for the controller to report its new state; the maximum wait is 5 minutes.
+func (c *Client) runCommand(
+	ctx context.Context, iid InstrumentID, cid ControllerID, command string, wait time.Duration,
+	req interface{},
+) (result CommandResult, err error) {
+	query := make(url.Values)
+	if wait > 0 {
+		query.Set("wait", wait.String())
+	}
+	status, err := c.do(
+		ctx, http.MethodPost, controllerPath(iid, cid)+"/"+command, query, req, &result.State,
+	)
+	result.Confirmed = status == http.StatusOK
+	return result, err
+}
+
+func (c *Client) SetPump(
+	ctx context.Context, iid InstrumentID, cid ControllerID, wait time.Duration, req PumpRequest,
+) (CommandResult, error) {
+	return c.runCommand(ctx, iid, cid, "pump", wait, req)
+}
+
+func (c *Client) SetCameraSettings(
+	ctx context.Context, iid InstrumentID, cid ControllerID, wait time.Duration,
+	req CameraSettingsRequest,
+) (CommandResult, error) {
+	return c.runCommand(ctx, iid, cid, "camera", wait, req)
+}
+
+func (c *Client) SetImager(
+	ctx context.Context, iid InstrumentID, cid ControllerID, wait time.Duration, req ImagerRequest,
+) (CommandResult, error) {
+	return c.runCommand(ctx, iid, cid, "imager", wait, req)
+}
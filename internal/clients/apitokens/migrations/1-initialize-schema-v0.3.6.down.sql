@@ -0,0 +1,3 @@
+drop index apitokens_token_idx_identity_id;
+
+drop table apitokens_token;
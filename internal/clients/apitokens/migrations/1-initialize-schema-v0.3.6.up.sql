@@ -0,0 +1,17 @@
+-- API Tokens
+
+create table apitokens_token (
+  id              integer primary key,
+  identity_id     text    not null,
+  name            text    not null,
+  -- Only a hash of each token's secret is stored, so that the secrets can't be recovered from the
+  -- database
+  secret_hash     text    not null unique,
+  scopes          text    not null,
+  creation_time   integer not null,
+  expiration_time integer not null,
+  last_use_time   integer not null default 0
+) strict;
+
+create index apitokens_token_idx_identity_id
+on apitokens_token (identity_id);
@@ -0,0 +1,157 @@
+package apitokens
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"zombiezen.com/go/sqlite"
+)
+
+type (
+	TokenID    int64
+	IdentityID string
+)
+
+// Scope
+
+// Scope limits what an API token can be used to do.
+type Scope string
+
+const (
+	// ScopeInstrumentsRead allows viewing instruments and their cameras, even for instruments which
+	// are only visible to their members.
+	ScopeInstrumentsRead Scope = "instruments:read"
+	// ScopeInstrumentsManage allows creating instruments and changing the settings, members,
+	// cameras, controllers, and webhooks of instruments.
+	ScopeInstrumentsManage Scope = "instruments:manage"
+	// ScopeControllersControl allows operating the controllers of instruments.
+	ScopeControllersControl Scope = "controllers:control"
+	// ScopeJobsManage allows creating, changing, starting, and stopping automation jobs.
+	ScopeJobsManage Scope = "jobs:manage"
+)
+
+var Scopes []Scope = []Scope{
+	ScopeInstrumentsRead, ScopeInstrumentsManage, ScopeControllersControl, ScopeJobsManage,
+}
+
+func ParseScope(raw string) (Scope, error) {
+	for _, scope := range Scopes {
+		if Scope(raw) == scope {
+			return scope, nil
+		}
+	}
+	return "", errors.Errorf("unknown api token scope %s", raw)
+}
+
+func formatScopes(scopes []Scope) string {
+	raw := make([]string, len(scopes))
+	for i, scope := range scopes {
+		raw[i] = string(scope)
+	}
+	return strings.Join(raw, " ")
+}
+
+func parseScopes(raw string) []Scope {
+	fields := strings.Fields(raw)
+	scopes := make([]Scope, len(fields))
+	for i, field := range fields {
+		scopes[i] = Scope(field)
+	}
+	return scopes
+}
+
+// Token
+
+type Token struct {
+	ID             TokenID
+	IdentityID     IdentityID
+	Name           string
+	Scopes         []Scope
+	CreationTime   time.Time
+	ExpirationTime time.Time
+	// LastUseTime is the zero value if the token has never been used.
+	LastUseTime time.Time
+}
+
+func (t Token) Expired(now time.Time) bool {
+	return !now.Before(t.ExpirationTime)
+}
+
+func (t Token) HasScope(scope Scope) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func (t Token) newInsertion(secretHash string) map[string]interface{} {
+	return map[string]interface{}{
+		"$identity_id":     t.IdentityID,
+		"$name":            t.Name,
+		"$secret_hash":     secretHash,
+		"$scopes":          formatScopes(t.Scopes),
+		"$creation_time":   t.CreationTime.UnixMilli(),
+		"$expiration_time": t.ExpirationTime.UnixMilli(),
+	}
+}
+
+func (t Token) newLastUseUpdate() map[string]interface{} {
+	return map[string]interface{}{
+		"$id":            t.ID,
+		"$last_use_time": t.LastUseTime.UnixMilli(),
+	}
+}
+
+func (t Token) newDelete() map[string]interface{} {
+	return map[string]interface{}{
+		"$id":          t.ID,
+		"$identity_id": t.IdentityID,
+	}
+}
+
+// Tokens
+
+func newTokensByIdentityIDSelection(identityID IdentityID) map[string]interface{} {
+	return map[string]interface{}{
+		"$identity_id": identityID,
+	}
+}
+
+func newTokenBySecretHashSelection(secretHash string) map[string]interface{} {
+	return map[string]interface{}{
+		"$secret_hash": secretHash,
+	}
+}
+
+type tokensSelector struct {
+	tokens []Token
+}
+
+func newTokensSelector() *tokensSelector {
+	return &tokensSelector{
+		tokens: make([]Token, 0),
+	}
+}
+
+func (sel *tokensSelector) Step(s *sqlite.Stmt) error {
+	t := Token{
+		ID:             TokenID(s.GetInt64("id")),
+		IdentityID:     IdentityID(s.GetText("identity_id")),
+		Name:           s.GetText("name"),
+		Scopes:         parseScopes(s.GetText("scopes")),
+		CreationTime:   time.UnixMilli(s.GetInt64("creation_time")),
+		ExpirationTime: time.UnixMilli(s.GetInt64("expiration_time")),
+	}
+	if lastUseTime := s.GetInt64("last_use_time"); lastUseTime != 0 {
+		t.LastUseTime = time.UnixMilli(lastUseTime)
+	}
+	sel.tokens = append(sel.tokens, t)
+	return nil
+}
+
+func (sel *tokensSelector) Tokens() []Token {
+	return sel.tokens
+}
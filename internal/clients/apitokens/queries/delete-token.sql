@@ -0,0 +1,4 @@
+delete from apitokens_token
+where
+  apitokens_token.id = $id
+  and apitokens_token.identity_id = $identity_id
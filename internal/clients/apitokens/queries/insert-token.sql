@@ -0,0 +1,4 @@
+insert into apitokens_token (
+  identity_id, name, secret_hash, scopes, creation_time, expiration_time
+)
+values ($identity_id, $name, $secret_hash, $scopes, $creation_time, $expiration_time);
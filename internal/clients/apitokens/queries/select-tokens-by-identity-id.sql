@@ -0,0 +1,12 @@
+select
+  id              as id,
+  identity_id     as identity_id,
+  name            as name,
+  scopes          as scopes,
+  creation_time   as creation_time,
+  expiration_time as expiration_time,
+  last_use_time   as last_use_time
+from apitokens_token
+where
+  identity_id = $identity_id
+order by creation_time desc
@@ -0,0 +1,5 @@
+update apitokens_token
+set
+  last_use_time = $last_use_time
+where
+  apitokens_token.id = $id
@@ -0,0 +1,144 @@
+// Package apitokens provides a high-level store of personal API tokens for programmatic access
+package apitokens
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/database"
+)
+
+type Store struct {
+	db *database.DB
+}
+
+func NewStore(db *database.DB) *Store {
+	return &Store{
+		db: db,
+	}
+}
+
+// Secrets
+
+const (
+	secretPrefix = "pslive_"
+	secretLength = 32 // bytes
+)
+
+func newSecret() (string, error) {
+	raw := make([]byte, secretLength)
+	if _, err := rand.Read(raw); err != nil {
+		return "", errors.Wrap(err, "couldn't generate random bytes")
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// hashSecret hashes the secret for storage and lookup. Secrets are long random strings rather than
+// user-chosen passwords, so a fast unsalted hash is sufficient.
+func hashSecret(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
+
+// Tokens
+
+//go:embed queries/insert-token.sql
+var rawInsertTokenQuery string
+var insertTokenQuery string = strings.TrimSpace(rawInsertTokenQuery)
+
+// AddToken generates a secret for the token and stores the token. The secret is returned so that
+// it can be shown to the token's owner, but it can't be retrieved again later.
+func (s *Store) AddToken(ctx context.Context, t Token) (tokenID TokenID, secret string, err error) {
+	if secret, err = newSecret(); err != nil {
+		return 0, "", errors.Wrapf(err, "couldn't generate secret for api token of %s", t.IdentityID)
+	}
+	rowID, err := s.db.ExecuteInsertionForID(ctx, insertTokenQuery, t.newInsertion(hashSecret(secret)))
+	if err != nil {
+		return 0, "", errors.Wrapf(err, "couldn't add api token for %s", t.IdentityID)
+	}
+	return TokenID(rowID), secret, nil
+}
+
+//go:embed queries/select-tokens-by-identity-id.sql
+var rawSelectTokensByIdentityIDQuery string
+var selectTokensByIdentityIDQuery string = strings.TrimSpace(rawSelectTokensByIdentityIDQuery)
+
+func (s *Store) GetTokensByIdentityID(
+	ctx context.Context, identityID IdentityID,
+) (tokens []Token, err error) {
+	sel := newTokensSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectTokensByIdentityIDQuery, newTokensByIdentityIDSelection(identityID), sel.Step,
+	); err != nil {
+		return nil, errors.Wrapf(err, "couldn't get api tokens of %s", identityID)
+	}
+	return sel.Tokens(), nil
+}
+
+//go:embed queries/select-token-by-secret-hash.sql
+var rawSelectTokenBySecretHashQuery string
+var selectTokenBySecretHashQuery string = strings.TrimSpace(rawSelectTokenBySecretHashQuery)
+
+//go:embed queries/update-token-last-use.sql
+var rawUpdateTokenLastUseQuery string
+var updateTokenLastUseQuery string = strings.TrimSpace(rawUpdateTokenLastUseQuery)
+
+// lastUseResolution is how stale the recorded last use time of a token may become, so that we
+// don't write to the database on every request made with a token.
+const lastUseResolution = time.Minute
+
+// Authenticate looks up the unexpired token with the secret, if there is one, and records that the
+// token was used.
+func (s *Store) Authenticate(ctx context.Context, secret string) (t Token, ok bool, err error) {
+	if !strings.HasPrefix(secret, secretPrefix) {
+		return Token{}, false, nil
+	}
+	sel := newTokensSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectTokenBySecretHashQuery, newTokenBySecretHashSelection(hashSecret(secret)),
+		sel.Step,
+	); err != nil {
+		return Token{}, false, errors.Wrap(err, "couldn't look up api token")
+	}
+	tokens := sel.Tokens()
+	if len(tokens) == 0 {
+		return Token{}, false, nil
+	}
+	t = tokens[0]
+	now := time.Now()
+	if t.Expired(now) {
+		return Token{}, false, nil
+	}
+
+	if now.Sub(t.LastUseTime) < lastUseResolution {
+		return t, true, nil
+	}
+	t.LastUseTime = now
+	if err = s.db.ExecuteUpdate(ctx, updateTokenLastUseQuery, t.newLastUseUpdate()); err != nil {
+		return Token{}, false, errors.Wrapf(err, "couldn't record use of api token %d", t.ID)
+	}
+	return t, true, nil
+}
+
+//go:embed queries/delete-token.sql
+var rawDeleteTokenQuery string
+var deleteTokenQuery string = strings.TrimSpace(rawDeleteTokenQuery)
+
+// DeleteToken revokes the token, if it belongs to the identity.
+func (s *Store) DeleteToken(ctx context.Context, identityID IdentityID, id TokenID) error {
+	t := Token{
+		ID:         id,
+		IdentityID: identityID,
+	}
+	return errors.Wrapf(
+		s.db.ExecuteDelete(ctx, deleteTokenQuery, t.newDelete()),
+		"couldn't delete api token %d of %s", id, identityID,
+	)
+}
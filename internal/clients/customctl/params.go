@@ -0,0 +1,143 @@
+// Package customctl provides the params, payload templates, and state values shared by clients
+// for controllers whose commands and states are described by a user-defined schema
+package customctl
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+const (
+	ParamString  = "string"
+	ParamNumber  = "number"
+	ParamBoolean = "boolean"
+)
+
+// Params are the inputs of a command.
+type Params []Param
+
+// Param is a named input of a command. Params without a default value are required.
+type Param struct {
+	Name    string  `json:"name"`
+	Type    string  `json:"type"`
+	Default *string `json:"default,omitempty"`
+}
+
+func (p Param) HasDefault() bool {
+	return p.Default != nil
+}
+
+func (p Param) DefaultValue() string {
+	if p.Default == nil {
+		return ""
+	}
+	return *p.Default
+}
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+// ValidateName checks that a command, param, or state name is usable in URLs and automation job
+// specifications, and that it hasn't already been seen.
+func ValidateName(name, typeName string, seen map[string]bool) error {
+	if !namePattern.MatchString(name) {
+		return errors.Errorf(
+			"%s name %q must only have letters, digits, underscores, and hyphens", typeName, name,
+		)
+	}
+	if seen[name] {
+		return errors.Errorf("duplicate %s name %s", typeName, name)
+	}
+	seen[name] = true
+	return nil
+}
+
+// Validate checks the names, types, and default values of the params.
+func (ps Params) Validate() error {
+	seen := make(map[string]bool)
+	for _, p := range ps {
+		if err := ValidateName(p.Name, "param", seen); err != nil {
+			return err
+		}
+		switch p.Type {
+		default:
+			return errors.Errorf("param %s has unknown type %s", p.Name, p.Type)
+		case ParamString, ParamNumber, ParamBoolean:
+		}
+		if p.Default == nil {
+			continue
+		}
+		if _, err := p.parse(*p.Default); err != nil {
+			return errors.Wrapf(err, "invalid default value for param %s", p.Name)
+		}
+	}
+	return nil
+}
+
+func (p Param) parse(raw string) (interface{}, error) {
+	switch p.Type {
+	default:
+		return nil, errors.Errorf("unknown param type %s", p.Type)
+	case ParamString:
+		return raw, nil
+	case ParamNumber:
+		const floatWidth = 64
+		value, err := strconv.ParseFloat(strings.TrimSpace(raw), floatWidth)
+		return value, errors.Wrapf(err, "couldn't parse %s as a number", raw)
+	case ParamBoolean:
+		value, err := strconv.ParseBool(strings.TrimSpace(raw))
+		return value, errors.Wrapf(err, "couldn't parse %s as a boolean", raw)
+	}
+}
+
+// Parse converts raw param values into typed values. Unknown params are rejected, and missing
+// params take their default values.
+func (ps Params) Parse(rawParams map[string]string) (params map[string]interface{}, err error) {
+	params = make(map[string]interface{})
+	for _, p := range ps {
+		raw, ok := rawParams[p.Name]
+		if !ok {
+			if p.Default == nil {
+				return nil, errors.Errorf("missing param %s", p.Name)
+			}
+			raw = *p.Default
+		}
+		if params[p.Name], err = p.parse(raw); err != nil {
+			return nil, errors.Wrapf(err, "invalid param %s", p.Name)
+		}
+	}
+	for name := range rawParams {
+		if _, ok := params[name]; !ok {
+			return nil, errors.Errorf("unknown param %s", name)
+		}
+	}
+	return params, nil
+}
+
+// DecodeParams converts the params of a controller action into raw param values.
+func DecodeParams(params hcl.Body) (rawParams map[string]string, err error) {
+	attributes, diags := params.JustAttributes()
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	rawParams = make(map[string]string)
+	for name, attribute := range attributes {
+		value, diags := attribute.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		if value, err = convert.Convert(value, cty.String); err != nil {
+			return nil, errors.Wrapf(err, "param %s isn't a string, number, or boolean", name)
+		}
+		if value.IsNull() || !value.IsKnown() {
+			return nil, errors.Errorf("param %s has no value", name)
+		}
+		rawParams[name] = value.AsString()
+	}
+	return rawParams, nil
+}
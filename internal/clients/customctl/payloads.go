@@ -0,0 +1,37 @@
+package customctl
+
+import (
+	"bytes"
+	"encoding/json"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"json": func(v interface{}) (string, error) {
+			marshaled, err := json.Marshal(v)
+			return string(marshaled), err
+		},
+	}
+}
+
+// ParsePayloadTemplate parses a Go text/template for a message payload, in which the `json`
+// function encodes a param as a JSON value.
+func ParsePayloadTemplate(name, raw string) (*template.Template, error) {
+	t, err := template.New(name).Funcs(templateFuncs()).Option("missingkey=error").Parse(raw)
+	return t, errors.Wrap(err, "couldn't parse payload template")
+}
+
+// RenderPayload executes a payload template with typed param values.
+func RenderPayload(t *template.Template, params map[string]interface{}) ([]byte, error) {
+	if t == nil {
+		return nil, errors.New("payload template wasn't parsed")
+	}
+	buf := &bytes.Buffer{}
+	if err := t.Execute(buf, params); err != nil {
+		return nil, errors.Wrap(err, "couldn't render payload template")
+	}
+	return buf.Bytes(), nil
+}
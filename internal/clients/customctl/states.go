@@ -0,0 +1,66 @@
+package customctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// StateValue is the most recent value of a state defined in a controller's schema.
+type StateValue struct {
+	Name    string
+	Unit    string
+	Known   bool
+	Value   interface{}
+	Updated time.Time
+}
+
+// Display formats the value for showing to users.
+func (v StateValue) Display() string {
+	if !v.Known {
+		return ""
+	}
+	switch value := v.Value.(type) {
+	default:
+		marshaled, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Sprint(value)
+		}
+		return string(marshaled)
+	case string:
+		return value
+	case float64, bool:
+		return fmt.Sprint(value)
+	}
+}
+
+// LookupPath looks up the value at a dot-separated path of object keys and array indices in a
+// decoded JSON value.
+func LookupPath(value interface{}, path string) (interface{}, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	if path == "" {
+		return value, nil
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		default:
+			return nil, errors.Errorf("couldn't look up %s in a non-container value", key)
+		case map[string]interface{}:
+			var ok bool
+			if value, ok = v[key]; !ok {
+				return nil, errors.Errorf("couldn't find key %s", key)
+			}
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, errors.Errorf("couldn't find index %s", key)
+			}
+			value = v[index]
+		}
+	}
+	return value, nil
+}
@@ -0,0 +1,218 @@
+// Package events provides an in-memory broker of typed events published on topics, with a short
+// history of each topic's events so that subscribers can resume after reconnecting.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+)
+
+type (
+	Topic   string
+	EventID uint64
+)
+
+type Event struct {
+	ID   EventID
+	Type string
+	Time time.Time
+	Data json.RawMessage
+}
+
+// Producer publishes events on a topic until its context is canceled. A topic's producer only
+// runs while the topic has subscribers, so events published by producers aren't recorded in the
+// topic's history while nobody is listening.
+type Producer func(ctx context.Context, topic Topic) error
+
+const (
+	historySize   = 256
+	historyMaxAge = 10 * time.Minute
+	// subscriberBuffer is the number of events which can be queued for a subscriber before it's
+	// considered too slow and dropped.
+	subscriberBuffer = 64
+)
+
+type topicState struct {
+	history []Event // oldest first
+	// since is the ID of the most recent event which is no longer in the history, or which may have
+	// been missed because it was published before the topic's history started. When the topic's
+	// producer starts, since is set to a newly-reserved ID which is never used for an event, because
+	// events may have been missed while the producer wasn't running.
+	since          EventID
+	subscribers    map[chan Event]struct{}
+	cancelProducer func()
+}
+
+type Broker struct {
+	topics map[Topic]*topicState
+	nextID EventID
+	mu     *sync.Mutex
+
+	logger godest.Logger
+}
+
+func NewBroker(logger godest.Logger) *Broker {
+	return &Broker{
+		topics: make(map[Topic]*topicState),
+		// Event IDs are seeded from the time when the broker was created, so that event IDs issued
+		// before a restart of the server are always older than the IDs issued after it.
+		nextID: EventID(time.Now().UnixMicro()),
+		mu:     &sync.Mutex{},
+		logger: logger,
+	}
+}
+
+// getTopic returns the topic's state, creating it if necessary. The broker's mutex must be held.
+func (b *Broker) getTopic(topic Topic) *topicState {
+	t, ok := b.topics[topic]
+	if !ok {
+		t = &topicState{
+			since:       b.nextID - 1,
+			subscribers: make(map[chan Event]struct{}),
+		}
+		b.topics[topic] = t
+	}
+	return t
+}
+
+// prune drops events from the topic's history once they're too old or too numerous. The broker's
+// mutex must be held.
+func (t *topicState) prune(now time.Time) {
+	start := 0
+	for start < len(t.history) && (len(t.history)-start > historySize ||
+		now.Sub(t.history[start].Time) > historyMaxAge) {
+		t.since = t.history[start].ID
+		start++
+	}
+	if start > 0 {
+		t.history = append([]Event{}, t.history[start:]...)
+	}
+}
+
+// Publish encodes the data as JSON and sends it as an event of the specified type to the
+// topic's subscribers, and it records the event in the topic's history.
+func (b *Broker) Publish(topic Topic, eventType string, data interface{}) (Event, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, errors.Wrapf(err, "couldn't encode data of %s event for %s", eventType, topic)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e := Event{
+		ID:   b.nextID,
+		Type: eventType,
+		Time: time.Now(),
+		Data: encoded,
+	}
+	b.nextID++
+	t := b.getTopic(topic)
+	t.history = append(t.history, e)
+	t.prune(e.Time)
+	for subscriber := range t.subscribers {
+		select {
+		case subscriber <- e:
+		default:
+			// The subscriber can't keep up, so we drop it rather than blocking every other publisher;
+			// it can resume from its last received event by subscribing again.
+			delete(t.subscribers, subscriber)
+			close(subscriber)
+			b.stopProducer(t)
+		}
+	}
+	return e, nil
+}
+
+// Subscribe returns the events of the topic's history which were published after the event with
+// the specified ID, followed by a channel of newly-published events which is closed when the
+// context is canceled, when the subscriber falls too far behind, or when the topic's producer
+// fails. If the specified ID is nil, no events are replayed. Complete is false if some events
+// after the specified ID are no longer available, in which case subscribers should assume that
+// they may have missed some events. The producer is started if the topic has no other
+// subscribers.
+func (b *Broker) Subscribe(
+	ctx context.Context, topic Topic, after *EventID, producer Producer,
+) (replay []Event, complete bool, events <-chan Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	t := b.getTopic(topic)
+	t.prune(time.Now())
+	if t.cancelProducer == nil && producer != nil {
+		b.startProducer(topic, t, producer)
+	}
+	complete = true
+	if after != nil {
+		complete = *after >= t.since && *after < b.nextID
+		for _, e := range t.history {
+			if e.ID > *after {
+				replay = append(replay, e)
+			}
+		}
+	}
+
+	subscriber := make(chan Event, subscriberBuffer)
+	t.subscribers[subscriber] = struct{}{}
+	go func() {
+		<-ctx.Done()
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if _, ok := t.subscribers[subscriber]; !ok {
+			return // the subscriber was already dropped
+		}
+		delete(t.subscribers, subscriber)
+		close(subscriber)
+		b.stopProducer(t)
+	}()
+	return replay, complete, subscriber
+}
+
+// startProducer runs the producer for the topic. If the producer stops before it's canceled, the
+// topic's subscribers are closed, so that they subscribe again and restart the producer. The
+// broker's mutex must be held.
+func (b *Broker) startProducer(topic Topic, t *topicState, producer Producer) {
+	// Any events which the producer would have published while it wasn't running were missed
+	t.since = b.nextID
+	b.nextID++
+	ctx, canceler := context.WithCancel(context.Background())
+	t.cancelProducer = canceler
+	go func() {
+		err := producer(ctx, topic)
+		if err != nil && err != context.Canceled {
+			b.logger.Error(errors.Wrapf(err, "event producer for %s failed", topic))
+		}
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if ctx.Err() != nil {
+			return // the producer was stopped
+		}
+		if err == nil {
+			b.logger.Warnf("event producer for %s stopped unexpectedly", topic)
+		}
+		for subscriber := range t.subscribers {
+			delete(t.subscribers, subscriber)
+			close(subscriber)
+		}
+		canceler()
+		t.cancelProducer = nil
+	}()
+}
+
+// stopProducer stops the topic's producer if the topic has no more subscribers. The broker's mutex
+// must be held.
+func (b *Broker) stopProducer(t *topicState) {
+	if len(t.subscribers) > 0 || t.cancelProducer == nil {
+		return
+	}
+	t.cancelProducer()
+	t.cancelProducer = nil
+}
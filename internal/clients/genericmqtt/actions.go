@@ -0,0 +1,50 @@
+package genericmqtt
+
+import (
+	"context"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/pkg/errors"
+
+	"github.com/sargassum-world/pslive/internal/clients/customctl"
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
+)
+
+// Send Commands
+
+func (c *Client) GetCommandParams(name string) (params customctl.Params, ok bool) {
+	command, ok := c.Schema.GetCommand(name)
+	return command.Params, ok
+}
+
+// RunCommand sends the command defined in the schema, waiting until the MQTT broker has
+// acknowledged it according to the command's QoS.
+func (c *Client) RunCommand(ctx context.Context, name string, rawParams map[string]string) error {
+	command, ok := c.Schema.GetCommand(name)
+	if !ok {
+		return errors.Errorf("unknown command %s", name)
+	}
+	payload, err := command.RenderPayload(rawParams)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't make payload for command %s", name)
+	}
+
+	c.countMessage(planktoscope.TrafficOutbound)
+	token := c.MQTT.Publish(command.Topic, command.QoS, command.Retained, payload)
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-token.Done():
+		return errors.Wrapf(token.Error(), "couldn't send command %s", name)
+	}
+}
+
+// Controller Action
+
+func (c *Client) RunControllerAction(ctx context.Context, command string, params hcl.Body) error {
+	rawParams, err := customctl.DecodeParams(params)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't decode params of generic mqtt controller command %s", command)
+	}
+	return c.RunCommand(ctx, command, rawParams)
+}
@@ -0,0 +1,201 @@
+// Package genericmqtt provides a client for controllers whose MQTT commands and states are
+// described by a user-defined schema
+package genericmqtt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+
+	"github.com/sargassum-world/pslive/internal/clients/customctl"
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
+)
+
+const (
+	Protocol        = "generic-mqtt"
+	mqttAtLeastOnce = 1
+)
+
+type ClientID int64
+
+type Client struct {
+	ID                   ClientID
+	Config               Config
+	Schema               Schema
+	Logger               godest.Logger
+	MQTT                 mqtt.Client
+	firstConnSuccess     chan struct{}
+	firstConnSuccessOnce *sync.Once
+	logReconnectOnce     *sync.Once
+	logReconnectOnceMu   *sync.Mutex
+
+	stateL *sync.RWMutex
+	states []customctl.StateValue
+	stateB *customctl.Broadcaster
+}
+
+func NewClient(id ClientID, c Config, s Schema, l godest.Logger) (client *Client, err error) {
+	client = &Client{}
+	client.ID = id
+	client.Config = c
+	client.Schema = s
+	client.Logger = l
+	client.firstConnSuccess = make(chan struct{})
+	client.firstConnSuccessOnce = &sync.Once{}
+	client.logReconnectOnce = &sync.Once{}
+	client.logReconnectOnceMu = &sync.Mutex{}
+	client.stateL = &sync.RWMutex{}
+	client.states = make([]customctl.StateValue, len(s.States))
+	for i, st := range s.States {
+		client.states[i] = customctl.StateValue{
+			Name: st.Name,
+			Unit: st.Unit,
+		}
+	}
+	client.stateB = customctl.NewBroadcaster()
+
+	c.MQTT.SetOnConnectHandler(client.handleConnected)
+	c.MQTT.SetConnectionLostHandler(client.handleConnectionLost)
+	c.MQTT.SetReconnectingHandler(client.handleReconnecting)
+	client.MQTT = mqtt.NewClient(&c.MQTT)
+	return client, nil
+}
+
+func (c *Client) GetState() Controller {
+	c.stateL.RLock()
+	defer c.stateL.RUnlock()
+
+	states := make([]customctl.StateValue, len(c.states))
+	copy(states, c.states)
+	return Controller{
+		Schema: c.Schema,
+		States: states,
+	}
+}
+
+func (c *Client) GetStates() []customctl.StateValue {
+	return c.GetState().States
+}
+
+func (c *Client) StateBroadcasted() <-chan struct{} {
+	return c.stateB.Broadcasted()
+}
+
+// Receive Updates
+
+func (c *Client) updateState(i int, value interface{}) {
+	c.stateL.Lock()
+	defer c.stateL.Unlock()
+
+	c.states[i].Known = true
+	c.states[i].Value = value
+	c.states[i].Updated = time.Now()
+	c.stateB.BroadcastNext()
+}
+
+func (c *Client) handleStateMessage(i int) mqtt.MessageHandler {
+	st := c.Schema.States[i]
+	return func(_ mqtt.Client, m mqtt.Message) {
+		c.countMessage(planktoscope.TrafficInbound)
+		value, err := st.ExtractValue(m.Payload())
+		if err != nil {
+			c.Logger.Errorf(errors.Wrapf(
+				err, "%s/%s: couldn't extract state %s from payload %s",
+				c.Config.URL, m.Topic(), st.Name, m.Payload(),
+			).Error())
+			return
+		}
+		c.updateState(i, value)
+	}
+}
+
+// MQTT
+
+func (c *Client) handleConnected(cm mqtt.Client) {
+	c.firstConnSuccessOnce.Do(func() {
+		close(c.firstConnSuccess)
+	})
+	c.Logger.Infof("connected as %s to MQTT broker %s", c.Config.ClientID, c.Config.URL)
+	for i, st := range c.Schema.States {
+		token := cm.Subscribe(st.Topic, mqttAtLeastOnce, c.handleStateMessage(i))
+		go func(t mqtt.Token, topic string) {
+			if t.Wait(); t.Error() != nil {
+				c.Logger.Error(errors.Wrapf(t.Error(), "couldn't subscribe to %s", topic))
+			}
+		}(token, st.Topic)
+	}
+
+	c.logReconnectOnceMu.Lock()
+	c.logReconnectOnce = &sync.Once{}
+	c.logReconnectOnceMu.Unlock()
+}
+
+func (c *Client) handleConnectionLost(_ mqtt.Client, err error) {
+	c.stateL.Lock()
+	defer c.stateL.Unlock()
+
+	for i := range c.states {
+		c.states[i].Known = false
+	}
+	c.stateB.BroadcastNext()
+	c.Logger.Warn(errors.Wrap(err, "connection lost"))
+}
+
+func (c *Client) handleReconnecting(_ mqtt.Client, _ *mqtt.ClientOptions) {
+	c.logReconnectOnceMu.Lock()
+	defer c.logReconnectOnceMu.Unlock()
+
+	c.logReconnectOnce.Do(func() {
+		c.Logger.Warn("reconnecting to MQTT broker...")
+	})
+}
+
+func (c *Client) Connect() error {
+	token := c.MQTT.Connect()
+	_ = token.Wait()
+	return errors.Wrapf(token.Error(), "couldn't connect to %s", c.Config.URL)
+}
+
+func (c *Client) HasConnection() bool {
+	return c.MQTT.IsConnectionOpen()
+}
+
+func (c *Client) Shutdown(ctx context.Context) error {
+	if !c.MQTT.IsConnected() {
+		return nil
+	}
+
+	const defaultTimeout = 5000 // ms
+	var timeout uint
+	select {
+	default:
+		// See planktoscope.Client.Shutdown for why we don't wait for clients which never connected
+		timeout = 0
+	case <-c.firstConnSuccess:
+		timeout = defaultTimeout
+	}
+
+	closedNormally := make(chan struct{})
+	go func() {
+		c.MQTT.Disconnect(timeout)
+		close(closedNormally)
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-closedNormally:
+		return nil
+	}
+}
+
+func (c *Client) Close() {
+	if !c.MQTT.IsConnected() {
+		return
+	}
+
+	c.MQTT.Disconnect(0)
+}
@@ -0,0 +1,44 @@
+package genericmqtt
+
+import (
+	"fmt"
+
+	"github.com/atrox/haikunatorgo"
+	"github.com/eclipse/paho.mqtt.golang"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/env"
+
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
+)
+
+const envPrefix = "GENERICMQTT_"
+
+type Config struct {
+	URL      string
+	ClientID string
+	Auth     planktoscope.MQTTAuth
+	MQTT     mqtt.ClientOptions
+}
+
+func GetConfig(brokerURL, clientInstanceID string, auth planktoscope.MQTTAuth) (c Config, err error) {
+	c.URL = brokerURL
+	c.Auth = auth
+
+	client := env.GetString(envPrefix+"MQTT_CLIENT", "")
+	if client == "" {
+		client = haikunator.New().Haikunate()
+	}
+	if clientInstanceID == "" {
+		clientInstanceID = haikunator.New().Haikunate()
+	}
+	c.ClientID = fmt.Sprintf("pslive/%s/mqtt/%s", client, clientInstanceID)
+
+	// The connection settings are shared with planktoscope clients, since both just connect to MQTT
+	// brokers
+	options, err := planktoscope.GetMQTTConfig(brokerURL, c.ClientID, auth)
+	if err != nil {
+		return Config{}, errors.Wrap(err, "couldn't make MQTT config")
+	}
+	c.MQTT = *options
+	return c, nil
+}
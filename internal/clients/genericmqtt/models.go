@@ -0,0 +1,10 @@
+package genericmqtt
+
+import (
+	"github.com/sargassum-world/pslive/internal/clients/customctl"
+)
+
+type Controller struct {
+	Schema Schema
+	States []customctl.StateValue
+}
@@ -0,0 +1,135 @@
+package genericmqtt
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/sargassum-world/pslive/internal/clients/planktoscope"
+)
+
+type Orchestrator struct {
+	clients   map[ClientID]*Client
+	clientsMu *sync.RWMutex
+
+	logger godest.Logger
+}
+
+func NewOrchestrator(logger godest.Logger) *Orchestrator {
+	return &Orchestrator{
+		clients:   make(map[ClientID]*Client),
+		clientsMu: &sync.RWMutex{},
+		logger:    logger,
+	}
+}
+
+func (o *Orchestrator) Add(
+	id ClientID, url string, auth planktoscope.MQTTAuth, rawSchema string,
+) error {
+	if _, ok := o.Get(id); ok {
+		o.logger.Warnf(
+			"skipped adding generic mqtt client %d (%s) because it's already running", id, url,
+		)
+		return nil
+	}
+
+	schema, err := ParseSchema(rawSchema)
+	if err != nil {
+		return errors.Wrapf(err, "invalid schema for generic mqtt client %d", id)
+	}
+	const idBase = 10
+	config, err := GetConfig(url, strconv.FormatInt(int64(id), idBase), auth)
+	if err != nil {
+		return errors.Wrap(err, "couldn't set up generic mqtt config")
+	}
+	client, err := NewClient(id, config, schema, o.logger)
+	if err != nil {
+		return errors.Wrapf(
+			err, "couldn't set up generic mqtt client %d (%s @ %s)", id, config.ClientID, url,
+		)
+	}
+
+	o.clientsMu.Lock()
+	o.clients[id] = client
+	o.clientsMu.Unlock()
+
+	go func() {
+		o.logger.Infof("adding generic mqtt client %d (%s @ %s)", id, client.Config.ClientID, url)
+		if err := client.Connect(); err != nil {
+			o.logger.Error(errors.Wrapf(
+				err, "couldn't add generic mqtt client %d (%s @ %s)", id, client.Config.ClientID, url,
+			))
+		}
+	}()
+	return nil
+}
+
+func (o *Orchestrator) Get(id ClientID) (c *Client, ok bool) {
+	o.clientsMu.RLock()
+	defer o.clientsMu.RUnlock()
+
+	c, ok = o.clients[id]
+	return c, ok
+}
+
+func (o *Orchestrator) Remove(ctx context.Context, id ClientID) error {
+	o.clientsMu.Lock()
+	defer o.clientsMu.Unlock()
+
+	client, ok := o.clients[id]
+	if !ok {
+		return nil
+	}
+	o.logger.Infof(
+		"removing generic mqtt client %d (%s @ %s)", id, client.Config.ClientID, client.Config.URL,
+	)
+	err := client.Shutdown(ctx)
+	if err != nil {
+		client.Close()
+	}
+	delete(o.clients, id)
+	forgetMetrics(id)
+	return err
+}
+
+// Update replaces the client with a new client using the provided connection settings and schema.
+// The schema is validated first, so that an invalid schema doesn't remove a working client.
+func (o *Orchestrator) Update(
+	ctx context.Context, id ClientID, url string, auth planktoscope.MQTTAuth, rawSchema string,
+) error {
+	if _, err := ParseSchema(rawSchema); err != nil {
+		return errors.Wrapf(err, "invalid schema for generic mqtt client %d", id)
+	}
+	if err := o.Remove(ctx, id); err != nil {
+		return errors.Wrapf(err, "couldn't remove old generic mqtt client %d to update it", id)
+	}
+	return errors.Wrapf(
+		o.Add(id, url, auth, rawSchema), "couldn't add new generic mqtt client %d to update it", id,
+	)
+}
+
+func (o *Orchestrator) Close(ctx context.Context) error {
+	o.clientsMu.Lock()
+	defer o.clientsMu.Unlock()
+
+	eg, _ := errgroup.WithContext(ctx)
+	for _, client := range o.clients {
+		eg.Go(func(c *Client) func() error {
+			return func() error {
+				// We pass the parent context to isolate failure of one client's graceful shutdown from the
+				// other clients' graceful shutdowns
+				err := c.Shutdown(ctx)
+				if err != nil {
+					c.Close()
+				}
+				return err
+			}
+		}(client))
+	}
+	o.clients = nil
+	return eg.Wait()
+}
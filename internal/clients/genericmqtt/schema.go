@@ -0,0 +1,134 @@
+package genericmqtt
+
+import (
+	"encoding/json"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/sargassum-world/pslive/internal/clients/customctl"
+)
+
+// Schema describes the commands which can be sent to a controller and the states which can be
+// read from it, as defined by the instrument admin.
+type Schema struct {
+	Commands []Command    `json:"commands"`
+	States   []StateTopic `json:"states"`
+}
+
+// Command is a named MQTT message which can be sent to the controller. The payload is a Go
+// text/template which is executed with the command's params; the `json` function encodes a param
+// as a JSON value, e.g. `{"speed": {{json .speed}}}`.
+type Command struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Topic       string           `json:"topic"`
+	QoS         byte             `json:"qos"`
+	Retained    bool             `json:"retained,omitempty"`
+	Payload     string           `json:"payload"`
+	Params      customctl.Params `json:"params,omitempty"`
+
+	payload *template.Template
+}
+
+// StateTopic is a named value which is read from the messages published on an MQTT topic. The
+// path is a dot-separated list of object keys and array indices (e.g. `readings.0.value`) locating
+// the value in the message's JSON payload; an empty path selects the whole payload.
+type StateTopic struct {
+	Name  string `json:"name"`
+	Topic string `json:"topic"`
+	Path  string `json:"path,omitempty"`
+	Unit  string `json:"unit,omitempty"`
+}
+
+func (c *Command) validate() (err error) {
+	if c.Topic == "" {
+		return errors.New("missing topic")
+	}
+	if strings.ContainsAny(c.Topic, "+#") {
+		return errors.Errorf("topic %s has wildcards", c.Topic)
+	}
+	const maxQoS = 2
+	if c.QoS > maxQoS {
+		return errors.Errorf("invalid qos %d", c.QoS)
+	}
+	if err = c.Params.Validate(); err != nil {
+		return err
+	}
+	c.payload, err = customctl.ParsePayloadTemplate(c.Name, c.Payload)
+	return err
+}
+
+// ParseSchema parses and validates a schema from its JSON representation. An empty string is
+// parsed as an empty schema.
+func ParseSchema(raw string) (s Schema, err error) {
+	if strings.TrimSpace(raw) == "" {
+		return Schema{}, nil
+	}
+	if err = json.Unmarshal([]byte(raw), &s); err != nil {
+		return Schema{}, errors.Wrap(err, "couldn't parse schema as json")
+	}
+
+	seen := make(map[string]bool)
+	for i := range s.Commands {
+		c := &s.Commands[i]
+		if err = customctl.ValidateName(c.Name, "command", seen); err != nil {
+			return Schema{}, err
+		}
+		if err = c.validate(); err != nil {
+			return Schema{}, errors.Wrapf(err, "invalid command %s", c.Name)
+		}
+	}
+	seen = make(map[string]bool)
+	for _, st := range s.States {
+		if err = customctl.ValidateName(st.Name, "state", seen); err != nil {
+			return Schema{}, err
+		}
+		if st.Topic == "" {
+			return Schema{}, errors.Errorf("state %s is missing a topic", st.Name)
+		}
+	}
+	return s, nil
+}
+
+func (s Schema) CommandNames() []string {
+	names := make([]string, len(s.Commands))
+	for i, command := range s.Commands {
+		names[i] = command.Name
+	}
+	return names
+}
+
+func (s Schema) GetCommand(name string) (c Command, ok bool) {
+	for _, command := range s.Commands {
+		if command.Name == name {
+			return command, true
+		}
+	}
+	return Command{}, false
+}
+
+// RenderPayload produces the MQTT message payload of the command from raw param values.
+func (c Command) RenderPayload(rawParams map[string]string) ([]byte, error) {
+	params, err := c.Params.Parse(rawParams)
+	if err != nil {
+		return nil, err
+	}
+	return customctl.RenderPayload(c.payload, params)
+}
+
+// State Values
+
+// ExtractValue looks up the state's value in a message payload. If the payload isn't JSON and the
+// path is empty, the payload is returned as a string.
+func (st StateTopic) ExtractValue(payload []byte) (interface{}, error) {
+	var value interface{}
+	if err := json.Unmarshal(payload, &value); err != nil {
+		if st.Path == "" {
+			return string(payload), nil
+		}
+		return nil, errors.Wrap(err, "couldn't parse payload as json")
+	}
+	return customctl.LookupPath(value, st.Path)
+}
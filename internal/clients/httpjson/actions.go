@@ -0,0 +1,46 @@
+package httpjson
+
+import (
+	"context"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/pkg/errors"
+
+	"github.com/sargassum-world/pslive/internal/clients/customctl"
+)
+
+// Send Commands
+
+func (c *Client) GetCommandParams(name string) (params customctl.Params, ok bool) {
+	command, ok := c.Schema.GetCommand(name)
+	return command.Params, ok
+}
+
+// RunCommand sends the request for the command defined in the schema, and then polls the status
+// endpoint so that the command's effects are shown promptly.
+func (c *Client) RunCommand(ctx context.Context, name string, rawParams map[string]string) error {
+	command, ok := c.Schema.GetCommand(name)
+	if !ok {
+		return errors.Errorf("unknown command %s", name)
+	}
+	body, err := command.RenderBody(rawParams)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't make body for command %s", name)
+	}
+
+	if _, err = c.do(ctx, command.Method, command.Path, body); err != nil {
+		return errors.Wrapf(err, "couldn't send command %s", name)
+	}
+	c.requestPoll()
+	return nil
+}
+
+// Controller Action
+
+func (c *Client) RunControllerAction(ctx context.Context, command string, params hcl.Body) error {
+	rawParams, err := customctl.DecodeParams(params)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't decode params of http json controller command %s", command)
+	}
+	return c.RunCommand(ctx, command, rawParams)
+}
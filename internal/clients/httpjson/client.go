@@ -0,0 +1,235 @@
+// Package httpjson provides a client for controllers with HTTP APIs whose endpoints are described
+// by a user-defined schema
+package httpjson
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+
+	"github.com/sargassum-world/pslive/internal/clients/customctl"
+)
+
+const Protocol = "http-json"
+
+type ClientID int64
+
+type Client struct {
+	ID     ClientID
+	Config Config
+	Schema Schema
+	Logger godest.Logger
+	HTTP   *http.Client
+
+	pollNow chan struct{}
+	cancel  context.CancelFunc
+	done    chan struct{}
+
+	stateL    *sync.RWMutex
+	reachable bool
+	states    []customctl.StateValue
+	stateB    *customctl.Broadcaster
+}
+
+// Controller is the schema and current state of a controller.
+type Controller struct {
+	Schema Schema
+	States []customctl.StateValue
+}
+
+func NewClient(id ClientID, c Config, s Schema, l godest.Logger) (client *Client, err error) {
+	client = &Client{}
+	client.ID = id
+	client.Config = c
+	client.Schema = s
+	client.Logger = l
+	client.HTTP = &http.Client{Timeout: c.Timeout}
+	client.pollNow = make(chan struct{}, 1)
+	client.done = make(chan struct{})
+	client.stateL = &sync.RWMutex{}
+	client.states = make([]customctl.StateValue, len(s.Status.States))
+	for i, st := range s.Status.States {
+		client.states[i] = customctl.StateValue{
+			Name: st.Name,
+			Unit: st.Unit,
+		}
+	}
+	client.stateB = customctl.NewBroadcaster()
+	return client, nil
+}
+
+func (c *Client) GetState() Controller {
+	c.stateL.RLock()
+	defer c.stateL.RUnlock()
+
+	states := make([]customctl.StateValue, len(c.states))
+	copy(states, c.states)
+	return Controller{
+		Schema: c.Schema,
+		States: states,
+	}
+}
+
+func (c *Client) GetStates() []customctl.StateValue {
+	return c.GetState().States
+}
+
+func (c *Client) StateBroadcasted() <-chan struct{} {
+	return c.stateB.Broadcasted()
+}
+
+// HasConnection reports whether the most recent poll of the status endpoint succeeded. Controllers
+// without a status endpoint are assumed to be reachable.
+func (c *Client) HasConnection() bool {
+	if c.Schema.Status.Path == "" {
+		return true
+	}
+
+	c.stateL.RLock()
+	defer c.stateL.RUnlock()
+	return c.reachable
+}
+
+// Requests
+
+func (c *Client) do(
+	ctx context.Context, method, path string, body []byte,
+) (response []byte, err error) {
+	url := strings.TrimSuffix(c.Config.URL, "/") + path
+	var bodyReader io.Reader
+	if len(body) > 0 {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't make request for %s %s", method, url)
+	}
+	req.Header.Set("Accept", "application/json")
+	if len(body) > 0 {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	res, err := c.HTTP.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "couldn't send request for %s %s", method, url)
+	}
+	defer res.Body.Close()
+
+	const maxResponseSize = 1 << 20 // 1 MiB
+	if response, err = io.ReadAll(io.LimitReader(res.Body, maxResponseSize)); err != nil {
+		return nil, errors.Wrapf(err, "couldn't read response for %s %s", method, url)
+	}
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return nil, errors.Errorf(
+			"%s %s failed with status %d: %s", method, url, res.StatusCode, response,
+		)
+	}
+	return response, nil
+}
+
+// Poll Status
+
+func (c *Client) setUnreachable() {
+	c.stateL.Lock()
+	defer c.stateL.Unlock()
+
+	if !c.reachable {
+		return
+	}
+	c.reachable = false
+	for i := range c.states {
+		c.states[i].Known = false
+	}
+	c.stateB.BroadcastNext()
+}
+
+func (c *Client) updateStates(status interface{}) {
+	c.stateL.Lock()
+	defer c.stateL.Unlock()
+
+	c.reachable = true
+	now := time.Now()
+	for i, st := range c.Schema.Status.States {
+		value, err := customctl.LookupPath(status, st.Path)
+		if err != nil {
+			c.Logger.Warn(errors.Wrapf(err, "%s: couldn't extract state %s", c.Config.URL, st.Name))
+			c.states[i].Known = false
+			continue
+		}
+		c.states[i].Known = true
+		c.states[i].Value = value
+		c.states[i].Updated = now
+	}
+	c.stateB.BroadcastNext()
+}
+
+func (c *Client) poll(ctx context.Context) error {
+	response, err := c.do(ctx, http.MethodGet, c.Schema.Status.Path, nil)
+	if err != nil {
+		c.setUnreachable()
+		return err
+	}
+	var status interface{}
+	if err = json.Unmarshal(response, &status); err != nil {
+		c.setUnreachable()
+		return errors.Wrapf(err, "couldn't parse status %s", response)
+	}
+	c.updateStates(status)
+	return nil
+}
+
+// Start polls the status endpoint until the client is shut down.
+func (c *Client) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	go func() {
+		defer close(c.done)
+		if c.Schema.Status.Path == "" {
+			<-ctx.Done()
+			return
+		}
+
+		ticker := time.NewTicker(c.Schema.Status.interval)
+		defer ticker.Stop()
+		for {
+			if err := c.poll(ctx); err != nil && ctx.Err() == nil {
+				c.Logger.Warn(errors.Wrap(err, "couldn't poll controller status"))
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			case <-c.pollNow:
+			}
+		}
+	}()
+}
+
+// requestPoll makes the client poll the status endpoint without waiting for the poll interval.
+func (c *Client) requestPoll() {
+	select {
+	case c.pollNow <- struct{}{}:
+	default:
+		// A poll has already been requested
+	}
+}
+
+func (c *Client) Shutdown(ctx context.Context) error {
+	if c.cancel == nil {
+		return nil
+	}
+	c.cancel()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.done:
+		return nil
+	}
+}
@@ -0,0 +1,28 @@
+package httpjson
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/env"
+)
+
+const envPrefix = "HTTPJSON_"
+
+type Config struct {
+	URL string
+	// Timeout limits the duration of each request to the controller.
+	Timeout time.Duration
+}
+
+func GetConfig(baseURL string) (c Config, err error) {
+	c.URL = baseURL
+
+	const defaultTimeout = 10 // default: 10 seconds
+	timeoutRaw, err := env.GetInt64(envPrefix+"TIMEOUT", defaultTimeout)
+	if err != nil {
+		return Config{}, errors.Wrap(err, "couldn't make timeout config")
+	}
+	c.Timeout = time.Duration(timeoutRaw) * time.Second
+	return c, nil
+}
@@ -0,0 +1,109 @@
+package httpjson
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+	"golang.org/x/sync/errgroup"
+)
+
+type Orchestrator struct {
+	clients   map[ClientID]*Client
+	clientsMu *sync.RWMutex
+
+	logger godest.Logger
+}
+
+func NewOrchestrator(logger godest.Logger) *Orchestrator {
+	return &Orchestrator{
+		clients:   make(map[ClientID]*Client),
+		clientsMu: &sync.RWMutex{},
+		logger:    logger,
+	}
+}
+
+func (o *Orchestrator) Add(id ClientID, url string, rawSchema string) error {
+	if _, ok := o.Get(id); ok {
+		o.logger.Warnf(
+			"skipped adding http json client %d (%s) because it's already running", id, url,
+		)
+		return nil
+	}
+
+	schema, err := ParseSchema(rawSchema)
+	if err != nil {
+		return errors.Wrapf(err, "invalid schema for http json client %d", id)
+	}
+	config, err := GetConfig(url)
+	if err != nil {
+		return errors.Wrap(err, "couldn't set up http json config")
+	}
+	client, err := NewClient(id, config, schema, o.logger)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't set up http json client %d (%s)", id, url)
+	}
+
+	o.clientsMu.Lock()
+	o.clients[id] = client
+	o.clientsMu.Unlock()
+
+	o.logger.Infof("adding http json client %d (%s)", id, url)
+	client.Start()
+	return nil
+}
+
+func (o *Orchestrator) Get(id ClientID) (c *Client, ok bool) {
+	o.clientsMu.RLock()
+	defer o.clientsMu.RUnlock()
+
+	c, ok = o.clients[id]
+	return c, ok
+}
+
+func (o *Orchestrator) Remove(ctx context.Context, id ClientID) error {
+	o.clientsMu.Lock()
+	defer o.clientsMu.Unlock()
+
+	client, ok := o.clients[id]
+	if !ok {
+		return nil
+	}
+	o.logger.Infof("removing http json client %d (%s)", id, client.Config.URL)
+	err := client.Shutdown(ctx)
+	delete(o.clients, id)
+	return err
+}
+
+// Update replaces the client with a new client using the provided base URL and schema. The schema
+// is validated first, so that an invalid schema doesn't remove a working client.
+func (o *Orchestrator) Update(
+	ctx context.Context, id ClientID, url string, rawSchema string,
+) error {
+	if _, err := ParseSchema(rawSchema); err != nil {
+		return errors.Wrapf(err, "invalid schema for http json client %d", id)
+	}
+	if err := o.Remove(ctx, id); err != nil {
+		return errors.Wrapf(err, "couldn't remove old http json client %d to update it", id)
+	}
+	return errors.Wrapf(
+		o.Add(id, url, rawSchema), "couldn't add new http json client %d to update it", id,
+	)
+}
+
+func (o *Orchestrator) Close(ctx context.Context) error {
+	o.clientsMu.Lock()
+	defer o.clientsMu.Unlock()
+
+	eg, _ := errgroup.WithContext(ctx)
+	for _, client := range o.clients {
+		eg.Go(func(c *Client) func() error {
+			return func() error {
+				return c.Shutdown(ctx)
+			}
+		}(client))
+	}
+	o.clients = nil
+	return eg.Wait()
+}
@@ -0,0 +1,146 @@
+package httpjson
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/sargassum-world/pslive/internal/clients/customctl"
+)
+
+// Schema describes the HTTP endpoints of a controller, as defined by the instrument admin. Paths
+// are relative to the controller's base URL.
+type Schema struct {
+	Status   Status    `json:"status"`
+	Commands []Command `json:"commands"`
+}
+
+// Status describes how the controller's states are polled. If the path is empty, the controller
+// isn't polled.
+type Status struct {
+	Path string `json:"path,omitempty"`
+	// Interval is a string which parses with time.ParseDuration()
+	Interval string  `json:"interval,omitempty"`
+	States   []State `json:"states,omitempty"`
+
+	interval time.Duration
+}
+
+// State is a named value which is read from the JSON response of the status endpoint, at a
+// dot-separated path of object keys and array indices.
+type State struct {
+	Name string `json:"name"`
+	Path string `json:"path,omitempty"`
+	Unit string `json:"unit,omitempty"`
+}
+
+// Command is a named HTTP request which can be sent to the controller. The body is a Go
+// text/template which is executed with the command's params, as with generic MQTT commands.
+type Command struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Method      string           `json:"method,omitempty"`
+	Path        string           `json:"path"`
+	Body        string           `json:"body,omitempty"`
+	Params      customctl.Params `json:"params,omitempty"`
+
+	body *template.Template
+}
+
+const defaultPollInterval = 5 * time.Second
+
+func (s *Status) validate() (err error) {
+	if s.Path != "" && !strings.HasPrefix(s.Path, "/") {
+		return errors.Errorf("path %s isn't absolute", s.Path)
+	}
+	s.interval = defaultPollInterval
+	if s.Interval != "" {
+		if s.interval, err = time.ParseDuration(s.Interval); err != nil {
+			return errors.Wrapf(err, "couldn't parse interval %s", s.Interval)
+		}
+		if s.interval <= 0 {
+			return errors.Errorf("interval %s isn't positive", s.Interval)
+		}
+	}
+	seen := make(map[string]bool)
+	for _, st := range s.States {
+		if err = customctl.ValidateName(st.Name, "state", seen); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Command) validate() (err error) {
+	if c.Method == "" {
+		c.Method = http.MethodPost
+	}
+	c.Method = strings.ToUpper(c.Method)
+	switch c.Method {
+	default:
+		return errors.Errorf("unsupported method %s", c.Method)
+	case http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+	}
+	if !strings.HasPrefix(c.Path, "/") {
+		return errors.Errorf("path %s isn't absolute", c.Path)
+	}
+	if err = c.Params.Validate(); err != nil {
+		return err
+	}
+	c.body, err = customctl.ParsePayloadTemplate(c.Name, c.Body)
+	return err
+}
+
+// ParseSchema parses and validates a schema from its JSON representation. An empty string is
+// parsed as an empty schema.
+func ParseSchema(raw string) (s Schema, err error) {
+	if strings.TrimSpace(raw) != "" {
+		if err = json.Unmarshal([]byte(raw), &s); err != nil {
+			return Schema{}, errors.Wrap(err, "couldn't parse schema as json")
+		}
+	}
+	if err = s.Status.validate(); err != nil {
+		return Schema{}, errors.Wrap(err, "invalid status")
+	}
+	seen := make(map[string]bool)
+	for i := range s.Commands {
+		c := &s.Commands[i]
+		if err = customctl.ValidateName(c.Name, "command", seen); err != nil {
+			return Schema{}, err
+		}
+		if err = c.validate(); err != nil {
+			return Schema{}, errors.Wrapf(err, "invalid command %s", c.Name)
+		}
+	}
+	return s, nil
+}
+
+func (s Schema) CommandNames() []string {
+	names := make([]string, len(s.Commands))
+	for i, command := range s.Commands {
+		names[i] = command.Name
+	}
+	return names
+}
+
+func (s Schema) GetCommand(name string) (c Command, ok bool) {
+	for _, command := range s.Commands {
+		if command.Name == name {
+			return command, true
+		}
+	}
+	return Command{}, false
+}
+
+// RenderBody produces the request body of the command from raw param values.
+func (c Command) RenderBody(rawParams map[string]string) ([]byte, error) {
+	params, err := c.Params.Parse(rawParams)
+	if err != nil {
+		return nil, err
+	}
+	return customctl.RenderPayload(c.body, params)
+}
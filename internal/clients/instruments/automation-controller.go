@@ -12,20 +12,34 @@ type ControllerActionRunner interface {
 	RunControllerAction(ctx context.Context, command string, params hcl.Body) error
 }
 
+// ControllerActionStarter is implemented by action runners whose actions wait for the controller
+// to respond after sending commands, so that the waiting can be done without holding up later
+// commands to the controller.
+type ControllerActionStarter interface {
+	// StartControllerAction sends the commands of the action, and it returns a function which waits
+	// for the controller to respond to them.
+	StartControllerAction(
+		ctx context.Context, command string, params hcl.Body,
+	) (await func() error, err error)
+}
+
 type ControllerActionRunnerGetter func(id ControllerID) (a ControllerActionRunner, ok bool)
 
 // Controller Action Runner Store
 
 type ControllerActionRunnerStore struct {
 	instruments     *Store
+	control         *ControlArbiter
 	protocolGetters map[string]ControllerActionRunnerGetter
 }
 
 func NewControllerActionRunnerStore(
-	instruments *Store, protocolGetters map[string]ControllerActionRunnerGetter,
+	instruments *Store, control *ControlArbiter,
+	protocolGetters map[string]ControllerActionRunnerGetter,
 ) *ControllerActionRunnerStore {
 	return &ControllerActionRunnerStore{
 		instruments:     instruments,
+		control:         control,
 		protocolGetters: protocolGetters,
 	}
 }
@@ -72,8 +86,21 @@ func (s *ControllerActionRunnerStore) HandleControllerAction(
 	if len(runners) == 0 {
 		return errors.Errorf("couldn't find any controllers named %s", a.Controller)
 	}
+	// Actions outside of job runs are treated like commands from an operator
+	holder, _ := ControlHolderFromContext(ctx)
 	for id, runner := range runners {
-		if err := runner.RunControllerAction(ctx, a.Command, a.Params); err != nil {
+		await := func() error { return nil }
+		if err := s.control.Command(ctx, id, holder, func() (err error) {
+			starter, ok := runner.(ControllerActionStarter)
+			if !ok {
+				return runner.RunControllerAction(ctx, a.Command, a.Params)
+			}
+			await, err = starter.StartControllerAction(ctx, a.Command, a.Params)
+			return err
+		}); err != nil {
+			return errors.Wrapf(err, "couldn't run action %s with controller %d", name, id)
+		}
+		if err := await(); err != nil {
 			return errors.Wrapf(err, "couldn't run action %s with controller %d", name, id)
 		}
 	}
@@ -12,6 +12,8 @@ import (
 	"github.com/hashicorp/hcl/v2/hclsimple"
 	"github.com/pkg/errors"
 	"github.com/sargassum-world/godest"
+
+	"github.com/sargassum-world/pslive/internal/clients/events"
 )
 
 // Job Specification
@@ -118,16 +120,24 @@ func (j *OrchestratedJob) Cancel() {
 type JobOrchestrator struct {
 	jobs           map[AutomationJobID]*OrchestratedJob
 	mu             *sync.RWMutex
+	halted         map[InstrumentID]bool
+	runs           map[AutomationJobID]activeRun
+	runsMu         *sync.Mutex
 	scheduler      *gocron.Scheduler
 	toStart        chan *OrchestratedJob
 	canceler       func()
 	actionHandlers map[string]ActionHandler
+	control        *ControlArbiter
+	store          *Store
+	events         *events.Broker
+	webhooks       *WebhookDispatcher
 
 	logger godest.Logger
 }
 
 func NewJobOrchestrator(
-	actionHandlers map[string]ActionHandler, logger godest.Logger,
+	actionHandlers map[string]ActionHandler, control *ControlArbiter, store *Store,
+	eb *events.Broker, webhooks *WebhookDispatcher, logger godest.Logger,
 ) *JobOrchestrator {
 	scheduler := gocron.NewScheduler(time.UTC)
 	scheduler.StartAsync()
@@ -135,9 +145,16 @@ func NewJobOrchestrator(
 	return &JobOrchestrator{
 		mu:             &sync.RWMutex{},
 		jobs:           make(map[AutomationJobID]*OrchestratedJob),
+		halted:         make(map[InstrumentID]bool),
+		runs:           make(map[AutomationJobID]activeRun),
+		runsMu:         &sync.Mutex{},
 		scheduler:      scheduler,
 		toStart:        make(chan *OrchestratedJob),
 		actionHandlers: actionHandlers,
+		control:        control,
+		store:          store,
+		events:         eb,
+		webhooks:       webhooks,
 		logger:         logger,
 	}
 }
@@ -169,14 +186,251 @@ func (o *JobOrchestrator) startJob(ctx context.Context, job *OrchestratedJob) er
 				return
 			}
 
-			if jobErr := job.Run(jobCtx, o.actionHandlers); jobErr != nil {
+			start := time.Now()
+			runCtx, ended, ok := o.beginRun(jobCtx, job)
+			if !ok {
+				o.logger.Infof(
+					"skipped run of job %d %s because instrument %d is emergency-stopped",
+					job.ID, job.Name, job.InstrumentID,
+				)
+				o.publishRunStatus(job, JobRunSkipped, nil)
+				observeJobRun(job, JobRunSkipped, start)
+				return
+			}
+			defer o.endRun(job, ended)
+
+			o.publishRunStatus(job, JobRunStarted, nil)
+			jobErr := job.Run(runCtx, o.newAuditedActionHandlers(job))
+			switch {
+			case jobErr == nil:
+				o.publishRunStatus(job, JobRunSucceeded, nil)
+				observeJobRun(job, JobRunSucceeded, start)
+			case runCtx.Err() != nil:
+				o.publishRunStatus(job, JobRunCanceled, jobErr)
+				observeJobRun(job, JobRunCanceled, start)
+			default:
 				o.logger.Error(errors.Wrapf(jobErr, "job %d %s failed", job.ID, job.Name))
+				o.publishRunStatus(job, JobRunFailed, jobErr)
+				observeJobRun(job, JobRunFailed, start)
 			}
 		}
 	})
 	return errors.Wrapf(err, "couldn't start job %d %s", job.ID, job.Name)
 }
 
+// Auditing
+
+// newAuditedActionHandlers wraps the orchestrator's action handlers so that every action taken by
+// the job is recorded in the audit log of the job's instrument.
+func (o *JobOrchestrator) newAuditedActionHandlers(job *OrchestratedJob) map[string]ActionHandler {
+	handlers := make(map[string]ActionHandler, len(o.actionHandlers))
+	for actionType, handler := range o.actionHandlers {
+		actionType := actionType
+		handler := handler
+		handlers[actionType] = func(
+			ctx context.Context, instrumentID InstrumentID, name string, params hcl.Body,
+		) error {
+			err := handler(ctx, instrumentID, name, params)
+			o.auditAction(job, actionType, name, err)
+			return err
+		}
+	}
+	return handlers
+}
+
+func (o *JobOrchestrator) auditAction(job *OrchestratedJob, actionType, name string, err error) {
+	e := AuditEvent{
+		InstrumentID: job.InstrumentID,
+		Time:         time.Now(),
+		Source:       fmt.Sprintf("automation job %d", job.ID),
+		Component:    fmt.Sprintf("automation-jobs/%d", job.ID),
+		Action:       actionType,
+		Parameters: map[string]string{
+			"job":    job.Name,
+			"action": name,
+		},
+		Outcome: AuditOutcomeSucceeded,
+	}
+	if err != nil {
+		e.Outcome = AuditOutcomeFailed
+		e.Error = err.Error()
+	}
+	// The run's context may already be canceled, but the action should be recorded anyways
+	if _, aerr := o.store.AddAuditEvent(context.Background(), e); aerr != nil {
+		o.logger.Error(errors.Wrapf(aerr, "couldn't audit action %s of job %d", name, job.ID))
+	}
+}
+
+// Run Statuses
+
+type JobRunStatus string
+
+const (
+	JobRunStarted   JobRunStatus = "started"
+	JobRunSucceeded JobRunStatus = "succeeded"
+	JobRunFailed    JobRunStatus = "failed"
+	JobRunCanceled  JobRunStatus = "canceled"
+	JobRunSkipped   JobRunStatus = "skipped"
+)
+
+// JobStatusEventType is the type of the events published on the events topic of an instrument
+// when a run of one of its automation jobs changes status.
+const JobStatusEventType = "job.status"
+
+type JobStatusEvent struct {
+	JobID   AutomationJobID `json:"jobId"`
+	JobName string          `json:"jobName"`
+	Status  JobRunStatus    `json:"status"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// EventsTopic is the topic of the events broker on which an instrument's events are published.
+func EventsTopic(id InstrumentID) events.Topic {
+	return events.Topic(fmt.Sprintf("/instruments/%d/events", id))
+}
+
+func (o *JobOrchestrator) publishRunStatus(job *OrchestratedJob, status JobRunStatus, err error) {
+	e := JobStatusEvent{
+		JobID:   job.ID,
+		JobName: job.Name,
+		Status:  status,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	if _, perr := o.events.Publish(
+		EventsTopic(job.InstrumentID), JobStatusEventType, e,
+	); perr != nil {
+		o.logger.Error(errors.Wrapf(perr, "couldn't publish status of job %d", job.ID))
+	}
+	if status != JobRunFailed {
+		return
+	}
+	// The run's context may already be canceled, but the failure should be delivered anyways
+	if werr := o.webhooks.Enqueue(
+		context.Background(), job.InstrumentID, WebhookEventJobFailed, e,
+	); werr != nil {
+		o.logger.Error(errors.Wrapf(werr, "couldn't deliver failure of job %d to webhooks", job.ID))
+	}
+}
+
+// Runs
+
+type activeRun struct {
+	instrumentID InstrumentID
+	holder       ControlHolder
+	canceler     func()
+	// ended is closed once the run has returned
+	ended <-chan struct{}
+}
+
+func newRunControlHolder(job *OrchestratedJob) ControlHolder {
+	return ControlHolder{
+		JobID:   job.ID,
+		JobName: job.Name,
+	}
+}
+
+// beginRun registers a new run of the job, unless the job's instrument is halted. Commands sent to
+// controllers with the returned context will reserve the controllers for the run. The
+// returned channel must be passed to endRun once the run has returned.
+func (o *JobOrchestrator) beginRun(
+	ctx context.Context, job *OrchestratedJob,
+) (runCtx context.Context, ended chan struct{}, ok bool) {
+	o.runsMu.Lock()
+	defer o.runsMu.Unlock()
+
+	if o.halted[job.InstrumentID] {
+		return nil, nil, false
+	}
+	runCtx, canceler := context.WithCancel(ctx)
+	holder := newRunControlHolder(job)
+	ended = make(chan struct{})
+	o.runs[job.ID] = activeRun{
+		instrumentID: job.InstrumentID,
+		holder:       holder,
+		canceler:     canceler,
+		ended:        ended,
+	}
+	return WithControlHolder(runCtx, holder), ended, true
+}
+
+func (o *JobOrchestrator) endRun(job *OrchestratedJob, ended chan struct{}) {
+	o.runsMu.Lock()
+	defer o.runsMu.Unlock()
+
+	if run, ok := o.runs[job.ID]; ok {
+		run.canceler()
+		delete(o.runs, job.ID)
+	}
+	o.control.ReleaseAll(newRunControlHolder(job))
+	close(ended)
+}
+
+// Halt cancels every run in progress of the instrument's jobs, and it prevents any new runs of the
+// instrument's jobs from starting until Resume is called. Jobs stay scheduled while halted, but
+// their scheduled runs are skipped. Halt waits for the canceled runs to return, so that they can't
+// send any more commands to the instrument's controllers; if the runs don't return before the
+// context is canceled or the halt timeout elapses, Halt returns an error but the instrument stays
+// halted.
+func (o *JobOrchestrator) Halt(ctx context.Context, instrumentID InstrumentID) error {
+	ended := o.cancelRuns(instrumentID)
+
+	const haltTimeout = 5 * time.Second
+	ctx, cancel := context.WithTimeout(ctx, haltTimeout)
+	defer cancel()
+	for id, runEnded := range ended {
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(
+				ctx.Err(), "canceled run of job %d of instrument %d didn't return", id, instrumentID,
+			)
+		case <-runEnded:
+		}
+	}
+	return nil
+}
+
+func (o *JobOrchestrator) cancelRuns(
+	instrumentID InstrumentID,
+) (ended map[AutomationJobID]<-chan struct{}) {
+	o.runsMu.Lock()
+	defer o.runsMu.Unlock()
+
+	o.halted[instrumentID] = true
+	ended = make(map[AutomationJobID]<-chan struct{})
+	for id, run := range o.runs {
+		if run.instrumentID != instrumentID {
+			continue
+		}
+		run.canceler()
+		// The run may be stuck waiting on a controller, so its reservations shouldn't wait for it to end
+		o.control.ReleaseAll(run.holder)
+		delete(o.runs, id)
+		ended[id] = run.ended
+		o.logger.Warnf("canceled run of job %d for emergency stop of instrument %d", id, instrumentID)
+	}
+	return ended
+}
+
+// Resume allows runs of the instrument's jobs to start again after Halt.
+func (o *JobOrchestrator) Resume(instrumentID InstrumentID) {
+	o.runsMu.Lock()
+	defer o.runsMu.Unlock()
+
+	delete(o.halted, instrumentID)
+}
+
+// Halted reports whether runs of the instrument's jobs are currently blocked by Halt.
+func (o *JobOrchestrator) Halted(instrumentID InstrumentID) bool {
+	o.runsMu.Lock()
+	defer o.runsMu.Unlock()
+
+	return o.halted[instrumentID]
+}
+
+// Orchestration
+
 func (o *JobOrchestrator) Orchestrate(ctx context.Context) error {
 	o.mu.Lock()
 	ctx, o.canceler = context.WithCancel(ctx)
@@ -0,0 +1,82 @@
+package instruments
+
+import (
+	"net"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/env"
+)
+
+const envPrefix = "INSTRUMENTS_"
+
+type Config struct {
+	// SecretsKey is the 32-byte key used to encrypt secrets (such as controller passwords) stored in
+	// the database. If it's nil, no secrets can be stored.
+	SecretsKey []byte
+	// ControlLeaseDuration is how long an operator's lease on control of an instrument lasts before
+	// it must be renewed.
+	ControlLeaseDuration time.Duration
+	// ControlLeaseAbsenceTimeout is how long the holder of a control lease may be absent from the
+	// instrument's page before the lease is released.
+	ControlLeaseAbsenceTimeout time.Duration
+	// WebhookAllowedNetworks are the non-public networks which webhook endpoints are allowed to be
+	// in. Otherwise, webhook deliveries are only sent to public IP addresses, so that instrument
+	// administrators can't use webhooks to send requests to the server's internal network.
+	WebhookAllowedNetworks []*net.IPNet
+	// WebhookDeliveryRetention is how long finished webhook deliveries are kept in the delivery logs
+	// of webhooks.
+	WebhookDeliveryRetention time.Duration
+}
+
+func GetConfig() (c Config, err error) {
+	const secretsKeySize = 32
+	// We don't generate a random key when none is set, since secrets encrypted with it would become
+	// undecryptable when the server is restarted
+	if c.SecretsKey, err = env.GetBase64(envPrefix + "SECRETS_KEY"); err != nil {
+		return Config{}, errors.Wrap(err, "couldn't make secrets key config")
+	}
+	if c.SecretsKey != nil && len(c.SecretsKey) != secretsKeySize {
+		return Config{}, errors.Errorf(
+			"%sSECRETS_KEY must be a base64-encoded %d-byte key", envPrefix, secretsKeySize,
+		)
+	}
+
+	const defaultLeaseDuration = 15 // default: 15 minutes
+	rawLeaseDuration, err := env.GetInt64(envPrefix+"CONTROLLEASE_DURATION", defaultLeaseDuration)
+	if err != nil {
+		return Config{}, errors.Wrap(err, "couldn't make control lease duration config")
+	}
+	c.ControlLeaseDuration = time.Duration(rawLeaseDuration) * time.Minute
+
+	const defaultAbsenceTimeout = 30 // default: 30 seconds
+	rawAbsenceTimeout, err := env.GetInt64(
+		envPrefix+"CONTROLLEASE_ABSENCETIMEOUT", defaultAbsenceTimeout,
+	)
+	if err != nil {
+		return Config{}, errors.Wrap(err, "couldn't make control lease absence timeout config")
+	}
+	c.ControlLeaseAbsenceTimeout = time.Duration(rawAbsenceTimeout) * time.Second
+
+	for _, rawNetwork := range strings.Fields(
+		env.GetString(envPrefix+"WEBHOOKS_ALLOWEDNETWORKS", ""),
+	) {
+		_, network, err := net.ParseCIDR(rawNetwork)
+		if err != nil {
+			return Config{}, errors.Wrapf(err, "couldn't parse allowed webhook network %s", rawNetwork)
+		}
+		c.WebhookAllowedNetworks = append(c.WebhookAllowedNetworks, network)
+	}
+
+	const defaultDeliveryRetention = 30 // default: 30 days
+	rawDeliveryRetention, err := env.GetInt64(
+		envPrefix+"WEBHOOKS_DELIVERYRETENTION", defaultDeliveryRetention,
+	)
+	if err != nil {
+		return Config{}, errors.Wrap(err, "couldn't make webhook delivery retention config")
+	}
+	const day = 24 * time.Hour
+	c.WebhookDeliveryRetention = time.Duration(rawDeliveryRetention) * day
+	return c, nil
+}
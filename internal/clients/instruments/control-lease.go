@@ -0,0 +1,192 @@
+package instruments
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+)
+
+// ControlLeaser manages the leases which give one operator at a time control of an instrument, and
+// it notifies listeners of changes to the leases.
+type ControlLeaser struct {
+	is             *Store
+	duration       time.Duration
+	absenceTimeout time.Duration
+
+	absentSince map[InstrumentID]time.Time
+	mu          *sync.Mutex
+	changed     chan struct{}
+
+	logger godest.Logger
+}
+
+func NewControlLeaser(is *Store, c Config, logger godest.Logger) *ControlLeaser {
+	return &ControlLeaser{
+		is:             is,
+		duration:       c.ControlLeaseDuration,
+		absenceTimeout: c.ControlLeaseAbsenceTimeout,
+		absentSince:    make(map[InstrumentID]time.Time),
+		mu:             &sync.Mutex{},
+		changed:        make(chan struct{}),
+		logger:         logger,
+	}
+}
+
+func (l *ControlLeaser) broadcastChange() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	close(l.changed)
+	l.changed = make(chan struct{})
+}
+
+// resetAbsence forgets when the holder of the lease on the instrument was first noticed to be
+// absent, so that a new or renewed lease isn't expired for a previous holder's absence.
+func (l *ControlLeaser) resetAbsence(iid InstrumentID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.absentSince, iid)
+}
+
+// Changed returns a channel which is closed at the next change to the lease of any instrument.
+func (l *ControlLeaser) Changed() <-chan struct{} {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.changed
+}
+
+// Get returns the unexpired lease on the instrument, if there is one.
+func (l *ControlLeaser) Get(
+	ctx context.Context, iid InstrumentID,
+) (lease ControlLease, held bool, err error) {
+	if lease, held, err = l.is.GetControlLease(ctx, iid); err != nil || !held {
+		return ControlLease{}, false, err
+	}
+	if !lease.ExpirationTime.After(time.Now()) {
+		return ControlLease{}, false, nil
+	}
+	return lease, true, nil
+}
+
+// Request gives the lease on the instrument to the user if nobody else holds it, or renews it if
+// the user already holds it. Otherwise, the request is recorded for the lease's holder to see.
+func (l *ControlLeaser) Request(
+	ctx context.Context, iid InstrumentID, identityID string,
+) (lease ControlLease, granted bool, err error) {
+	now := time.Now()
+	if lease, err = l.is.SetControlLeaseIfAvailable(ctx, ControlLease{
+		InstrumentID:   iid,
+		IdentityID:     identityID,
+		AcquireTime:    now,
+		ExpirationTime: now.Add(l.duration),
+	}); err != nil {
+		return ControlLease{}, false, err
+	}
+	defer l.broadcastChange()
+	if lease.IdentityID == identityID {
+		l.resetAbsence(iid)
+		return lease, true, nil
+	}
+	if err = l.is.SetControlLeaseRequester(ctx, lease, identityID); err != nil {
+		return ControlLease{}, false, err
+	}
+	lease.RequesterIdentityID = identityID
+	return lease, false, nil
+}
+
+// Transfer passes the lease on to another user, unless it has since been renewed or transferred.
+func (l *ControlLeaser) Transfer(ctx context.Context, lease ControlLease, identityID string) error {
+	now := time.Now()
+	if err := l.is.TransferControlLease(ctx, lease, ControlLease{
+		InstrumentID:   lease.InstrumentID,
+		IdentityID:     identityID,
+		AcquireTime:    now,
+		ExpirationTime: now.Add(l.duration),
+	}); err != nil {
+		return err
+	}
+	l.resetAbsence(lease.InstrumentID)
+	l.broadcastChange()
+	return nil
+}
+
+// Release ends the lease, unless it has since been renewed or transferred.
+func (l *ControlLeaser) Release(ctx context.Context, lease ControlLease) error {
+	if err := l.is.DeleteControlLease(ctx, lease); err != nil {
+		return err
+	}
+	l.resetAbsence(lease.InstrumentID)
+	l.broadcastChange()
+	return nil
+}
+
+// Expire releases the leases which have expired, as well as the leases whose holders have been
+// absent from the instrument for longer than the absence timeout. Failures to release individual
+// leases are logged, so that they're retried at the next expiration.
+func (l *ControlLeaser) Expire(
+	ctx context.Context, isPresent func(iid InstrumentID, identityID string) bool,
+) error {
+	leases, err := l.is.GetControlLeases(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	for _, lease := range leases {
+		// A lease which can't be released shouldn't prevent the other leases from expiring
+		if !lease.ExpirationTime.After(now) {
+			if err := l.Release(ctx, lease); err != nil {
+				l.logger.Error(errors.Wrapf(
+					err, "couldn't release expired lease on instrument %d", lease.InstrumentID,
+				))
+			}
+			continue
+		}
+
+		l.mu.Lock()
+		if isPresent(lease.InstrumentID, lease.IdentityID) {
+			delete(l.absentSince, lease.InstrumentID)
+			l.mu.Unlock()
+			continue
+		}
+		absentSince, ok := l.absentSince[lease.InstrumentID]
+		if !ok {
+			l.absentSince[lease.InstrumentID] = now
+		}
+		l.mu.Unlock()
+		if !ok || now.Sub(absentSince) < l.absenceTimeout {
+			continue
+		}
+
+		if err := l.Release(ctx, lease); err != nil {
+			l.logger.Error(errors.Wrapf(
+				err, "couldn't release lease of absent holder on instrument %d", lease.InstrumentID,
+			))
+		}
+	}
+	return nil
+}
+
+// PeriodicallyExpire runs Expire at regular intervals until the context is canceled. Failures to
+// expire leases are logged rather than returned, so that they're retried at the next interval.
+func (l *ControlLeaser) PeriodicallyExpire(
+	ctx context.Context, interval time.Duration,
+	isPresent func(iid InstrumentID, identityID string) bool,
+) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := l.Expire(ctx, isPresent); err != nil {
+				l.logger.Error(errors.Wrap(err, "couldn't expire control leases"))
+			}
+		}
+	}
+}
@@ -0,0 +1,317 @@
+package instruments
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Control Holder
+
+// ControlHolder identifies who or what sends commands to a controller: either a run of an
+// automation job, or an operator.
+type ControlHolder struct {
+	JobID      AutomationJobID
+	JobName    string
+	OperatorID string
+}
+
+func (h ControlHolder) IsAutomation() bool {
+	return h.JobID != 0
+}
+
+func (h ControlHolder) String() string {
+	if h.IsAutomation() {
+		return fmt.Sprintf("automation job %d %s", h.JobID, h.JobName)
+	}
+	return fmt.Sprintf("operator %s", h.OperatorID)
+}
+
+type controlHolderContextKey struct{}
+
+// WithControlHolder returns a context for sending commands on behalf of the control holder.
+func WithControlHolder(ctx context.Context, holder ControlHolder) context.Context {
+	return context.WithValue(ctx, controlHolderContextKey{}, holder)
+}
+
+// ControlHolderFromContext returns the control holder which the context sends commands for, if it
+// has one.
+func ControlHolderFromContext(ctx context.Context) (holder ControlHolder, ok bool) {
+	holder, ok = ctx.Value(controlHolderContextKey{}).(ControlHolder)
+	return holder, ok
+}
+
+// Control Status
+
+// ControlStatus describes who or what is in control of a controller.
+type ControlStatus struct {
+	Reserved        bool
+	Reservation     ControlHolder
+	ReservationTime time.Time
+	Commanding      bool
+	Commander       ControlHolder
+	Queued          int
+}
+
+// ReservationError reports that an operator's command was rejected because an automation job run
+// has reserved the controller.
+type ReservationError struct {
+	ControllerID ControllerID
+	Holder       ControlHolder
+}
+
+func (e ReservationError) Error() string {
+	return fmt.Sprintf(
+		"controller %d is under the control of %s until it finishes", e.ControllerID, e.Holder,
+	)
+}
+
+// Control Arbiter
+
+type controllerControl struct {
+	queue    chan struct{}
+	released chan struct{}
+	// command identifies the command which holds the slot in the queue, so that a command whose
+	// slot was already freed by ReleaseAll doesn't free the slot of a later command
+	command uint64
+	status  ControlStatus
+}
+
+// ControlArbiter serializes the commands sent to each controller, and it manages the exclusive
+// reservations which automation job runs make of the controllers they command. Reservations are
+// unrelated to the control leases which operators take on instruments.
+type ControlArbiter struct {
+	controllers map[ControllerID]*controllerControl
+	// waiting tracks the controller whose reservation each automation job run is waiting for
+	waiting map[ControlHolder]ControllerID
+	mu      *sync.Mutex
+	changed chan struct{}
+}
+
+func NewControlArbiter() *ControlArbiter {
+	return &ControlArbiter{
+		controllers: make(map[ControllerID]*controllerControl),
+		waiting:     make(map[ControlHolder]ControllerID),
+		mu:          &sync.Mutex{},
+		changed:     make(chan struct{}),
+	}
+}
+
+// get returns the control of the controller, creating it if needed. a.mu must be held.
+func (a *ControlArbiter) get(cid ControllerID) *controllerControl {
+	c, ok := a.controllers[cid]
+	if !ok {
+		c = &controllerControl{
+			queue: make(chan struct{}, 1),
+		}
+		a.controllers[cid] = c
+	}
+	return c
+}
+
+// broadcastChange notifies listeners of a change in control. a.mu must be held.
+func (a *ControlArbiter) broadcastChange() {
+	close(a.changed)
+	a.changed = make(chan struct{})
+}
+
+// Changed returns a channel which is closed at the next change in control of any controller.
+func (a *ControlArbiter) Changed() <-chan struct{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return a.changed
+}
+
+func (a *ControlArbiter) GetStatus(cid ControllerID) ControlStatus {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if c, ok := a.controllers[cid]; ok {
+		return c.status
+	}
+	return ControlStatus{}
+}
+
+// reservationWouldDeadlock reports whether the holder would wait forever for the controller's
+// current reservation to be released, because the current reservation's holder is waiting
+// (directly or through other runs) for a controller which the holder has reserved. a.mu must be
+// held.
+func (a *ControlArbiter) reservationWouldDeadlock(cid ControllerID, holder ControlHolder) bool {
+	visited := make(map[ControllerID]bool)
+	for !visited[cid] {
+		visited[cid] = true
+		c, ok := a.controllers[cid]
+		if !ok || !c.status.Reserved {
+			return false
+		}
+		if c.status.Reservation == holder {
+			return true
+		}
+		if cid, ok = a.waiting[c.status.Reservation]; !ok {
+			return false
+		}
+	}
+	return false
+}
+
+// Reserve gives the automation job run exclusive control of the controller until the reservation
+// is released, waiting for any other run's reservation of the controller to be released first. If
+// the other run is itself waiting for a controller reserved by this run, Reserve returns an error
+// instead of waiting forever, so that this run can fail and release its reservations.
+func (a *ControlArbiter) Reserve(
+	ctx context.Context, cid ControllerID, holder ControlHolder,
+) error {
+	for {
+		a.mu.Lock()
+		c := a.get(cid)
+		if !c.status.Reserved {
+			c.status.Reserved = true
+			c.status.Reservation = holder
+			c.status.ReservationTime = time.Now()
+			c.released = make(chan struct{})
+			a.broadcastChange()
+			a.mu.Unlock()
+			return nil
+		}
+		if c.status.Reservation == holder {
+			a.mu.Unlock()
+			return nil
+		}
+		if a.reservationWouldDeadlock(cid, holder) {
+			reservation := c.status.Reservation
+			a.mu.Unlock()
+			return errors.Errorf(
+				"couldn't reserve controller %d, since %s is waiting for a controller reserved by %s",
+				cid, reservation, holder,
+			)
+		}
+		released := c.released
+		a.waiting[holder] = cid
+		a.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+		case <-released:
+		}
+		a.mu.Lock()
+		delete(a.waiting, holder)
+		a.mu.Unlock()
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// ReleaseAll releases every reservation held by the automation job run, and it frees the slot in
+// the command queue of any controller which the run is still commanding, so that a run stuck on a
+// command doesn't hold up commands from anyone else.
+func (a *ControlArbiter) ReleaseAll(holder ControlHolder) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	changed := false
+	for _, c := range a.controllers {
+		if c.status.Commanding && c.status.Commander == holder {
+			c.freeSlot()
+			changed = true
+		}
+		if !c.status.Reserved || c.status.Reservation != holder {
+			continue
+		}
+		c.status.Reserved = false
+		c.status.Reservation = ControlHolder{}
+		c.status.ReservationTime = time.Time{}
+		close(c.released)
+		changed = true
+	}
+	if changed {
+		a.broadcastChange()
+	}
+}
+
+// freeSlot frees the slot in the command queue held by the current command. a.mu must be held.
+func (c *controllerControl) freeSlot() {
+	c.status.Commanding = false
+	c.status.Commander = ControlHolder{}
+	<-c.queue
+}
+
+// checkReservation returns a ReservationError if the holder may not command the controller because
+// of another holder's reservation. a.mu must be held.
+func checkReservation(cid ControllerID, c *controllerControl, holder ControlHolder) error {
+	if c.status.Reserved && c.status.Reservation != holder {
+		return ReservationError{
+			ControllerID: cid,
+			Holder:       c.status.Reservation,
+		}
+	}
+	return nil
+}
+
+// Command sends the command after all earlier commands to the controller have been sent. The send
+// function should only send the command, and any waiting for the controller to respond should be
+// done after Command returns, so that it doesn't hold up later commands. Commands from automation
+// job runs first reserve the controller until the run releases it, while commands from operators
+// are rejected with a ReservationError if the controller is reserved.
+func (a *ControlArbiter) Command(
+	ctx context.Context, cid ControllerID, holder ControlHolder, send func() error,
+) error {
+	if holder.IsAutomation() {
+		if err := a.Reserve(ctx, cid, holder); err != nil {
+			return err
+		}
+	}
+
+	a.mu.Lock()
+	c := a.get(cid)
+	if err := checkReservation(cid, c, holder); err != nil {
+		a.mu.Unlock()
+		return err
+	}
+	c.status.Queued++
+	a.broadcastChange()
+	a.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		a.mu.Lock()
+		c.status.Queued--
+		a.broadcastChange()
+		a.mu.Unlock()
+		return ctx.Err()
+	case c.queue <- struct{}{}:
+	}
+
+	a.mu.Lock()
+	c.status.Queued--
+	// The controller may have been reserved while the command was waiting in the queue
+	if err := checkReservation(cid, c, holder); err != nil {
+		a.broadcastChange()
+		a.mu.Unlock()
+		<-c.queue
+		return err
+	}
+	c.status.Commanding = true
+	c.status.Commander = holder
+	c.command++
+	command := c.command
+	a.broadcastChange()
+	a.mu.Unlock()
+
+	defer func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		// ReleaseAll may have already freed the slot for another command
+		if !c.status.Commanding || c.command != command {
+			return
+		}
+		c.freeSlot()
+		a.broadcastChange()
+	}()
+	return send()
+}
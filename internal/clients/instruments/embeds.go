@@ -23,6 +23,18 @@ var MigrationFiles []string = []string{
 	"5-enabled-not-null-v0.3.5",
 	"6-add-automation-jobs-v0.3.5",
 	"7-add-names-v0.3.5",
+	"8-add-samples-v0.3.6",
+	"9-add-controller-mqtt-auth-v0.3.6",
+	"10-add-controller-schemas-v0.3.6",
+	"11-add-emergency-stops-v0.3.6",
+	"12-add-controller-limits-v0.3.6",
+	"13-add-controller-settings-v0.3.6",
+	"14-add-control-leases-v0.3.6",
+	"15-add-instrument-members-v0.3.6",
+	"16-add-instrument-visibility-v0.3.6",
+	"17-add-audit-events-v0.3.6",
+	"18-add-webhooks-v0.3.6",
+	"19-add-instrument-create-times-v0.3.6",
 }
 
 // Embeds
@@ -0,0 +1,62 @@
+package instruments
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "pslive"
+	metricsSubsystem = "automation"
+)
+
+var (
+	activeRunsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, metricsSubsystem, "job_runs_active"),
+		"Number of automation job runs which are in progress.",
+		nil, nil,
+	)
+	jobRunsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "job_runs_total",
+		Help: "Number of finished runs of each automation job, by status (succeeded, failed, " +
+			"canceled, or skipped).",
+	}, []string{"instrument", "job", "status"})
+	jobRunDurationHistogram = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "job_run_duration_seconds",
+		Help:      "Time taken by each run of each automation job, excluding skipped runs.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 14), //nolint:gomnd // 1 sec to ~2 hours
+	}, []string{"instrument", "job"})
+)
+
+func observeJobRun(job *OrchestratedJob, status JobRunStatus, start time.Time) {
+	const idBase = 10
+	instrument := strconv.FormatInt(int64(job.InstrumentID), idBase)
+	id := strconv.FormatInt(int64(job.ID), idBase)
+	jobRunsCounter.WithLabelValues(instrument, id, string(status)).Inc()
+	if status != JobRunSkipped {
+		jobRunDurationHistogram.WithLabelValues(instrument, id).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Describe implements [prometheus.Collector] for the metrics of automation jobs.
+func (o *JobOrchestrator) Describe(ch chan<- *prometheus.Desc) {
+	ch <- activeRunsDesc
+	jobRunsCounter.Describe(ch)
+	jobRunDurationHistogram.Describe(ch)
+}
+
+// Collect implements [prometheus.Collector] for the metrics of automation jobs.
+func (o *JobOrchestrator) Collect(ch chan<- prometheus.Metric) {
+	o.runsMu.Lock()
+	active := len(o.runs)
+	o.runsMu.Unlock()
+	ch <- prometheus.MustNewConstMetric(activeRunsDesc, prometheus.GaugeValue, float64(active))
+	jobRunsCounter.Collect(ch)
+	jobRunDurationHistogram.Collect(ch)
+}
@@ -0,0 +1,10 @@
+-- Controller Schemas
+
+create table instruments_controller_schema (
+  controller_id integer primary key,
+  schema        text    not null default '',
+  constraint instruments_controller_schema_fk_controller_id
+    foreign key(controller_id)
+      references instruments_controller(id)
+      on delete cascade
+) strict;
@@ -0,0 +1 @@
+drop table instruments_emergency_stop;
@@ -0,0 +1,11 @@
+-- Emergency Stops
+
+create table instruments_emergency_stop (
+  instrument_id integer primary key,
+  stop_time     integer not null,
+  identity_id   text    not null,
+  constraint instruments_emergency_stop_fk_instrument_id
+    foreign key(instrument_id)
+      references instruments_instrument(id)
+      on delete cascade
+) strict;
@@ -0,0 +1,17 @@
+-- Controller Limits
+
+create table instruments_controller_limits (
+  controller_id     integer primary key,
+  max_pump_volume   real    not null default 0,
+  min_pump_flowrate real    not null default 0,
+  max_pump_flowrate real    not null default 0,
+  max_imaging_steps integer not null default 0,
+  min_iso           integer not null default 0,
+  max_iso           integer not null default 0,
+  min_shutter_speed integer not null default 0,
+  max_shutter_speed integer not null default 0,
+  constraint instruments_controller_limits_fk_controller_id
+    foreign key(controller_id)
+      references instruments_controller(id)
+      on delete cascade
+) strict;
@@ -0,0 +1,23 @@
+-- Controller Settings
+
+create table instruments_controller_settings (
+  controller_id                  integer primary key,
+  saved                          integer not null default 0,
+  pump_forward                   integer not null default 1,
+  pump_volume                    real    not null default 0,
+  pump_flowrate                  real    not null default 0,
+  camera_iso                     integer not null default 0,
+  camera_shutter_speed           integer not null default 0,
+  camera_auto_white_balance      integer not null default 1,
+  camera_white_balance_red_gain  real    not null default 0,
+  camera_white_balance_blue_gain real    not null default 0,
+  imager_forward                 integer not null default 1,
+  imager_step_volume             real    not null default 0,
+  imager_step_delay              real    not null default 0,
+  imager_steps                   integer not null default 0,
+  restore_camera                 integer not null default 0,
+  constraint instruments_controller_settings_fk_controller_id
+    foreign key(controller_id)
+      references instruments_controller(id)
+      on delete cascade
+) strict;
@@ -0,0 +1 @@
+drop table instruments_control_lease;
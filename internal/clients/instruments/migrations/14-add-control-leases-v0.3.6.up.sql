@@ -0,0 +1,13 @@
+-- Control Leases
+
+create table instruments_control_lease (
+  instrument_id         integer primary key,
+  identity_id           text    not null,
+  acquire_time          integer not null,
+  expiration_time       integer not null,
+  requester_identity_id text    not null default '',
+  constraint instruments_control_lease_fk_instrument_id
+    foreign key(instrument_id)
+      references instruments_instrument(id)
+      on delete cascade
+) strict;
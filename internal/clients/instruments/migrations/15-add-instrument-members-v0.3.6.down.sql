@@ -0,0 +1,3 @@
+drop index instruments_instrument_member_idx_identity_id;
+
+drop table instruments_instrument_member;
@@ -0,0 +1,15 @@
+-- Instrument Members
+
+create table instruments_instrument_member (
+  instrument_id integer not null,
+  identity_id   text    not null,
+  role          text    not null check (role in ('admin', 'operator', 'viewer')),
+  primary key (instrument_id, identity_id),
+  constraint instruments_instrument_member_fk_instrument_id
+    foreign key(instrument_id)
+      references instruments_instrument(id)
+      on delete cascade
+) strict;
+
+create index instruments_instrument_member_idx_identity_id
+on instruments_instrument_member (identity_id);
@@ -0,0 +1,2 @@
+alter table instruments_instrument
+drop column visibility;
@@ -0,0 +1,5 @@
+-- Instrument Visibility
+
+alter table instruments_instrument
+add column visibility text not null default 'public'
+  check (visibility in ('public', 'unlisted', 'members'));
@@ -0,0 +1,7 @@
+drop trigger instruments_audit_event_prevent_delete;
+
+drop trigger instruments_audit_event_prevent_update;
+
+drop index instruments_audit_event_idx_instrument_id_time;
+
+drop table instruments_audit_event;
@@ -0,0 +1,34 @@
+-- Audit Events
+
+-- Audit events don't reference instruments with a foreign key, so that the record of what was done
+-- to an instrument outlives the instrument.
+create table instruments_audit_event (
+  id                integer primary key,
+  instrument_id     integer not null,
+  time              integer not null,
+  actor_identity_id text    not null,
+  api_token_id      integer not null default 0,
+  source            text    not null,
+  component         text    not null,
+  action            text    not null,
+  parameters        text    not null,
+  outcome           text    not null check (outcome in ('succeeded', 'failed')),
+  error             text    not null default ''
+) strict;
+
+create index instruments_audit_event_idx_instrument_id_time
+on instruments_audit_event (instrument_id, time);
+
+-- The audit log is append-only
+
+create trigger instruments_audit_event_prevent_update
+before update on instruments_audit_event
+begin
+  select raise(abort, 'audit events cannot be changed');
+end;
+
+create trigger instruments_audit_event_prevent_delete
+before delete on instruments_audit_event
+begin
+  select raise(abort, 'audit events cannot be deleted');
+end;
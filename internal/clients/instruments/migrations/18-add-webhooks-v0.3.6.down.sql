@@ -0,0 +1,9 @@
+drop index instruments_webhook_delivery_idx_status_next_attempt_time;
+
+drop index instruments_webhook_delivery_idx_webhook_id_create_time;
+
+drop table instruments_webhook_delivery;
+
+drop index instruments_webhook_idx_instrument_id;
+
+drop table instruments_webhook;
@@ -0,0 +1,48 @@
+-- Webhooks
+
+create table instruments_webhook (
+  id               integer primary key,
+  instrument_id    integer not null,
+  url              text    not null,
+  description      text    not null default '',
+  -- event_types is a space-separated list of the types of events which are delivered
+  event_types      text    not null,
+  encrypted_secret text    not null,
+  enabled          integer not null default 1 check (enabled in (0, 1)),
+  constraint instruments_webhook_fk_instrument_id
+    foreign key(instrument_id)
+      references instruments_instrument(id)
+      on delete cascade
+) strict;
+
+create index instruments_webhook_idx_instrument_id
+on instruments_webhook (instrument_id);
+
+-- Webhook Deliveries
+
+-- Webhook deliveries form an outbox: each event is recorded as a pending delivery in the same
+-- database as the rest of the instrument's state, and pending deliveries are sent (and retried)
+-- in the background until they succeed or run out of attempts.
+create table instruments_webhook_delivery (
+  id                integer primary key,
+  webhook_id        integer not null,
+  event_type        text    not null,
+  payload           text    not null,
+  create_time       integer not null,
+  status            text    not null check (status in ('pending', 'succeeded', 'failed')),
+  attempts          integer not null default 0,
+  next_attempt_time integer not null,
+  last_attempt_time integer not null default 0,
+  last_status_code  integer not null default 0,
+  last_error        text    not null default '',
+  constraint instruments_webhook_delivery_fk_webhook_id
+    foreign key(webhook_id)
+      references instruments_webhook(id)
+      on delete cascade
+) strict;
+
+create index instruments_webhook_delivery_idx_webhook_id_create_time
+on instruments_webhook_delivery (webhook_id, create_time);
+
+create index instruments_webhook_delivery_idx_status_next_attempt_time
+on instruments_webhook_delivery (status, next_attempt_time);
@@ -0,0 +1,7 @@
+-- Instrument Creation Times
+
+-- Instrument IDs may be reused after an instrument is deleted, so audit events are only shown for
+-- the instrument if they were recorded after it was created. The creation times of existing
+-- instruments aren't known, so all of their audit events are still shown.
+alter table instruments_instrument
+add column create_time integer not null default 0;
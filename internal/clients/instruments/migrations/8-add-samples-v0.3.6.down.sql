@@ -0,0 +1,3 @@
+drop table instruments_sample;
+
+drop table instruments_acquisition;
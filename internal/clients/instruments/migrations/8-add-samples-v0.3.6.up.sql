@@ -0,0 +1,37 @@
+-- Sample
+
+create table instruments_sample (
+  instrument_id integer primary key,
+  project_id    text    not null default "",
+  sample_id     text    not null default "",
+  operator      text    not null default "",
+  sampling_gear text    not null default "",
+  latitude      real    not null default 0,
+  longitude     real    not null default 0,
+  depth_min     real    not null default 0,
+  depth_max     real    not null default 0,
+  volume        real    not null default 0,
+  net_mesh      real    not null default 0,
+  constraint instruments_sample_fk_instrument_id
+    foreign key(instrument_id)
+      references instruments_instrument(id)
+      on delete cascade
+) strict;
+
+-- Acquisition
+
+create table instruments_acquisition (
+  id            integer primary key,
+  instrument_id integer not null,
+  controller_id integer not null,
+  start_time    integer not null,
+  source        text    not null,
+  metadata      text    not null,
+  constraint instruments_acquisition_fk_instrument_id
+    foreign key(instrument_id)
+      references instruments_instrument(id)
+      on delete cascade
+) strict;
+
+create index instruments_acquisition_idx_instrument_id_start_time
+on instruments_acquisition (instrument_id, start_time);
@@ -0,0 +1 @@
+drop table instruments_controller_mqtt_auth;
@@ -0,0 +1,14 @@
+-- Controller MQTT Authentication
+
+create table instruments_controller_mqtt_auth (
+  controller_id        integer primary key,
+  username             text    not null default "",
+  encrypted_password   text    not null default "",
+  ca_cert              text    not null default "",
+  client_cert          text    not null default "",
+  encrypted_client_key text    not null default "",
+  constraint instruments_controller_mqtt_auth_fk_controller_id
+    foreign key(controller_id)
+      references instruments_controller(id)
+      on delete cascade
+) strict;
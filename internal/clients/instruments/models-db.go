@@ -1,6 +1,10 @@
 package instruments
 
 import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
 	"zombiezen.com/go/sqlite"
 )
 
@@ -10,6 +14,7 @@ type (
 	CameraID        int64
 	ControllerID    int64
 	AutomationJobID int64
+	AcquisitionID   int64
 )
 
 type Identifiable[ID ~int64] interface {
@@ -195,6 +200,12 @@ func (c Controller) NewDelete() map[string]interface{} {
 	}
 }
 
+func newControllerSelection(id ControllerID) map[string]interface{} {
+	return map[string]interface{}{
+		"$id": id,
+	}
+}
+
 func (c Controller) newProtocolSelection() map[string]interface{} {
 	return map[string]interface{}{
 		"$protocol": c.Protocol,
@@ -255,6 +266,615 @@ func (sel *controllersSelector) Controllers() []Controller {
 	return controllers
 }
 
+// Controller MQTT Authentication
+
+// MQTTAuth holds the credentials and TLS certificates used to connect to a controller's MQTT
+// broker. Certificates and keys are PEM-encoded.
+type MQTTAuth struct {
+	Username   string
+	Password   string
+	CACert     string
+	ClientCert string
+	ClientKey  string
+}
+
+// Status summarizes the authentication settings without exposing any secrets, so that it can be
+// shown to users.
+func (a MQTTAuth) Status() MQTTAuthStatus {
+	return MQTTAuthStatus{
+		HasUsername:   a.Username != "",
+		HasPassword:   a.Password != "",
+		HasCACert:     a.CACert != "",
+		HasClientCert: a.ClientCert != "",
+		HasClientKey:  a.ClientKey != "",
+	}
+}
+
+type MQTTAuthStatus struct {
+	HasUsername   bool
+	HasPassword   bool
+	HasCACert     bool
+	HasClientCert bool
+	HasClientKey  bool
+}
+
+// encryptedMQTTAuth is the representation of MQTTAuth as stored in the database, with secrets
+// encrypted.
+type encryptedMQTTAuth struct {
+	ControllerID       ControllerID
+	Username           string
+	EncryptedPassword  string
+	CACert             string
+	ClientCert         string
+	EncryptedClientKey string
+}
+
+func (a encryptedMQTTAuth) newUpsert() map[string]interface{} {
+	return map[string]interface{}{
+		"$controller_id":        a.ControllerID,
+		"$username":             a.Username,
+		"$encrypted_password":   a.EncryptedPassword,
+		"$ca_cert":              a.CACert,
+		"$client_cert":          a.ClientCert,
+		"$encrypted_client_key": a.EncryptedClientKey,
+	}
+}
+
+func newMQTTAuthSelection(controllerID ControllerID) map[string]interface{} {
+	return map[string]interface{}{
+		"$controller_id": controllerID,
+	}
+}
+
+func newMQTTAuthsByInstrumentSelection(instrumentID InstrumentID) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": instrumentID,
+	}
+}
+
+type mqttAuthsSelector struct {
+	auths []encryptedMQTTAuth
+}
+
+func newMQTTAuthsSelector() *mqttAuthsSelector {
+	return &mqttAuthsSelector{
+		auths: make([]encryptedMQTTAuth, 0),
+	}
+}
+
+func (sel *mqttAuthsSelector) Step(s *sqlite.Stmt) error {
+	sel.auths = append(sel.auths, encryptedMQTTAuth{
+		ControllerID:       ControllerID(s.GetInt64("controller_id")),
+		Username:           s.GetText("username"),
+		EncryptedPassword:  s.GetText("encrypted_password"),
+		CACert:             s.GetText("ca_cert"),
+		ClientCert:         s.GetText("client_cert"),
+		EncryptedClientKey: s.GetText("encrypted_client_key"),
+	})
+	return nil
+}
+
+func (sel *mqttAuthsSelector) MQTTAuths() []encryptedMQTTAuth {
+	return sel.auths
+}
+
+// Controller Schema
+
+// ControllerSchema is a protocol-specific description of a controller's commands and states, for
+// protocols which are configured by the instrument admin rather than fixed by pslive.
+type ControllerSchema struct {
+	ControllerID ControllerID
+	Schema       string
+}
+
+func (s ControllerSchema) newUpsert() map[string]interface{} {
+	return map[string]interface{}{
+		"$controller_id": s.ControllerID,
+		"$schema":        s.Schema,
+	}
+}
+
+func newControllerSchemaSelection(controllerID ControllerID) map[string]interface{} {
+	return map[string]interface{}{
+		"$controller_id": controllerID,
+	}
+}
+
+func newControllerSchemasByInstrumentSelection(instrumentID InstrumentID) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": instrumentID,
+	}
+}
+
+type controllerSchemasSelector struct {
+	schemas []ControllerSchema
+}
+
+func newControllerSchemasSelector() *controllerSchemasSelector {
+	return &controllerSchemasSelector{
+		schemas: make([]ControllerSchema, 0),
+	}
+}
+
+func (sel *controllerSchemasSelector) Step(s *sqlite.Stmt) error {
+	sel.schemas = append(sel.schemas, ControllerSchema{
+		ControllerID: ControllerID(s.GetInt64("controller_id")),
+		Schema:       s.GetText("schema"),
+	})
+	return nil
+}
+
+func (sel *controllerSchemasSelector) ControllerSchemas() []ControllerSchema {
+	return sel.schemas
+}
+
+// Emergency Stop
+
+// EmergencyStop records that an operator halted all activity on an instrument; it remains in effect
+// until an instrument admin clears it.
+type EmergencyStop struct {
+	InstrumentID InstrumentID
+	StopTime     time.Time
+	IdentityID   string
+}
+
+func (s EmergencyStop) newUpsert() map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": s.InstrumentID,
+		"$stop_time":     s.StopTime.UnixMilli(),
+		"$identity_id":   s.IdentityID,
+	}
+}
+
+func newEmergencyStopSelection(instrumentID InstrumentID) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": instrumentID,
+	}
+}
+
+type emergencyStopsSelector struct {
+	stops []EmergencyStop
+}
+
+func newEmergencyStopsSelector() *emergencyStopsSelector {
+	return &emergencyStopsSelector{
+		stops: make([]EmergencyStop, 0),
+	}
+}
+
+func (sel *emergencyStopsSelector) Step(s *sqlite.Stmt) error {
+	sel.stops = append(sel.stops, EmergencyStop{
+		InstrumentID: InstrumentID(s.GetInt64("instrument_id")),
+		StopTime:     time.UnixMilli(s.GetInt64("stop_time")),
+		IdentityID:   s.GetText("identity_id"),
+	})
+	return nil
+}
+
+func (sel *emergencyStopsSelector) EmergencyStops() []EmergencyStop {
+	return sel.stops
+}
+
+// Control Lease
+
+// ControlLease gives one operator at a time control of an instrument, until the lease expires or
+// is released or transferred. Another user may request the lease from its holder.
+type ControlLease struct {
+	InstrumentID        InstrumentID
+	IdentityID          string
+	AcquireTime         time.Time
+	ExpirationTime      time.Time
+	RequesterIdentityID string
+}
+
+func (l ControlLease) newUpsert() map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id":   l.InstrumentID,
+		"$identity_id":     l.IdentityID,
+		"$acquire_time":    l.AcquireTime.UnixMilli(),
+		"$expiration_time": l.ExpirationTime.UnixMilli(),
+	}
+}
+
+func (l ControlLease) newRequesterUpdate(requesterIdentityID string) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id":         l.InstrumentID,
+		"$requester_identity_id": requesterIdentityID,
+	}
+}
+
+func (l ControlLease) newHolderUpdate(next ControlLease) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id":   l.InstrumentID,
+		"$identity_id":     l.IdentityID,
+		"$new_identity_id": next.IdentityID,
+		"$acquire_time":    next.AcquireTime.UnixMilli(),
+		"$expiration_time": next.ExpirationTime.UnixMilli(),
+	}
+}
+
+func (l ControlLease) newDelete() map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id":   l.InstrumentID,
+		"$identity_id":     l.IdentityID,
+		"$expiration_time": l.ExpirationTime.UnixMilli(),
+	}
+}
+
+func newControlLeaseSelection(instrumentID InstrumentID) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": instrumentID,
+	}
+}
+
+type controlLeasesSelector struct {
+	leases []ControlLease
+}
+
+func newControlLeasesSelector() *controlLeasesSelector {
+	return &controlLeasesSelector{
+		leases: make([]ControlLease, 0),
+	}
+}
+
+func (sel *controlLeasesSelector) Step(s *sqlite.Stmt) error {
+	sel.leases = append(sel.leases, ControlLease{
+		InstrumentID:        InstrumentID(s.GetInt64("instrument_id")),
+		IdentityID:          s.GetText("identity_id"),
+		AcquireTime:         time.UnixMilli(s.GetInt64("acquire_time")),
+		ExpirationTime:      time.UnixMilli(s.GetInt64("expiration_time")),
+		RequesterIdentityID: s.GetText("requester_identity_id"),
+	})
+	return nil
+}
+
+func (sel *controlLeasesSelector) ControlLeases() []ControlLease {
+	return sel.leases
+}
+
+// Instrument Member
+
+// MemberRole determines what a member of an instrument is allowed to do with the instrument. Each
+// role includes the permissions of the roles after it.
+type MemberRole string
+
+const (
+	MemberRoleAdmin    MemberRole = "admin"
+	MemberRoleOperator MemberRole = "operator"
+	MemberRoleViewer   MemberRole = "viewer"
+)
+
+// InstrumentMember grants an identity a role on an instrument, in addition to the instrument's
+// admin (who always has the admin role).
+type InstrumentMember struct {
+	InstrumentID InstrumentID
+	IdentityID   string
+	Role         MemberRole
+}
+
+func (m InstrumentMember) newUpsert() map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": m.InstrumentID,
+		"$identity_id":   m.IdentityID,
+		"$role":          string(m.Role),
+	}
+}
+
+func (m InstrumentMember) newDelete() map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": m.InstrumentID,
+		"$identity_id":   m.IdentityID,
+	}
+}
+
+func newInstrumentMembersSelection(instrumentID InstrumentID) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": instrumentID,
+	}
+}
+
+type instrumentMembersSelector struct {
+	members []InstrumentMember
+}
+
+func newInstrumentMembersSelector() *instrumentMembersSelector {
+	return &instrumentMembersSelector{
+		members: make([]InstrumentMember, 0),
+	}
+}
+
+func (sel *instrumentMembersSelector) Step(s *sqlite.Stmt) error {
+	sel.members = append(sel.members, InstrumentMember{
+		InstrumentID: InstrumentID(s.GetInt64("instrument_id")),
+		IdentityID:   s.GetText("identity_id"),
+		Role:         MemberRole(s.GetText("role")),
+	})
+	return nil
+}
+
+func (sel *instrumentMembersSelector) InstrumentMembers() []InstrumentMember {
+	return sel.members
+}
+
+// Audit Event
+
+type AuditEventID int64
+
+type AuditOutcome string
+
+const (
+	AuditOutcomeSucceeded AuditOutcome = "succeeded"
+	AuditOutcomeFailed    AuditOutcome = "failed"
+)
+
+// AuditEvent records an action which was taken on an instrument or one of its components, whether
+// by a user or by an automation job.
+type AuditEvent struct {
+	ID           AuditEventID
+	InstrumentID InstrumentID
+	Time         time.Time
+	// ActorIdentityID is empty for actions taken by automation jobs.
+	ActorIdentityID string
+	// APITokenID is zero for actions which weren't requested with an API token.
+	APITokenID int64
+	// Source is the network address of the request, or the automation job which took the action.
+	Source string
+	// Component is the path of the instrument's component, relative to the instrument.
+	Component  string
+	Action     string
+	Parameters map[string]string
+	Outcome    AuditOutcome
+	Error      string
+}
+
+func (e AuditEvent) newInsertion() (map[string]interface{}, error) {
+	parameters, err := json.Marshal(e.Parameters)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't serialize parameters")
+	}
+	return map[string]interface{}{
+		"$instrument_id":     e.InstrumentID,
+		"$time":              e.Time.UnixMilli(),
+		"$actor_identity_id": e.ActorIdentityID,
+		"$api_token_id":      e.APITokenID,
+		"$source":            e.Source,
+		"$component":         e.Component,
+		"$action":            e.Action,
+		"$parameters":        string(parameters),
+		"$outcome":           e.Outcome,
+		"$error":             e.Error,
+	}, nil
+}
+
+// Audit Events
+
+// AuditEventsFilter narrows down a selection of audit events. Empty fields don't filter anything.
+type AuditEventsFilter struct {
+	ActorIdentityID string
+	// Component also matches the subcomponents of the component.
+	Component string
+	Action    string
+	Outcome   AuditOutcome
+	Limit     int64
+}
+
+func newAuditEventsSelection(
+	instrumentID InstrumentID, filter AuditEventsFilter,
+) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id":     instrumentID,
+		"$actor_identity_id": filter.ActorIdentityID,
+		"$component":         filter.Component,
+		"$action":            filter.Action,
+		"$outcome":           filter.Outcome,
+		"$rows_limit":        filter.Limit,
+	}
+}
+
+type auditEventsSelector struct {
+	events []AuditEvent
+}
+
+func newAuditEventsSelector() *auditEventsSelector {
+	return &auditEventsSelector{
+		events: make([]AuditEvent, 0),
+	}
+}
+
+func (sel *auditEventsSelector) Step(s *sqlite.Stmt) error {
+	e := AuditEvent{
+		ID:              AuditEventID(s.GetInt64("id")),
+		InstrumentID:    InstrumentID(s.GetInt64("instrument_id")),
+		Time:            time.UnixMilli(s.GetInt64("time")),
+		ActorIdentityID: s.GetText("actor_identity_id"),
+		APITokenID:      s.GetInt64("api_token_id"),
+		Source:          s.GetText("source"),
+		Component:       s.GetText("component"),
+		Action:          s.GetText("action"),
+		Outcome:         AuditOutcome(s.GetText("outcome")),
+		Error:           s.GetText("error"),
+	}
+	if err := json.Unmarshal([]byte(s.GetText("parameters")), &e.Parameters); err != nil {
+		return errors.Wrapf(err, "couldn't parse parameters of audit event %d", e.ID)
+	}
+	sel.events = append(sel.events, e)
+	return nil
+}
+
+func (sel *auditEventsSelector) AuditEvents() []AuditEvent {
+	return sel.events
+}
+
+// Controller Limits
+
+// ControllerLimits bounds the settings of commands which can be sent to a controller. A zero value
+// for any limit means that the setting is not bounded by that limit.
+type ControllerLimits struct {
+	MaxPumpVolume   float64 // mL
+	MinPumpFlowrate float64 // mL/min
+	MaxPumpFlowrate float64 // mL/min
+	MaxImagingSteps uint64
+	MinISO          uint64
+	MaxISO          uint64
+	MinShutterSpeed uint64 // μs
+	MaxShutterSpeed uint64 // μs
+}
+
+func (l ControllerLimits) newUpsert(controllerID ControllerID) map[string]interface{} {
+	return map[string]interface{}{
+		"$controller_id":     controllerID,
+		"$max_pump_volume":   l.MaxPumpVolume,
+		"$min_pump_flowrate": l.MinPumpFlowrate,
+		"$max_pump_flowrate": l.MaxPumpFlowrate,
+		"$max_imaging_steps": int64(l.MaxImagingSteps),
+		"$min_iso":           int64(l.MinISO),
+		"$max_iso":           int64(l.MaxISO),
+		"$min_shutter_speed": int64(l.MinShutterSpeed),
+		"$max_shutter_speed": int64(l.MaxShutterSpeed),
+	}
+}
+
+func newControllerLimitsSelection(controllerID ControllerID) map[string]interface{} {
+	return map[string]interface{}{
+		"$controller_id": controllerID,
+	}
+}
+
+func newControllerLimitsByInstrumentSelection(instrumentID InstrumentID) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": instrumentID,
+	}
+}
+
+type controllerLimitsSelector struct {
+	limits map[ControllerID]ControllerLimits
+}
+
+func newControllerLimitsSelector() *controllerLimitsSelector {
+	return &controllerLimitsSelector{
+		limits: make(map[ControllerID]ControllerLimits),
+	}
+}
+
+func (sel *controllerLimitsSelector) Step(s *sqlite.Stmt) error {
+	sel.limits[ControllerID(s.GetInt64("controller_id"))] = ControllerLimits{
+		MaxPumpVolume:   s.GetFloat("max_pump_volume"),
+		MinPumpFlowrate: s.GetFloat("min_pump_flowrate"),
+		MaxPumpFlowrate: s.GetFloat("max_pump_flowrate"),
+		MaxImagingSteps: uint64(s.GetInt64("max_imaging_steps")),
+		MinISO:          uint64(s.GetInt64("min_iso")),
+		MaxISO:          uint64(s.GetInt64("max_iso")),
+		MinShutterSpeed: uint64(s.GetInt64("min_shutter_speed")),
+		MaxShutterSpeed: uint64(s.GetInt64("max_shutter_speed")),
+	}
+	return nil
+}
+
+func (sel *controllerLimitsSelector) ControllerLimits() map[ControllerID]ControllerLimits {
+	return sel.limits
+}
+
+// Controller Settings
+
+// ControllerSettings records the settings which were last applied to a controller's pump, camera,
+// and imager, so that they can be restored after a restart.
+type ControllerSettings struct {
+	// Saved is false if no settings have been recorded for the controller yet.
+	Saved bool
+
+	PumpForward  bool
+	PumpVolume   float64 // mL
+	PumpFlowrate float64 // mL/min
+
+	CameraISO                  uint64
+	CameraShutterSpeed         uint64 // μs
+	CameraAutoWhiteBalance     bool
+	CameraWhiteBalanceRedGain  float64
+	CameraWhiteBalanceBlueGain float64
+
+	ImagerForward    bool
+	ImagerStepVolume float64 // mL
+	ImagerStepDelay  float64 // sec
+	ImagerSteps      uint64
+
+	// RestoreCamera is set by the controller's admin, rather than by commands, to re-send the saved
+	// camera settings to the controller whenever the client connects to it.
+	RestoreCamera bool
+}
+
+func (s ControllerSettings) newUpsert(controllerID ControllerID) map[string]interface{} {
+	return map[string]interface{}{
+		"$controller_id":                  controllerID,
+		"$pump_forward":                   s.PumpForward,
+		"$pump_volume":                    s.PumpVolume,
+		"$pump_flowrate":                  s.PumpFlowrate,
+		"$camera_iso":                     int64(s.CameraISO),
+		"$camera_shutter_speed":           int64(s.CameraShutterSpeed),
+		"$camera_auto_white_balance":      s.CameraAutoWhiteBalance,
+		"$camera_white_balance_red_gain":  s.CameraWhiteBalanceRedGain,
+		"$camera_white_balance_blue_gain": s.CameraWhiteBalanceBlueGain,
+		"$imager_forward":                 s.ImagerForward,
+		"$imager_step_volume":             s.ImagerStepVolume,
+		"$imager_step_delay":              s.ImagerStepDelay,
+		"$imager_steps":                   int64(s.ImagerSteps),
+	}
+}
+
+func newControllerRestoreCameraUpsert(
+	controllerID ControllerID, restoreCamera bool,
+) map[string]interface{} {
+	return map[string]interface{}{
+		"$controller_id":  controllerID,
+		"$restore_camera": restoreCamera,
+	}
+}
+
+func newControllerSettingsSelection(controllerID ControllerID) map[string]interface{} {
+	return map[string]interface{}{
+		"$controller_id": controllerID,
+	}
+}
+
+func newControllerSettingsByInstrumentSelection(instrumentID InstrumentID) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": instrumentID,
+	}
+}
+
+type controllerSettingsSelector struct {
+	settings map[ControllerID]ControllerSettings
+}
+
+func newControllerSettingsSelector() *controllerSettingsSelector {
+	return &controllerSettingsSelector{
+		settings: make(map[ControllerID]ControllerSettings),
+	}
+}
+
+func (sel *controllerSettingsSelector) Step(s *sqlite.Stmt) error {
+	sel.settings[ControllerID(s.GetInt64("controller_id"))] = ControllerSettings{
+		Saved:                      s.GetBool("saved"),
+		PumpForward:                s.GetBool("pump_forward"),
+		PumpVolume:                 s.GetFloat("pump_volume"),
+		PumpFlowrate:               s.GetFloat("pump_flowrate"),
+		CameraISO:                  uint64(s.GetInt64("camera_iso")),
+		CameraShutterSpeed:         uint64(s.GetInt64("camera_shutter_speed")),
+		CameraAutoWhiteBalance:     s.GetBool("camera_auto_white_balance"),
+		CameraWhiteBalanceRedGain:  s.GetFloat("camera_white_balance_red_gain"),
+		CameraWhiteBalanceBlueGain: s.GetFloat("camera_white_balance_blue_gain"),
+		ImagerForward:              s.GetBool("imager_forward"),
+		ImagerStepVolume:           s.GetFloat("imager_step_volume"),
+		ImagerStepDelay:            s.GetFloat("imager_step_delay"),
+		ImagerSteps:                uint64(s.GetInt64("imager_steps")),
+		RestoreCamera:              s.GetBool("restore_camera"),
+	}
+	return nil
+}
+
+func (sel *controllerSettingsSelector) ControllerSettings() map[ControllerID]ControllerSettings {
+	return sel.settings
+}
+
 // Automation Job
 
 type AutomationJob struct {
@@ -355,13 +975,152 @@ func (sel *automationJobsSelector) AutomationJobs() []AutomationJob {
 	return automationJobs
 }
 
+// Sample
+
+type Sample struct {
+	InstrumentID InstrumentID
+	ProjectID    string
+	SampleID     string
+	Operator     string
+	SamplingGear string
+	Latitude     float64 // decimal degrees
+	Longitude    float64 // decimal degrees
+	DepthMin     float64 // m
+	DepthMax     float64 // m
+	Volume       float64 // L
+	NetMesh      float64 // µm
+}
+
+func (s Sample) newUpsert() map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": s.InstrumentID,
+		"$project_id":    s.ProjectID,
+		"$sample_id":     s.SampleID,
+		"$operator":      s.Operator,
+		"$sampling_gear": s.SamplingGear,
+		"$latitude":      s.Latitude,
+		"$longitude":     s.Longitude,
+		"$depth_min":     s.DepthMin,
+		"$depth_max":     s.DepthMax,
+		"$volume":        s.Volume,
+		"$net_mesh":      s.NetMesh,
+	}
+}
+
+func newSampleSelection(instrumentID InstrumentID) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": instrumentID,
+	}
+}
+
+type samplesSelector struct {
+	samples []Sample
+}
+
+func newSamplesSelector() *samplesSelector {
+	return &samplesSelector{
+		samples: make([]Sample, 0),
+	}
+}
+
+func (sel *samplesSelector) Step(s *sqlite.Stmt) error {
+	sel.samples = append(sel.samples, Sample{
+		InstrumentID: InstrumentID(s.GetInt64("instrument_id")),
+		ProjectID:    s.GetText("project_id"),
+		SampleID:     s.GetText("sample_id"),
+		Operator:     s.GetText("operator"),
+		SamplingGear: s.GetText("sampling_gear"),
+		Latitude:     s.GetFloat("latitude"),
+		Longitude:    s.GetFloat("longitude"),
+		DepthMin:     s.GetFloat("depth_min"),
+		DepthMax:     s.GetFloat("depth_max"),
+		Volume:       s.GetFloat("volume"),
+		NetMesh:      s.GetFloat("net_mesh"),
+	})
+	return nil
+}
+
+func (sel *samplesSelector) Samples() []Sample {
+	return sel.samples
+}
+
+// Acquisition
+
+type Acquisition struct {
+	ID           AcquisitionID
+	InstrumentID InstrumentID
+	ControllerID ControllerID
+	StartTime    time.Time
+	Source       string
+	Metadata     string // JSON-encoded metadata as sent to the controller
+}
+
+func (a Acquisition) newInsertion() map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": a.InstrumentID,
+		"$controller_id": a.ControllerID,
+		"$start_time":    a.StartTime.UnixMilli(),
+		"$source":        a.Source,
+		"$metadata":      a.Metadata,
+	}
+}
+
+// Acquisitions
+
+func newAcquisitionsByInstrumentSelection(
+	instrumentID InstrumentID, rowsLimit int64,
+) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": instrumentID,
+		"$rows_limit":    rowsLimit,
+	}
+}
+
+type acquisitionsSelector struct {
+	acquisitions []Acquisition
+}
+
+func newAcquisitionsSelector() *acquisitionsSelector {
+	return &acquisitionsSelector{
+		acquisitions: make([]Acquisition, 0),
+	}
+}
+
+func (sel *acquisitionsSelector) Step(s *sqlite.Stmt) error {
+	sel.acquisitions = append(sel.acquisitions, Acquisition{
+		ID:           AcquisitionID(s.GetInt64("id")),
+		InstrumentID: InstrumentID(s.GetInt64("instrument_id")),
+		ControllerID: ControllerID(s.GetInt64("controller_id")),
+		StartTime:    time.UnixMilli(s.GetInt64("start_time")),
+		Source:       s.GetText("source"),
+		Metadata:     s.GetText("metadata"),
+	})
+	return nil
+}
+
+func (sel *acquisitionsSelector) Acquisitions() []Acquisition {
+	return sel.acquisitions
+}
+
 // Instrument
 
+// Visibility determines who can see an instrument. Public instruments are listed to everyone,
+// unlisted instruments can be seen by anyone with a link, and members-only instruments can only be
+// seen by the instrument's members.
+type Visibility string
+
+const (
+	VisibilityPublic   Visibility = "public"
+	VisibilityUnlisted Visibility = "unlisted"
+	VisibilityMembers  Visibility = "members"
+)
+
 type Instrument struct {
 	ID             InstrumentID
 	Name           string
 	Description    string
 	AdminID        AdminID
+	Visibility     Visibility
 	Cameras        map[CameraID]Camera
 	Controllers    map[ControllerID]Controller
 	AutomationJobs map[AutomationJobID]AutomationJob
@@ -371,11 +1130,17 @@ func (i Instrument) GetID() InstrumentID {
 	return i.ID
 }
 
-func (i Instrument) newInsertion() map[string]interface{} {
+func (i Instrument) newInsertion(createTime time.Time) map[string]interface{} {
+	visibility := i.Visibility
+	if visibility == "" {
+		visibility = VisibilityPublic
+	}
 	return map[string]interface{}{
 		"$name":        i.Name,
 		"$description": i.Description,
 		"$admin_id":    i.AdminID,
+		"$visibility":  visibility,
+		"$create_time": createTime.UnixMilli(),
 	}
 }
 
@@ -393,6 +1158,13 @@ func (i Instrument) newDescriptionUpdate() map[string]interface{} {
 	}
 }
 
+func (i Instrument) newVisibilityUpdate() map[string]interface{} {
+	return map[string]interface{}{
+		"$id":         i.ID,
+		"$visibility": string(i.Visibility),
+	}
+}
+
 func (i Instrument) NewDelete() map[string]interface{} {
 	return map[string]interface{}{
 		"$id": i.ID,
@@ -437,6 +1209,7 @@ func (sel *instrumentsSelector) Step(s *sqlite.Stmt) error {
 			Name:           s.GetText("name"),
 			Description:    s.GetText("description"),
 			AdminID:        AdminID(s.GetText("admin_id")),
+			Visibility:     Visibility(s.GetText("visibility")),
 			Cameras:        make(map[CameraID]Camera),
 			Controllers:    make(map[ControllerID]Controller),
 			AutomationJobs: make(map[AutomationJobID]AutomationJob),
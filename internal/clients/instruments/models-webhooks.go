@@ -0,0 +1,313 @@
+package instruments
+
+import (
+	"strings"
+	"time"
+
+	"zombiezen.com/go/sqlite"
+)
+
+// Webhook
+
+type (
+	WebhookID        int64
+	WebhookEventType string
+)
+
+const (
+	WebhookEventImagerDone             WebhookEventType = "imager.done"
+	WebhookEventPumpDone               WebhookEventType = "pump.done"
+	WebhookEventControllerConnectivity WebhookEventType = "controller.connectivity"
+	WebhookEventJobFailed              WebhookEventType = "job.failed"
+	WebhookEventChatMessage            WebhookEventType = "chat.message"
+)
+
+// WebhookEventTypes lists every type of event which can be delivered to webhooks.
+var WebhookEventTypes = []WebhookEventType{
+	WebhookEventImagerDone,
+	WebhookEventPumpDone,
+	WebhookEventControllerConnectivity,
+	WebhookEventJobFailed,
+	WebhookEventChatMessage,
+}
+
+func ParseWebhookEventType(raw string) (t WebhookEventType, ok bool) {
+	for _, t := range WebhookEventTypes {
+		if raw == string(t) {
+			return t, true
+		}
+	}
+	return "", false
+}
+
+// Webhook is an endpoint which is sent a signed POST request for each of an instrument's events
+// whose type is in its event filter.
+type Webhook struct {
+	ID           WebhookID
+	InstrumentID InstrumentID
+	URL          string
+	Description  string
+	EventTypes   []WebhookEventType
+	// Secret is the key used to sign deliveries to the webhook. It's stored encrypted.
+	Secret  string
+	Enabled bool
+}
+
+// Delivers reports whether events of the specified type are delivered to the webhook.
+func (w Webhook) Delivers(eventType WebhookEventType) bool {
+	for _, t := range w.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+func joinWebhookEventTypes(eventTypes []WebhookEventType) string {
+	raw := make([]string, len(eventTypes))
+	for i, t := range eventTypes {
+		raw[i] = string(t)
+	}
+	return strings.Join(raw, " ")
+}
+
+func splitWebhookEventTypes(raw string) []WebhookEventType {
+	fields := strings.Fields(raw)
+	eventTypes := make([]WebhookEventType, len(fields))
+	for i, field := range fields {
+		eventTypes[i] = WebhookEventType(field)
+	}
+	return eventTypes
+}
+
+func (w Webhook) newInsertion(encryptedSecret string) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id":    w.InstrumentID,
+		"$url":              w.URL,
+		"$description":      w.Description,
+		"$event_types":      joinWebhookEventTypes(w.EventTypes),
+		"$encrypted_secret": encryptedSecret,
+		"$enabled":          w.Enabled,
+	}
+}
+
+func (w Webhook) newUpdate() map[string]interface{} {
+	return map[string]interface{}{
+		"$id":          w.ID,
+		"$url":         w.URL,
+		"$description": w.Description,
+		"$event_types": joinWebhookEventTypes(w.EventTypes),
+		"$enabled":     w.Enabled,
+	}
+}
+
+func (w Webhook) newDelete() map[string]interface{} {
+	return map[string]interface{}{
+		"$id": w.ID,
+	}
+}
+
+func newWebhookSelection(id WebhookID) map[string]interface{} {
+	return map[string]interface{}{
+		"$id": id,
+	}
+}
+
+func newWebhooksByInstrumentSelection(instrumentID InstrumentID) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": instrumentID,
+	}
+}
+
+// encryptedWebhook is a webhook whose secret hasn't been decrypted yet.
+type encryptedWebhook struct {
+	Webhook
+	EncryptedSecret string
+}
+
+type webhooksSelector struct {
+	webhooks []encryptedWebhook
+}
+
+func newWebhooksSelector() *webhooksSelector {
+	return &webhooksSelector{
+		webhooks: make([]encryptedWebhook, 0),
+	}
+}
+
+func (sel *webhooksSelector) Step(s *sqlite.Stmt) error {
+	sel.webhooks = append(sel.webhooks, encryptedWebhook{
+		Webhook: Webhook{
+			ID:           WebhookID(s.GetInt64("id")),
+			InstrumentID: InstrumentID(s.GetInt64("instrument_id")),
+			URL:          s.GetText("url"),
+			Description:  s.GetText("description"),
+			EventTypes:   splitWebhookEventTypes(s.GetText("event_types")),
+			Enabled:      s.GetBool("enabled"),
+		},
+		EncryptedSecret: s.GetText("encrypted_secret"),
+	})
+	return nil
+}
+
+func (sel *webhooksSelector) Webhooks() []encryptedWebhook {
+	return sel.webhooks
+}
+
+// Webhook Delivery
+
+type (
+	WebhookDeliveryID     int64
+	WebhookDeliveryStatus string
+)
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded"
+	WebhookDeliveryFailed    WebhookDeliveryStatus = "failed"
+)
+
+// WebhookDelivery records an event which was (or will be) sent to a webhook, along with the
+// outcome of the latest attempt to send it.
+type WebhookDelivery struct {
+	ID         WebhookDeliveryID
+	WebhookID  WebhookID
+	EventType  WebhookEventType
+	Payload    string
+	CreateTime time.Time
+	Status     WebhookDeliveryStatus
+	Attempts   int64
+	// NextAttemptTime is when the delivery will next be attempted, if it's pending.
+	NextAttemptTime time.Time
+	LastAttemptTime time.Time
+	// LastStatusCode is zero if the latest attempt didn't receive a response.
+	LastStatusCode int
+	LastError      string
+}
+
+func (d WebhookDelivery) newInsertion() map[string]interface{} {
+	return map[string]interface{}{
+		"$webhook_id":  d.WebhookID,
+		"$event_type":  d.EventType,
+		"$payload":     d.Payload,
+		"$create_time": d.CreateTime.UnixMilli(),
+	}
+}
+
+func newWebhookDeliveriesInsertion(
+	instrumentID InstrumentID, eventType WebhookEventType, payload string, createTime time.Time,
+) map[string]interface{} {
+	return map[string]interface{}{
+		"$instrument_id": instrumentID,
+		"$event_type":    eventType,
+		"$payload":       payload,
+		"$create_time":   createTime.UnixMilli(),
+	}
+}
+
+func (d WebhookDelivery) newAttemptUpdate() map[string]interface{} {
+	return map[string]interface{}{
+		"$id":                d.ID,
+		"$status":            d.Status,
+		"$attempts":          d.Attempts,
+		"$next_attempt_time": d.NextAttemptTime.UnixMilli(),
+		"$last_attempt_time": d.LastAttemptTime.UnixMilli(),
+		"$last_status_code":  d.LastStatusCode,
+		"$last_error":        d.LastError,
+	}
+}
+
+func newWebhookDeliverySelection(
+	webhookID WebhookID, id WebhookDeliveryID,
+) map[string]interface{} {
+	return map[string]interface{}{
+		"$webhook_id": webhookID,
+		"$id":         id,
+	}
+}
+
+func newWebhookDeliveriesSelection(webhookID WebhookID, limit int64) map[string]interface{} {
+	return map[string]interface{}{
+		"$webhook_id": webhookID,
+		"$rows_limit": limit,
+	}
+}
+
+func newDueWebhookDeliveriesSelection(now time.Time, limit int64) map[string]interface{} {
+	return map[string]interface{}{
+		"$now":        now.UnixMilli(),
+		"$rows_limit": limit,
+	}
+}
+
+func newFinishedWebhookDeliveriesDelete(threshold time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"$threshold": threshold.UnixMilli(),
+	}
+}
+
+func getWebhookDelivery(s *sqlite.Stmt) WebhookDelivery {
+	return WebhookDelivery{
+		ID:              WebhookDeliveryID(s.GetInt64("id")),
+		WebhookID:       WebhookID(s.GetInt64("webhook_id")),
+		EventType:       WebhookEventType(s.GetText("event_type")),
+		Payload:         s.GetText("payload"),
+		CreateTime:      time.UnixMilli(s.GetInt64("create_time")),
+		Status:          WebhookDeliveryStatus(s.GetText("status")),
+		Attempts:        s.GetInt64("attempts"),
+		NextAttemptTime: time.UnixMilli(s.GetInt64("next_attempt_time")),
+		LastAttemptTime: time.UnixMilli(s.GetInt64("last_attempt_time")),
+		LastStatusCode:  int(s.GetInt64("last_status_code")),
+		LastError:       s.GetText("last_error"),
+	}
+}
+
+type webhookDeliveriesSelector struct {
+	deliveries []WebhookDelivery
+}
+
+func newWebhookDeliveriesSelector() *webhookDeliveriesSelector {
+	return &webhookDeliveriesSelector{
+		deliveries: make([]WebhookDelivery, 0),
+	}
+}
+
+func (sel *webhookDeliveriesSelector) Step(s *sqlite.Stmt) error {
+	sel.deliveries = append(sel.deliveries, getWebhookDelivery(s))
+	return nil
+}
+
+func (sel *webhookDeliveriesSelector) WebhookDeliveries() []WebhookDelivery {
+	return sel.deliveries
+}
+
+// dueWebhookDelivery is a pending delivery along with the destination and encrypted secret of its
+// webhook.
+type dueWebhookDelivery struct {
+	WebhookDelivery
+	URL             string
+	EncryptedSecret string
+}
+
+type dueWebhookDeliveriesSelector struct {
+	deliveries []dueWebhookDelivery
+}
+
+func newDueWebhookDeliveriesSelector() *dueWebhookDeliveriesSelector {
+	return &dueWebhookDeliveriesSelector{
+		deliveries: make([]dueWebhookDelivery, 0),
+	}
+}
+
+func (sel *dueWebhookDeliveriesSelector) Step(s *sqlite.Stmt) error {
+	sel.deliveries = append(sel.deliveries, dueWebhookDelivery{
+		WebhookDelivery: getWebhookDelivery(s),
+		URL:             s.GetText("webhook_url"),
+		EncryptedSecret: s.GetText("webhook_encrypted_secret"),
+	})
+	return nil
+}
+
+func (sel *dueWebhookDeliveriesSelector) DueWebhookDeliveries() []dueWebhookDelivery {
+	return sel.deliveries
+}
@@ -0,0 +1,5 @@
+delete from instruments_control_lease
+where
+  instruments_control_lease.instrument_id = $instrument_id
+  and instruments_control_lease.identity_id = $identity_id
+  and instruments_control_lease.expiration_time = $expiration_time
@@ -0,0 +1,2 @@
+delete from instruments_emergency_stop
+where instruments_emergency_stop.instrument_id = $instrument_id
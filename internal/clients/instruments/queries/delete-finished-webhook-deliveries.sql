@@ -0,0 +1,4 @@
+delete from instruments_webhook_delivery
+where
+  instruments_webhook_delivery.status in ('succeeded', 'failed')
+  and instruments_webhook_delivery.create_time < $threshold
@@ -0,0 +1,4 @@
+delete from instruments_instrument_member
+where
+  instruments_instrument_member.instrument_id = $instrument_id
+  and instruments_instrument_member.identity_id = $identity_id
@@ -0,0 +1,2 @@
+delete from instruments_webhook
+where instruments_webhook.id = $id
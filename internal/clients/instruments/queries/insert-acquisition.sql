@@ -0,0 +1,2 @@
+insert into instruments_acquisition (instrument_id, controller_id, start_time, source, metadata)
+values ($instrument_id, $controller_id, $start_time, $source, $metadata);
@@ -0,0 +1,8 @@
+insert into instruments_audit_event (
+  instrument_id, time, actor_identity_id, api_token_id, source, component, action, parameters,
+  outcome, error
+)
+values (
+  $instrument_id, $time, $actor_identity_id, $api_token_id, $source, $component, $action,
+  $parameters, $outcome, $error
+);
@@ -1,2 +1,2 @@
-insert into instruments_instrument (name, description, admin_identity_id)
-values ($name, $description, $admin_id);
+insert into instruments_instrument (name, description, admin_identity_id, visibility, create_time)
+values ($name, $description, $admin_id, $visibility, $create_time);
@@ -0,0 +1,15 @@
+insert into instruments_webhook_delivery (
+  webhook_id, event_type, payload, create_time, status, next_attempt_time
+)
+select
+  instruments_webhook.id,
+  $event_type,
+  $payload,
+  $create_time,
+  'pending',
+  $create_time
+from instruments_webhook
+where
+  instruments_webhook.instrument_id = $instrument_id
+  and instruments_webhook.enabled
+  and ' ' || instruments_webhook.event_types || ' ' like '% ' || $event_type || ' %'
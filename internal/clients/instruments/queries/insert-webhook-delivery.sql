@@ -0,0 +1,4 @@
+insert into instruments_webhook_delivery (
+  webhook_id, event_type, payload, create_time, status, next_attempt_time
+)
+values ($webhook_id, $event_type, $payload, $create_time, 'pending', $create_time);
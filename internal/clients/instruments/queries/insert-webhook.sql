@@ -0,0 +1,4 @@
+insert into instruments_webhook (
+  instrument_id, url, description, event_types, encrypted_secret, enabled
+)
+values ($instrument_id, $url, $description, $event_types, $encrypted_secret, $enabled);
@@ -0,0 +1,12 @@
+select
+  id            as id,
+  instrument_id as instrument_id,
+  controller_id as controller_id,
+  start_time    as start_time,
+  source        as source,
+  metadata      as metadata
+from instruments_acquisition as a
+where
+  a.instrument_id = $instrument_id
+order by start_time desc
+limit $rows_limit
@@ -0,0 +1,27 @@
+select
+  id                as id,
+  instrument_id     as instrument_id,
+  time              as time,
+  actor_identity_id as actor_identity_id,
+  api_token_id      as api_token_id,
+  source            as source,
+  component         as component,
+  action            as action,
+  parameters        as parameters,
+  outcome           as outcome,
+  error             as error
+from instruments_audit_event
+where
+  instrument_id = $instrument_id
+  -- Events of a deleted instrument whose ID was reused aren't events of this instrument
+  and time >= (
+    select create_time
+    from instruments_instrument
+    where instruments_instrument.id = $instrument_id
+  )
+  and ($actor_identity_id = '' or actor_identity_id = $actor_identity_id)
+  and ($component = '' or component = $component or component like $component || '/%')
+  and ($action = '' or action = $action)
+  and ($outcome = '' or outcome = $outcome)
+order by time desc, id desc
+limit $rows_limit
@@ -0,0 +1,8 @@
+select
+  instrument_id         as instrument_id,
+  identity_id           as identity_id,
+  acquire_time          as acquire_time,
+  expiration_time       as expiration_time,
+  requester_identity_id as requester_identity_id
+from instruments_control_lease
+order by instrument_id asc
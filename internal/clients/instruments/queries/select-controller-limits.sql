@@ -0,0 +1,13 @@
+select
+  controller_id     as controller_id,
+  max_pump_volume   as max_pump_volume,
+  min_pump_flowrate as min_pump_flowrate,
+  max_pump_flowrate as max_pump_flowrate,
+  max_imaging_steps as max_imaging_steps,
+  min_iso           as min_iso,
+  max_iso           as max_iso,
+  min_shutter_speed as min_shutter_speed,
+  max_shutter_speed as max_shutter_speed
+from instruments_controller_limits
+where
+  controller_id = $controller_id
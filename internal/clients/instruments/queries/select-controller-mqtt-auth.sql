@@ -0,0 +1,10 @@
+select
+  controller_id        as controller_id,
+  username             as username,
+  encrypted_password   as encrypted_password,
+  ca_cert              as ca_cert,
+  client_cert          as client_cert,
+  encrypted_client_key as encrypted_client_key
+from instruments_controller_mqtt_auth
+where
+  controller_id = $controller_id
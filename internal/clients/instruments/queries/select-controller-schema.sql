@@ -0,0 +1,6 @@
+select
+  controller_id as controller_id,
+  schema        as schema
+from instruments_controller_schema
+where
+  controller_id = $controller_id
@@ -0,0 +1,19 @@
+select
+  controller_id                  as controller_id,
+  saved                          as saved,
+  pump_forward                   as pump_forward,
+  pump_volume                    as pump_volume,
+  pump_flowrate                  as pump_flowrate,
+  camera_iso                     as camera_iso,
+  camera_shutter_speed           as camera_shutter_speed,
+  camera_auto_white_balance      as camera_auto_white_balance,
+  camera_white_balance_red_gain  as camera_white_balance_red_gain,
+  camera_white_balance_blue_gain as camera_white_balance_blue_gain,
+  imager_forward                 as imager_forward,
+  imager_step_volume             as imager_step_volume,
+  imager_step_delay              as imager_step_delay,
+  imager_steps                   as imager_steps,
+  restore_camera                 as restore_camera
+from instruments_controller_settings
+where
+  controller_id = $controller_id
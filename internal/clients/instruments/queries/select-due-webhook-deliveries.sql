@@ -0,0 +1,22 @@
+select
+  delivery.id                as id,
+  delivery.webhook_id        as webhook_id,
+  delivery.event_type        as event_type,
+  delivery.payload           as payload,
+  delivery.create_time       as create_time,
+  delivery.status            as status,
+  delivery.attempts          as attempts,
+  delivery.next_attempt_time as next_attempt_time,
+  delivery.last_attempt_time as last_attempt_time,
+  delivery.last_status_code  as last_status_code,
+  delivery.last_error        as last_error,
+  webhook.url                as webhook_url,
+  webhook.encrypted_secret   as webhook_encrypted_secret
+from instruments_webhook_delivery as delivery
+  join instruments_webhook as webhook
+    on webhook.id = delivery.webhook_id
+where
+  delivery.status = 'pending'
+  and delivery.next_attempt_time <= $now
+order by delivery.next_attempt_time asc, delivery.id asc
+limit $rows_limit
@@ -0,0 +1,7 @@
+select
+  instrument_id as instrument_id,
+  stop_time     as stop_time,
+  identity_id   as identity_id
+from instruments_emergency_stop
+where
+  instrument_id = $instrument_id
@@ -0,0 +1,15 @@
+select
+  l.controller_id     as controller_id,
+  l.max_pump_volume   as max_pump_volume,
+  l.min_pump_flowrate as min_pump_flowrate,
+  l.max_pump_flowrate as max_pump_flowrate,
+  l.max_imaging_steps as max_imaging_steps,
+  l.min_iso           as min_iso,
+  l.max_iso           as max_iso,
+  l.min_shutter_speed as min_shutter_speed,
+  l.max_shutter_speed as max_shutter_speed
+from instruments_controller_limits as l
+join instruments_controller as c
+  on l.controller_id = c.id
+where
+  c.instrument_id = $instrument_id
@@ -0,0 +1,12 @@
+select
+  a.controller_id        as controller_id,
+  a.username             as username,
+  a.encrypted_password   as encrypted_password,
+  a.ca_cert              as ca_cert,
+  a.client_cert          as client_cert,
+  a.encrypted_client_key as encrypted_client_key
+from instruments_controller_mqtt_auth as a
+join instruments_controller as c
+  on a.controller_id = c.id
+where
+  c.instrument_id = $instrument_id
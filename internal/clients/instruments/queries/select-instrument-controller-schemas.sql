@@ -0,0 +1,8 @@
+select
+  s.controller_id as controller_id,
+  s.schema        as schema
+from instruments_controller_schema as s
+join instruments_controller as c
+  on s.controller_id = c.id
+where
+  c.instrument_id = $instrument_id
@@ -0,0 +1,21 @@
+select
+  s.controller_id                  as controller_id,
+  s.saved                          as saved,
+  s.pump_forward                   as pump_forward,
+  s.pump_volume                    as pump_volume,
+  s.pump_flowrate                  as pump_flowrate,
+  s.camera_iso                     as camera_iso,
+  s.camera_shutter_speed           as camera_shutter_speed,
+  s.camera_auto_white_balance      as camera_auto_white_balance,
+  s.camera_white_balance_red_gain  as camera_white_balance_red_gain,
+  s.camera_white_balance_blue_gain as camera_white_balance_blue_gain,
+  s.imager_forward                 as imager_forward,
+  s.imager_step_volume             as imager_step_volume,
+  s.imager_step_delay              as imager_step_delay,
+  s.imager_steps                   as imager_steps,
+  s.restore_camera                 as restore_camera
+from instruments_controller_settings as s
+join instruments_controller as c
+  on s.controller_id = c.id
+where
+  c.instrument_id = $instrument_id
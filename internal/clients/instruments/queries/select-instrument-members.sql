@@ -0,0 +1,8 @@
+select
+  instrument_id as instrument_id,
+  identity_id   as identity_id,
+  role          as role
+from instruments_instrument_member
+where
+  instrument_id = $instrument_id
+order by identity_id asc
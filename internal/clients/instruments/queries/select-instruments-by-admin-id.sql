@@ -3,6 +3,7 @@ select
   i.name              as name,
   i.description       as description,
   i.admin_identity_id as admin_id,
+  i.visibility        as visibility,
   ca.id               as camera_id,
   ca.enabled          as camera_enabled,
   ca.name             as camera_name,
@@ -0,0 +1,17 @@
+select
+  i.id                                       as instrument_id,
+  coalesce(nullif(s.project_id, ''), i.name) as project_id,
+  coalesce(s.sample_id, '')                  as sample_id,
+  coalesce(s.operator, '')                   as operator,
+  coalesce(s.sampling_gear, '')              as sampling_gear,
+  coalesce(s.latitude, 0)                    as latitude,
+  coalesce(s.longitude, 0)                   as longitude,
+  coalesce(s.depth_min, 0)                   as depth_min,
+  coalesce(s.depth_max, 0)                   as depth_max,
+  coalesce(s.volume, 0)                      as volume,
+  coalesce(s.net_mesh, 0)                    as net_mesh
+from instruments_instrument as i
+left join instruments_sample as s
+  on i.id = s.instrument_id
+where
+  i.id = $instrument_id
@@ -0,0 +1,13 @@
+select
+  controller_id as owner_id
+from instruments_controller_mqtt_auth
+where
+  encrypted_password != ''
+  or encrypted_client_key != ''
+union all
+select
+  id as owner_id
+from instruments_webhook
+where
+  encrypted_secret != ''
+limit 1
@@ -0,0 +1,16 @@
+select
+  id                as id,
+  webhook_id        as webhook_id,
+  event_type        as event_type,
+  payload           as payload,
+  create_time       as create_time,
+  status            as status,
+  attempts          as attempts,
+  next_attempt_time as next_attempt_time,
+  last_attempt_time as last_attempt_time,
+  last_status_code  as last_status_code,
+  last_error        as last_error
+from instruments_webhook_delivery
+where instruments_webhook_delivery.webhook_id = $webhook_id
+order by create_time desc, id desc
+limit $rows_limit
@@ -0,0 +1,10 @@
+select
+  id               as id,
+  instrument_id    as instrument_id,
+  url              as url,
+  description      as description,
+  event_types      as event_types,
+  encrypted_secret as encrypted_secret,
+  enabled          as enabled
+from instruments_webhook
+where instruments_webhook.id = $id
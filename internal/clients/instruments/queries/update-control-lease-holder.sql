@@ -0,0 +1,9 @@
+update instruments_control_lease
+set
+  identity_id           = $new_identity_id,
+  acquire_time          = $acquire_time,
+  expiration_time       = $expiration_time,
+  requester_identity_id = ''
+where
+  instruments_control_lease.instrument_id = $instrument_id
+  and instruments_control_lease.identity_id = $identity_id
@@ -0,0 +1,6 @@
+update instruments_control_lease
+set
+  requester_identity_id = $requester_identity_id
+where
+  instruments_control_lease.instrument_id = $instrument_id
+  and instruments_control_lease.identity_id != $requester_identity_id
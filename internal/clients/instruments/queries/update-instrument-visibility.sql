@@ -0,0 +1,4 @@
+update instruments_instrument
+set
+  visibility = $visibility
+where instruments_instrument.id = $id
@@ -0,0 +1,9 @@
+update instruments_webhook_delivery
+set
+  status = $status,
+  attempts = $attempts,
+  next_attempt_time = $next_attempt_time,
+  last_attempt_time = $last_attempt_time,
+  last_status_code = $last_status_code,
+  last_error = $last_error
+where instruments_webhook_delivery.id = $id
@@ -0,0 +1,7 @@
+update instruments_webhook
+set
+  url = $url,
+  description = $description,
+  event_types = $event_types,
+  enabled = $enabled
+where instruments_webhook.id = $id
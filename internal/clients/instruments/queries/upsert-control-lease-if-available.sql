@@ -0,0 +1,16 @@
+insert into instruments_control_lease (
+  instrument_id, identity_id, acquire_time, expiration_time, requester_identity_id
+)
+values ($instrument_id, $identity_id, $acquire_time, $expiration_time, '')
+on conflict(instrument_id) do update set
+  identity_id           = excluded.identity_id,
+  acquire_time          = iif(
+    instruments_control_lease.identity_id = excluded.identity_id,
+    instruments_control_lease.acquire_time,
+    excluded.acquire_time
+  ),
+  expiration_time       = excluded.expiration_time,
+  requester_identity_id = ''
+where
+  instruments_control_lease.identity_id = excluded.identity_id
+  or instruments_control_lease.expiration_time <= excluded.acquire_time;
@@ -0,0 +1,17 @@
+insert into instruments_controller_limits (
+  controller_id, max_pump_volume, min_pump_flowrate, max_pump_flowrate, max_imaging_steps,
+  min_iso, max_iso, min_shutter_speed, max_shutter_speed
+)
+values (
+  $controller_id, $max_pump_volume, $min_pump_flowrate, $max_pump_flowrate, $max_imaging_steps,
+  $min_iso, $max_iso, $min_shutter_speed, $max_shutter_speed
+)
+on conflict(controller_id) do update set
+  max_pump_volume   = excluded.max_pump_volume,
+  min_pump_flowrate = excluded.min_pump_flowrate,
+  max_pump_flowrate = excluded.max_pump_flowrate,
+  max_imaging_steps = excluded.max_imaging_steps,
+  min_iso           = excluded.min_iso,
+  max_iso           = excluded.max_iso,
+  min_shutter_speed = excluded.min_shutter_speed,
+  max_shutter_speed = excluded.max_shutter_speed;
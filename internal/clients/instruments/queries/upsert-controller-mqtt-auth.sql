@@ -0,0 +1,12 @@
+insert into instruments_controller_mqtt_auth (
+  controller_id, username, encrypted_password, ca_cert, client_cert, encrypted_client_key
+)
+values (
+  $controller_id, $username, $encrypted_password, $ca_cert, $client_cert, $encrypted_client_key
+)
+on conflict(controller_id) do update set
+  username             = excluded.username,
+  encrypted_password   = excluded.encrypted_password,
+  ca_cert              = excluded.ca_cert,
+  client_cert          = excluded.client_cert,
+  encrypted_client_key = excluded.encrypted_client_key;
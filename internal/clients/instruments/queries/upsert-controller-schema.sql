@@ -0,0 +1,4 @@
+insert into instruments_controller_schema (controller_id, schema)
+values ($controller_id, $schema)
+on conflict(controller_id) do update set
+  schema = excluded.schema;
@@ -0,0 +1,4 @@
+insert into instruments_controller_settings (controller_id, restore_camera)
+values ($controller_id, $restore_camera)
+on conflict(controller_id) do update set
+  restore_camera = excluded.restore_camera;
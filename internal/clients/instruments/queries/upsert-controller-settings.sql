@@ -0,0 +1,26 @@
+insert into instruments_controller_settings (
+  controller_id, saved, pump_forward, pump_volume, pump_flowrate,
+  camera_iso, camera_shutter_speed, camera_auto_white_balance,
+  camera_white_balance_red_gain, camera_white_balance_blue_gain,
+  imager_forward, imager_step_volume, imager_step_delay, imager_steps
+)
+values (
+  $controller_id, 1, $pump_forward, $pump_volume, $pump_flowrate,
+  $camera_iso, $camera_shutter_speed, $camera_auto_white_balance,
+  $camera_white_balance_red_gain, $camera_white_balance_blue_gain,
+  $imager_forward, $imager_step_volume, $imager_step_delay, $imager_steps
+)
+on conflict(controller_id) do update set
+  saved                          = 1,
+  pump_forward                   = excluded.pump_forward,
+  pump_volume                    = excluded.pump_volume,
+  pump_flowrate                  = excluded.pump_flowrate,
+  camera_iso                     = excluded.camera_iso,
+  camera_shutter_speed           = excluded.camera_shutter_speed,
+  camera_auto_white_balance      = excluded.camera_auto_white_balance,
+  camera_white_balance_red_gain  = excluded.camera_white_balance_red_gain,
+  camera_white_balance_blue_gain = excluded.camera_white_balance_blue_gain,
+  imager_forward                 = excluded.imager_forward,
+  imager_step_volume             = excluded.imager_step_volume,
+  imager_step_delay              = excluded.imager_step_delay,
+  imager_steps                   = excluded.imager_steps;
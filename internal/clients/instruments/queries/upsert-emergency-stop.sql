@@ -0,0 +1,5 @@
+insert into instruments_emergency_stop (instrument_id, stop_time, identity_id)
+values ($instrument_id, $stop_time, $identity_id)
+on conflict(instrument_id) do update set
+  stop_time   = excluded.stop_time,
+  identity_id = excluded.identity_id;
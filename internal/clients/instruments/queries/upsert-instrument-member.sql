@@ -0,0 +1,4 @@
+insert into instruments_instrument_member (instrument_id, identity_id, role)
+values ($instrument_id, $identity_id, $role)
+on conflict(instrument_id, identity_id) do update set
+  role = excluded.role;
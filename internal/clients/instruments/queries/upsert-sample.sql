@@ -0,0 +1,19 @@
+insert into instruments_sample (
+  instrument_id, project_id, sample_id, operator, sampling_gear,
+  latitude, longitude, depth_min, depth_max, volume, net_mesh
+)
+values (
+  $instrument_id, $project_id, $sample_id, $operator, $sampling_gear,
+  $latitude, $longitude, $depth_min, $depth_max, $volume, $net_mesh
+)
+on conflict(instrument_id) do update set
+  project_id    = excluded.project_id,
+  sample_id     = excluded.sample_id,
+  operator      = excluded.operator,
+  sampling_gear = excluded.sampling_gear,
+  latitude      = excluded.latitude,
+  longitude     = excluded.longitude,
+  depth_min     = excluded.depth_min,
+  depth_max     = excluded.depth_max,
+  volume        = excluded.volume,
+  net_mesh      = excluded.net_mesh;
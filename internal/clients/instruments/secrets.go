@@ -0,0 +1,102 @@
+package instruments
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	_ "embed"
+	"encoding/base64"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+	"zombiezen.com/go/sqlite"
+)
+
+// encryptSecret encrypts the plaintext with AES-GCM and returns it as a base64-encoded string
+// with the nonce prepended. Empty plaintexts are stored as empty strings.
+func encryptSecret(key []byte, plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+	aead, err := newSecretsAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", errors.Wrap(err, "couldn't generate nonce")
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// ErrSecretUnreadable is the cause of errors from reading stored secrets which can't be decrypted
+// with the configured secrets key.
+var ErrSecretUnreadable = errors.New("secret can't be decrypted with INSTRUMENTS_SECRETS_KEY")
+
+func decryptSecret(key []byte, ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", nil
+	}
+	if len(key) == 0 {
+		return "", errors.Wrap(ErrSecretUnreadable, "no secrets key is set")
+	}
+	aead, err := newSecretsAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", errors.Wrap(ErrSecretUnreadable, "encrypted secret is malformed")
+	}
+	nonce, sealed := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, sealed, nil)
+	if err != nil {
+		return "", errors.Wrapf(
+			ErrSecretUnreadable, "%s (was INSTRUMENTS_SECRETS_KEY changed?)", err,
+		)
+	}
+	return string(plaintext), nil
+}
+
+func newSecretsAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) == 0 {
+		return nil, errors.New("secrets can't be stored because INSTRUMENTS_SECRETS_KEY isn't set")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "couldn't make secrets cipher")
+	}
+	aead, err := cipher.NewGCM(block)
+	return aead, errors.Wrap(err, "couldn't make secrets AEAD")
+}
+
+//go:embed queries/select-stored-secrets.sql
+var rawSelectStoredSecretsQuery string
+var selectStoredSecretsQuery string = strings.TrimSpace(rawSelectStoredSecretsQuery)
+
+// CheckSecretsKey checks that a secrets key was configured if the database has any stored secrets,
+// since those secrets would otherwise be unreadable.
+func (s *Store) CheckSecretsKey(ctx context.Context) error {
+	if len(s.secretsKey) > 0 {
+		return nil
+	}
+	hasSecrets := false
+	if err := s.db.ExecuteSelection(
+		ctx, selectStoredSecretsQuery, map[string]interface{}{}, func(*sqlite.Stmt) error {
+			hasSecrets = true
+			return nil
+		},
+	); err != nil {
+		return errors.Wrap(err, "couldn't check for stored secrets")
+	}
+	if hasSecrets {
+		return errors.New(
+			"the database has encrypted secrets, so INSTRUMENTS_SECRETS_KEY must be set to the key " +
+				"which they were encrypted with",
+		)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package instruments
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed queries/insert-acquisition.sql
+var rawInsertAcquisitionQuery string
+var insertAcquisitionQuery string = strings.TrimSpace(rawInsertAcquisitionQuery)
+
+func (s *Store) AddAcquisition(
+	ctx context.Context, a Acquisition,
+) (acquisitionID AcquisitionID, err error) {
+	rowID, err := s.db.ExecuteInsertionForID(ctx, insertAcquisitionQuery, a.newInsertion())
+	if err != nil {
+		return 0, errors.Wrapf(
+			err, "couldn't add acquisition for controller %d of instrument %d",
+			a.ControllerID, a.InstrumentID,
+		)
+	}
+	return AcquisitionID(rowID), nil
+}
+
+//go:embed queries/select-acquisitions-by-instrument.sql
+var rawSelectAcquisitionsByInstrumentQuery string
+
+var selectAcquisitionsByInstrumentQuery string = strings.TrimSpace(
+	rawSelectAcquisitionsByInstrumentQuery,
+)
+
+const DefaultAcquisitionsLimit = 10
+
+func (s *Store) GetAcquisitionsByInstrument(
+	ctx context.Context, iid InstrumentID, acquisitionsLimit int64,
+) (acquisitions []Acquisition, err error) {
+	sel := newAcquisitionsSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectAcquisitionsByInstrumentQuery,
+		newAcquisitionsByInstrumentSelection(iid, acquisitionsLimit), sel.Step,
+	); err != nil {
+		return nil, errors.Wrapf(err, "couldn't get acquisitions of instrument %d", iid)
+	}
+	return sel.Acquisitions(), nil
+}
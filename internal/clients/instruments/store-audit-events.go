@@ -0,0 +1,51 @@
+package instruments
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed queries/insert-audit-event.sql
+var rawInsertAuditEventQuery string
+var insertAuditEventQuery string = strings.TrimSpace(rawInsertAuditEventQuery)
+
+// AddAuditEvent appends the event to the audit log. Audit events can't be changed or deleted
+// afterwards.
+func (s *Store) AddAuditEvent(ctx context.Context, e AuditEvent) (eventID AuditEventID, err error) {
+	insertion, err := e.newInsertion()
+	if err != nil {
+		return 0, errors.Wrapf(err, "couldn't prepare audit event for instrument %d", e.InstrumentID)
+	}
+	rowID, err := s.db.ExecuteInsertionForID(ctx, insertAuditEventQuery, insertion)
+	if err != nil {
+		return 0, errors.Wrapf(err, "couldn't add audit event for instrument %d", e.InstrumentID)
+	}
+	return AuditEventID(rowID), nil
+}
+
+//go:embed queries/select-audit-events.sql
+var rawSelectAuditEventsQuery string
+var selectAuditEventsQuery string = strings.TrimSpace(rawSelectAuditEventsQuery)
+
+const DefaultAuditEventsLimit = 200
+
+// GetAuditEvents returns the instrument's audit events which match the filter, starting with the
+// most recent events. Events from before the instrument was created are excluded, since they were
+// recorded for a deleted instrument which previously had the same ID.
+func (s *Store) GetAuditEvents(
+	ctx context.Context, iid InstrumentID, filter AuditEventsFilter,
+) (events []AuditEvent, err error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultAuditEventsLimit
+	}
+	sel := newAuditEventsSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectAuditEventsQuery, newAuditEventsSelection(iid, filter), sel.Step,
+	); err != nil {
+		return nil, errors.Wrapf(err, "couldn't get audit events for instrument %d", iid)
+	}
+	return sel.AuditEvents(), nil
+}
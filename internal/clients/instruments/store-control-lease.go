@@ -0,0 +1,111 @@
+package instruments
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed queries/upsert-control-lease-if-available.sql
+var rawUpsertControlLeaseIfAvailableQuery string
+var upsertControlLeaseIfAvailableQuery string = strings.TrimSpace(
+	rawUpsertControlLeaseIfAvailableQuery,
+)
+
+// SetControlLeaseIfAvailable takes the lease for its holder if no other user holds an unexpired
+// lease on the instrument; if the holder already holds the lease, it's renewed. The lease which is
+// in effect afterwards is returned, so that callers can check whether it was taken.
+func (s *Store) SetControlLeaseIfAvailable(
+	ctx context.Context, lease ControlLease,
+) (current ControlLease, err error) {
+	if err = s.db.ExecuteUpdate(
+		ctx, upsertControlLeaseIfAvailableQuery, lease.newUpsert(),
+	); err != nil {
+		return ControlLease{}, errors.Wrapf(
+			err, "couldn't set control lease for instrument %d", lease.InstrumentID,
+		)
+	}
+	current, _, err = s.GetControlLease(ctx, lease.InstrumentID)
+	return current, err
+}
+
+//go:embed queries/update-control-lease-requester.sql
+var rawUpdateControlLeaseRequesterQuery string
+var updateControlLeaseRequesterQuery string = strings.TrimSpace(
+	rawUpdateControlLeaseRequesterQuery,
+)
+
+// SetControlLeaseRequester records a request for the lease from a user other than its holder.
+func (s *Store) SetControlLeaseRequester(
+	ctx context.Context, lease ControlLease, requesterIdentityID string,
+) error {
+	return errors.Wrapf(
+		s.db.ExecuteUpdate(
+			ctx, updateControlLeaseRequesterQuery, lease.newRequesterUpdate(requesterIdentityID),
+		),
+		"couldn't request control lease for instrument %d", lease.InstrumentID,
+	)
+}
+
+//go:embed queries/update-control-lease-holder.sql
+var rawUpdateControlLeaseHolderQuery string
+var updateControlLeaseHolderQuery string = strings.TrimSpace(rawUpdateControlLeaseHolderQuery)
+
+// TransferControlLease replaces the lease with the next lease, if the lease's holder still holds
+// it.
+func (s *Store) TransferControlLease(ctx context.Context, lease, next ControlLease) error {
+	return errors.Wrapf(
+		s.db.ExecuteUpdate(ctx, updateControlLeaseHolderQuery, lease.newHolderUpdate(next)),
+		"couldn't transfer control lease for instrument %d", lease.InstrumentID,
+	)
+}
+
+//go:embed queries/delete-control-lease.sql
+var rawDeleteControlLeaseQuery string
+var deleteControlLeaseQuery string = strings.TrimSpace(rawDeleteControlLeaseQuery)
+
+// DeleteControlLease releases the lease, unless it has since been renewed or transferred.
+func (s *Store) DeleteControlLease(ctx context.Context, lease ControlLease) error {
+	return errors.Wrapf(
+		s.db.ExecuteDelete(ctx, deleteControlLeaseQuery, lease.newDelete()),
+		"couldn't delete control lease for instrument %d", lease.InstrumentID,
+	)
+}
+
+//go:embed queries/select-control-lease.sql
+var rawSelectControlLeaseQuery string
+var selectControlLeaseQuery string = strings.TrimSpace(rawSelectControlLeaseQuery)
+
+// GetControlLease returns the control lease on the instrument, if there is one. The lease may have
+// expired without having been deleted yet.
+func (s *Store) GetControlLease(
+	ctx context.Context, iid InstrumentID,
+) (lease ControlLease, held bool, err error) {
+	sel := newControlLeasesSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectControlLeaseQuery, newControlLeaseSelection(iid), sel.Step,
+	); err != nil {
+		return ControlLease{}, false, errors.Wrapf(
+			err, "couldn't get control lease for instrument %d", iid,
+		)
+	}
+	leases := sel.ControlLeases()
+	if len(leases) == 0 {
+		return ControlLease{}, false, nil
+	}
+	return leases[0], true, nil
+}
+
+//go:embed queries/select-control-leases.sql
+var rawSelectControlLeasesQuery string
+var selectControlLeasesQuery string = strings.TrimSpace(rawSelectControlLeasesQuery)
+
+func (s *Store) GetControlLeases(ctx context.Context) (leases []ControlLease, err error) {
+	sel := newControlLeasesSelector()
+	if err = s.db.ExecuteSelection(ctx, selectControlLeasesQuery, nil, sel.Step); err != nil {
+		return nil, errors.Wrap(err, "couldn't get control leases")
+	}
+	return sel.ControlLeases(), nil
+}
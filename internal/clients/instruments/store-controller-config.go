@@ -0,0 +1,88 @@
+package instruments
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/database"
+	"zombiezen.com/go/sqlite"
+)
+
+// ControllerConfig is the configuration which is stored alongside a controller and which its client
+// is started with.
+type ControllerConfig struct {
+	Schema        string
+	Limits        ControllerLimits
+	RestoreCamera bool
+	MQTTAuth      MQTTAuth
+}
+
+func (c ControllerConfig) write(
+	conn *sqlite.Conn, cid ControllerID, encryptedAuth encryptedMQTTAuth,
+) error {
+	if err := database.ExecuteUpdate(
+		conn, upsertControllerSchemaQuery,
+		ControllerSchema{ControllerID: cid, Schema: c.Schema}.newUpsert(),
+	); err != nil {
+		return errors.Wrapf(err, "couldn't set schema for controller %d", cid)
+	}
+	if err := database.ExecuteUpdate(
+		conn, upsertControllerLimitsQuery, c.Limits.newUpsert(cid),
+	); err != nil {
+		return errors.Wrapf(err, "couldn't set limits for controller %d", cid)
+	}
+	if err := database.ExecuteUpdate(
+		conn, upsertControllerSettingsRestoreCameraQuery,
+		newControllerRestoreCameraUpsert(cid, c.RestoreCamera),
+	); err != nil {
+		return errors.Wrapf(err, "couldn't set camera restoration option for controller %d", cid)
+	}
+	encryptedAuth.ControllerID = cid
+	if err := database.ExecuteUpdate(
+		conn, upsertControllerMQTTAuthQuery, encryptedAuth.newUpsert(),
+	); err != nil {
+		return errors.Wrapf(err, "couldn't set mqtt auth for controller %d", cid)
+	}
+	return nil
+}
+
+// AddConfiguredController adds the controller together with its configuration, so that the
+// controller is never stored without its limits and credentials.
+func (s *Store) AddConfiguredController(
+	ctx context.Context, c Controller, config ControllerConfig,
+) (cid ControllerID, err error) {
+	encryptedAuth, err := s.encryptMQTTAuth(0, config.MQTTAuth)
+	if err != nil {
+		return 0, errors.Wrap(err, "couldn't encrypt mqtt auth for new controller")
+	}
+	err = executeWriteTx(ctx, s.db, func(conn *sqlite.Conn) error {
+		rowID, err := database.ExecuteInsertionForID(conn, insertControllerQuery, c.NewInsertion())
+		if err != nil {
+			return errors.Wrapf(err, "couldn't add controller for instrument %d", c.InstrumentID)
+		}
+		cid = ControllerID(rowID)
+		return config.write(conn, cid, encryptedAuth)
+	})
+	if err != nil {
+		return 0, err
+	}
+	return cid, nil
+}
+
+// UpdateConfiguredController updates the controller together with its configuration, so that a
+// failed update can't leave the controller's limits or credentials out of step with its other
+// settings.
+func (s *Store) UpdateConfiguredController(
+	ctx context.Context, c Controller, config ControllerConfig,
+) error {
+	encryptedAuth, err := s.encryptMQTTAuth(c.ID, config.MQTTAuth)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't encrypt mqtt auth for controller %d", c.ID)
+	}
+	return executeWriteTx(ctx, s.db, func(conn *sqlite.Conn) error {
+		if err := database.ExecuteUpdate(conn, updateControllerQuery, c.NewUpdate()); err != nil {
+			return errors.Wrapf(err, "couldn't update controller %d", c.ID)
+		}
+		return config.write(conn, c.ID, encryptedAuth)
+	})
+}
@@ -0,0 +1,61 @@
+package instruments
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed queries/upsert-controller-limits.sql
+var rawUpsertControllerLimitsQuery string
+var upsertControllerLimitsQuery string = strings.TrimSpace(rawUpsertControllerLimitsQuery)
+
+func (s *Store) SetControllerLimits(
+	ctx context.Context, cid ControllerID, limits ControllerLimits,
+) error {
+	return errors.Wrapf(
+		s.db.ExecuteUpdate(ctx, upsertControllerLimitsQuery, limits.newUpsert(cid)),
+		"couldn't set limits for controller %d", cid,
+	)
+}
+
+//go:embed queries/select-controller-limits.sql
+var rawSelectControllerLimitsQuery string
+var selectControllerLimitsQuery string = strings.TrimSpace(rawSelectControllerLimitsQuery)
+
+// GetControllerLimits returns the safety limits of the controller. If none have been set, zero
+// limits (which don't bound anything) are returned.
+func (s *Store) GetControllerLimits(
+	ctx context.Context, cid ControllerID,
+) (limits ControllerLimits, err error) {
+	sel := newControllerLimitsSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectControllerLimitsQuery, newControllerLimitsSelection(cid), sel.Step,
+	); err != nil {
+		return ControllerLimits{}, errors.Wrapf(err, "couldn't get limits for controller %d", cid)
+	}
+	return sel.ControllerLimits()[cid], nil
+}
+
+//go:embed queries/select-instrument-controller-limits.sql
+var rawSelectInstrumentControllerLimitsQuery string
+var selectInstrumentControllerLimitsQuery string = strings.TrimSpace(
+	rawSelectInstrumentControllerLimitsQuery,
+)
+
+// GetInstrumentControllerLimits returns the safety limits of each controller of the instrument
+// which has limits.
+func (s *Store) GetInstrumentControllerLimits(
+	ctx context.Context, iid InstrumentID,
+) (limits map[ControllerID]ControllerLimits, err error) {
+	sel := newControllerLimitsSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectInstrumentControllerLimitsQuery,
+		newControllerLimitsByInstrumentSelection(iid), sel.Step,
+	); err != nil {
+		return nil, errors.Wrapf(err, "couldn't get controller limits for instrument %d", iid)
+	}
+	return sel.ControllerLimits(), nil
+}
@@ -0,0 +1,108 @@
+package instruments
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+func (s *Store) encryptMQTTAuth(cid ControllerID, a MQTTAuth) (e encryptedMQTTAuth, err error) {
+	e = encryptedMQTTAuth{
+		ControllerID: cid,
+		Username:     a.Username,
+		CACert:       a.CACert,
+		ClientCert:   a.ClientCert,
+	}
+	if e.EncryptedPassword, err = encryptSecret(s.secretsKey, a.Password); err != nil {
+		return encryptedMQTTAuth{}, errors.Wrap(err, "couldn't encrypt password")
+	}
+	if e.EncryptedClientKey, err = encryptSecret(s.secretsKey, a.ClientKey); err != nil {
+		return encryptedMQTTAuth{}, errors.Wrap(err, "couldn't encrypt client key")
+	}
+	return e, nil
+}
+
+func (s *Store) decryptMQTTAuth(e encryptedMQTTAuth) (a MQTTAuth, err error) {
+	a = MQTTAuth{
+		Username:   e.Username,
+		CACert:     e.CACert,
+		ClientCert: e.ClientCert,
+	}
+	if a.Password, err = decryptSecret(s.secretsKey, e.EncryptedPassword); err != nil {
+		return MQTTAuth{}, errors.Wrap(err, "couldn't decrypt password")
+	}
+	if a.ClientKey, err = decryptSecret(s.secretsKey, e.EncryptedClientKey); err != nil {
+		return MQTTAuth{}, errors.Wrap(err, "couldn't decrypt client key")
+	}
+	return a, nil
+}
+
+//go:embed queries/upsert-controller-mqtt-auth.sql
+var rawUpsertControllerMQTTAuthQuery string
+var upsertControllerMQTTAuthQuery string = strings.TrimSpace(rawUpsertControllerMQTTAuthQuery)
+
+func (s *Store) SetControllerMQTTAuth(ctx context.Context, cid ControllerID, a MQTTAuth) error {
+	encrypted, err := s.encryptMQTTAuth(cid, a)
+	if err != nil {
+		return errors.Wrapf(err, "couldn't encrypt mqtt auth for controller %d", cid)
+	}
+	return errors.Wrapf(
+		s.db.ExecuteUpdate(ctx, upsertControllerMQTTAuthQuery, encrypted.newUpsert()),
+		"couldn't set mqtt auth for controller %d", cid,
+	)
+}
+
+//go:embed queries/select-controller-mqtt-auth.sql
+var rawSelectControllerMQTTAuthQuery string
+var selectControllerMQTTAuthQuery string = strings.TrimSpace(rawSelectControllerMQTTAuthQuery)
+
+// GetControllerMQTTAuth returns the decrypted MQTT authentication settings of the controller. If
+// none have been set, a zero-valued MQTTAuth is returned.
+func (s *Store) GetControllerMQTTAuth(ctx context.Context, cid ControllerID) (MQTTAuth, error) {
+	sel := newMQTTAuthsSelector()
+	if err := s.db.ExecuteSelection(
+		ctx, selectControllerMQTTAuthQuery, newMQTTAuthSelection(cid), sel.Step,
+	); err != nil {
+		return MQTTAuth{}, errors.Wrapf(err, "couldn't get mqtt auth for controller %d", cid)
+	}
+	auths := sel.MQTTAuths()
+	if len(auths) == 0 {
+		return MQTTAuth{}, nil
+	}
+	a, err := s.decryptMQTTAuth(auths[0])
+	return a, errors.Wrapf(err, "couldn't decrypt mqtt auth for controller %d", cid)
+}
+
+//go:embed queries/select-instrument-controller-mqtt-auths.sql
+var rawSelectInstrumentControllerMQTTAuthsQuery string
+var selectInstrumentControllerMQTTAuthsQuery string = strings.TrimSpace(
+	rawSelectInstrumentControllerMQTTAuthsQuery,
+)
+
+// GetInstrumentControllerMQTTAuthStatuses returns a summary of the MQTT authentication settings of
+// each controller of the instrument which has any such settings.
+func (s *Store) GetInstrumentControllerMQTTAuthStatuses(
+	ctx context.Context, iid InstrumentID,
+) (statuses map[ControllerID]MQTTAuthStatus, err error) {
+	sel := newMQTTAuthsSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectInstrumentControllerMQTTAuthsQuery, newMQTTAuthsByInstrumentSelection(iid),
+		sel.Step,
+	); err != nil {
+		return nil, errors.Wrapf(err, "couldn't get controller mqtt auths for instrument %d", iid)
+	}
+	statuses = make(map[ControllerID]MQTTAuthStatus)
+	for _, encrypted := range sel.MQTTAuths() {
+		// Statuses only depend on whether the secrets are non-empty, so we don't need to decrypt them
+		statuses[encrypted.ControllerID] = MQTTAuth{
+			Username:   encrypted.Username,
+			Password:   encrypted.EncryptedPassword,
+			CACert:     encrypted.CACert,
+			ClientCert: encrypted.ClientCert,
+			ClientKey:  encrypted.EncryptedClientKey,
+		}.Status()
+	}
+	return statuses, nil
+}
@@ -0,0 +1,68 @@
+package instruments
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed queries/upsert-controller-schema.sql
+var rawUpsertControllerSchemaQuery string
+var upsertControllerSchemaQuery string = strings.TrimSpace(rawUpsertControllerSchemaQuery)
+
+func (s *Store) SetControllerSchema(ctx context.Context, cid ControllerID, schema string) error {
+	return errors.Wrapf(
+		s.db.ExecuteUpdate(
+			ctx, upsertControllerSchemaQuery,
+			ControllerSchema{ControllerID: cid, Schema: schema}.newUpsert(),
+		),
+		"couldn't set schema for controller %d", cid,
+	)
+}
+
+//go:embed queries/select-controller-schema.sql
+var rawSelectControllerSchemaQuery string
+var selectControllerSchemaQuery string = strings.TrimSpace(rawSelectControllerSchemaQuery)
+
+// GetControllerSchema returns the schema of the controller. If none has been set, an empty string
+// is returned.
+func (s *Store) GetControllerSchema(ctx context.Context, cid ControllerID) (string, error) {
+	sel := newControllerSchemasSelector()
+	if err := s.db.ExecuteSelection(
+		ctx, selectControllerSchemaQuery, newControllerSchemaSelection(cid), sel.Step,
+	); err != nil {
+		return "", errors.Wrapf(err, "couldn't get schema for controller %d", cid)
+	}
+	schemas := sel.ControllerSchemas()
+	if len(schemas) == 0 {
+		return "", nil
+	}
+	return schemas[0].Schema, nil
+}
+
+//go:embed queries/select-instrument-controller-schemas.sql
+var rawSelectInstrumentControllerSchemasQuery string
+var selectInstrumentControllerSchemasQuery string = strings.TrimSpace(
+	rawSelectInstrumentControllerSchemasQuery,
+)
+
+// GetInstrumentControllerSchemas returns the schema of each controller of the instrument which has
+// a schema.
+func (s *Store) GetInstrumentControllerSchemas(
+	ctx context.Context, iid InstrumentID,
+) (schemas map[ControllerID]string, err error) {
+	sel := newControllerSchemasSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectInstrumentControllerSchemasQuery,
+		newControllerSchemasByInstrumentSelection(iid), sel.Step,
+	); err != nil {
+		return nil, errors.Wrapf(err, "couldn't get controller schemas for instrument %d", iid)
+	}
+	schemas = make(map[ControllerID]string)
+	for _, schema := range sel.ControllerSchemas() {
+		schemas[schema.ControllerID] = schema.Schema
+	}
+	return schemas, nil
+}
@@ -0,0 +1,81 @@
+package instruments
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed queries/upsert-controller-settings.sql
+var rawUpsertControllerSettingsQuery string
+var upsertControllerSettingsQuery string = strings.TrimSpace(rawUpsertControllerSettingsQuery)
+
+// SetControllerSettings records the settings which were last applied to the controller. It leaves
+// the controller's RestoreCamera option unchanged.
+func (s *Store) SetControllerSettings(
+	ctx context.Context, cid ControllerID, settings ControllerSettings,
+) error {
+	return errors.Wrapf(
+		s.db.ExecuteUpdate(ctx, upsertControllerSettingsQuery, settings.newUpsert(cid)),
+		"couldn't set settings for controller %d", cid,
+	)
+}
+
+//go:embed queries/upsert-controller-settings-restore-camera.sql
+var rawUpsertControllerSettingsRestoreCameraQuery string
+var upsertControllerSettingsRestoreCameraQuery string = strings.TrimSpace(
+	rawUpsertControllerSettingsRestoreCameraQuery,
+)
+
+func (s *Store) SetControllerRestoreCamera(
+	ctx context.Context, cid ControllerID, restoreCamera bool,
+) error {
+	return errors.Wrapf(
+		s.db.ExecuteUpdate(
+			ctx, upsertControllerSettingsRestoreCameraQuery,
+			newControllerRestoreCameraUpsert(cid, restoreCamera),
+		),
+		"couldn't set camera restoration option for controller %d", cid,
+	)
+}
+
+//go:embed queries/select-controller-settings.sql
+var rawSelectControllerSettingsQuery string
+var selectControllerSettingsQuery string = strings.TrimSpace(rawSelectControllerSettingsQuery)
+
+// GetControllerSettings returns the settings which were last applied to the controller. If none
+// have been recorded, the returned settings aren't marked as saved.
+func (s *Store) GetControllerSettings(
+	ctx context.Context, cid ControllerID,
+) (settings ControllerSettings, err error) {
+	sel := newControllerSettingsSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectControllerSettingsQuery, newControllerSettingsSelection(cid), sel.Step,
+	); err != nil {
+		return ControllerSettings{}, errors.Wrapf(err, "couldn't get settings for controller %d", cid)
+	}
+	return sel.ControllerSettings()[cid], nil
+}
+
+//go:embed queries/select-instrument-controller-settings.sql
+var rawSelectInstrumentControllerSettingsQuery string
+var selectInstrumentControllerSettingsQuery string = strings.TrimSpace(
+	rawSelectInstrumentControllerSettingsQuery,
+)
+
+// GetInstrumentControllerSettings returns the recorded settings of each controller of the
+// instrument which has any.
+func (s *Store) GetInstrumentControllerSettings(
+	ctx context.Context, iid InstrumentID,
+) (settings map[ControllerID]ControllerSettings, err error) {
+	sel := newControllerSettingsSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectInstrumentControllerSettingsQuery,
+		newControllerSettingsByInstrumentSelection(iid), sel.Step,
+	); err != nil {
+		return nil, errors.Wrapf(err, "couldn't get controller settings for instrument %d", iid)
+	}
+	return sel.ControllerSettings(), nil
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	_ "embed"
 	"strings"
+
+	"github.com/pkg/errors"
 )
 
 //go:embed queries/insert-controller.sql
@@ -29,3 +31,21 @@ var deleteControllerQuery string = strings.TrimSpace(rawDeleteControllerQuery)
 func (s *Store) DeleteController(ctx context.Context, id ControllerID) (err error) {
 	return executeDelete[ControllerID](ctx, deleteControllerQuery, Controller{ID: id}, s.db)
 }
+
+//go:embed queries/select-controller.sql
+var rawSelectControllerQuery string
+var selectControllerQuery string = strings.TrimSpace(rawSelectControllerQuery)
+
+func (s *Store) GetController(ctx context.Context, id ControllerID) (c Controller, err error) {
+	sel := newControllersSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectControllerQuery, newControllerSelection(id), sel.Step,
+	); err != nil {
+		return Controller{}, errors.Wrapf(err, "couldn't get controller with id %d", id)
+	}
+	controllers := sel.Controllers()
+	if len(controllers) == 0 {
+		return Controller{}, errors.Errorf("couldn't get non-existent controller with id %d", id)
+	}
+	return controllers[0], nil
+}
@@ -0,0 +1,67 @@
+package instruments
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed queries/upsert-emergency-stop.sql
+var rawUpsertEmergencyStopQuery string
+var upsertEmergencyStopQuery string = strings.TrimSpace(rawUpsertEmergencyStopQuery)
+
+func (s *Store) SetEmergencyStop(ctx context.Context, stop EmergencyStop) error {
+	return errors.Wrapf(
+		s.db.ExecuteUpdate(ctx, upsertEmergencyStopQuery, stop.newUpsert()),
+		"couldn't set emergency stop for instrument %d", stop.InstrumentID,
+	)
+}
+
+//go:embed queries/delete-emergency-stop.sql
+var rawDeleteEmergencyStopQuery string
+var deleteEmergencyStopQuery string = strings.TrimSpace(rawDeleteEmergencyStopQuery)
+
+func (s *Store) ClearEmergencyStop(ctx context.Context, iid InstrumentID) error {
+	return errors.Wrapf(
+		s.db.ExecuteDelete(ctx, deleteEmergencyStopQuery, newEmergencyStopSelection(iid)),
+		"couldn't clear emergency stop for instrument %d", iid,
+	)
+}
+
+//go:embed queries/select-emergency-stop.sql
+var rawSelectEmergencyStopQuery string
+var selectEmergencyStopQuery string = strings.TrimSpace(rawSelectEmergencyStopQuery)
+
+// GetEmergencyStop returns the emergency stop currently in effect for the instrument, if there is
+// one.
+func (s *Store) GetEmergencyStop(
+	ctx context.Context, iid InstrumentID,
+) (stop EmergencyStop, stopped bool, err error) {
+	sel := newEmergencyStopsSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectEmergencyStopQuery, newEmergencyStopSelection(iid), sel.Step,
+	); err != nil {
+		return EmergencyStop{}, false, errors.Wrapf(
+			err, "couldn't get emergency stop for instrument %d", iid,
+		)
+	}
+	stops := sel.EmergencyStops()
+	if len(stops) == 0 {
+		return EmergencyStop{}, false, nil
+	}
+	return stops[0], true, nil
+}
+
+//go:embed queries/select-emergency-stops.sql
+var rawSelectEmergencyStopsQuery string
+var selectEmergencyStopsQuery string = strings.TrimSpace(rawSelectEmergencyStopsQuery)
+
+func (s *Store) GetEmergencyStops(ctx context.Context) (stops []EmergencyStop, err error) {
+	sel := newEmergencyStopsSelector()
+	if err = s.db.ExecuteSelection(ctx, selectEmergencyStopsQuery, nil, sel.Step); err != nil {
+		return nil, errors.Wrap(err, "couldn't get emergency stops")
+	}
+	return sel.EmergencyStops(), nil
+}
@@ -0,0 +1,64 @@
+package instruments
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+func ParseMemberRole(raw string) (MemberRole, error) {
+	switch role := MemberRole(raw); role {
+	default:
+		return "", errors.Errorf("unknown member role %s", raw)
+	case MemberRoleAdmin, MemberRoleOperator, MemberRoleViewer:
+		return role, nil
+	}
+}
+
+//go:embed queries/upsert-instrument-member.sql
+var rawUpsertInstrumentMemberQuery string
+var upsertInstrumentMemberQuery string = strings.TrimSpace(rawUpsertInstrumentMemberQuery)
+
+// SetInstrumentMember adds the member to the instrument, or changes the member's role if the
+// member's identity is already a member of the instrument.
+func (s *Store) SetInstrumentMember(ctx context.Context, m InstrumentMember) error {
+	return errors.Wrapf(
+		s.db.ExecuteUpdate(ctx, upsertInstrumentMemberQuery, m.newUpsert()),
+		"couldn't set member %s of instrument %d", m.IdentityID, m.InstrumentID,
+	)
+}
+
+//go:embed queries/delete-instrument-member.sql
+var rawDeleteInstrumentMemberQuery string
+var deleteInstrumentMemberQuery string = strings.TrimSpace(rawDeleteInstrumentMemberQuery)
+
+func (s *Store) DeleteInstrumentMember(
+	ctx context.Context, iid InstrumentID, identityID string,
+) error {
+	m := InstrumentMember{
+		InstrumentID: iid,
+		IdentityID:   identityID,
+	}
+	return errors.Wrapf(
+		s.db.ExecuteDelete(ctx, deleteInstrumentMemberQuery, m.newDelete()),
+		"couldn't delete member %s of instrument %d", identityID, iid,
+	)
+}
+
+//go:embed queries/select-instrument-members.sql
+var rawSelectInstrumentMembersQuery string
+var selectInstrumentMembersQuery string = strings.TrimSpace(rawSelectInstrumentMembersQuery)
+
+func (s *Store) GetInstrumentMembers(
+	ctx context.Context, iid InstrumentID,
+) (members []InstrumentMember, err error) {
+	sel := newInstrumentMembersSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectInstrumentMembersQuery, newInstrumentMembersSelection(iid), sel.Step,
+	); err != nil {
+		return nil, errors.Wrapf(err, "couldn't get members of instrument %d", iid)
+	}
+	return sel.InstrumentMembers(), nil
+}
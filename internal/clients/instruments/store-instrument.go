@@ -4,6 +4,7 @@ import (
 	"context"
 	_ "embed"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 )
@@ -15,7 +16,9 @@ var insertInstrumentQuery string = strings.TrimSpace(rawInsertInstrumentQuery)
 func (s *Store) AddInstrument(
 	ctx context.Context, i Instrument,
 ) (instrumentID InstrumentID, err error) {
-	rowID, err := s.db.ExecuteInsertionForID(ctx, insertInstrumentQuery, i.newInsertion())
+	rowID, err := s.db.ExecuteInsertionForID(
+		ctx, insertInstrumentQuery, i.newInsertion(time.Now()),
+	)
 	if err != nil {
 		return 0, errors.Wrapf(err, "couldn't add instrument with admin %s", i.AdminID)
 	}
@@ -57,6 +60,31 @@ func (s *Store) UpdateInstrumentDescription(
 	)
 }
 
+func ParseVisibility(raw string) (Visibility, error) {
+	switch visibility := Visibility(raw); visibility {
+	default:
+		return "", errors.Errorf("unknown visibility %s", raw)
+	case VisibilityPublic, VisibilityUnlisted, VisibilityMembers:
+		return visibility, nil
+	}
+}
+
+//go:embed queries/update-instrument-visibility.sql
+var rawUpdateInstrumentVisibilityQuery string
+var updateInstrumentVisibilityQuery string = strings.TrimSpace(rawUpdateInstrumentVisibilityQuery)
+
+func (s *Store) UpdateInstrumentVisibility(
+	ctx context.Context, id InstrumentID, visibility Visibility,
+) (err error) {
+	return errors.Wrapf(
+		s.db.ExecuteUpdate(ctx, updateInstrumentVisibilityQuery, Instrument{
+			ID:         id,
+			Visibility: visibility,
+		}.newVisibilityUpdate()),
+		"couldn't update visibility of instrument %d", id,
+	)
+}
+
 //go:embed queries/delete-instrument.sql
 var rawDeleteInstrumentQuery string
 var deleteInstrumentQuery string = strings.TrimSpace(rawDeleteInstrumentQuery)
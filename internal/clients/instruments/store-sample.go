@@ -0,0 +1,40 @@
+package instruments
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+//go:embed queries/upsert-sample.sql
+var rawUpsertSampleQuery string
+var upsertSampleQuery string = strings.TrimSpace(rawUpsertSampleQuery)
+
+func (s *Store) SetSample(ctx context.Context, sample Sample) (err error) {
+	return errors.Wrapf(
+		s.db.ExecuteUpdate(ctx, upsertSampleQuery, sample.newUpsert()),
+		"couldn't set sample of instrument %d", sample.InstrumentID,
+	)
+}
+
+//go:embed queries/select-sample.sql
+var rawSelectSampleQuery string
+var selectSampleQuery string = strings.TrimSpace(rawSelectSampleQuery)
+
+// GetSample returns the current sample of the instrument. If no sample has been set for the
+// instrument, a sample with the instrument's name as its project ID is returned.
+func (s *Store) GetSample(ctx context.Context, iid InstrumentID) (sample Sample, err error) {
+	sel := newSamplesSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectSampleQuery, newSampleSelection(iid), sel.Step,
+	); err != nil {
+		return Sample{}, errors.Wrapf(err, "couldn't get sample of instrument %d", iid)
+	}
+	samples := sel.Samples()
+	if len(samples) == 0 {
+		return Sample{}, errors.Errorf("couldn't get sample of non-existent instrument %d", iid)
+	}
+	return samples[0], nil
+}
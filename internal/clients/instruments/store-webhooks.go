@@ -0,0 +1,236 @@
+package instruments
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Webhooks
+
+func (s *Store) decryptWebhook(e encryptedWebhook) (w Webhook, err error) {
+	w = e.Webhook
+	if w.Secret, err = decryptSecret(s.secretsKey, e.EncryptedSecret); err != nil {
+		return Webhook{}, errors.Wrapf(err, "couldn't decrypt secret of webhook %d", w.ID)
+	}
+	return w, nil
+}
+
+//go:embed queries/insert-webhook.sql
+var rawInsertWebhookQuery string
+var insertWebhookQuery string = strings.TrimSpace(rawInsertWebhookQuery)
+
+func (s *Store) AddWebhook(ctx context.Context, w Webhook) (webhookID WebhookID, err error) {
+	encryptedSecret, err := encryptSecret(s.secretsKey, w.Secret)
+	if err != nil {
+		return 0, errors.Wrapf(
+			err, "couldn't encrypt secret of webhook for instrument %d", w.InstrumentID,
+		)
+	}
+	rowID, err := s.db.ExecuteInsertionForID(ctx, insertWebhookQuery, w.newInsertion(encryptedSecret))
+	if err != nil {
+		return 0, errors.Wrapf(err, "couldn't add webhook for instrument %d", w.InstrumentID)
+	}
+	return WebhookID(rowID), nil
+}
+
+//go:embed queries/update-webhook.sql
+var rawUpdateWebhookQuery string
+var updateWebhookQuery string = strings.TrimSpace(rawUpdateWebhookQuery)
+
+// UpdateWebhook changes the webhook's settings. The webhook's secret can't be changed.
+func (s *Store) UpdateWebhook(ctx context.Context, w Webhook) error {
+	return errors.Wrapf(
+		s.db.ExecuteUpdate(ctx, updateWebhookQuery, w.newUpdate()), "couldn't update webhook %d", w.ID,
+	)
+}
+
+//go:embed queries/delete-webhook.sql
+var rawDeleteWebhookQuery string
+var deleteWebhookQuery string = strings.TrimSpace(rawDeleteWebhookQuery)
+
+// DeleteWebhook deletes the webhook along with its delivery log.
+func (s *Store) DeleteWebhook(ctx context.Context, id WebhookID) error {
+	return errors.Wrapf(
+		s.db.ExecuteDelete(ctx, deleteWebhookQuery, Webhook{ID: id}.newDelete()),
+		"couldn't delete webhook %d", id,
+	)
+}
+
+//go:embed queries/select-webhook.sql
+var rawSelectWebhookQuery string
+var selectWebhookQuery string = strings.TrimSpace(rawSelectWebhookQuery)
+
+func (s *Store) GetWebhook(ctx context.Context, id WebhookID) (w Webhook, err error) {
+	sel := newWebhooksSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectWebhookQuery, newWebhookSelection(id), sel.Step,
+	); err != nil {
+		return Webhook{}, errors.Wrapf(err, "couldn't get webhook with id %d", id)
+	}
+	webhooks := sel.Webhooks()
+	if len(webhooks) == 0 {
+		return Webhook{}, errors.Errorf("couldn't get non-existent webhook with id %d", id)
+	}
+	return s.decryptWebhook(webhooks[0])
+}
+
+//go:embed queries/select-webhooks-by-instrument.sql
+var rawSelectWebhooksByInstrumentQuery string
+var selectWebhooksByInstrumentQuery string = strings.TrimSpace(
+	rawSelectWebhooksByInstrumentQuery,
+)
+
+func (s *Store) GetWebhooksByInstrument(
+	ctx context.Context, iid InstrumentID,
+) (webhooks []Webhook, err error) {
+	sel := newWebhooksSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectWebhooksByInstrumentQuery, newWebhooksByInstrumentSelection(iid), sel.Step,
+	); err != nil {
+		return nil, errors.Wrapf(err, "couldn't get webhooks of instrument %d", iid)
+	}
+	encrypted := sel.Webhooks()
+	webhooks = make([]Webhook, len(encrypted))
+	for i, e := range encrypted {
+		if webhooks[i], err = s.decryptWebhook(e); err != nil {
+			return nil, err
+		}
+	}
+	return webhooks, nil
+}
+
+// Webhook Deliveries
+
+//go:embed queries/insert-webhook-deliveries.sql
+var rawInsertWebhookDeliveriesQuery string
+var insertWebhookDeliveriesQuery string = strings.TrimSpace(rawInsertWebhookDeliveriesQuery)
+
+// AddWebhookDeliveries adds a pending delivery of the payload to each enabled webhook of the
+// instrument whose event filter includes the event type.
+func (s *Store) AddWebhookDeliveries(
+	ctx context.Context, iid InstrumentID, eventType WebhookEventType, payload string,
+	createTime time.Time,
+) error {
+	return errors.Wrapf(
+		s.db.ExecuteInsertion(
+			ctx, insertWebhookDeliveriesQuery,
+			newWebhookDeliveriesInsertion(iid, eventType, payload, createTime),
+		),
+		"couldn't add %s webhook deliveries for instrument %d", eventType, iid,
+	)
+}
+
+//go:embed queries/insert-webhook-delivery.sql
+var rawInsertWebhookDeliveryQuery string
+var insertWebhookDeliveryQuery string = strings.TrimSpace(rawInsertWebhookDeliveryQuery)
+
+// AddWebhookDelivery adds a pending delivery to the webhook.
+func (s *Store) AddWebhookDelivery(
+	ctx context.Context, d WebhookDelivery,
+) (deliveryID WebhookDeliveryID, err error) {
+	rowID, err := s.db.ExecuteInsertionForID(ctx, insertWebhookDeliveryQuery, d.newInsertion())
+	if err != nil {
+		return 0, errors.Wrapf(err, "couldn't add delivery to webhook %d", d.WebhookID)
+	}
+	return WebhookDeliveryID(rowID), nil
+}
+
+//go:embed queries/select-webhook-delivery.sql
+var rawSelectWebhookDeliveryQuery string
+var selectWebhookDeliveryQuery string = strings.TrimSpace(rawSelectWebhookDeliveryQuery)
+
+func (s *Store) GetWebhookDelivery(
+	ctx context.Context, webhookID WebhookID, id WebhookDeliveryID,
+) (d WebhookDelivery, err error) {
+	sel := newWebhookDeliveriesSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectWebhookDeliveryQuery, newWebhookDeliverySelection(webhookID, id), sel.Step,
+	); err != nil {
+		return WebhookDelivery{}, errors.Wrapf(
+			err, "couldn't get delivery %d of webhook %d", id, webhookID,
+		)
+	}
+	deliveries := sel.WebhookDeliveries()
+	if len(deliveries) == 0 {
+		return WebhookDelivery{}, errors.Errorf(
+			"couldn't get non-existent delivery %d of webhook %d", id, webhookID,
+		)
+	}
+	return deliveries[0], nil
+}
+
+//go:embed queries/select-webhook-deliveries.sql
+var rawSelectWebhookDeliveriesQuery string
+var selectWebhookDeliveriesQuery string = strings.TrimSpace(rawSelectWebhookDeliveriesQuery)
+
+const DefaultWebhookDeliveriesLimit = 100
+
+// GetWebhookDeliveries returns the webhook's deliveries, starting with the most recent deliveries.
+func (s *Store) GetWebhookDeliveries(
+	ctx context.Context, webhookID WebhookID, limit int64,
+) (deliveries []WebhookDelivery, err error) {
+	if limit <= 0 {
+		limit = DefaultWebhookDeliveriesLimit
+	}
+	sel := newWebhookDeliveriesSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectWebhookDeliveriesQuery, newWebhookDeliveriesSelection(webhookID, limit), sel.Step,
+	); err != nil {
+		return nil, errors.Wrapf(err, "couldn't get deliveries of webhook %d", webhookID)
+	}
+	return sel.WebhookDeliveries(), nil
+}
+
+//go:embed queries/select-due-webhook-deliveries.sql
+var rawSelectDueWebhookDeliveriesQuery string
+var selectDueWebhookDeliveriesQuery string = strings.TrimSpace(
+	rawSelectDueWebhookDeliveriesQuery,
+)
+
+// getDueWebhookDeliveries returns the pending deliveries which should be attempted by the
+// specified time, starting with the deliveries which have been due the longest.
+func (s *Store) getDueWebhookDeliveries(
+	ctx context.Context, now time.Time, limit int64,
+) (deliveries []dueWebhookDelivery, err error) {
+	sel := newDueWebhookDeliveriesSelector()
+	if err = s.db.ExecuteSelection(
+		ctx, selectDueWebhookDeliveriesQuery, newDueWebhookDeliveriesSelection(now, limit), sel.Step,
+	); err != nil {
+		return nil, errors.Wrap(err, "couldn't get due webhook deliveries")
+	}
+	return sel.DueWebhookDeliveries(), nil
+}
+
+//go:embed queries/update-webhook-delivery-attempt.sql
+var rawUpdateWebhookDeliveryAttemptQuery string
+var updateWebhookDeliveryAttemptQuery string = strings.TrimSpace(
+	rawUpdateWebhookDeliveryAttemptQuery,
+)
+
+func (s *Store) updateWebhookDeliveryAttempt(ctx context.Context, d WebhookDelivery) error {
+	return errors.Wrapf(
+		s.db.ExecuteUpdate(ctx, updateWebhookDeliveryAttemptQuery, d.newAttemptUpdate()),
+		"couldn't record attempt of webhook delivery %d", d.ID,
+	)
+}
+
+//go:embed queries/delete-finished-webhook-deliveries.sql
+var rawDeleteFinishedWebhookDeliveriesQuery string
+var deleteFinishedWebhookDeliveriesQuery string = strings.TrimSpace(
+	rawDeleteFinishedWebhookDeliveriesQuery,
+)
+
+// deleteFinishedWebhookDeliveries deletes the succeeded and failed deliveries which were created
+// before the threshold.
+func (s *Store) deleteFinishedWebhookDeliveries(ctx context.Context, threshold time.Time) error {
+	return errors.Wrap(
+		s.db.ExecuteDelete(
+			ctx, deleteFinishedWebhookDeliveriesQuery, newFinishedWebhookDeliveriesDelete(threshold),
+		),
+		"couldn't delete finished webhook deliveries",
+	)
+}
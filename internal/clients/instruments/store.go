@@ -6,15 +6,19 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/sargassum-world/godest/database"
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
 )
 
 type Store struct {
-	db *database.DB
+	db         *database.DB
+	secretsKey []byte
 }
 
-func NewStore(db *database.DB) *Store {
+func NewStore(db *database.DB, c Config) *Store {
 	return &Store{
-		db: db,
+		db:         db,
+		secretsKey: c.SecretsKey,
 	}
 }
 
@@ -48,3 +52,18 @@ func executeDelete[ID ~int64, Model Deletable[ID]](
 		db.ExecuteDelete(ctx, query, model.NewDelete()), "couldn't delete %T %d", model, model.GetID(),
 	)
 }
+
+// executeWriteTx runs the writes in a single transaction on the database's writer, so that either
+// all of them take effect or none of them do.
+func executeWriteTx(
+	ctx context.Context, db *database.DB, writes func(conn *sqlite.Conn) error,
+) (err error) {
+	conn, err := db.AcquireWriter(ctx)
+	if err != nil {
+		return errors.Wrap(err, "couldn't acquire database writer")
+	}
+	defer db.ReleaseWriter(conn)
+
+	defer sqlitex.Save(conn)(&err)
+	return writes(conn)
+}
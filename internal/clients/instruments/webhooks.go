@@ -0,0 +1,380 @@
+package instruments
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+)
+
+// Headers of webhook deliveries
+const (
+	WebhookEventHeader     = "X-Pslive-Webhook-Event"
+	WebhookDeliveryHeader  = "X-Pslive-Webhook-Delivery"
+	WebhookTimestampHeader = "X-Pslive-Webhook-Timestamp"
+	// WebhookSignatureHeader carries "sha256=" followed by the hex-encoded HMAC-SHA256 of the
+	// timestamp header's value, a period, and the request body, keyed with the webhook's secret.
+	WebhookSignatureHeader = "X-Pslive-Webhook-Signature"
+)
+
+// NewWebhookSecret generates a random secret for signing the deliveries to a webhook.
+func NewWebhookSecret() (string, error) {
+	const secretSize = 32
+	secret := make([]byte, secretSize)
+	if _, err := io.ReadFull(rand.Reader, secret); err != nil {
+		return "", errors.Wrap(err, "couldn't generate webhook secret")
+	}
+	return base64.RawURLEncoding.EncodeToString(secret), nil
+}
+
+// SignWebhookPayload computes the value of the signature header for a delivery of the payload.
+func SignWebhookPayload(secret string, timestamp int64, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	const intBase = 10
+	_, _ = mac.Write([]byte(strconv.FormatInt(timestamp, intBase) + "."))
+	_, _ = mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ValidateWebhookURL checks that the URL can be used as the endpoint of a webhook.
+func ValidateWebhookURL(raw string) error {
+	parsed, err := url.Parse(raw)
+	if err != nil {
+		return errors.Wrap(err, "couldn't parse url")
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return errors.Errorf("url %s doesn't use http or https", raw)
+	}
+	if parsed.Host == "" {
+		return errors.Errorf("url %s has no host", raw)
+	}
+	return nil
+}
+
+// WebhookPayload is the body of each webhook delivery.
+type WebhookPayload struct {
+	Type         WebhookEventType `json:"type"`
+	InstrumentID InstrumentID     `json:"instrumentId"`
+	Time         time.Time        `json:"time"`
+	Data         interface{}      `json:"data"`
+}
+
+// Webhook Dialing
+
+// nonPublicNetworks are the special-purpose networks which aren't already excluded by
+// [isPublicIP]'s checks of the standard library's IP address classifications.
+var nonPublicNetworks = mustParseCIDRs(
+	"0.0.0.0/8",     // "this" network
+	"100.64.0.0/10", // shared address space for carrier-grade NAT
+	"192.0.0.0/24",  // IETF protocol assignments
+	"198.18.0.0/15", // benchmarking
+	"64:ff9b::/96",  // NAT64, which can reach any IPv4 address
+)
+
+func mustParseCIDRs(rawNetworks ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, len(rawNetworks))
+	for i, rawNetwork := range rawNetworks {
+		_, network, err := net.ParseCIDR(rawNetwork)
+		if err != nil {
+			panic(err)
+		}
+		networks[i] = network
+	}
+	return networks
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPublicIP checks whether the IP address is a globally-routable unicast address, which excludes
+// loopback, private, link-local, multicast, and unspecified addresses.
+func isPublicIP(ip net.IP) bool {
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !containsIP(nonPublicNetworks, ip)
+}
+
+// newWebhookDialer makes a dialer which refuses to connect to non-public IP addresses outside the
+// allowed networks. The check is made on each address as it's dialed, so it also covers hostnames
+// which resolve to different addresses at different times.
+func newWebhookDialer(allowedNetworks []*net.IPNet) *net.Dialer {
+	return &net.Dialer{
+		Timeout: webhookAttemptTimeout,
+		Control: func(_, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return errors.Wrapf(err, "couldn't parse dialed address %s", address)
+			}
+			ip := net.ParseIP(host)
+			if ip == nil {
+				return errors.Errorf("dialed address %s isn't an ip address", address)
+			}
+			if !isPublicIP(ip) && !containsIP(allowedNetworks, ip) {
+				return errors.Errorf("webhook endpoint address %s isn't a public ip address", ip)
+			}
+			return nil
+		},
+	}
+}
+
+// Webhook Dispatcher
+
+const (
+	webhookPollInterval     = 10 * time.Second
+	webhookPruneInterval    = time.Hour
+	webhookBatchSize        = 20
+	webhookAttemptTimeout   = 10 * time.Second
+	webhookMaxAttempts      = 8
+	webhookInitialBackoff   = 30 * time.Second
+	webhookMaxBackoff       = time.Hour
+	webhookMaxErrorLength   = 500
+	webhookDefaultUserAgent = "pslive-webhooks"
+)
+
+// WebhookDispatcher records the events of instruments as pending deliveries to their webhooks in
+// the instruments store, which acts as a persistent outbox, and it sends pending deliveries in the
+// background, retrying failed attempts with exponential backoff.
+type WebhookDispatcher struct {
+	is     *Store
+	config Config
+	client *http.Client
+	// wake is signaled when new deliveries are added, so that they're sent without waiting for the
+	// next poll of the outbox.
+	wake chan struct{}
+
+	logger godest.Logger
+}
+
+func NewWebhookDispatcher(is *Store, config Config, logger godest.Logger) *WebhookDispatcher {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// A proxy would be dialed instead of the webhook endpoint, which would bypass the dialer's check
+	transport.Proxy = nil
+	transport.DialContext = newWebhookDialer(config.WebhookAllowedNetworks).DialContext
+	return &WebhookDispatcher{
+		is:     is,
+		config: config,
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   webhookAttemptTimeout,
+			// Webhook endpoints are expected to respond directly, so redirects are treated as failures
+			CheckRedirect: func(_ *http.Request, _ []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		wake:   make(chan struct{}, 1),
+		logger: logger,
+	}
+}
+
+func (d *WebhookDispatcher) signal() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Enqueue adds a pending delivery of the event to each of the instrument's enabled webhooks which
+// subscribe to the event's type.
+func (d *WebhookDispatcher) Enqueue(
+	ctx context.Context, iid InstrumentID, eventType WebhookEventType, data interface{},
+) error {
+	now := time.Now()
+	payload, err := json.Marshal(WebhookPayload{
+		Type:         eventType,
+		InstrumentID: iid,
+		Time:         now.UTC(),
+		Data:         data,
+	})
+	if err != nil {
+		return errors.Wrapf(err, "couldn't encode %s webhook payload for instrument %d", eventType, iid)
+	}
+	if err = d.is.AddWebhookDeliveries(ctx, iid, eventType, string(payload), now); err != nil {
+		return err
+	}
+	d.signal()
+	return nil
+}
+
+// Redeliver adds a new pending delivery to the webhook with the same payload as an existing
+// delivery.
+func (d *WebhookDispatcher) Redeliver(
+	ctx context.Context, webhookID WebhookID, deliveryID WebhookDeliveryID,
+) (redeliveryID WebhookDeliveryID, err error) {
+	delivery, err := d.is.GetWebhookDelivery(ctx, webhookID, deliveryID)
+	if err != nil {
+		return 0, err
+	}
+	delivery.CreateTime = time.Now()
+	if redeliveryID, err = d.is.AddWebhookDelivery(ctx, delivery); err != nil {
+		return 0, errors.Wrapf(err, "couldn't redeliver delivery %d", deliveryID)
+	}
+	d.signal()
+	return redeliveryID, nil
+}
+
+// Dispatch sends pending deliveries until the context is canceled. It also periodically deletes
+// finished deliveries which are older than the configured retention period.
+func (d *WebhookDispatcher) Dispatch(ctx context.Context) error {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	var lastPrune time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-d.wake:
+		case <-timer.C:
+		}
+		if err := ctx.Err(); err != nil {
+			// Context was also canceled and it should have priority
+			return err
+		}
+
+		if time.Since(lastPrune) >= webhookPruneInterval {
+			if err := d.is.deleteFinishedWebhookDeliveries(
+				ctx, time.Now().Add(-d.config.WebhookDeliveryRetention),
+			); err != nil {
+				d.logger.Error(err)
+			}
+			lastPrune = time.Now()
+		}
+		sent, err := d.sendDue(ctx)
+		if err != nil {
+			d.logger.Error(errors.Wrap(err, "couldn't send webhook deliveries"))
+		}
+		timer.Stop()
+		select {
+		case <-timer.C:
+		default:
+		}
+		if sent == webhookBatchSize {
+			// There may be more deliveries which are already due
+			timer.Reset(0)
+			continue
+		}
+		timer.Reset(webhookPollInterval)
+	}
+}
+
+func (d *WebhookDispatcher) sendDue(ctx context.Context) (sent int, err error) {
+	due, err := d.is.getDueWebhookDeliveries(ctx, time.Now(), webhookBatchSize)
+	if err != nil {
+		return 0, err
+	}
+	wg := sync.WaitGroup{}
+	for _, delivery := range due {
+		wg.Add(1)
+		go func(delivery dueWebhookDelivery) {
+			defer wg.Done()
+			attempted := d.attempt(ctx, delivery)
+			// The outcome of the attempt should be recorded even if the context was just canceled
+			if err := d.is.updateWebhookDeliveryAttempt(context.Background(), attempted); err != nil {
+				d.logger.Error(err)
+			}
+		}(delivery)
+	}
+	wg.Wait()
+	return len(due), nil
+}
+
+func truncateWebhookError(err error) string {
+	message := err.Error()
+	if len(message) > webhookMaxErrorLength {
+		message = message[:webhookMaxErrorLength]
+	}
+	return message
+}
+
+// attempt sends the delivery and returns it with the outcome of the attempt.
+func (d *WebhookDispatcher) attempt(
+	ctx context.Context, delivery dueWebhookDelivery,
+) WebhookDelivery {
+	result := delivery.WebhookDelivery
+	secret, err := decryptSecret(d.is.secretsKey, delivery.EncryptedSecret)
+	if err != nil {
+		// A secret which can't be decrypted is a problem with the server's configuration rather than
+		// with the endpoint, so the delivery is postponed without using up any of its attempts
+		err = errors.Wrapf(err, "couldn't decrypt secret of webhook %d", delivery.WebhookID)
+		d.logger.Error(err)
+		result.LastError = truncateWebhookError(err)
+		result.NextAttemptTime = time.Now().Add(webhookInitialBackoff)
+		return result
+	}
+
+	result.Attempts++
+	result.LastAttemptTime = time.Now()
+	result.LastStatusCode = 0
+	result.LastError = ""
+
+	statusCode, err := d.send(ctx, delivery, secret, result.LastAttemptTime)
+	result.LastStatusCode = statusCode
+	switch {
+	case err == nil:
+		result.Status = WebhookDeliverySucceeded
+		return result
+	case result.Attempts >= webhookMaxAttempts:
+		result.Status = WebhookDeliveryFailed
+	default:
+		result.Status = WebhookDeliveryPending
+		backoff := webhookInitialBackoff << (result.Attempts - 1)
+		if backoff > webhookMaxBackoff || backoff <= 0 {
+			backoff = webhookMaxBackoff
+		}
+		result.NextAttemptTime = result.LastAttemptTime.Add(backoff)
+	}
+	result.LastError = truncateWebhookError(err)
+	return result
+}
+
+func (d *WebhookDispatcher) send(
+	ctx context.Context, delivery dueWebhookDelivery, secret string, now time.Time,
+) (statusCode int, err error) {
+	req, err := http.NewRequestWithContext(
+		ctx, http.MethodPost, delivery.URL, bytes.NewReader([]byte(delivery.Payload)),
+	)
+	if err != nil {
+		return 0, errors.Wrap(err, "couldn't make request")
+	}
+	const intBase = 10
+	timestamp := now.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", webhookDefaultUserAgent)
+	req.Header.Set(WebhookEventHeader, string(delivery.EventType))
+	req.Header.Set(WebhookDeliveryHeader, strconv.FormatInt(int64(delivery.ID), intBase))
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, intBase))
+	req.Header.Set(
+		WebhookSignatureHeader, SignWebhookPayload(secret, timestamp, []byte(delivery.Payload)),
+	)
+
+	res, err := d.client.Do(req)
+	if err != nil {
+		return 0, errors.Wrap(err, "couldn't send request")
+	}
+	defer res.Body.Close()
+	// Drain a bit of the body so that the connection can be reused
+	const maxDrained = 4096
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, maxDrained))
+	if res.StatusCode < http.StatusOK || res.StatusCode >= http.StatusMultipleChoices {
+		return res.StatusCode, errors.Errorf("endpoint responded with %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
@@ -35,3 +35,12 @@ func (c *Client) GetPath(ctx context.Context, endpoint, route string) (string, e
 	basePath, err := c.Config.KratosAPI.ServerURLWithContext(ctx, endpoint)
 	return basePath + route, errors.Wrap(err, "couldn't look up base path for Ory API")
 }
+
+// CheckAlive checks whether the Ory Kratos API server is responding to requests.
+func (c *Client) CheckAlive(ctx context.Context) error {
+	_, res, err := c.Ory.MetadataApi.IsAlive(ctx).Execute()
+	if err != nil {
+		return errors.Wrap(err, "couldn't check whether Ory API is alive")
+	}
+	return errors.Wrap(res.Body.Close(), "couldn't close Ory API response body")
+}
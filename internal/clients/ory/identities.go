@@ -180,3 +180,24 @@ func (c *Client) GetIdentities(ctx context.Context) ([]Identity, error) {
 	}
 	return identities, err
 }
+
+// FindIdentity returns the identity with the identifier, if there is one.
+func (c *Client) FindIdentity(
+	ctx context.Context, identifier IdentityIdentifier,
+) (identity Identity, found bool, err error) {
+	if c.Config.NoAuth {
+		// Without Ory Kratos, identities are identified by their IDs (see GetIdentifier)
+		return Identity{ID: IdentityID(identifier), Identifier: identifier}, true, nil
+	}
+
+	identities, err := c.GetIdentities(ctx)
+	if err != nil {
+		return Identity{}, false, errors.Wrapf(err, "couldn't look up identity of %s", identifier)
+	}
+	for _, identity := range identities {
+		if identity.Identifier == identifier {
+			return identity, true, nil
+		}
+	}
+	return Identity{}, false, nil
+}
@@ -4,11 +4,43 @@ import (
 	"context"
 	"time"
 
+	"github.com/eclipse/paho.mqtt.golang"
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/gohcl"
 	"github.com/pkg/errors"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
 )
 
+// awaitSent waits until the command has been sent, or until the context is canceled.
+func awaitSent(ctx context.Context, token mqtt.Token, command string) error {
+	select {
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "couldn't wait for command to %s to be sent", command)
+	case <-token.Done():
+		return errors.Wrapf(token.Error(), "couldn't send command to %s", command)
+	}
+}
+
+// newStateUpdateAwaiter returns a function which waits until the planktoscope has broadcast a
+// state update, or until the context is canceled.
+func newStateUpdateAwaiter(
+	ctx context.Context, stateUpdated <-chan struct{}, command string,
+) func() error {
+	return func() error {
+		// TODO: instead of waiting until the context is canceled, have an action-configured optional
+		// timeout before returning an error that we haven't heard any updates from the planktoscope.
+		select {
+		case <-ctx.Done():
+			return errors.Wrapf(
+				ctx.Err(), "couldn't wait for state update after command to %s", command,
+			)
+		case <-stateUpdated:
+			return nil
+		}
+	}
+}
+
 // Pump Actions
 
 type PlanktoscopePumpParams struct {
@@ -17,109 +49,220 @@ type PlanktoscopePumpParams struct {
 	Flowrate float64 `hcl:"flowrate"`
 }
 
-func (c *Client) RunPumpAction(ctx context.Context, p PlanktoscopePumpParams) error {
+func (c *Client) StartPumpAction(
+	ctx context.Context, p PlanktoscopePumpParams,
+) (await func() error, err error) {
+	if err = ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, "couldn't start the pump")
+	}
 	token, err := c.StartPump(p.Forward, p.Volume, p.Flowrate)
 	if err != nil {
-		return errors.Wrap(err, "couldn't send command to start the pump")
+		return nil, errors.Wrap(err, "couldn't send command to start the pump")
 	}
 	stateUpdated := c.PumpStateBroadcasted()
-	// TODO: instead of always waiting forever, have an action-configured optional timeout before
-	// returning an error that we haven't heard any pump updates from the planktoscope.
-	if token.Wait(); token.Error() != nil {
-		return token.Error()
+	if err = awaitSent(ctx, token, "start the pump"); err != nil {
+		return nil, err
 	}
-	<-stateUpdated
-	return nil
+	return newStateUpdateAwaiter(ctx, stateUpdated, "start the pump"), nil
 }
 
-func (c *Client) RunStopPumpAction(ctx context.Context) error {
+func (c *Client) StartStopPumpAction(ctx context.Context) (await func() error, err error) {
 	token, err := c.StopPump()
 	if err != nil {
-		return errors.Wrap(err, "couldn't send command to stop the pump")
+		return nil, errors.Wrap(err, "couldn't send command to stop the pump")
 	}
 	stateUpdated := c.PumpStateBroadcasted()
-	// TODO: instead of always waiting forever, have an action-configured optional timeout before
-	// returning an error that we haven't heard any pump updates from the planktoscope.
-	if token.Wait(); token.Error() != nil {
-		return token.Error()
+	if err = awaitSent(ctx, token, "stop the pump"); err != nil {
+		return nil, err
 	}
-	<-stateUpdated
-	return nil
+	return newStateUpdateAwaiter(ctx, stateUpdated, "stop the pump"), nil
 }
 
 // Imager Actions
 
 type PlanktoscopeImagingParams struct {
-	SampleProjectID string  `hcl:"sample_project_id"` // TODO: move the project ID somewhere else?
-	SampleID        string  `hcl:"sample_id"`         // TODO: move the sample ID somewhere else?
+	// If provided, SampleProjectID and SampleID override the IDs of the instrument's current sample
+	SampleProjectID string  `hcl:"sample_project_id,optional"`
+	SampleID        string  `hcl:"sample_id,optional"`
 	Forward         bool    `hcl:"forward"`
 	StepVolume      float64 `hcl:"step_volume"`
 	StepDelay       float64 `hcl:"step_delay"`
 	Steps           uint64  `hcl:"steps"`
 }
 
-func (c *Client) RunImagingAction(ctx context.Context, p PlanktoscopeImagingParams) error {
-	token, err := c.SetMetadata(p.SampleProjectID, p.SampleID, time.Now())
-	if err != nil {
-		return err
+func (c *Client) StartImagingAction(
+	ctx context.Context, p PlanktoscopeImagingParams,
+) (await func() error, err error) {
+	// Limits must be checked before the acquisition is recorded with the sample metadata
+	if err = c.GetLimits().CheckImaging(p.StepVolume, p.StepDelay, p.Steps); err != nil {
+		return nil, err
+	}
+	if err = ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, "couldn't start imaging")
 	}
-	if token.Wait(); token.Error() != nil {
-		return token.Error()
+	if err = c.SetSampleMetadata(
+		ctx, AcquisitionSourceAutomation, p.SampleProjectID, p.SampleID,
+	); err != nil {
+		return nil, errors.Wrap(err, "couldn't set sample metadata")
 	}
-	token, err = c.StartImaging(p.Forward, p.StepVolume, p.StepDelay, p.Steps)
+	// The action may have been canceled while the sample metadata was being sent
+	if err = ctx.Err(); err != nil {
+		return nil, errors.Wrap(err, "couldn't start imaging")
+	}
+	token, err := c.StartImaging(p.Forward, p.StepVolume, p.StepDelay, p.Steps)
 	if err != nil {
-		return errors.Wrap(err, "couldn't send command to start imaging")
+		return nil, errors.Wrap(err, "couldn't send command to start imaging")
 	}
 	stateUpdated := c.ImagerStateBroadcasted()
-	// TODO: instead of always waiting forever, have an action-configured optional timeout before
-	// returning an error that we haven't heard any imager updates from the planktoscope.
-	if token.Wait(); token.Error() != nil {
-		return token.Error()
+	if err = awaitSent(ctx, token, "start imaging"); err != nil {
+		return nil, err
 	}
-	<-stateUpdated
-	return nil
+	return newStateUpdateAwaiter(ctx, stateUpdated, "start imaging"), nil
 }
 
-func (c *Client) RunStopImagingAction(ctx context.Context) error {
+func (c *Client) StartStopImagingAction(ctx context.Context) (await func() error, err error) {
 	token, err := c.StopImaging()
 	if err != nil {
-		return errors.Wrap(err, "couldn't send command to stop imaging")
+		return nil, errors.Wrap(err, "couldn't send command to stop imaging")
 	}
 	stateUpdated := c.ImagerStateBroadcasted()
-	// TODO: instead of always waiting forever, have an action-configured optional timeout before
-	// returning an error that we haven't heard any imager updates from the planktoscope.
-	if token.Wait(); token.Error() != nil {
-		return token.Error()
+	if err = awaitSent(ctx, token, "stop imaging"); err != nil {
+		return nil, err
+	}
+	return newStateUpdateAwaiter(ctx, stateUpdated, "stop imaging"), nil
+}
+
+// Wait Actions
+
+type PlanktoscopeWaitParams struct {
+	// Condition is a boolean expression over the pump and imager states, e.g.
+	// `imager.images_captured >= 50 || !imager.imaging`
+	Condition hcl.Expression `hcl:"condition"`
+	// Timeout is an optional string which parses with time.ParseDuration()
+	Timeout string `hcl:"timeout,optional"`
+}
+
+func newConditionContext(state Planktoscope) *hcl.EvalContext {
+	pump := state.Pump
+	imager := state.Imager
+	return &hcl.EvalContext{
+		Variables: map[string]cty.Value{
+			"pump": cty.ObjectVal(map[string]cty.Value{
+				"state_known": cty.BoolVal(pump.StateKnown),
+				"pumping":     cty.BoolVal(pump.Pumping),
+			}),
+			"imager": cty.ObjectVal(map[string]cty.Value{
+				"state_known":       cty.BoolVal(imager.StateKnown),
+				"imaging":           cty.BoolVal(imager.Imaging),
+				"last_status":       cty.StringVal(imager.LastStatus),
+				"images_captured":   cty.NumberUIntVal(imager.ImagesCaptured),
+				"steps":             cty.NumberUIntVal(imager.Steps),
+				"elapsed_seconds":   cty.NumberFloatVal(imager.ElapsedTime().Seconds()),
+				"remaining_seconds": cty.NumberFloatVal(imager.RemainingTime().Seconds()),
+			}),
+		},
+	}
+}
+
+func evaluateCondition(condition hcl.Expression, state Planktoscope) (bool, error) {
+	value, diags := condition.Value(newConditionContext(state))
+	if diags.HasErrors() {
+		return false, diags
+	}
+	value, err := convert.Convert(value, cty.Bool)
+	if err != nil {
+		return false, errors.Wrap(err, "condition isn't a boolean")
+	}
+	if value.IsNull() || !value.IsKnown() {
+		return false, errors.New("condition has no value")
+	}
+	return value.True(), nil
+}
+
+func (c *Client) RunWaitUntilAction(ctx context.Context, p PlanktoscopeWaitParams) error {
+	if p.Timeout != "" {
+		timeout, err := time.ParseDuration(p.Timeout)
+		if err != nil {
+			return errors.Wrapf(err, "couldn't parse timeout %s", p.Timeout)
+		}
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	// Conditions on elapsed or remaining time can change without any state updates
+	const reevaluateInterval = 1 * time.Second
+	ticker := time.NewTicker(reevaluateInterval)
+	defer ticker.Stop()
+	for {
+		pumpUpdated := c.PumpStateBroadcasted()
+		imagerUpdated := c.ImagerStateBroadcasted()
+		met, err := evaluateCondition(p.Condition, c.GetState())
+		if err != nil {
+			return errors.Wrap(err, "couldn't evaluate condition")
+		}
+		if met {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(ctx.Err(), "condition wasn't met")
+		case <-pumpUpdated:
+		case <-imagerUpdated:
+		case <-ticker.C:
+		}
 	}
-	<-stateUpdated
-	return nil
 }
 
 // Controller Action
 
-func (c *Client) RunControllerAction(ctx context.Context, command string, params hcl.Body) error {
+// StartControllerAction sends the commands of the action, and it returns a function which waits for
+// the planktoscope to respond to them.
+func (c *Client) StartControllerAction(
+	ctx context.Context, command string, params hcl.Body,
+) (await func() error, err error) {
 	switch command {
 	default:
-		return errors.Errorf("unrecognized planktoscope controller command %s", command)
+		return nil, errors.Errorf("unrecognized planktoscope controller command %s", command)
 	case "pump":
 		var p PlanktoscopePumpParams
 		if err := gohcl.DecodeBody(params, nil, &p); err != nil {
-			return errors.Wrapf(
+			return nil, errors.Wrapf(
 				err, "couldn't decode params of planktoscope controller command %s", command,
 			)
 		}
-		return c.RunPumpAction(ctx, p)
+		return c.StartPumpAction(ctx, p)
 	case "stop-pump":
-		return c.RunStopPumpAction(ctx)
+		return c.StartStopPumpAction(ctx)
 	case "image":
 		var p PlanktoscopeImagingParams
 		if err := gohcl.DecodeBody(params, nil, &p); err != nil {
-			return errors.Wrapf(
+			return nil, errors.Wrapf(
 				err, "couldn't decode params of planktoscope controller command %s", command,
 			)
 		}
-		return c.RunImagingAction(ctx, p)
+		return c.StartImagingAction(ctx, p)
 	case "stop-imaging":
-		return c.RunStopImagingAction(ctx)
+		return c.StartStopImagingAction(ctx)
+	case "wait-until":
+		var p PlanktoscopeWaitParams
+		if err := gohcl.DecodeBody(params, nil, &p); err != nil {
+			return nil, errors.Wrapf(
+				err, "couldn't decode params of planktoscope controller command %s", command,
+			)
+		}
+		// Nothing is sent to the planktoscope, so all of the action is waiting
+		return func() error {
+			return c.RunWaitUntilAction(ctx, p)
+		}, nil
+	}
+}
+
+func (c *Client) RunControllerAction(ctx context.Context, command string, params hcl.Body) error {
+	await, err := c.StartControllerAction(ctx, command, params)
+	if err != nil {
+		return err
 	}
+	return await()
 }
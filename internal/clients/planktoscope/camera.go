@@ -67,6 +67,7 @@ func (c *Client) handleCameraSettingsUpdate(_ string, rawPayload []byte) error {
 
 	// Commit changes
 	c.updateCameraSettings(newSettings)
+	c.saveSettings()
 	c.Logger.Debugf("%s: %+v", c.Config.URL, newSettings)
 	return nil
 }
@@ -120,6 +121,11 @@ func (c *Client) SetCamera(
 	c.stateL.Lock()
 	defer c.stateL.Unlock()
 
+	if err := c.limits.CheckCamera(
+		iso, shutterSpeed, whiteBalanceRedGain, whiteBalanceBlueGain,
+	); err != nil {
+		return nil, err
+	}
 	c.cameraSettings.StateKnown = true
 	c.cameraSettings.ISO = iso
 	c.cameraSettings.ShutterSpeed = shutterSpeed
@@ -127,6 +133,6 @@ func (c *Client) SetCamera(
 	c.cameraSettings.WhiteBalanceRedGain = whiteBalanceRedGain
 	c.cameraSettings.WhiteBalanceBlueGain = whiteBalanceBlueGain
 
-	token := c.MQTT.Publish("imager/image", mqttExactlyOnce, false, marshaled)
+	token := c.publish("imager/image", mqttExactlyOnce, false, marshaled)
 	return token, nil
 }
@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"sync"
+	"time"
 
 	"github.com/eclipse/paho.mqtt.golang"
 	"github.com/pkg/errors"
@@ -18,7 +19,10 @@ const (
 )
 
 type Client struct {
+	ID                   ClientID
 	Config               Config
+	Samples              SampleStore
+	Settings             SettingsStore
 	Logger               godest.Logger
 	MQTT                 mqtt.Client
 	firstConnSuccess     chan struct{}
@@ -35,11 +39,24 @@ type Client struct {
 	imager         Imager
 	imagerB        *Broadcaster
 	imagerSettings ImagerSettings
+	limits         Limits
+
+	settingsSaveMu *sync.Mutex
+
+	traffic  *TrafficLog
+	trafficB *Broadcaster
+
+	connectionB *Broadcaster
 }
 
-func NewClient(c Config, l godest.Logger) (client *Client, err error) {
+func NewClient(
+	id ClientID, c Config, ss SampleStore, sts SettingsStore, l godest.Logger,
+) (client *Client, err error) {
 	client = &Client{}
+	client.ID = id
 	client.Config = c
+	client.Samples = ss
+	client.Settings = sts
 	client.Logger = l
 	client.firstConnSuccess = make(chan struct{})
 	client.firstConnSuccessOnce = &sync.Once{}
@@ -52,6 +69,10 @@ func NewClient(c Config, l godest.Logger) (client *Client, err error) {
 	client.cameraSettings = DefaultCameraSettings()
 	client.imagerB = NewBroadcaster()
 	client.imagerSettings = DefaultImagerSettings()
+	client.settingsSaveMu = &sync.Mutex{}
+	client.traffic = NewTrafficLog(c.TrafficLogSize)
+	client.trafficB = NewBroadcaster()
+	client.connectionB = NewBroadcaster()
 
 	c.MQTT.SetOnConnectHandler(client.handleConnected)
 	c.MQTT.SetConnectionLostHandler(client.handleConnectionLost)
@@ -91,6 +112,13 @@ func (c *Client) handleConnected(cm mqtt.Client) {
 	c.logReconnectOnceMu.Lock()
 	c.logReconnectOnce = &sync.Once{}
 	c.logReconnectOnceMu.Unlock()
+	c.connectionB.BroadcastNext()
+
+	go func() {
+		if err := c.restoreCamera(); err != nil {
+			c.Logger.Error(errors.Wrapf(err, "couldn't restore camera of planktoscope client %d", c.ID))
+		}
+	}()
 }
 
 func (c *Client) handleConnectionLost(_ mqtt.Client, err error) {
@@ -101,6 +129,7 @@ func (c *Client) handleConnectionLost(_ mqtt.Client, err error) {
 	c.cameraSettings.StateKnown = false
 	c.imager.StateKnown = false
 	c.Logger.Warn(errors.Wrap(err, "connection lost"))
+	c.connectionB.BroadcastNext()
 	// TODO: notify clients that control has been lost
 }
 
@@ -116,6 +145,14 @@ func (c *Client) handleReconnecting(_ mqtt.Client, _ *mqtt.ClientOptions) {
 func (c *Client) handleMessage(topic mqtt.Client, m mqtt.Message) {
 	broker := c.Config.URL
 	rawPayload := string(m.Payload())
+	c.recordTraffic(TrafficMessage{
+		Direction: TrafficInbound,
+		Topic:     m.Topic(),
+		Payload:   rawPayload,
+		QoS:       m.Qos(),
+		Retained:  m.Retained(),
+		Time:      time.Now(),
+	})
 
 	switch topic := m.Topic(); topic {
 	default:
@@ -169,6 +206,12 @@ func (c *Client) HasConnection() bool {
 	return c.MQTT.IsConnectionOpen()
 }
 
+// ConnectionBroadcasted returns a channel which is closed when the client next connects to or
+// loses its connection with the MQTT broker.
+func (c *Client) ConnectionBroadcasted() <-chan struct{} {
+	return c.connectionB.Broadcasted()
+}
+
 func (c *Client) Shutdown(ctx context.Context) error {
 	if !c.MQTT.IsConnected() {
 		return nil
@@ -1,7 +1,10 @@
 package planktoscope
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"net/url"
 	"time"
 
 	"github.com/atrox/haikunatorgo"
@@ -15,11 +18,26 @@ const envPrefix = "PLANKTOSCOPE_"
 type Config struct {
 	URL      string
 	ClientID string
+	Auth     MQTTAuth
 	MQTT     mqtt.ClientOptions
+
+	// TrafficLogSize is the number of recent MQTT messages kept for debugging.
+	TrafficLogSize int
+}
+
+// MQTTAuth holds the credentials and PEM-encoded TLS certificates used to connect to the MQTT
+// broker.
+type MQTTAuth struct {
+	Username   string
+	Password   string
+	CACert     string
+	ClientCert string
+	ClientKey  string
 }
 
-func GetConfig(brokerURL, clientInstanceID string) (c Config, err error) {
+func GetConfig(brokerURL, clientInstanceID string, auth MQTTAuth) (c Config, err error) {
 	c.URL = brokerURL
+	c.Auth = auth
 
 	client := env.GetString(envPrefix+"MQTT_CLIENT", "")
 	if client == "" {
@@ -30,12 +48,22 @@ func GetConfig(brokerURL, clientInstanceID string) (c Config, err error) {
 	}
 	c.ClientID = fmt.Sprintf("pslive/%s/ps/%s", client, clientInstanceID)
 
-	options, err := GetMQTTConfig(brokerURL, c.ClientID)
+	options, err := GetMQTTConfig(brokerURL, c.ClientID, auth)
 	if err != nil {
 		return Config{}, errors.Wrap(err, "couldn't make MQTT config")
 	}
 	c.MQTT = *options
 
+	const defaultTrafficLogSize = 200
+	trafficLogSize, err := env.GetInt64(envPrefix+"TRAFFIC_LOG_SIZE", defaultTrafficLogSize)
+	if err != nil {
+		return Config{}, errors.Wrap(err, "couldn't make traffic log size config")
+	}
+	if trafficLogSize < 0 {
+		return Config{}, errors.Errorf("invalid negative traffic log size %d", trafficLogSize)
+	}
+	c.TrafficLogSize = int(trafficLogSize)
+
 	return c, nil
 }
 
@@ -70,7 +98,40 @@ func getMQTTReconnectInterval() (time.Duration, error) {
 	return time.Duration(intervalRaw) * time.Second, nil
 }
 
-func GetMQTTConfig(brokerURL, clientID string) (c *mqtt.ClientOptions, err error) {
+func usesTLS(brokerURL string) (bool, error) {
+	parsed, err := url.Parse(brokerURL)
+	if err != nil {
+		return false, errors.Wrapf(err, "couldn't parse broker url %s", brokerURL)
+	}
+	switch parsed.Scheme {
+	default:
+		return false, nil
+	case "mqtts", "ssl", "tls", "tcps", "mqtt+ssl", "wss":
+		return true, nil
+	}
+}
+
+func getTLSConfig(auth MQTTAuth) (*tls.Config, error) {
+	c := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+	}
+	if auth.CACert != "" {
+		c.RootCAs = x509.NewCertPool()
+		if !c.RootCAs.AppendCertsFromPEM([]byte(auth.CACert)) {
+			return nil, errors.New("couldn't parse any CA certificates")
+		}
+	}
+	if auth.ClientCert != "" || auth.ClientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(auth.ClientCert), []byte(auth.ClientKey))
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't parse client certificate and key")
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	return c, nil
+}
+
+func GetMQTTConfig(brokerURL, clientID string, auth MQTTAuth) (c *mqtt.ClientOptions, err error) {
 	c = mqtt.NewClientOptions()
 	if len(brokerURL) == 0 {
 		// If no broker is provided, return a zero-valued config
@@ -81,6 +142,22 @@ func GetMQTTConfig(brokerURL, clientID string) (c *mqtt.ClientOptions, err error
 	c.SetCleanSession(true)
 	c.SetClientID(clientID)
 
+	if auth.Username != "" {
+		c.SetUsername(auth.Username)
+		c.SetPassword(auth.Password)
+	}
+	secure, err := usesTLS(brokerURL)
+	if err != nil {
+		return nil, err
+	}
+	if secure || auth.CACert != "" || auth.ClientCert != "" {
+		tlsConfig, err := getTLSConfig(auth)
+		if err != nil {
+			return nil, errors.Wrap(err, "couldn't make TLS config")
+		}
+		c.SetTLSConfig(tlsConfig)
+	}
+
 	c.SetConnectRetry(true)
 	connectTimeout, err := getMQTTConnectTimeout()
 	if err != nil {
@@ -2,6 +2,8 @@ package planktoscope
 
 import (
 	"encoding/json"
+	"regexp"
+	"strconv"
 	"time"
 
 	"github.com/eclipse/paho.mqtt.golang"
@@ -22,6 +24,26 @@ func (c *Client) updateImagerState(newState Imager) {
 	c.imagerB.BroadcastNext()
 }
 
+// imageProgressPattern matches status messages like "Image 3/100 has been imaged to <path>."
+var imageProgressPattern = regexp.MustCompile(`^Image (\d+)/(\d+) `)
+
+func parseImageProgress(status string) (captured, steps uint64, ok bool) {
+	matches := imageProgressPattern.FindStringSubmatch(status)
+	if matches == nil {
+		return 0, 0, false
+	}
+	const base = 10
+	const bitSize = 64
+	captured, err := strconv.ParseUint(matches[1], base, bitSize)
+	if err != nil {
+		return 0, 0, false
+	}
+	if steps, err = strconv.ParseUint(matches[2], base, bitSize); err != nil {
+		return 0, 0, false
+	}
+	return captured, steps, true
+}
+
 func (c *Client) handleImagerStatusUpdate(_ string, rawPayload []byte) error {
 	type ImagerStatus struct {
 		Status   string  `json:"status"`
@@ -31,23 +53,40 @@ func (c *Client) handleImagerStatusUpdate(_ string, rawPayload []byte) error {
 	if err := json.Unmarshal(rawPayload, &payload); err != nil {
 		return errors.Wrapf(err, "unparseable payload")
 	}
-	newState := Imager{
-		StateKnown: true,
-	}
+
+	c.stateL.RLock()
+	newState := c.imager
+	settings := c.imagerSettings
+	c.stateL.RUnlock()
+	newState.StateKnown = true
+	newState.LastStatus = payload.Status
 	switch status := payload.Status; status {
 	default:
-		// TODO: write the status to the imager state for display in the GUI
-		c.Logger.Infof("unknown status %s", status)
-		return nil
-	case "Camera settings updated":
+		captured, steps, ok := parseImageProgress(status)
+		if !ok {
+			c.Logger.Infof("unknown status %s", status)
+			break
+		}
+		newState.ImagesCaptured = captured
+		newState.Steps = steps
+		newState.LastImage = time.Now()
+	case "Camera settings updated", "Config updated":
+		// These acknowledge configuration commands rather than reporting on imaging
 		return nil
 	case "Started":
 		newState.Imaging = true
 		newState.Start = time.Now()
+		newState.End = time.Time{}
+		newState.ImagesCaptured = 0
+		newState.Steps = settings.Steps
+		newState.StepDelay = settings.StepDelay
+		newState.LastImage = time.Time{}
 	case "Interrupted":
 		newState.Imaging = false
+		newState.End = time.Now()
 	case "Done":
 		newState.Imaging = false
+		newState.End = time.Now()
 	}
 
 	// Commit changes
@@ -105,6 +144,7 @@ func (c *Client) handleImagerImagingUpdate(_ string, rawPayload []byte) error {
 
 		// Commit changes
 		c.updateImagerSettings(newSettings)
+		c.saveSettings()
 		c.Logger.Debugf("%s: %+v", c.Config.URL, newSettings)
 	}
 	return nil
@@ -154,7 +194,7 @@ func (c *Client) StopImaging() (mqtt.Token, error) {
 	if err != nil {
 		return nil, err
 	}
-	token := c.MQTT.Publish("imager/image", mqttAtLeastOnce, false, marshaled)
+	token := c.publish("imager/image", mqttAtLeastOnce, false, marshaled)
 	return token, nil
 }
 
@@ -186,11 +226,14 @@ func (c *Client) StartImaging(
 	c.stateL.Lock()
 	defer c.stateL.Unlock()
 
+	if err := c.limits.CheckImaging(stepVolume, stepDelay, steps); err != nil {
+		return nil, err
+	}
 	c.imagerSettings.Forward = forward
 	c.imagerSettings.StepVolume = stepVolume
 	c.imagerSettings.StepDelay = stepDelay
 	c.imagerSettings.Steps = steps
 
-	token := c.MQTT.Publish("imager/image", mqttExactlyOnce, false, marshaled)
+	token := c.publish("imager/image", mqttExactlyOnce, false, marshaled)
 	return token, nil
 }
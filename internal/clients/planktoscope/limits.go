@@ -0,0 +1,187 @@
+package planktoscope
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/pkg/errors"
+)
+
+// Limits bounds the settings of commands which can be sent to the planktoscope, to protect the
+// sample and the hardware from mistyped settings. A zero value for any limit means that the setting
+// is not bounded by that limit. Regardless of the limits, volumes, flowrates, and other settings
+// which can't be negative are always checked to be finite numbers in their valid ranges.
+type Limits struct {
+	// MaxPumpVolume bounds the total volume pumped by any single command, including all steps of an
+	// imaging run.
+	MaxPumpVolume   float64 // mL
+	MinPumpFlowrate float64 // mL/min
+	MaxPumpFlowrate float64 // mL/min
+	MaxImagingSteps uint64
+	MinISO          uint64
+	MaxISO          uint64
+	MinShutterSpeed uint64 // μs
+	MaxShutterSpeed uint64 // μs
+}
+
+// LimitError reports a command setting which violates the planktoscope's safety limits.
+type LimitError struct {
+	Setting string
+	Message string
+}
+
+func (e LimitError) Error() string {
+	return fmt.Sprintf("%s %s", e.Setting, e.Message)
+}
+
+func formatQuantity(value float64, unit string) string {
+	if unit == "" {
+		return fmt.Sprintf("%g", value)
+	}
+	return fmt.Sprintf("%g %s", value, unit)
+}
+
+func checkPositiveFloat(setting string, value float64, unit string) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) || value <= 0 {
+		return LimitError{
+			Setting: setting,
+			Message: fmt.Sprintf("%s must be a positive number", formatQuantity(value, unit)),
+		}
+	}
+	return nil
+}
+
+func checkNonNegativeFloat(setting string, value float64, unit string) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) || value < 0 {
+		return LimitError{
+			Setting: setting,
+			Message: fmt.Sprintf("%s must not be a negative number", formatQuantity(value, unit)),
+		}
+	}
+	return nil
+}
+
+func checkMaxFloat(setting string, value, maxValue float64, unit string) error {
+	if maxValue > 0 && value > maxValue {
+		return LimitError{
+			Setting: setting,
+			Message: fmt.Sprintf("%g %s exceeds the limit of %g %s", value, unit, maxValue, unit),
+		}
+	}
+	return nil
+}
+
+func checkMinFloat(setting string, value, minValue float64, unit string) error {
+	if minValue > 0 && value < minValue {
+		return LimitError{
+			Setting: setting,
+			Message: fmt.Sprintf("%g %s is below the limit of %g %s", value, unit, minValue, unit),
+		}
+	}
+	return nil
+}
+
+func checkUintRange(setting string, value, minValue, maxValue uint64) error {
+	if minValue > 0 && value < minValue {
+		return LimitError{
+			Setting: setting,
+			Message: fmt.Sprintf("%d is below the limit of %d", value, minValue),
+		}
+	}
+	if maxValue > 0 && value > maxValue {
+		return LimitError{
+			Setting: setting,
+			Message: fmt.Sprintf("%d exceeds the limit of %d", value, maxValue),
+		}
+	}
+	return nil
+}
+
+// Validate checks that the limits are consistent with each other.
+func (l Limits) Validate() error {
+	for _, limit := range []float64{l.MaxPumpVolume, l.MinPumpFlowrate, l.MaxPumpFlowrate} {
+		if math.IsNaN(limit) || math.IsInf(limit, 0) {
+			return errors.Errorf("pump limit %g must be a finite number", limit)
+		}
+	}
+	if l.MaxPumpVolume < 0 || l.MinPumpFlowrate < 0 || l.MaxPumpFlowrate < 0 {
+		return errors.New("pump limits must not be negative")
+	}
+	if l.MaxPumpFlowrate > 0 && l.MinPumpFlowrate > l.MaxPumpFlowrate {
+		return errors.Errorf(
+			"minimum pump flowrate %g is above maximum pump flowrate %g",
+			l.MinPumpFlowrate, l.MaxPumpFlowrate,
+		)
+	}
+	if l.MaxISO > 0 && l.MinISO > l.MaxISO {
+		return errors.Errorf("minimum iso %d is above maximum iso %d", l.MinISO, l.MaxISO)
+	}
+	if l.MaxShutterSpeed > 0 && l.MinShutterSpeed > l.MaxShutterSpeed {
+		return errors.Errorf(
+			"minimum shutter speed %d is above maximum shutter speed %d",
+			l.MinShutterSpeed, l.MaxShutterSpeed,
+		)
+	}
+	return nil
+}
+
+func (l Limits) CheckPump(volume, flowrate float64) error {
+	if err := checkPositiveFloat("volume", volume, "mL"); err != nil {
+		return err
+	}
+	if err := checkPositiveFloat("flowrate", flowrate, "mL/min"); err != nil {
+		return err
+	}
+	if err := checkMaxFloat("volume", volume, l.MaxPumpVolume, "mL"); err != nil {
+		return err
+	}
+	if err := checkMinFloat("flowrate", flowrate, l.MinPumpFlowrate, "mL/min"); err != nil {
+		return err
+	}
+	return checkMaxFloat("flowrate", flowrate, l.MaxPumpFlowrate, "mL/min")
+}
+
+func (l Limits) CheckImaging(stepVolume, stepDelay float64, steps uint64) error {
+	if err := checkPositiveFloat("step volume", stepVolume, "mL"); err != nil {
+		return err
+	}
+	if err := checkNonNegativeFloat("step delay", stepDelay, "sec"); err != nil {
+		return err
+	}
+	const minSteps = 1
+	if err := checkUintRange("steps", steps, minSteps, l.MaxImagingSteps); err != nil {
+		return err
+	}
+	return checkMaxFloat("total volume", stepVolume*float64(steps), l.MaxPumpVolume, "mL")
+}
+
+func (l Limits) CheckCamera(
+	iso, shutterSpeed uint64, whiteBalanceRedGain, whiteBalanceBlueGain float64,
+) error {
+	if err := checkNonNegativeFloat("white balance red gain", whiteBalanceRedGain, ""); err != nil {
+		return err
+	}
+	if err := checkNonNegativeFloat("white balance blue gain", whiteBalanceBlueGain, ""); err != nil {
+		return err
+	}
+	if err := checkUintRange("iso", iso, l.MinISO, l.MaxISO); err != nil {
+		return err
+	}
+	return checkUintRange("shutter speed", shutterSpeed, l.MinShutterSpeed, l.MaxShutterSpeed)
+}
+
+// GetLimits returns the safety limits currently enforced on commands sent by the client.
+func (c *Client) GetLimits() Limits {
+	c.stateL.RLock()
+	defer c.stateL.RUnlock()
+
+	return c.limits
+}
+
+// SetLimits changes the safety limits enforced on commands sent by the client.
+func (c *Client) SetLimits(limits Limits) {
+	c.stateL.Lock()
+	defer c.stateL.Unlock()
+
+	c.limits = limits
+}
@@ -1,43 +1,142 @@
 package planktoscope
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"time"
 
 	"github.com/eclipse/paho.mqtt.golang"
+	"github.com/pkg/errors"
 )
 
-// Send Commands
+const (
+	AcquisitionSourceGUI        = "gui"
+	AcquisitionSourceAutomation = "automation"
+	AcquisitionSourceAPI        = "api"
+)
+
+// SampleMetadata describes the sample loaded into the planktoscope.
+type SampleMetadata struct {
+	ProjectID    string
+	SampleID     string
+	Operator     string
+	SamplingGear string
+	Latitude     float64 // decimal degrees
+	Longitude    float64 // decimal degrees
+	DepthMin     float64 // m
+	DepthMax     float64 // m
+	Volume       float64 // L
+	NetMesh      float64 // µm
+}
+
+// Metadata is the acquisition metadata config as sent to the planktoscope in an update_config
+// command.
+type Metadata struct {
+	SampleProjectID      string  `json:"sample_project"`
+	SampleID             string  `json:"sample_id"`
+	SampleOperator       string  `json:"sample_operator"`
+	SampleSamplingGear   string  `json:"sample_sampling_gear"`
+	SampleTotalVolume    float64 `json:"sample_total_volume"`
+	AcquisitionMeshSize  float64 `json:"acq_minimum_mesh"`
+	AcquisitionID        string  `json:"acq_id"`
+	SampleCollectionDate string  `json:"object_date"`
+	SampleCollectionTime string  `json:"object_time"`
+	SampleLatitude       float64 `json:"object_lat"`
+	SampleLongitude      float64 `json:"object_lon"`
+	SampleDepthMin       float64 `json:"object_depth_min"`
+	SampleDepthMax       float64 `json:"object_depth_max"`
+}
 
-func (c *Client) SetMetadata(
-	sampleProjectID, sampleID string, acquisitionTime time.Time,
-) (mqtt.Token, error) {
-	type Metadata struct {
-		SampleProjectID      string `json:"sample_project"`
-		SampleID             string `json:"sample_id"`
-		SampleCollectionDate string `json:"object_date"`
-		SampleCollectionTime string `json:"object_time"`
-		AcquisitionID        string `json:"acq_id"`
+func NewMetadata(s SampleMetadata, acquisitionTime time.Time) Metadata {
+	return Metadata{
+		SampleProjectID:      s.ProjectID,
+		SampleID:             fmt.Sprintf("%s_%s", s.ProjectID, s.SampleID),
+		SampleOperator:       s.Operator,
+		SampleSamplingGear:   s.SamplingGear,
+		SampleTotalVolume:    s.Volume,
+		AcquisitionMeshSize:  s.NetMesh,
+		AcquisitionID:        acquisitionTime.Format(time.RFC3339),
+		SampleCollectionDate: acquisitionTime.Format("2006-01-02"),
+		SampleCollectionTime: acquisitionTime.Format("15:04:05"),
+		SampleLatitude:       s.Latitude,
+		SampleLongitude:      s.Longitude,
+		SampleDepthMin:       s.DepthMin,
+		SampleDepthMax:       s.DepthMax,
 	}
+}
+
+// SampleStore provides the sample metadata for a client's acquisitions, and records the metadata
+// which was actually sent for each acquisition.
+type SampleStore interface {
+	GetSample(ctx context.Context, id ClientID) (SampleMetadata, error)
+	AddAcquisition(
+		ctx context.Context, id ClientID, start time.Time, source string, metadata Metadata,
+	) error
+}
+
+// Send Commands
+
+func (c *Client) SetMetadata(metadata Metadata) (mqtt.Token, error) {
 	command := struct {
 		Action   string   `json:"action"`
 		Metadata Metadata `json:"config"`
 	}{
-		Action: "update_config",
-		Metadata: Metadata{
-			SampleProjectID:      sampleProjectID,
-			SampleID:             fmt.Sprintf("%s_%s", sampleProjectID, sampleID),
-			SampleCollectionDate: acquisitionTime.Format("2006-01-02"),
-			SampleCollectionTime: acquisitionTime.Format("15:04:05"),
-			AcquisitionID:        acquisitionTime.Format(time.RFC3339),
-		},
+		Action:   "update_config",
+		Metadata: metadata,
 	}
 	marshaled, err := json.Marshal(command)
 	if err != nil {
 		return nil, err
 	}
 
-	token := c.MQTT.Publish("imager/image", mqttExactlyOnce, false, marshaled)
+	token := c.publish("imager/image", mqttExactlyOnce, false, marshaled)
 	return token, nil
 }
+
+// SetSampleMetadata sends the metadata of the current sample to the planktoscope before an
+// acquisition, and records it with the acquisition. Non-empty sample project and sample IDs
+// override the IDs of the current sample; if the sample ID is still empty, the source of the
+// acquisition is used as the sample ID.
+func (c *Client) SetSampleMetadata(
+	ctx context.Context, source, sampleProjectID, sampleID string,
+) (err error) {
+	var sample SampleMetadata
+	if c.Samples != nil {
+		if sample, err = c.Samples.GetSample(ctx, c.ID); err != nil {
+			return errors.Wrap(err, "couldn't look up current sample")
+		}
+	}
+	if sampleProjectID != "" {
+		sample.ProjectID = sampleProjectID
+	}
+	if sampleID != "" {
+		sample.SampleID = sampleID
+	}
+	if sample.SampleID == "" {
+		sample.SampleID = source
+	}
+
+	start := time.Now()
+	metadata := NewMetadata(sample, start)
+	token, err := c.SetMetadata(metadata)
+	if err != nil {
+		return errors.Wrap(err, "couldn't send command to update metadata")
+	}
+	select {
+	case <-ctx.Done():
+		return errors.Wrap(ctx.Err(), "couldn't wait for command to update metadata to be sent")
+	case <-token.Done():
+		if err = token.Error(); err != nil {
+			return errors.Wrap(err, "couldn't send command to update metadata")
+		}
+	}
+
+	if c.Samples == nil {
+		return nil
+	}
+	return errors.Wrap(
+		c.Samples.AddAcquisition(ctx, c.ID, start, source, metadata),
+		"couldn't record acquisition metadata",
+	)
+}
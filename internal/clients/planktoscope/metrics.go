@@ -0,0 +1,60 @@
+package planktoscope
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "pslive"
+	metricsSubsystem = "planktoscope"
+)
+
+var (
+	connectedDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, metricsSubsystem, "mqtt_connected"),
+		"Whether the client of each planktoscope controller is connected to its MQTT broker.",
+		[]string{"controller"}, nil,
+	)
+	messagesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "mqtt_messages_total",
+		Help: "Number of MQTT messages sent or received by the client of each planktoscope " +
+			"controller.",
+	}, []string{"controller", "direction"})
+)
+
+func metricsController(id ClientID) string {
+	const idBase = 10
+	return strconv.FormatInt(int64(id), idBase)
+}
+
+func (c *Client) countMessage(direction string) {
+	messagesCounter.WithLabelValues(metricsController(c.ID), direction).Inc()
+}
+
+// Describe implements [prometheus.Collector] for the metrics of planktoscope clients.
+func (o *Orchestrator) Describe(ch chan<- *prometheus.Desc) {
+	ch <- connectedDesc
+	messagesCounter.Describe(ch)
+}
+
+// Collect implements [prometheus.Collector] for the metrics of planktoscope clients.
+func (o *Orchestrator) Collect(ch chan<- prometheus.Metric) {
+	for id, connected := range o.Connections() {
+		value := 0.0
+		if connected {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(
+			connectedDesc, prometheus.GaugeValue, value, metricsController(id),
+		)
+	}
+	messagesCounter.Collect(ch)
+}
+
+func forgetMetrics(id ClientID) {
+	messagesCounter.DeletePartialMatch(prometheus.Labels{"controller": metricsController(id)})
+}
@@ -69,6 +69,49 @@ type Imager struct {
 	StateKnown bool
 	Imaging    bool
 	Start      time.Time
+	End        time.Time
+	// LastStatus is the text of the most recent status message from the imager.
+	LastStatus string
+
+	// Progress of the most recent acquisition
+	ImagesCaptured uint64
+	Steps          uint64
+	StepDelay      float64 // sec
+	LastImage      time.Time
+}
+
+// ProgressKnown reports whether the total number of images in the acquisition is known.
+func (i Imager) ProgressKnown() bool {
+	return i.Steps > 0
+}
+
+// ElapsedTime returns the time (rounded to seconds) which has elapsed since the start of the
+// most recent acquisition, or the duration of the acquisition if it has ended.
+func (i Imager) ElapsedTime() time.Duration {
+	if i.Start.IsZero() {
+		return 0
+	}
+	end := i.End
+	if i.Imaging || end.Before(i.Start) {
+		end = time.Now()
+	}
+	return end.Sub(i.Start).Round(time.Second)
+}
+
+// RemainingTime estimates the time (rounded to seconds) until all images of the acquisition will
+// have been captured. The estimate is based on the observed rate of image capture, or on the step
+// delay if no images have been captured yet (which underestimates the time needed for pumping).
+// It returns 0 if no estimate is available.
+func (i Imager) RemainingTime() time.Duration {
+	if !i.Imaging || !i.ProgressKnown() || i.ImagesCaptured >= i.Steps {
+		return 0
+	}
+	remaining := i.Steps - i.ImagesCaptured
+	perImage := time.Duration(i.StepDelay * float64(time.Second))
+	if i.ImagesCaptured > 0 && i.LastImage.After(i.Start) {
+		perImage = i.LastImage.Sub(i.Start) / time.Duration(i.ImagesCaptured)
+	}
+	return (perImage * time.Duration(remaining)).Round(time.Second)
 }
 
 type ImagerSettings struct {
@@ -15,19 +15,27 @@ type ClientID int64
 type Orchestrator struct {
 	planktoscopes   map[ClientID]*Client
 	planktoscopesMu *sync.RWMutex
+	samples         SampleStore
+	settings        SettingsStore
 
 	logger godest.Logger
 }
 
-func NewOrchestrator(logger godest.Logger) *Orchestrator {
+func NewOrchestrator(
+	samples SampleStore, settings SettingsStore, logger godest.Logger,
+) *Orchestrator {
 	return &Orchestrator{
 		planktoscopes:   make(map[ClientID]*Client),
 		planktoscopesMu: &sync.RWMutex{},
+		samples:         samples,
+		settings:        settings,
 		logger:          logger,
 	}
 }
 
-func (o *Orchestrator) Add(id ClientID, url string) error {
+func (o *Orchestrator) Add(
+	ctx context.Context, id ClientID, url string, auth MQTTAuth, limits Limits,
+) error {
 	if _, ok := o.Get(id); ok {
 		o.logger.Warnf(
 			"skipped adding planktoscope client %d (%s) because it's already running", id, url,
@@ -36,16 +44,24 @@ func (o *Orchestrator) Add(id ClientID, url string) error {
 	}
 
 	const idBase = 10
-	config, err := GetConfig(url, strconv.FormatInt(int64(id), idBase))
+	config, err := GetConfig(url, strconv.FormatInt(int64(id), idBase), auth)
 	if err != nil {
 		return errors.Wrap(err, "couldn't set up planktoscope config")
 	}
-	client, err := NewClient(config, o.logger)
+	client, err := NewClient(id, config, o.samples, o.settings, o.logger)
 	if err != nil {
 		return errors.Wrapf(
 			err, "couldn't set up planktoscope client %d (%s @ %s)", id, client.Config.ClientID, url,
 		)
 	}
+	client.SetLimits(limits)
+	if err := client.restoreSettings(ctx); err != nil {
+		// The client is still usable with its default settings
+		o.logger.Error(errors.Wrapf(
+			err, "couldn't restore settings of planktoscope client %d (%s @ %s)",
+			id, client.Config.ClientID, url,
+		))
+	}
 
 	o.planktoscopesMu.Lock()
 	o.planktoscopes[id] = client
@@ -72,6 +88,18 @@ func (o *Orchestrator) Get(id ClientID) (c *Client, ok bool) {
 	return c, ok
 }
 
+// Connections reports whether each client is connected to its MQTT broker.
+func (o *Orchestrator) Connections() map[ClientID]bool {
+	o.planktoscopesMu.RLock()
+	defer o.planktoscopesMu.RUnlock()
+
+	connections := make(map[ClientID]bool, len(o.planktoscopes))
+	for id, client := range o.planktoscopes {
+		connections[id] = client.HasConnection()
+	}
+	return connections
+}
+
 func (o *Orchestrator) Remove(ctx context.Context, id ClientID) error {
 	o.planktoscopesMu.Lock()
 	defer o.planktoscopesMu.Unlock()
@@ -88,25 +116,32 @@ func (o *Orchestrator) Remove(ctx context.Context, id ClientID) error {
 		client.Close()
 	}
 	delete(o.planktoscopes, id)
+	forgetMetrics(id)
 	return err
 }
 
-func (o *Orchestrator) Update(ctx context.Context, id ClientID, url string) error {
+func (o *Orchestrator) Update(
+	ctx context.Context, id ClientID, url string, auth MQTTAuth, limits Limits,
+) error {
 	o.planktoscopesMu.RLock()
 	client, ok := o.planktoscopes[id]
 	o.planktoscopesMu.RUnlock()
 	if !ok {
-		return o.Add(id, url)
+		return o.Add(ctx, id, url, auth, limits)
 	}
 
-	if client.Config.URL == url {
+	if client.Config.URL == url && client.Config.Auth == auth {
+		// Limits can be changed without reconnecting
+		client.SetLimits(limits)
 		return nil
 	}
 
 	if err := o.Remove(ctx, id); err != nil {
 		return errors.Wrapf(err, "couldn't remove old planktoscope client %d to update it", id)
 	}
-	return errors.Wrapf(o.Add(id, url), "couldn't add new planktoscope client %d to update it", id)
+	return errors.Wrapf(
+		o.Add(ctx, id, url, auth, limits), "couldn't add new planktoscope client %d to update it", id,
+	)
 }
 
 func (o *Orchestrator) Close(ctx context.Context) error {
@@ -130,6 +130,7 @@ func (c *Client) handlePumpActuatorUpdate(_ string, rawPayload []byte) error {
 
 		// Commit changes
 		c.updatePumpSettings(newSettings)
+		c.saveSettings()
 		c.Logger.Debugf("%s: %+v", c.Config.URL, newSettings)
 	}
 	return nil
@@ -147,7 +148,7 @@ func (c *Client) StopPump() (mqtt.Token, error) {
 	if err != nil {
 		return nil, err
 	}
-	token := c.MQTT.Publish("actuator/pump", mqttAtLeastOnce, false, marshaled)
+	token := c.publish("actuator/pump", mqttAtLeastOnce, false, marshaled)
 	return token, nil
 }
 
@@ -175,10 +176,13 @@ func (c *Client) StartPump(forward bool, volume, flowrate float64) (mqtt.Token,
 	c.stateL.Lock()
 	defer c.stateL.Unlock()
 
+	if err := c.limits.CheckPump(volume, flowrate); err != nil {
+		return nil, err
+	}
 	c.pumpSettings.Forward = forward
 	c.pumpSettings.Volume = volume
 	c.pumpSettings.Flowrate = flowrate
 
-	token := c.MQTT.Publish("actuator/pump", mqttExactlyOnce, false, marshaled)
+	token := c.publish("actuator/pump", mqttExactlyOnce, false, marshaled)
 	return token, nil
 }
@@ -0,0 +1,111 @@
+package planktoscope
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Settings are the settings which were last applied to the planktoscope's pump, camera, and imager.
+type Settings struct {
+	Pump   PumpSettings
+	Camera CameraSettings
+	Imager ImagerSettings
+}
+
+// SettingsStore records the settings which were last applied to each client's planktoscope, so that
+// they can be restored when the client is restarted.
+type SettingsStore interface {
+	// GetSettings returns the recorded settings, or saved=false if none have been recorded.
+	GetSettings(ctx context.Context, id ClientID) (settings Settings, saved bool, err error)
+	SaveSettings(ctx context.Context, id ClientID, settings Settings) error
+	// RestoresCamera reports whether the recorded camera settings should be sent to the planktoscope
+	// whenever the client connects to it.
+	RestoresCamera(ctx context.Context, id ClientID) (bool, error)
+}
+
+// settingsStoreTimeout bounds how long the client waits for the settings store, since the settings
+// are loaded and saved from MQTT message handlers.
+const settingsStoreTimeout = 5 * time.Second
+
+func (c *Client) getSettings() Settings {
+	c.stateL.RLock()
+	defer c.stateL.RUnlock()
+
+	return Settings{
+		Pump:   c.pumpSettings,
+		Camera: c.cameraSettings,
+		Imager: c.imagerSettings,
+	}
+}
+
+// restoreSettings replaces the default settings with the settings which were last applied to the
+// planktoscope, if any were recorded. The restored camera settings aren't marked as known, since
+// the planktoscope may have been reset since they were applied.
+func (c *Client) restoreSettings(ctx context.Context) error {
+	settings, saved, err := c.Settings.GetSettings(ctx, c.ID)
+	if err != nil {
+		return errors.Wrap(err, "couldn't load saved settings")
+	}
+	if !saved {
+		return nil
+	}
+
+	c.stateL.Lock()
+	defer c.stateL.Unlock()
+
+	c.pumpSettings = settings.Pump
+	c.cameraSettings = settings.Camera
+	c.cameraSettings.StateKnown = false
+	c.imagerSettings = settings.Imager
+	return nil
+}
+
+// saveSettings records the client's current settings in the settings store.
+func (c *Client) saveSettings() {
+	// Saves are serialized so that an older snapshot of the settings can't overwrite a newer one
+	c.settingsSaveMu.Lock()
+	defer c.settingsSaveMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), settingsStoreTimeout)
+	defer cancel()
+	if err := c.Settings.SaveSettings(ctx, c.ID, c.getSettings()); err != nil {
+		c.Logger.Error(errors.Wrapf(err, "couldn't save settings of planktoscope client %d", c.ID))
+	}
+}
+
+// restoreCamera sends the recorded camera settings to the planktoscope, if the settings store says
+// to do so.
+func (c *Client) restoreCamera() error {
+	ctx, cancel := context.WithTimeout(context.Background(), settingsStoreTimeout)
+	defer cancel()
+	restore, err := c.Settings.RestoresCamera(ctx, c.ID)
+	if err != nil {
+		return errors.Wrap(err, "couldn't check whether to restore camera settings")
+	}
+	if !restore {
+		return nil
+	}
+	settings, saved, err := c.Settings.GetSettings(ctx, c.ID)
+	if err != nil {
+		return errors.Wrap(err, "couldn't load saved camera settings")
+	}
+	if !saved {
+		return nil
+	}
+
+	c.Logger.Infof("restoring camera settings of planktoscope client %d", c.ID)
+	camera := settings.Camera
+	token, err := c.SetCamera(
+		camera.ISO, camera.ShutterSpeed,
+		camera.AutoWhiteBalance, camera.WhiteBalanceRedGain, camera.WhiteBalanceBlueGain,
+	)
+	if err != nil {
+		return errors.Wrap(err, "couldn't restore camera settings")
+	}
+	if token.Wait(); token.Error() != nil {
+		return errors.Wrap(token.Error(), "couldn't send restored camera settings")
+	}
+	return nil
+}
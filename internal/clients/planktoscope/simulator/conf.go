@@ -0,0 +1,170 @@
+package simulator
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/atrox/haikunatorgo"
+	"github.com/eclipse/paho.mqtt.golang"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest/env"
+)
+
+const envPrefix = "PSSIM_"
+
+type Config struct {
+	URL      string
+	ClientID string
+	MQTT     mqtt.ClientOptions
+
+	// TimeScale multiplies all simulated durations; values below 1 speed up the simulation.
+	TimeScale float64
+	// ImagingFlowrate is the pump flowrate (in mL/min) used between frames of an imaging run.
+	ImagingFlowrate float64
+	// CaptureDuration is the time needed to capture and save each frame of an imaging run.
+	CaptureDuration time.Duration
+
+	Preview PreviewConfig
+	Faults  FaultsConfig
+}
+
+type PreviewConfig struct {
+	Port    int
+	Width   int
+	Height  int
+	Quality int
+	FPS     float64
+}
+
+// FaultsConfig specifies the probabilities (from 0 to 1) of injected faults.
+type FaultsConfig struct {
+	// DropProbability is the probability that a status message is never published.
+	DropProbability float64
+	// SlowProbability is the probability that a status message is published after SlowDelay.
+	SlowProbability float64
+	SlowDelay       time.Duration
+	// DisconnectProbability is the probability that the simulator disconnects from the MQTT broker
+	// for DisconnectDuration after receiving a command.
+	DisconnectProbability float64
+	DisconnectDuration    time.Duration
+}
+
+func GetConfig() (c Config, err error) {
+	c.URL = env.GetString(envPrefix+"MQTT_URL", "mqtt://localhost:1883")
+	c.ClientID = fmt.Sprintf(
+		"pssim/%s", env.GetString(envPrefix+"MQTT_CLIENT", haikunator.New().Haikunate()),
+	)
+	c.MQTT = *getMQTTConfig(c.URL, c.ClientID)
+
+	const defaultTimeScale = 1
+	if c.TimeScale, err = getFloat(envPrefix+"TIMESCALE", defaultTimeScale); err != nil {
+		return Config{}, errors.Wrap(err, "couldn't make time scale config")
+	}
+	if c.TimeScale <= 0 {
+		return Config{}, errors.Errorf(
+			"%sTIMESCALE must be positive, but it's %g", envPrefix, c.TimeScale,
+		)
+	}
+	const defaultImagingFlowrate = 2 // mL/min
+	if c.ImagingFlowrate, err = getFloat(
+		envPrefix+"IMAGING_FLOWRATE", defaultImagingFlowrate,
+	); err != nil {
+		return Config{}, errors.Wrap(err, "couldn't make imaging flowrate config")
+	}
+	const defaultCaptureDuration = 500 // ms
+	if c.CaptureDuration, err = getMilliseconds(
+		envPrefix+"CAPTURE_DURATION", defaultCaptureDuration,
+	); err != nil {
+		return Config{}, errors.Wrap(err, "couldn't make capture duration config")
+	}
+
+	if c.Preview, err = getPreviewConfig(); err != nil {
+		return Config{}, errors.Wrap(err, "couldn't make preview config")
+	}
+	if c.Faults, err = getFaultsConfig(); err != nil {
+		return Config{}, errors.Wrap(err, "couldn't make faults config")
+	}
+	return c, nil
+}
+
+func getPreviewConfig() (c PreviewConfig, err error) {
+	const defaultPort = 8000 // matches the planktoscope's picamera preview stream
+	port, err := env.GetInt64(envPrefix+"PREVIEW_PORT", defaultPort)
+	if err != nil {
+		return PreviewConfig{}, errors.Wrap(err, "couldn't make port config")
+	}
+	c.Port = int(port)
+	const defaultWidth = 800
+	width, err := env.GetInt64(envPrefix+"PREVIEW_WIDTH", defaultWidth)
+	if err != nil {
+		return PreviewConfig{}, errors.Wrap(err, "couldn't make width config")
+	}
+	c.Width = int(width)
+	const defaultHeight = 600
+	height, err := env.GetInt64(envPrefix+"PREVIEW_HEIGHT", defaultHeight)
+	if err != nil {
+		return PreviewConfig{}, errors.Wrap(err, "couldn't make height config")
+	}
+	c.Height = int(height)
+	const defaultQuality = 50
+	quality, err := env.GetInt64(envPrefix+"PREVIEW_QUALITY", defaultQuality)
+	if err != nil {
+		return PreviewConfig{}, errors.Wrap(err, "couldn't make quality config")
+	}
+	c.Quality = int(quality)
+	const defaultFPS = 10
+	if c.FPS, err = getFloat(envPrefix+"PREVIEW_FPS", defaultFPS); err != nil {
+		return PreviewConfig{}, errors.Wrap(err, "couldn't make fps config")
+	}
+	return c, nil
+}
+
+func getFaultsConfig() (c FaultsConfig, err error) {
+	if c.DropProbability, err = getFloat(envPrefix+"FAULTS_DROP", 0); err != nil {
+		return FaultsConfig{}, errors.Wrap(err, "couldn't make dropped responses config")
+	}
+	if c.SlowProbability, err = getFloat(envPrefix+"FAULTS_SLOW", 0); err != nil {
+		return FaultsConfig{}, errors.Wrap(err, "couldn't make slow replies config")
+	}
+	const defaultSlowDelay = 5000 // ms
+	if c.SlowDelay, err = getMilliseconds(envPrefix+"FAULTS_SLOW_DELAY", defaultSlowDelay); err != nil {
+		return FaultsConfig{}, errors.Wrap(err, "couldn't make slow reply delay config")
+	}
+	if c.DisconnectProbability, err = getFloat(envPrefix+"FAULTS_DISCONNECT", 0); err != nil {
+		return FaultsConfig{}, errors.Wrap(err, "couldn't make disconnects config")
+	}
+	const defaultDisconnectDuration = 10000 // ms
+	if c.DisconnectDuration, err = getMilliseconds(
+		envPrefix+"FAULTS_DISCONNECT_DURATION", defaultDisconnectDuration,
+	); err != nil {
+		return FaultsConfig{}, errors.Wrap(err, "couldn't make disconnect duration config")
+	}
+	return c, nil
+}
+
+func getFloat(varName string, defaultValue float32) (float64, error) {
+	value, err := env.GetFloat32(varName, defaultValue)
+	return float64(value), err
+}
+
+func getMilliseconds(varName string, defaultValue int64) (time.Duration, error) {
+	value, err := env.GetInt64(varName, defaultValue)
+	return time.Duration(value) * time.Millisecond, err
+}
+
+func getMQTTConfig(brokerURL, clientID string) *mqtt.ClientOptions {
+	c := mqtt.NewClientOptions()
+	c.AddBroker(brokerURL)
+	c.SetCleanSession(true)
+	c.SetClientID(clientID)
+	c.SetUsername(env.GetString(envPrefix+"MQTT_USERNAME", ""))
+	c.SetPassword(env.GetString(envPrefix+"MQTT_PASSWORD", ""))
+	// Commands are handled concurrently so that simulated delays don't block other commands
+	c.SetOrderMatters(false)
+	c.SetConnectRetry(true)
+	c.SetAutoReconnect(true)
+	const retryInterval = 5 * time.Second
+	c.SetConnectRetryInterval(retryInterval)
+	c.SetMaxReconnectInterval(retryInterval)
+	return c
+}
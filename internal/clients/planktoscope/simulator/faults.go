@@ -0,0 +1,47 @@
+package simulator
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+type faultInjector struct {
+	config FaultsConfig
+	rand   *rand.Rand
+	randL  *sync.Mutex
+}
+
+func newFaultInjector(c FaultsConfig) *faultInjector {
+	return &faultInjector{
+		config: c,
+		//nolint:gosec // We don't need cryptographically-secure random number generation here
+		rand:  rand.New(rand.NewSource(time.Now().UnixNano())),
+		randL: &sync.Mutex{},
+	}
+}
+
+func (f *faultInjector) occurs(probability float64) bool {
+	if probability <= 0 {
+		return false
+	}
+	f.randL.Lock()
+	defer f.randL.Unlock()
+
+	return f.rand.Float64() < probability
+}
+
+func (f *faultInjector) drop() bool {
+	return f.occurs(f.config.DropProbability)
+}
+
+func (f *faultInjector) delay() time.Duration {
+	if !f.occurs(f.config.SlowProbability) {
+		return 0
+	}
+	return f.config.SlowDelay
+}
+
+func (f *faultInjector) disconnect() bool {
+	return f.occurs(f.config.DisconnectProbability)
+}
@@ -0,0 +1,129 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func (s *Simulator) handleImagerCommand(rawPayload []byte) error {
+	type ImagerCommand struct {
+		Action     string                 `json:"action"`
+		Direction  string                 `json:"pump_direction,omitempty"`
+		StepVolume float64                `json:"volume,omitempty"`
+		StepDelay  float64                `json:"sleep,omitempty"`
+		Steps      uint64                 `json:"nb_frame,omitempty"`
+		Config     map[string]interface{} `json:"config,omitempty"`
+	}
+	var payload ImagerCommand
+	if err := json.Unmarshal(rawPayload, &payload); err != nil {
+		return errors.Wrap(err, "unparseable payload")
+	}
+	switch action := payload.Action; action {
+	default:
+		return errors.Errorf("unknown action %s", action)
+	case "stop":
+		s.Logger.Info("stopping imaging")
+		if stopped := s.stopRun(); stopped != imagingRun {
+			s.publishStatus("status/imager", statusMessage{Status: "Interrupted"})
+		}
+		return nil
+	case "update_config":
+		s.stateL.Lock()
+		for key, value := range payload.Config {
+			s.metadata[key] = value
+		}
+		s.stateL.Unlock()
+		s.publishStatus("status/imager", statusMessage{Status: "Config updated"})
+		return nil
+	case "settings":
+		s.publishStatus("status/imager", statusMessage{Status: "Camera settings updated"})
+		return nil
+	case "image":
+		forward, err := parseDirection(payload.Direction)
+		if err != nil {
+			return err
+		}
+		if payload.StepVolume <= 0 || payload.Steps == 0 || payload.StepDelay < 0 {
+			s.publishStatus("status/imager", statusMessage{Status: "Error"})
+			return errors.Errorf(
+				"invalid step volume %f, step delay %f, or steps %d",
+				payload.StepVolume, payload.StepDelay, payload.Steps,
+			)
+		}
+		s.Logger.Infof(
+			"imaging %d frames with %f mL per step and %f s delay (forward: %t)",
+			payload.Steps, payload.StepVolume, payload.StepDelay, forward,
+		)
+		s.startRun(imagingRun, func(ctx context.Context) {
+			s.runImaging(ctx, forward, payload.StepVolume, payload.StepDelay, payload.Steps)
+		})
+		return nil
+	}
+}
+
+func (s *Simulator) getImagePath(start time.Time, frame uint64) string {
+	s.stateL.RLock()
+	defer s.stateL.RUnlock()
+
+	sampleID := fmt.Sprint(s.metadata["sample_id"])
+	if _, ok := s.metadata["sample_id"]; !ok {
+		sampleID = "sample"
+	}
+	const nsPerUs = 1000
+	now := time.Now()
+	return path.Join(
+		"/home/pi/data/img", start.Format("2006-01-02"), sampleID, start.Format("150405"),
+		fmt.Sprintf("%s_%06d_%d.jpg", now.Format("15_04_05"), now.Nanosecond()/nsPerUs, frame),
+	)
+}
+
+func (s *Simulator) runImaging(
+	ctx context.Context, forward bool, stepVolume, stepDelay float64, steps uint64,
+) {
+	start := time.Now()
+	stepPumpDuration := time.Duration(stepVolume / s.Config.ImagingFlowrate * float64(time.Minute))
+	stepSleepDuration := time.Duration(stepDelay * float64(time.Second))
+	s.updateState(func(state *State) {
+		state.Imaging = true
+		state.Forward = forward
+		state.ImagesDone = 0
+		state.ImagesGoal = steps
+	})
+	defer s.updateState(func(state *State) {
+		state.Pumping = false
+		state.Flowrate = 0
+		state.Imaging = false
+	})
+
+	s.publishStatus("status/imager", statusMessage{Status: "Started"})
+	for frame := uint64(1); frame <= steps; frame++ {
+		s.updateState(func(state *State) {
+			state.Pumping = true
+			state.Flowrate = s.Config.ImagingFlowrate
+		})
+		if !s.wait(ctx, stepPumpDuration) {
+			s.publishStatus("status/imager", statusMessage{Status: "Interrupted"})
+			return
+		}
+		s.updateState(func(state *State) {
+			state.Pumping = false
+			state.Flowrate = 0
+		})
+		if !s.wait(ctx, stepSleepDuration+s.Config.CaptureDuration) {
+			s.publishStatus("status/imager", statusMessage{Status: "Interrupted"})
+			return
+		}
+		s.updateState(func(state *State) {
+			state.ImagesDone = frame
+		})
+		s.publishStatus("status/imager", statusMessage{Status: fmt.Sprintf(
+			"Image %d/%d has been imaged to %s.", frame, steps, s.getImagePath(start, frame),
+		)})
+	}
+	s.publishStatus("status/imager", statusMessage{Status: "Done"})
+}
@@ -0,0 +1,170 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/sargassum-world/godest/handling"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/sargassum-world/pslive/internal/clients/mjpeg"
+	"github.com/sargassum-world/pslive/internal/clients/videostreams"
+)
+
+// Particle field
+
+type particle struct {
+	x      float64
+	y      float64
+	radius int
+	shade  uint8
+}
+
+type particleField struct {
+	width     int
+	height    int
+	particles []particle
+	rand      *rand.Rand
+}
+
+func newParticleField(width, height int) *particleField {
+	const density = 1.0 / 4000 // particles per pixel
+	f := &particleField{
+		width:  width,
+		height: height,
+		//nolint:gosec // We don't need cryptographically-secure random number generation here
+		rand: rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	f.particles = make([]particle, int(float64(width*height)*density))
+	for i := range f.particles {
+		f.particles[i] = f.newParticle(f.rand.Float64() * float64(height))
+	}
+	return f
+}
+
+func (f *particleField) newParticle(y float64) particle {
+	const (
+		minRadius = 2
+		maxRadius = 12
+		minShade  = 40
+		maxShade  = 160
+	)
+	return particle{
+		x:      f.rand.Float64() * float64(f.width),
+		y:      y,
+		radius: minRadius + f.rand.Intn(maxRadius-minRadius),
+		shade:  uint8(minShade + f.rand.Intn(maxShade-minShade)),
+	}
+}
+
+// advance moves all particles by the specified displacement (in pixels), replacing particles
+// which leave the field of view with new particles entering it from the opposite side.
+func (f *particleField) advance(displacement float64) {
+	const jitter = 0.5 // px
+	for i, p := range f.particles {
+		p.x += (f.rand.Float64() - 0.5) * jitter
+		p.y += displacement
+		switch {
+		case p.y > float64(f.height+p.radius):
+			p = f.newParticle(float64(-p.radius))
+		case p.y < float64(-p.radius):
+			p = f.newParticle(float64(f.height + p.radius))
+		}
+		f.particles[i] = p
+	}
+}
+
+func (f *particleField) render() draw.Image {
+	const background = 230
+	im := image.NewRGBA(image.Rect(0, 0, f.width, f.height))
+	draw.Draw(im, im.Bounds(), &image.Uniform{color.Gray{Y: background}}, image.Point{}, draw.Src)
+	for _, p := range f.particles {
+		co := color.Gray{Y: p.shade}
+		cx, cy := int(p.x), int(p.y)
+		for dy := -p.radius; dy <= p.radius; dy++ {
+			for dx := -p.radius; dx <= p.radius; dx++ {
+				if dx*dx+dy*dy <= p.radius*p.radius {
+					im.Set(cx+dx, cy+dy, co)
+				}
+			}
+		}
+	}
+	return im
+}
+
+// Preview stream
+
+func (s *Simulator) describeState(state State) string {
+	switch {
+	case state.Imaging:
+		return fmt.Sprintf("imaging: %d/%d frames", state.ImagesDone, state.ImagesGoal)
+	case state.Pumping:
+		direction := "backward"
+		if state.Forward {
+			direction = "forward"
+		}
+		return fmt.Sprintf("pumping %s at %.2f mL/min", direction, state.Flowrate)
+	default:
+		return "idle"
+	}
+}
+
+func (s *Simulator) newPreviewFrame(field *particleField, interval time.Duration) videostreams.Frame {
+	state := s.GetState()
+	if state.Pumping {
+		// Particles move at a rate proportional to the flowrate, scaled for simulated time
+		const speed = 100 // px/s per mL/min
+		displacement := speed * state.Flowrate * interval.Seconds() / s.Config.TimeScale
+		if !state.Forward {
+			displacement = -displacement
+		}
+		field.advance(displacement)
+	} else {
+		field.advance(0)
+	}
+
+	frame := &videostreams.ImageFrame{
+		Im: field.render(),
+		Meta: &videostreams.Metadata{
+			ReceiveTime: time.Now(),
+			Settings: videostreams.Settings{
+				JPEGEncodeQuality: s.Config.Preview.Quality,
+			},
+		},
+	}
+	return frame.WithAnnotation(fmt.Sprintf("pssim %s", s.describeState(state)), 1)
+}
+
+// HandlePreview serves an MJPEG stream of synthetic preview frames, in which particles flow
+// through the field of view while the simulated pump is running.
+func (s *Simulator) HandlePreview(w http.ResponseWriter, r *http.Request) error {
+	frames := make(chan videostreams.Frame)
+	field := newParticleField(s.Config.Preview.Width, s.Config.Preview.Height)
+	interval := time.Duration(float64(time.Second) / s.Config.Preview.FPS)
+	eg, egctx := errgroup.WithContext(r.Context())
+	eg.Go(func() error {
+		return handling.Repeat(egctx, interval, func() (done bool, err error) {
+			select {
+			case <-egctx.Done():
+				return true, nil
+			case frames <- s.newPreviewFrame(field, interval):
+				return false, nil
+			}
+		})
+	})
+	eg.Go(func() error {
+		return mjpeg.SendFrameStream(egctx, w, frames)
+	})
+	if err := handling.Except(eg.Wait(), context.Canceled, syscall.EPIPE); err != nil {
+		return err
+	}
+	close(frames)
+	return nil
+}
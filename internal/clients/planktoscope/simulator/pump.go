@@ -0,0 +1,80 @@
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const maxFlowrate = 50 // mL/min
+
+func (s *Simulator) handlePumpCommand(rawPayload []byte) error {
+	type PumpCommand struct {
+		Action    string      `json:"action"`
+		Direction string      `json:"direction,omitempty"`
+		Volume    interface{} `json:"volume,omitempty"`
+		Flowrate  interface{} `json:"flowrate,omitempty"`
+	}
+	var payload PumpCommand
+	if err := json.Unmarshal(rawPayload, &payload); err != nil {
+		return errors.Wrap(err, "unparseable payload")
+	}
+	switch action := payload.Action; action {
+	default:
+		return errors.Errorf("unknown action %s", action)
+	case "stop":
+		s.Logger.Info("stopping pump")
+		if stopped := s.stopRun(); stopped != pumpRun {
+			// The real planktoscope reports an interruption even if the pump wasn't running
+			s.publishStatus("status/pump", statusMessage{Status: "Interrupted"})
+		}
+		return nil
+	case "move":
+		forward, err := parseDirection(payload.Direction)
+		if err != nil {
+			return err
+		}
+		volume, err := parseFloat(payload.Volume)
+		if err != nil {
+			return errors.Wrap(err, "couldn't parse volume")
+		}
+		flowrate, err := parseFloat(payload.Flowrate)
+		if err != nil {
+			return errors.Wrap(err, "couldn't parse flowrate")
+		}
+		if volume <= 0 || flowrate <= 0 || flowrate > maxFlowrate {
+			s.publishStatus("status/pump", statusMessage{Status: "Error"})
+			return errors.Errorf("invalid volume %f or flowrate %f", volume, flowrate)
+		}
+		s.Logger.Infof("pumping %f mL at %f mL/min (forward: %t)", volume, flowrate, forward)
+		s.startRun(pumpRun, func(ctx context.Context) {
+			s.runPump(ctx, forward, volume, flowrate)
+		})
+		return nil
+	}
+}
+
+func (s *Simulator) runPump(ctx context.Context, forward bool, volume, flowrate float64) {
+	duration := time.Duration(volume / flowrate * float64(time.Minute))
+	s.updateState(func(state *State) {
+		state.Pumping = true
+		state.Forward = forward
+		state.Flowrate = flowrate
+	})
+	defer s.updateState(func(state *State) {
+		state.Pumping = false
+		state.Flowrate = 0
+	})
+
+	// The duration is reported in whole seconds, like the real planktoscope
+	reportedDuration := math.Round(s.scaled(duration).Seconds())
+	s.publishStatus("status/pump", statusMessage{Status: "Started", Duration: &reportedDuration})
+	if !s.wait(ctx, duration) {
+		s.publishStatus("status/pump", statusMessage{Status: "Interrupted"})
+		return
+	}
+	s.publishStatus("status/pump", statusMessage{Status: "Done"})
+}
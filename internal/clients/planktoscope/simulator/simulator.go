@@ -0,0 +1,316 @@
+// Package simulator provides a simulated planktoscope which speaks the v2.3 MQTT API, for local
+// development and testing without planktoscope hardware.
+package simulator
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang"
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+)
+
+const mqttAtLeastOnce = 1
+
+type runKind int
+
+const (
+	noRun runKind = iota
+	pumpRun
+	imagingRun
+)
+
+// State is the simulated state of the planktoscope's hardware.
+type State struct {
+	Pumping    bool
+	Forward    bool
+	Flowrate   float64 // mL/min
+	Imaging    bool
+	ImagesDone uint64
+	ImagesGoal uint64
+}
+
+type Simulator struct {
+	Config Config
+	Logger godest.Logger
+	MQTT   mqtt.Client
+
+	faults *faultInjector
+
+	// commands are handled in order outside of the MQTT client's message handlers, since handling a
+	// command can wait for the previous run to stop or for a simulated slow status to be published.
+	commands     chan command
+	stopCommands context.CancelFunc
+
+	// runL serializes the starting and stopping of runs, so that each run has finished publishing
+	// its final status before another run starts.
+	runL      *sync.Mutex
+	run       runKind
+	cancelRun context.CancelFunc
+	runDone   chan struct{}
+
+	stateL   *sync.RWMutex
+	state    State
+	metadata map[string]interface{}
+}
+
+func New(c Config, l godest.Logger) *Simulator {
+	s := &Simulator{
+		Config:   c,
+		Logger:   l,
+		faults:   newFaultInjector(c.Faults),
+		runL:     &sync.Mutex{},
+		stateL:   &sync.RWMutex{},
+		metadata: make(map[string]interface{}),
+	}
+	const commandQueueSize = 64
+	s.commands = make(chan command, commandQueueSize)
+	var ctx context.Context
+	ctx, s.stopCommands = context.WithCancel(context.Background())
+	go s.handleCommands(ctx)
+	c.MQTT.SetOnConnectHandler(s.handleConnected)
+	c.MQTT.SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+		s.Logger.Warn(errors.Wrap(err, "connection lost"))
+	})
+	s.MQTT = mqtt.NewClient(&c.MQTT)
+	return s
+}
+
+func (s *Simulator) GetState() State {
+	s.stateL.RLock()
+	defer s.stateL.RUnlock()
+
+	return s.state
+}
+
+func (s *Simulator) updateState(update func(state *State)) {
+	s.stateL.Lock()
+	defer s.stateL.Unlock()
+
+	update(&s.state)
+}
+
+// MQTT
+
+func (s *Simulator) Connect() error {
+	token := s.MQTT.Connect()
+	_ = token.Wait()
+	return errors.Wrapf(token.Error(), "couldn't connect to %s", s.Config.URL)
+}
+
+func (s *Simulator) Shutdown(ctx context.Context) error {
+	s.stopCommands()
+	s.stopRun()
+	if !s.MQTT.IsConnected() {
+		return nil
+	}
+
+	// As of v1.4.2 of paho.mqtt.golang, s.MQTT.Disconnect can hang while the client is trying to
+	// reconnect, so we don't wait for it beyond the context's deadline.
+	closedNormally := make(chan struct{})
+	go func() {
+		const disconnectTimeout = 1000 // ms
+		s.MQTT.Disconnect(disconnectTimeout)
+		close(closedNormally)
+	}()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-closedNormally:
+		return nil
+	}
+}
+
+func (s *Simulator) handleConnected(cm mqtt.Client) {
+	s.Logger.Infof("connected as %s to MQTT broker %s", s.Config.ClientID, s.Config.URL)
+	for _, topic := range []string{"actuator/pump", "imager/image"} {
+		token := cm.Subscribe(topic, mqttAtLeastOnce, s.handleMessage)
+		go func(topic string, t mqtt.Token) {
+			if t.Wait(); t.Error() != nil {
+				s.Logger.Error(errors.Wrapf(t.Error(), "couldn't subscribe to %s", topic))
+			}
+		}(topic, token)
+	}
+}
+
+type command struct {
+	topic   string
+	payload []byte
+}
+
+func (s *Simulator) handleMessage(_ mqtt.Client, m mqtt.Message) {
+	select {
+	case s.commands <- command{topic: m.Topic(), payload: m.Payload()}:
+	default:
+		s.Logger.Errorf(
+			"%s: dropped command %s because too many commands are queued", m.Topic(), m.Payload(),
+		)
+	}
+}
+
+func (s *Simulator) handleCommands(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case c := <-s.commands:
+			s.handleCommand(c)
+		}
+	}
+}
+
+func (s *Simulator) handleCommand(c command) {
+	var err error
+	switch c.topic {
+	default:
+		return
+	case "actuator/pump":
+		err = s.handlePumpCommand(c.payload)
+	case "imager/image":
+		err = s.handleImagerCommand(c.payload)
+	}
+	if err != nil {
+		s.Logger.Error(errors.Wrapf(err, "%s: invalid command %s", c.topic, c.payload))
+	}
+
+	if s.faults.disconnect() {
+		go s.simulateDisconnect()
+	}
+}
+
+func (s *Simulator) simulateDisconnect() {
+	s.Logger.Warnf(
+		"simulating disconnection from MQTT broker for %s", s.Config.Faults.DisconnectDuration,
+	)
+	s.MQTT.Disconnect(0)
+	time.Sleep(s.Config.Faults.DisconnectDuration)
+	if err := s.Connect(); err != nil {
+		s.Logger.Error(errors.Wrap(err, "couldn't reconnect after simulated disconnection"))
+	}
+}
+
+func (s *Simulator) publishStatus(topic string, status interface{}) {
+	marshaled, err := json.Marshal(status)
+	if err != nil {
+		s.Logger.Error(errors.Wrapf(err, "couldn't marshal status for %s", topic))
+		return
+	}
+	if s.faults.drop() {
+		s.Logger.Warnf("%s: simulating dropped status %s", topic, marshaled)
+		return
+	}
+	if delay := s.faults.delay(); delay > 0 {
+		s.Logger.Warnf("%s: simulating slow status %s after %s", topic, marshaled, delay)
+		time.Sleep(delay)
+	}
+	s.Logger.Debugf("%s: %s", topic, marshaled)
+	token := s.MQTT.Publish(topic, mqttAtLeastOnce, false, marshaled)
+	go func(t mqtt.Token) {
+		if t.Wait(); t.Error() != nil {
+			s.Logger.Error(errors.Wrapf(t.Error(), "couldn't publish status to %s", topic))
+		}
+	}(token)
+}
+
+type statusMessage struct {
+	Status   string   `json:"status"`
+	Duration *float64 `json:"duration,omitempty"`
+}
+
+// Runs
+
+// scaled returns the simulated duration corresponding to the specified real-world duration.
+func (s *Simulator) scaled(d time.Duration) time.Duration {
+	return time.Duration(float64(d) * s.Config.TimeScale)
+}
+
+// wait waits for the scaled duration, and returns false if the run was canceled before then.
+func (s *Simulator) wait(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(s.scaled(d))
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// startRun stops any previous run and then starts the new run in a goroutine.
+func (s *Simulator) startRun(kind runKind, run func(ctx context.Context)) {
+	s.runL.Lock()
+	defer s.runL.Unlock()
+
+	s.stopRunLocked()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.run = kind
+	s.cancelRun = cancel
+	s.runDone = done
+	go func() {
+		defer close(done)
+		run(ctx)
+	}()
+}
+
+// stopRun stops the current run, if one is in progress, and returns the kind of the stopped run.
+func (s *Simulator) stopRun() runKind {
+	s.runL.Lock()
+	defer s.runL.Unlock()
+
+	return s.stopRunLocked()
+}
+
+func (s *Simulator) stopRunLocked() (stopped runKind) {
+	if s.cancelRun == nil {
+		return noRun
+	}
+	select {
+	case <-s.runDone:
+		// The run already finished
+	default:
+		stopped = s.run
+	}
+	s.cancelRun()
+	<-s.runDone
+	s.run = noRun
+	s.cancelRun = nil
+	s.runDone = nil
+	return stopped
+}
+
+// Parsing
+
+func parseFloat(n interface{}) (float64, error) {
+	switch number := n.(type) {
+	default:
+		return 0, errors.Errorf("unknown float type %T", number)
+	case float64:
+		return number, nil
+	case string:
+		const floatWidth = 64
+		parsed, err := strconv.ParseFloat(number, floatWidth)
+		return parsed, errors.Wrapf(err, "couldn't parse number %s", number)
+	}
+}
+
+const (
+	forwardDirection  = "FORWARD"
+	backwardDirection = "BACKWARD"
+)
+
+func parseDirection(direction string) (forward bool, err error) {
+	switch direction {
+	default:
+		return false, errors.Errorf("unknown direction %s", direction)
+	case forwardDirection:
+		return true, nil
+	case backwardDirection:
+		return false, nil
+	}
+}
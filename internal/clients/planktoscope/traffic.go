@@ -0,0 +1,127 @@
+package planktoscope
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang"
+)
+
+const (
+	TrafficInbound  = "inbound"
+	TrafficOutbound = "outbound"
+)
+
+// TrafficMessage is a raw MQTT message sent or received by a client.
+type TrafficMessage struct {
+	Direction string
+	Topic     string
+	Payload   string
+	QoS       byte
+	Retained  bool
+	Time      time.Time
+}
+
+// TrafficLog is a bounded ring buffer of the most recent MQTT messages sent or received by a
+// client.
+type TrafficLog struct {
+	messagesL *sync.RWMutex
+	messages  []TrafficMessage
+	next      int
+	full      bool
+}
+
+func NewTrafficLog(size int) *TrafficLog {
+	return &TrafficLog{
+		messagesL: &sync.RWMutex{},
+		messages:  make([]TrafficMessage, size),
+	}
+}
+
+func (l *TrafficLog) Add(m TrafficMessage) {
+	l.messagesL.Lock()
+	defer l.messagesL.Unlock()
+
+	if len(l.messages) == 0 {
+		return
+	}
+	l.messages[l.next] = m
+	l.next = (l.next + 1) % len(l.messages)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// List returns the messages whose topics match the MQTT topic filter, ordered from newest to
+// oldest. An empty filter matches all topics.
+func (l *TrafficLog) List(filter string) []TrafficMessage {
+	l.messagesL.RLock()
+	defer l.messagesL.RUnlock()
+
+	count := l.next
+	if l.full {
+		count = len(l.messages)
+	}
+	messages := make([]TrafficMessage, 0, count)
+	for i := 1; i <= count; i++ {
+		m := l.messages[(l.next-i+len(l.messages))%len(l.messages)]
+		if filter == "" || MatchTopic(filter, m.Topic) {
+			messages = append(messages, m)
+		}
+	}
+	return messages
+}
+
+// MatchTopic checks whether the topic matches the MQTT topic filter, which may contain the
+// single-level wildcard "+" and the multi-level wildcard "#".
+func MatchTopic(filter, topic string) bool {
+	filterLevels := strings.Split(filter, "/")
+	topicLevels := strings.Split(topic, "/")
+	for i, filterLevel := range filterLevels {
+		if filterLevel == "#" {
+			return true
+		}
+		if i >= len(topicLevels) {
+			return false
+		}
+		if filterLevel != "+" && filterLevel != topicLevels[i] {
+			return false
+		}
+	}
+	return len(filterLevels) == len(topicLevels)
+}
+
+// Client Traffic
+
+func (c *Client) recordTraffic(m TrafficMessage) {
+	c.countMessage(m.Direction)
+	c.traffic.Add(m)
+	c.trafficB.BroadcastNext()
+}
+
+// publish sends a message over MQTT and records it in the client's traffic log.
+func (c *Client) publish(topic string, qos byte, retained bool, payload []byte) mqtt.Token {
+	c.recordTraffic(TrafficMessage{
+		Direction: TrafficOutbound,
+		Topic:     topic,
+		Payload:   string(payload),
+		QoS:       qos,
+		Retained:  retained,
+		Time:      time.Now(),
+	})
+	return c.MQTT.Publish(topic, qos, retained, payload)
+}
+
+// PublishRaw sends an arbitrary message over MQTT, e.g. for testing and debugging.
+func (c *Client) PublishRaw(topic string, qos byte, retained bool, payload string) mqtt.Token {
+	return c.publish(topic, qos, retained, []byte(payload))
+}
+
+func (c *Client) GetTraffic(filter string) []TrafficMessage {
+	return c.traffic.List(filter)
+}
+
+func (c *Client) TrafficBroadcasted() <-chan struct{} {
+	return c.trafficB.Broadcasted()
+}
@@ -114,6 +114,18 @@ func (s *Store) Count(topic Topic) (count int) {
 	return len(s.presences[topic])
 }
 
+// Counts returns the number of sessions present on each topic with any sessions.
+func (s *Store) Counts() map[Topic]int {
+	s.pmu.RLock()
+	defer s.pmu.RUnlock()
+
+	counts := make(map[Topic]int, len(s.presences))
+	for topic, sessions := range s.presences {
+		counts[topic] = len(sessions)
+	}
+	return counts
+}
+
 func (s *Store) IsKnown(sessionID SessionID) bool {
 	s.umu.RLock()
 	defer s.umu.RUnlock()
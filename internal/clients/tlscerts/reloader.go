@@ -0,0 +1,107 @@
+// Package tlscerts provides TLS certificates loaded from files which may be replaced while the
+// server is running, such as certificates renewed by an ACME client.
+package tlscerts
+
+import (
+	"context"
+	"crypto/tls"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sargassum-world/godest"
+)
+
+type Reloader struct {
+	certFile string
+	keyFile  string
+	logger   godest.Logger
+
+	cert     *tls.Certificate
+	modTimes [2]time.Time
+	mu       *sync.RWMutex
+}
+
+// NewReloader loads the certificate and private key from the PEM-encoded files.
+func NewReloader(certFile, keyFile string, l godest.Logger) (*Reloader, error) {
+	r := &Reloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		logger:   l,
+		mu:       &sync.RWMutex{},
+	}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reloader) readModTimes() (modTimes [2]time.Time, err error) {
+	for i, file := range []string{r.certFile, r.keyFile} {
+		info, err := os.Stat(file)
+		if err != nil {
+			return modTimes, errors.Wrapf(err, "couldn't check %s", file)
+		}
+		modTimes[i] = info.ModTime()
+	}
+	return modTimes, nil
+}
+
+// Reload loads the certificate and private key again if either file has changed since they were
+// last loaded. If they can't be loaded, the previously-loaded certificate is kept.
+func (r *Reloader) Reload() (reloaded bool, err error) {
+	modTimes, err := r.readModTimes()
+	if err != nil {
+		return false, err
+	}
+	r.mu.RLock()
+	unchanged := r.cert != nil && modTimes == r.modTimes
+	r.mu.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, errors.Wrapf(
+			err, "couldn't load tls certificate %s with key %s", r.certFile, r.keyFile,
+		)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cert = &cert
+	r.modTimes = modTimes
+	return true, nil
+}
+
+// PeriodicallyReload runs Reload at regular intervals until the context is canceled. Failures to
+// reload are logged rather than returned, since the files may be only partially written while
+// they're being replaced.
+func (r *Reloader) PeriodicallyReload(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			if err != nil {
+				r.logger.Error(errors.Wrap(err, "couldn't reload tls certificate"))
+				continue
+			}
+			if reloaded {
+				r.logger.Infof("reloaded tls certificate %s", r.certFile)
+			}
+		}
+	}
+}
+
+// GetCertificate returns the most recently-loaded certificate, for [tls.Config.GetCertificate].
+func (r *Reloader) GetCertificate(_ *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.cert, nil
+}
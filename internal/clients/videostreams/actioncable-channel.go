@@ -26,9 +26,9 @@ type Channel struct {
 	logger     pubsub.Logger
 }
 
-// parseStreamName parses the Video Streams stream name from the Action Cable subscription
+// ParseStreamName parses the Video Streams stream name from the Action Cable subscription
 // identifier.
-func parseStreamName(identifier string) (string, error) {
+func ParseStreamName(identifier string) (string, error) {
 	var i struct {
 		Name string `json:"name"`
 	}
@@ -45,7 +45,7 @@ func NewChannel(
 	identifier string, h *pubsub.Hub[[]Frame], subscriber subscriber, sessionID string,
 	checkers []actioncable.IdentifierChecker, logger pubsub.Logger,
 ) (*Channel, error) {
-	name, err := parseStreamName(identifier)
+	name, err := ParseStreamName(identifier)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,112 @@
+package videostreams
+
+import (
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "pslive"
+	metricsSubsystem = "videostreams"
+)
+
+var (
+	subscriptionsGauge = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "subscriptions",
+		Help:      "Number of active subscriptions to video stream topics on each route.",
+	}, []string{"route"})
+	framesPublishedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "frames_published_total",
+		Help:      "Number of frames published on video stream topics on each route.",
+	}, []string{"route"})
+	framesDeliveredCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "frames_delivered_total",
+		Help:      "Number of frames delivered to subscribers of video stream topics on each route.",
+	}, []string{"route"})
+	framesDroppedCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "frames_dropped_total",
+		Help: "Number of frames of video stream topics on each route which were dropped because a " +
+			"subscriber couldn't keep up.",
+	}, []string{"route"})
+	jpegEncodeHistogram = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "jpeg_encode_duration_seconds",
+		Help:      "Time taken to JPEG-encode a frame.",
+		Buckets:   prometheus.ExponentialBuckets(0.001, 2, 12), //nolint:gomnd // 1 ms to ~4 sec
+	})
+)
+
+var collectors = []prometheus.Collector{
+	subscriptionsGauge,
+	framesPublishedCounter,
+	framesDeliveredCounter,
+	framesDroppedCounter,
+	jpegEncodeHistogram,
+}
+
+// Describe implements [prometheus.Collector] for the metrics of video streams.
+func (b *Broker) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range collectors {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements [prometheus.Collector] for the metrics of video streams.
+func (b *Broker) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range collectors {
+		c.Collect(ch)
+	}
+}
+
+// topicMetrics tracks the number of subscriptions on each route, so that the metrics of a route
+// can be deleted once it has no more subscribers.
+type topicMetrics struct {
+	subscriptions map[string]int
+	mu            *sync.Mutex
+}
+
+func newTopicMetrics() *topicMetrics {
+	return &topicMetrics{
+		subscriptions: make(map[string]int),
+		mu:            &sync.Mutex{},
+	}
+}
+
+func (m *topicMetrics) subscribe(route string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.subscriptions[route]++
+	subscriptionsGauge.WithLabelValues(route).Inc()
+}
+
+func (m *topicMetrics) unsubscribe(route string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.subscriptions[route]--
+	if m.subscriptions[route] > 0 {
+		subscriptionsGauge.WithLabelValues(route).Dec()
+		return
+	}
+	delete(m.subscriptions, route)
+	subscriptionsGauge.DeleteLabelValues(route)
+	framesPublishedCounter.DeleteLabelValues(route)
+	framesDeliveredCounter.DeleteLabelValues(route)
+	framesDroppedCounter.DeleteLabelValues(route)
+}
+
+func observeJPEGEncode(start time.Time) {
+	jpegEncodeHistogram.Observe(time.Since(start).Seconds())
+}
@@ -13,6 +13,14 @@ type brokerContext = pubsub.BrokerContext[*Context, Frame]
 
 type Context struct {
 	*brokerContext
+	sources *sourceTracker
+}
+
+// Publish broadcasts the frames to the subscribers of the context's topic.
+func (c *Context) Publish(frames ...Frame) {
+	framesPublishedCounter.WithLabelValues(c.Path()).Add(float64(len(frames)))
+	c.sources.publish(c.Topic(), frames)
+	c.brokerContext.Publish(frames...)
 }
 
 // Handlers
@@ -40,14 +48,25 @@ const (
 )
 
 type Broker struct {
-	broker *pubsub.Broker[*Context, Frame]
-	logger pubsub.Logger
+	broker  *pubsub.Broker[*Context, Frame]
+	sources *sourceTracker
+	metrics *topicMetrics
+	logger  pubsub.Logger
 }
 
 func NewBroker(logger pubsub.Logger) *Broker {
 	return &Broker{
-		broker: pubsub.NewBroker[*Context, Frame](logger),
-		logger: logger,
+		broker:  pubsub.NewBroker[*Context, Frame](logger),
+		sources: newSourceTracker(),
+		metrics: newTopicMetrics(),
+		logger:  logger,
+	}
+}
+
+func (b *Broker) newContext(c *brokerContext) *Context {
+	return &Context{
+		brokerContext: c,
+		sources:       b.sources,
 	}
 }
 
@@ -71,6 +90,15 @@ func (b *Broker) Use(middleware ...MiddlewareFunc) {
 	b.broker.Use(middleware...)
 }
 
+// route returns the path which the topic's PUB handler was registered with. Metrics are labeled
+// by route rather than by topic, since topics may include arbitrary query params such as the URLs
+// of external video sources.
+func (b *Broker) route(topic string) string {
+	c := b.broker.NewBrokerContext(context.Background(), MethodPub, topic).RouterContext()
+	b.broker.GetHandler(MethodPub, topic, c)
+	return c.Path()
+}
+
 type BroadcastReceiver func(ctx context.Context, frames []Frame) error
 
 func (b *Broker) subscribe(
@@ -78,27 +106,28 @@ func (b *Broker) subscribe(
 ) (finished <-chan struct{}) {
 	// we keep this private because we're handling frames and we don't want a slow consumer to block
 	// every other consumer; the public Subscribe method drops frames for busy consumers
-	return b.broker.Subscribe(
-		ctx, topic, func(c *brokerContext) *Context {
-			return &Context{
-				brokerContext: c,
-			}
-		},
-		broadcastHandler,
-	)
+	return b.broker.Subscribe(ctx, topic, b.newContext, broadcastHandler)
 }
 
 func (b *Broker) Subscribe(ctx context.Context, topic string) <-chan Frame {
 	buffer := make(chan Frame, 1)
 	wg := sync.WaitGroup{}
+	route := b.route(topic)
+	b.metrics.subscribe(route)
+	delivered := framesDeliveredCounter.WithLabelValues(route)
+	dropped := framesDroppedCounter.WithLabelValues(route)
+	b.sources.subscribe(topic)
 	finished := b.subscribe(
 		ctx, topic, func(ctx context.Context, frames []Frame) error {
 			wg.Add(1)
 			select {
 			case buffer <- frames[len(frames)-1]:
+				delivered.Inc()
+				// Only the latest frame of the broadcast is delivered, so the rest are dropped
+				dropped.Add(float64(len(frames) - 1))
 			default:
 				// The buffer consumer can't keep up, so drop the frame to avoid blocking other subscribers
-				// TODO: update a frames-dropped-per-sec counter
+				dropped.Add(float64(len(frames)))
 			}
 			wg.Done()
 			return nil
@@ -108,16 +137,14 @@ func (b *Broker) Subscribe(ctx context.Context, topic string) <-chan Frame {
 		<-finished
 		wg.Wait() // prevent closing channel with pending sends, which is a data race
 		close(buffer)
+		b.metrics.unsubscribe(route)
+		b.sources.unsubscribe(topic)
 	}()
 	return buffer
 }
 
 func (b *Broker) Serve(ctx context.Context) error {
-	return b.broker.Serve(ctx, func(c *brokerContext) *Context {
-		return &Context{
-			brokerContext: c,
-		}
-	})
+	return b.broker.Serve(ctx, b.newContext)
 }
 
 type Router = pubsub.Router[*Context]
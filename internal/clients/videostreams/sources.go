@@ -0,0 +1,106 @@
+package videostreams
+
+import (
+	"sync"
+	"time"
+)
+
+// SourceStatus describes the recent activity of the publisher of a topic.
+type SourceStatus struct {
+	// Subscribers is the number of active subscriptions to the topic. The topic's publisher only runs
+	// while the topic has subscribers.
+	Subscribers int
+	// LastFrame is when the publisher last published a frame without an error.
+	LastFrame time.Time
+	// LastError is when the publisher last published an error frame, and Err is that frame's error.
+	LastError time.Time
+	Err       error
+
+	unsubscribed time.Time
+}
+
+// sourceRetention is how long the status of a topic's publisher is kept after the topic loses its
+// last subscriber, so that errors which made subscribers give up can still be reported.
+const sourceRetention = time.Minute
+
+type sourceTracker struct {
+	sources map[string]*SourceStatus
+	mu      *sync.RWMutex
+}
+
+func newSourceTracker() *sourceTracker {
+	return &sourceTracker{
+		sources: make(map[string]*SourceStatus),
+		mu:      &sync.RWMutex{},
+	}
+}
+
+func (t *sourceTracker) subscribe(topic string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.prune()
+	status, ok := t.sources[topic]
+	if !ok {
+		status = &SourceStatus{}
+		t.sources[topic] = status
+	}
+	status.Subscribers++
+}
+
+func (t *sourceTracker) unsubscribe(topic string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status, ok := t.sources[topic]
+	if !ok {
+		return
+	}
+	status.Subscribers--
+	if status.Subscribers <= 0 {
+		status.Subscribers = 0
+		status.unsubscribed = time.Now()
+	}
+	t.prune()
+}
+
+func (t *sourceTracker) prune() {
+	for topic, status := range t.sources {
+		if status.Subscribers == 0 && time.Since(status.unsubscribed) > sourceRetention {
+			delete(t.sources, topic)
+		}
+	}
+}
+
+func (t *sourceTracker) publish(topic string, frames []Frame) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	status, ok := t.sources[topic]
+	if !ok {
+		// Nobody has subscribed to the topic, so nobody will ask about it
+		return
+	}
+	now := time.Now()
+	for _, frame := range frames {
+		if err := frame.Error(); err != nil {
+			status.LastError = now
+			status.Err = err
+			continue
+		}
+		status.LastFrame = now
+	}
+}
+
+// SourceStatus returns the recent activity of the publisher of the topic. If the topic hasn't had
+// any subscribers recently, the status is empty.
+func (b *Broker) SourceStatus(topic string) SourceStatus {
+	b.sources.mu.RLock()
+	defer b.sources.mu.RUnlock()
+
+	status, ok := b.sources.sources[topic]
+	if !ok {
+		return SourceStatus{}
+	}
+	return *status
+}
@@ -81,6 +81,7 @@ func (f *ImageFrame) AsJPEGFrame() (*JPEGFrame, error) {
 	}
 
 	buf := new(bytes.Buffer)
+	defer observeJPEGEncode(time.Now())
 	if err := jpeg.Encode(buf, f.Im, &jpeg.Options{
 		Quality: quality,
 	}); err != nil {
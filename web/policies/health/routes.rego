@@ -0,0 +1,24 @@
+package sargassum.pslive.web.policies.health
+
+import future.keywords
+
+import data.sargassum.godest.errors as e
+
+# Policy Scope
+
+in_scope if {
+	input.resource.path in {"/healthz", "/readyz"}
+}
+
+# Policy Result & Error
+
+# Health checks are used by load balancers and orchestrators, which don't sign in
+allow if {
+	input.operation.method == "GET"
+}
+
+errors contains error_method if {
+	in_scope
+	not allow
+	error_method := e.new("route not implemented")
+}
@@ -6,40 +6,91 @@ import data.sargassum.pslive.internal.app.pslive.auth
 
 # Internal Route Checks
 
-allow_instruments_post(subject) := auth.is_authenticated(subject)
+allow_instruments_post(subject) := auth.has_scope(subject, "instruments:manage")
 
-allow_instrument_get(instrument_id) := is_valid_instrument(instrument_id)
+allow_instrument_get(subject, instrument_id) if {
+	is_valid_instrument(instrument_id)
+	is_instrument_visible(subject, instrument_id)
+}
+
+allow_instrument_list(subject, instrument_id) if {
+	is_valid_instrument(instrument_id)
+	is_instrument_listed(subject, instrument_id)
+}
 
 allow_instrument_post(subject, instrument_id) if {
 	is_valid_instrument(instrument_id)
+	auth.has_scope(subject, "instruments:manage")
 	is_instrument_admin(subject, instrument_id)
 }
 
-allow_camera_get(instrument_id, camera_id) if {
+allow_instrument_config_get(subject, instrument_id) if {
 	is_valid_instrument(instrument_id)
+	auth.has_scope(subject, "instruments:read")
+	is_instrument_admin(subject, instrument_id)
+}
+
+allow_instrument_audit_get(subject, instrument_id) if {
+	allow_instrument_config_get(subject, instrument_id)
+}
+
+allow_instrument_sample_post(subject, instrument_id) if {
+	is_valid_instrument(instrument_id)
+	auth.has_scope(subject, "controllers:control")
+	is_instrument_operator(subject, instrument_id)
+}
+
+allow_instrument_emergency_stop_post(subject, instrument_id) if {
+	is_valid_instrument(instrument_id)
+	auth.has_scope(subject, "controllers:control")
+	is_instrument_operator(subject, instrument_id)
+}
+
+allow_control_lease_post(subject, instrument_id) if {
+	allow_instrument_get(subject, instrument_id)
+	auth.has_scope(subject, "controllers:control")
+}
+
+allow_control_lease_holder_post(subject, instrument_id) if {
+	is_valid_instrument(instrument_id)
+	auth.has_scope(subject, "controllers:control")
+	is_instrument_operator(subject, instrument_id)
+}
+
+allow_camera_get(subject, instrument_id, camera_id) if {
+	allow_instrument_get(subject, instrument_id)
+	is_valid_camera(instrument_id, camera_id)
+}
+
+allow_camera_config_get(subject, instrument_id, camera_id) if {
+	allow_instrument_config_get(subject, instrument_id)
 	is_valid_camera(instrument_id, camera_id)
 }
 
 allow_camera_post(subject, instrument_id, camera_id) if {
-	is_valid_instrument(instrument_id)
+	allow_instrument_post(subject, instrument_id)
 	is_valid_camera(instrument_id, camera_id)
-	is_instrument_admin(subject, instrument_id)
 }
 
-allow_controller_get(_, instrument_id, controller_id) if {
-	is_valid_instrument(instrument_id)
+allow_controller_get(subject, instrument_id, controller_id) if {
+	allow_instrument_get(subject, instrument_id)
+	is_valid_controller(instrument_id, controller_id)
+}
+
+allow_controller_config_get(subject, instrument_id, controller_id) if {
+	allow_instrument_config_get(subject, instrument_id)
 	is_valid_controller(instrument_id, controller_id)
 }
 
 allow_controller_post(subject, instrument_id, controller_id) if {
-	is_valid_instrument(instrument_id)
+	allow_instrument_post(subject, instrument_id)
 	is_valid_controller(instrument_id, controller_id)
-	is_instrument_admin(subject, instrument_id)
 }
 
 allow_controller_module_post(subject, instrument_id, controller_id) if {
 	is_valid_instrument(instrument_id)
 	is_valid_controller(instrument_id, controller_id)
+	auth.has_scope(subject, "controllers:control")
 	is_instrument_operator(subject, instrument_id)
 }
 
@@ -55,19 +106,59 @@ allow_controller_imager_post(subject, instrument_id, controller_id) if {
 	allow_controller_module_post(subject, instrument_id, controller_id)
 }
 
-allow_automation_job_get(instrument_id, automation_job_id) if {
-	is_valid_instrument(instrument_id)
+allow_controller_command_post(subject, instrument_id, controller_id) if {
+	allow_controller_module_post(subject, instrument_id, controller_id)
+}
+
+allow_controller_mqtt_get(subject, instrument_id, controller_id) if {
+	allow_controller_post(subject, instrument_id, controller_id)
+}
+
+allow_controller_mqtt_post(subject, instrument_id, controller_id) if {
+	allow_controller_post(subject, instrument_id, controller_id)
+}
+
+allow_automation_job_get(subject, instrument_id, automation_job_id) if {
+	allow_instrument_get(subject, instrument_id)
 	is_valid_automation_job(instrument_id, automation_job_id)
 }
 
-allow_automation_job_post(subject, instrument_id, automation_job_id) if {
-	is_valid_instrument(instrument_id)
+allow_automation_job_config_get(subject, instrument_id, automation_job_id) if {
+	allow_instrument_config_get(subject, instrument_id)
 	is_valid_automation_job(instrument_id, automation_job_id)
+}
+
+allow_automation_jobs_post(subject, instrument_id) if {
+	is_valid_instrument(instrument_id)
+	auth.has_scope(subject, "jobs:manage")
 	is_instrument_admin(subject, instrument_id)
 }
 
+allow_automation_job_post(subject, instrument_id, automation_job_id) if {
+	allow_automation_jobs_post(subject, instrument_id)
+	is_valid_automation_job(instrument_id, automation_job_id)
+}
+
+allow_webhooks_get(subject, instrument_id) if {
+	allow_instrument_post(subject, instrument_id)
+}
+
+allow_webhooks_post(subject, instrument_id) if {
+	allow_instrument_post(subject, instrument_id)
+}
+
+allow_webhook_get(subject, instrument_id, webhook_id) if {
+	allow_webhooks_get(subject, instrument_id)
+	is_valid_webhook(instrument_id, webhook_id)
+}
+
+allow_webhook_post(subject, instrument_id, webhook_id) if {
+	allow_webhooks_post(subject, instrument_id)
+	is_valid_webhook(instrument_id, webhook_id)
+}
+
 allow_instrument_chat_post(subject, instrument_id) if {
-	is_valid_instrument(instrument_id)
+	allow_instrument_get(subject, instrument_id)
 	auth.is_authenticated(subject)
 }
 
@@ -97,13 +188,89 @@ is_valid_automation_job(instrument_id, automation_job_id) if {
 	to_number(instrument_id) == automation_job.instrument_id
 }
 
+is_valid_webhook(instrument_id, webhook_id) if {
+	webhook := input.context.db.instruments_webhook[_]
+	to_number(webhook_id) == webhook.id
+	to_number(instrument_id) == webhook.instrument_id
+}
+
+# Members-only instruments are only visible to their members, while public and unlisted instruments
+# are visible to everyone.
+is_instrument_visible(_, instrument_id) if {
+	instrument := input.context.db.instruments_instrument[_]
+	to_number(instrument_id) == instrument.id
+	instrument.visibility == "public"
+}
+
+is_instrument_visible(_, instrument_id) if {
+	instrument := input.context.db.instruments_instrument[_]
+	to_number(instrument_id) == instrument.id
+	instrument.visibility == "unlisted"
+}
+
+is_instrument_visible(subject, instrument_id) if {
+	auth.has_scope(subject, "instruments:read")
+	is_instrument_viewer(subject, instrument_id)
+}
+
+# Only public instruments are listed to everyone; unlisted and members-only instruments are only
+# listed to their members.
+is_instrument_listed(_, instrument_id) if {
+	instrument := input.context.db.instruments_instrument[_]
+	to_number(instrument_id) == instrument.id
+	instrument.visibility == "public"
+}
+
+is_instrument_listed(subject, instrument_id) if {
+	auth.has_scope(subject, "instruments:read")
+	is_instrument_viewer(subject, instrument_id)
+}
+
 is_instrument_admin(subject, instrument_id) if {
-	auth.is_authenticated(subject)
+	auth.is_identified(subject)
 	instrument := input.context.db.instruments_instrument[_]
 	to_number(instrument_id) == instrument.id
 	subject.identity == instrument.admin_identity_id
 }
 
-is_instrument_operator(subject, _) if {
-	auth.is_authenticated(subject) # TODO: implement operator permissions
+is_instrument_admin(subject, instrument_id) if {
+	has_instrument_role(subject, instrument_id, "admin")
+}
+
+is_instrument_operator(subject, instrument_id) if {
+	is_instrument_admin(subject, instrument_id)
+}
+
+is_instrument_operator(subject, instrument_id) if {
+	has_instrument_role(subject, instrument_id, "operator")
+}
+
+is_instrument_operator(subject, instrument_id) if {
+	is_control_lease_holder(subject, instrument_id)
+}
+
+is_instrument_viewer(subject, instrument_id) if {
+	is_instrument_operator(subject, instrument_id)
+}
+
+is_instrument_viewer(subject, instrument_id) if {
+	has_instrument_role(subject, instrument_id, "viewer")
+}
+
+has_instrument_role(subject, instrument_id, role) if {
+	auth.is_identified(subject)
+	member := input.context.db.instruments_instrument_member[_]
+	to_number(instrument_id) == member.instrument_id
+	subject.identity == member.identity_id
+	role == member.role
+}
+
+# Expired leases are deleted by the server shortly after they expire, but they must not grant
+# control in the meantime. input.now is the current Unix time in milliseconds.
+is_control_lease_holder(subject, instrument_id) if {
+	auth.is_identified(subject)
+	lease := input.context.db.instruments_control_lease[_]
+	to_number(instrument_id) == lease.instrument_id
+	subject.identity == lease.identity_id
+	lease.expiration_time > input.now
 }
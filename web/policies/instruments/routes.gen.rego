@@ -24,6 +24,10 @@ in_scope if {
 	glob.match("/instruments/*.mjpeg", [], input.resource.path)
 }
 
+in_scope if {
+	glob.match("/instruments/*.json", [], input.resource.path)
+}
+
 # Policy Result & Error
 
 matching_routes contains route if {
@@ -58,7 +62,19 @@ matching_routes contains route if {
 allow if {
 	"GET" == input.operation.method
 	["instruments", id] = split(trim_prefix(input.resource.path, "/"), "/")
-	allow_instrument_get(id)
+	allow_instrument_get(input.subject, id)
+}
+
+matching_routes contains route if {
+	"LIST" == input.operation.method
+	["instruments", id] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "LIST /instruments/:id"
+}
+
+allow if {
+	"LIST" == input.operation.method
+	["instruments", id] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_instrument_list(input.subject, id)
 }
 
 matching_routes contains route if {
@@ -97,6 +113,230 @@ allow if {
 	allow_instrument_post(input.subject, id)
 }
 
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["instruments", id, "visibility"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /instruments/:id/visibility"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["instruments", id, "visibility"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_instrument_post(input.subject, id)
+}
+
+matching_routes contains route if {
+	"GET" == input.operation.method
+	["instruments", id, "audit"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "GET /instruments/:id/audit"
+}
+
+allow if {
+	"GET" == input.operation.method
+	["instruments", id, "audit"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_instrument_audit_get(input.subject, id)
+}
+
+matching_routes contains route if {
+	"GET" == input.operation.method
+	["instruments", id, "audit.json"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "GET /instruments/:id/audit.json"
+}
+
+allow if {
+	"GET" == input.operation.method
+	["instruments", id, "audit.json"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_instrument_audit_get(input.subject, id)
+}
+
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["instruments", id, "members"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /instruments/:id/members"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["instruments", id, "members"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_instrument_post(input.subject, id)
+}
+
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["instruments", id, "members", identity_id] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /instruments/:id/members/:identity_id"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["instruments", id, "members", identity_id] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_instrument_post(input.subject, id)
+}
+
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["instruments", id, "sample"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /instruments/:id/sample"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["instruments", id, "sample"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_instrument_sample_post(input.subject, id)
+}
+
+matching_routes contains route if {
+	"SUB" == input.operation.method
+	["instruments", id, "sample"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "SUB /instruments/:id/sample"
+}
+
+allow if {
+	"SUB" == input.operation.method
+	["instruments", id, "sample"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_instrument_get(input.subject, id)
+}
+
+matching_routes contains route if {
+	"MSG" == input.operation.method
+	["instruments", id, "sample"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "MSG /instruments/:id/sample"
+}
+
+allow if {
+	"MSG" == input.operation.method
+	["instruments", id, "sample"] = split(trim_prefix(input.resource.path, "/"), "/")
+}
+
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["instruments", id, "emergency-stop"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /instruments/:id/emergency-stop"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["instruments", id, "emergency-stop"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_instrument_emergency_stop_post(input.subject, id)
+}
+
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["instruments", id, "emergency-stop", "clear"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /instruments/:id/emergency-stop/clear"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["instruments", id, "emergency-stop", "clear"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_instrument_post(input.subject, id)
+}
+
+matching_routes contains route if {
+	"SUB" == input.operation.method
+	["instruments", id, "emergency-stop"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "SUB /instruments/:id/emergency-stop"
+}
+
+allow if {
+	"SUB" == input.operation.method
+	["instruments", id, "emergency-stop"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_instrument_get(input.subject, id)
+}
+
+matching_routes contains route if {
+	"MSG" == input.operation.method
+	["instruments", id, "emergency-stop"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "MSG /instruments/:id/emergency-stop"
+}
+
+allow if {
+	"MSG" == input.operation.method
+	["instruments", id, "emergency-stop"] = split(trim_prefix(input.resource.path, "/"), "/")
+}
+
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["instruments", id, "control-lease"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /instruments/:id/control-lease"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["instruments", id, "control-lease"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_control_lease_post(input.subject, id)
+}
+
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["instruments", id, "control-lease", "release"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /instruments/:id/control-lease/release"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["instruments", id, "control-lease", "release"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_control_lease_holder_post(input.subject, id)
+}
+
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["instruments", id, "control-lease", "transfer"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /instruments/:id/control-lease/transfer"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["instruments", id, "control-lease", "transfer"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_control_lease_holder_post(input.subject, id)
+}
+
+matching_routes contains route if {
+	"SUB" == input.operation.method
+	["instruments", id, "control-lease"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "SUB /instruments/:id/control-lease"
+}
+
+allow if {
+	"SUB" == input.operation.method
+	["instruments", id, "control-lease"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_instrument_get(input.subject, id)
+}
+
+matching_routes contains route if {
+	"PUB" == input.operation.method
+	["instruments", id, "control-lease"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "PUB /instruments/:id/control-lease"
+}
+
+allow if {
+	"PUB" == input.operation.method
+	["instruments", id, "control-lease"] = split(trim_prefix(input.resource.path, "/"), "/")
+}
+
+matching_routes contains route if {
+	"MSG" == input.operation.method
+	["instruments", id, "control-lease"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "MSG /instruments/:id/control-lease"
+}
+
+allow if {
+	"MSG" == input.operation.method
+	["instruments", id, "control-lease"] = split(trim_prefix(input.resource.path, "/"), "/")
+}
+
+matching_routes contains route if {
+	"GET" == input.operation.method
+	["instruments", id, "events"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "GET /instruments/:id/events"
+}
+
+allow if {
+	"GET" == input.operation.method
+	["instruments", id, "events"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_instrument_get(input.subject, id)
+}
+
 matching_routes contains route if {
 	"GET" == input.operation.method
 	["instruments", id, "users"] = split(trim_prefix(input.resource.path, "/"), "/")
@@ -106,7 +346,7 @@ matching_routes contains route if {
 allow if {
 	"GET" == input.operation.method
 	["instruments", id, "users"] = split(trim_prefix(input.resource.path, "/"), "/")
-	allow_instrument_get(id)
+	allow_instrument_get(input.subject, id)
 }
 
 matching_routes contains route if {
@@ -118,7 +358,7 @@ matching_routes contains route if {
 allow if {
 	"SUB" == input.operation.method
 	["instruments", id, "users"] = split(trim_prefix(input.resource.path, "/"), "/")
-	allow_instrument_get(id)
+	allow_instrument_get(input.subject, id)
 }
 
 matching_routes contains route if {
@@ -141,7 +381,7 @@ matching_routes contains route if {
 allow if {
 	"SUB" == input.operation.method
 	["instruments", id, "users", "list"] = split(trim_prefix(input.resource.path, "/"), "/")
-	allow_instrument_get(id)
+	allow_instrument_get(input.subject, id)
 }
 
 matching_routes contains route if {
@@ -164,7 +404,7 @@ matching_routes contains route if {
 allow if {
 	"SUB" == input.operation.method
 	["instruments", id, "users", "count"] = split(trim_prefix(input.resource.path, "/"), "/")
-	allow_instrument_get(id)
+	allow_instrument_get(input.subject, id)
 }
 
 matching_routes contains route if {
@@ -211,7 +451,7 @@ matching_routes contains route if {
 allow if {
 	"GET" == input.operation.method
 	["instruments", id, "cameras", camera_id, "frame.jpeg"] = split(trim_prefix(input.resource.path, "/"), "/")
-	allow_camera_get(id, camera_id)
+	allow_camera_get(input.subject, id, camera_id)
 }
 
 matching_routes contains route if {
@@ -223,7 +463,7 @@ matching_routes contains route if {
 allow if {
 	"GET" == input.operation.method
 	["instruments", id, "cameras", camera_id, "stream.mjpeg"] = split(trim_prefix(input.resource.path, "/"), "/")
-	allow_camera_get(id, camera_id)
+	allow_camera_get(input.subject, id, camera_id)
 }
 
 matching_routes contains route if {
@@ -235,7 +475,7 @@ matching_routes contains route if {
 allow if {
 	"SUB" == input.operation.method
 	["instruments", id, "cameras", camera_id, "stream.mjpeg"] = split(trim_prefix(input.resource.path, "/"), "/")
-	allow_camera_get(id, camera_id)
+	allow_camera_get(input.subject, id, camera_id)
 }
 
 matching_routes contains route if {
@@ -273,6 +513,40 @@ allow if {
 	allow_controller_post(input.subject, id, controller_id)
 }
 
+matching_routes contains route if {
+	"SUB" == input.operation.method
+	["instruments", id, "controllers", controller_id, "control"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "SUB /instruments/:id/controllers/:controller_id/control"
+}
+
+allow if {
+	"SUB" == input.operation.method
+	["instruments", id, "controllers", controller_id, "control"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_controller_get(input.subject, id, controller_id)
+}
+
+matching_routes contains route if {
+	"PUB" == input.operation.method
+	["instruments", id, "controllers", controller_id, "control"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "PUB /instruments/:id/controllers/:controller_id/control"
+}
+
+allow if {
+	"PUB" == input.operation.method
+	["instruments", id, "controllers", controller_id, "control"] = split(trim_prefix(input.resource.path, "/"), "/")
+}
+
+matching_routes contains route if {
+	"MSG" == input.operation.method
+	["instruments", id, "controllers", controller_id, "control"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "MSG /instruments/:id/controllers/:controller_id/control"
+}
+
+allow if {
+	"MSG" == input.operation.method
+	["instruments", id, "controllers", controller_id, "control"] = split(trim_prefix(input.resource.path, "/"), "/")
+}
+
 matching_routes contains route if {
 	"SUB" == input.operation.method
 	["instruments", id, "controllers", controller_id, "pump"] = split(trim_prefix(input.resource.path, "/"), "/")
@@ -282,7 +556,7 @@ matching_routes contains route if {
 allow if {
 	"SUB" == input.operation.method
 	["instruments", id, "controllers", controller_id, "pump"] = split(trim_prefix(input.resource.path, "/"), "/")
-	allow_controller_get(id, id, controller_id)
+	allow_controller_get(input.subject, id, controller_id)
 }
 
 matching_routes contains route if {
@@ -328,7 +602,7 @@ matching_routes contains route if {
 allow if {
 	"SUB" == input.operation.method
 	["instruments", id, "controllers", controller_id, "camera"] = split(trim_prefix(input.resource.path, "/"), "/")
-	allow_controller_get(id, id, controller_id)
+	allow_controller_get(input.subject, id, controller_id)
 }
 
 matching_routes contains route if {
@@ -374,7 +648,7 @@ matching_routes contains route if {
 allow if {
 	"SUB" == input.operation.method
 	["instruments", id, "controllers", controller_id, "imager"] = split(trim_prefix(input.resource.path, "/"), "/")
-	allow_controller_get(id, id, controller_id)
+	allow_controller_get(input.subject, id, controller_id)
 }
 
 matching_routes contains route if {
@@ -411,6 +685,110 @@ allow if {
 	allow_controller_imager_post(input.subject, id, controller_id)
 }
 
+matching_routes contains route if {
+	"GET" == input.operation.method
+	["instruments", id, "controllers", controller_id, "mqtt"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "GET /instruments/:id/controllers/:controller_id/mqtt"
+}
+
+allow if {
+	"GET" == input.operation.method
+	["instruments", id, "controllers", controller_id, "mqtt"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_controller_mqtt_get(input.subject, id, controller_id)
+}
+
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["instruments", id, "controllers", controller_id, "mqtt"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /instruments/:id/controllers/:controller_id/mqtt"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["instruments", id, "controllers", controller_id, "mqtt"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_controller_mqtt_post(input.subject, id, controller_id)
+}
+
+matching_routes contains route if {
+	"SUB" == input.operation.method
+	["instruments", id, "controllers", controller_id, "mqtt", "messages", filter] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "SUB /instruments/:id/controllers/:controller_id/mqtt/messages/:filter"
+}
+
+allow if {
+	"SUB" == input.operation.method
+	["instruments", id, "controllers", controller_id, "mqtt", "messages", filter] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_controller_mqtt_get(input.subject, id, controller_id)
+}
+
+matching_routes contains route if {
+	"PUB" == input.operation.method
+	["instruments", id, "controllers", controller_id, "mqtt", "messages", filter] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "PUB /instruments/:id/controllers/:controller_id/mqtt/messages/:filter"
+}
+
+allow if {
+	"PUB" == input.operation.method
+	["instruments", id, "controllers", controller_id, "mqtt", "messages", filter] = split(trim_prefix(input.resource.path, "/"), "/")
+}
+
+matching_routes contains route if {
+	"MSG" == input.operation.method
+	["instruments", id, "controllers", controller_id, "mqtt", "messages", filter] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "MSG /instruments/:id/controllers/:controller_id/mqtt/messages/:filter"
+}
+
+allow if {
+	"MSG" == input.operation.method
+	["instruments", id, "controllers", controller_id, "mqtt", "messages", filter] = split(trim_prefix(input.resource.path, "/"), "/")
+}
+
+matching_routes contains route if {
+	"SUB" == input.operation.method
+	["instruments", id, "controllers", controller_id, "state"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "SUB /instruments/:id/controllers/:controller_id/state"
+}
+
+allow if {
+	"SUB" == input.operation.method
+	["instruments", id, "controllers", controller_id, "state"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_controller_get(input.subject, id, controller_id)
+}
+
+matching_routes contains route if {
+	"PUB" == input.operation.method
+	["instruments", id, "controllers", controller_id, "state"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "PUB /instruments/:id/controllers/:controller_id/state"
+}
+
+allow if {
+	"PUB" == input.operation.method
+	["instruments", id, "controllers", controller_id, "state"] = split(trim_prefix(input.resource.path, "/"), "/")
+}
+
+matching_routes contains route if {
+	"MSG" == input.operation.method
+	["instruments", id, "controllers", controller_id, "state"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "MSG /instruments/:id/controllers/:controller_id/state"
+}
+
+allow if {
+	"MSG" == input.operation.method
+	["instruments", id, "controllers", controller_id, "state"] = split(trim_prefix(input.resource.path, "/"), "/")
+}
+
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["instruments", id, "controllers", controller_id, "commands", command] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /instruments/:id/controllers/:controller_id/commands/:command"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["instruments", id, "controllers", controller_id, "commands", command] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_controller_command_post(input.subject, id, controller_id)
+}
+
 matching_routes contains route if {
 	"POST" == input.operation.method
 	["instruments", id, "automation-jobs"] = split(trim_prefix(input.resource.path, "/"), "/")
@@ -420,7 +798,7 @@ matching_routes contains route if {
 allow if {
 	"POST" == input.operation.method
 	["instruments", id, "automation-jobs"] = split(trim_prefix(input.resource.path, "/"), "/")
-	allow_instrument_post(input.subject, id)
+	allow_automation_jobs_post(input.subject, id)
 }
 
 matching_routes contains route if {
@@ -435,6 +813,66 @@ allow if {
 	allow_automation_job_post(input.subject, id, automation_job_id)
 }
 
+matching_routes contains route if {
+	"GET" == input.operation.method
+	["instruments", id, "webhooks"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "GET /instruments/:id/webhooks"
+}
+
+allow if {
+	"GET" == input.operation.method
+	["instruments", id, "webhooks"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_webhooks_get(input.subject, id)
+}
+
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["instruments", id, "webhooks"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /instruments/:id/webhooks"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["instruments", id, "webhooks"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_webhooks_post(input.subject, id)
+}
+
+matching_routes contains route if {
+	"GET" == input.operation.method
+	["instruments", id, "webhooks", webhook_id] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "GET /instruments/:id/webhooks/:webhook_id"
+}
+
+allow if {
+	"GET" == input.operation.method
+	["instruments", id, "webhooks", webhook_id] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_webhook_get(input.subject, id, webhook_id)
+}
+
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["instruments", id, "webhooks", webhook_id] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /instruments/:id/webhooks/:webhook_id"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["instruments", id, "webhooks", webhook_id] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_webhook_post(input.subject, id, webhook_id)
+}
+
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["instruments", id, "webhooks", webhook_id, "deliveries", delivery_id, "redeliver"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /instruments/:id/webhooks/:webhook_id/deliveries/:delivery_id/redeliver"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["instruments", id, "webhooks", webhook_id, "deliveries", delivery_id, "redeliver"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_webhook_post(input.subject, id, webhook_id)
+}
+
 matching_routes contains route if {
 	"GET" == input.operation.method
 	["instruments", id, "chat", "messages"] = split(trim_prefix(input.resource.path, "/"), "/")
@@ -444,7 +882,7 @@ matching_routes contains route if {
 allow if {
 	"GET" == input.operation.method
 	["instruments", id, "chat", "messages"] = split(trim_prefix(input.resource.path, "/"), "/")
-	allow_instrument_get(id)
+	allow_instrument_get(input.subject, id)
 }
 
 matching_routes contains route if {
@@ -456,7 +894,7 @@ matching_routes contains route if {
 allow if {
 	"SUB" == input.operation.method
 	["instruments", id, "chat", "messages"] = split(trim_prefix(input.resource.path, "/"), "/")
-	allow_instrument_get(id)
+	allow_instrument_get(input.subject, id)
 }
 
 matching_routes contains route if {
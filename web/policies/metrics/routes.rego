@@ -0,0 +1,24 @@
+package sargassum.pslive.web.policies.metrics
+
+import future.keywords
+
+import data.sargassum.godest.errors as e
+
+# Policy Scope
+
+in_scope if {
+	"/metrics" == input.resource.path
+}
+
+# Policy Result & Error
+
+# Access to metrics is controlled by the server's configuration, rather than by users' permissions
+allow if {
+	input.operation.method == "GET"
+}
+
+errors contains error_method if {
+	in_scope
+	not allow
+	error_method := e.new("route not implemented")
+}
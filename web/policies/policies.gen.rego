@@ -6,15 +6,33 @@ import future.keywords
 
 import data.sargassum.godest.routing
 
+import data.sargassum.pslive.web.policies.api
 import data.sargassum.pslive.web.policies.assets
 import data.sargassum.pslive.web.policies.auth
 import data.sargassum.pslive.web.policies.cable
+import data.sargassum.pslive.web.policies.health
 import data.sargassum.pslive.web.policies.home
 import data.sargassum.pslive.web.policies.instruments
+import data.sargassum.pslive.web.policies.metrics
 import data.sargassum.pslive.web.policies.privatechat
 import data.sargassum.pslive.web.policies.users
 import data.sargassum.pslive.web.policies.videostreams
 
+# Policy api
+
+matching_policies contains "api" if {
+	api.in_scope
+}
+
+allow if {
+	api.in_scope
+	api.allow
+}
+
+policy_errors["api"] := error if {
+	some error in api.errors
+}
+
 # Policy assets
 
 matching_policies contains "assets" if {
@@ -60,6 +78,21 @@ policy_errors["cable"] := error if {
 	some error in cable.errors
 }
 
+# Policy health
+
+matching_policies contains "health" if {
+	health.in_scope
+}
+
+allow if {
+	health.in_scope
+	health.allow
+}
+
+policy_errors["health"] := error if {
+	some error in health.errors
+}
+
 # Policy home
 
 matching_policies contains "home" if {
@@ -90,6 +123,21 @@ policy_errors["instruments"] := error if {
 	some error in instruments.errors
 }
 
+# Policy metrics
+
+matching_policies contains "metrics" if {
+	metrics.in_scope
+}
+
+allow if {
+	metrics.in_scope
+	metrics.allow
+}
+
+policy_errors["metrics"] := error if {
+	some error in metrics.errors
+}
+
 # Policy privatechat
 
 matching_policies contains "privatechat" if {
@@ -24,7 +24,26 @@ allow_user_chat_post(subject, id) if {
 	auth.is_authenticated(subject)
 }
 
+# API tokens can only be managed by their owners from browser sessions, so that a leaked token
+# can't be used to make more tokens
+allow_user_api_tokens_post(subject, id) if {
+	is_valid_user(id)
+	auth.is_authenticated(subject)
+	subject.identity == id
+}
+
+allow_user_api_token_post(subject, id, token_id) if {
+	allow_user_api_tokens_post(subject, id)
+	is_valid_api_token(id, token_id)
+}
+
 # Internal Attribute Checks
 
 # TODO: implement (right now we don't have a users db table)
 is_valid_user(_) = true
+
+is_valid_api_token(id, token_id) if {
+	token := input.context.db.apitokens_token[_]
+	to_number(token_id) == token.id
+	id == token.identity_id
+}
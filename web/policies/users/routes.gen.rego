@@ -170,6 +170,30 @@ allow if {
 	allow_user_chat_post(input.subject, id)
 }
 
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["users", id, "api-tokens"] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /users/:id/api-tokens"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["users", id, "api-tokens"] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_user_api_tokens_post(input.subject, id)
+}
+
+matching_routes contains route if {
+	"POST" == input.operation.method
+	["users", id, "api-tokens", token_id] = split(trim_prefix(input.resource.path, "/"), "/")
+	route := "POST /users/:id/api-tokens/:token_id"
+}
+
+allow if {
+	"POST" == input.operation.method
+	["users", id, "api-tokens", token_id] = split(trim_prefix(input.resource.path, "/"), "/")
+	allow_user_api_token_post(input.subject, id, token_id)
+}
+
 errors contains error_matching if {
 	in_scope
 	error_matching := routing.error_matching_routes(matching_routes)